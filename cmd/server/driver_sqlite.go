@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package main
+
+// Registers the pure Go "sqlite" database/sql driver used by
+// STORE_BACKEND=sqlite, so the binary still builds without cgo.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+	"github.com/mamadbah2/farmer/internal/scheduler"
+	"github.com/mamadbah2/farmer/internal/server/handlers"
+	activitysvc "github.com/mamadbah2/farmer/internal/service/activity"
+	archivesvc "github.com/mamadbah2/farmer/internal/service/archive"
+	backupsvc "github.com/mamadbah2/farmer/internal/service/backup"
+	commandsvc "github.com/mamadbah2/farmer/internal/service/commands"
+	flagsvc "github.com/mamadbah2/farmer/internal/service/flags"
+	reconcilesvc "github.com/mamadbah2/farmer/internal/service/reconcile"
+	remindersvc "github.com/mamadbah2/farmer/internal/service/reminder"
+	reportingsvc "github.com/mamadbah2/farmer/internal/service/reporting"
+	whatsappsvc "github.com/mamadbah2/farmer/internal/service/whatsapp"
+	"github.com/mamadbah2/farmer/pkg/clients/anthropic"
+	whatsappclient "github.com/mamadbah2/farmer/pkg/clients/whatsapp"
+)
+
+// farm is what serves one farm: its spreadsheet and record store, the
+// services over them, its scheduler and its handlers. A deployment builds
+// one for its own configuration and one for each of the CONFIG_FILE farms.
+type farm struct {
+	cfg    *config.Config
+	logger *zap.Logger
+
+	sheets  sheets.Repository
+	layout  sheets.Layout
+	backend recordStore
+	// store is backend behind the sandbox and activity wrappers.
+	store mongodb.Repository
+
+	feed      *activitysvc.Feed
+	buffered  *sheets.WriteBehindRepository
+	flags     *flagsvc.Service
+	client    *whatsappclient.APIClient
+	messaging *whatsappsvc.MetaWhatsAppService
+	scheduler *scheduler.Scheduler
+	elector   *scheduler.Elector
+	services  reloadable
+
+	admin     *handlers.AdminHandler
+	dashboard *handlers.DashboardHandler
+	records   *handlers.RecordsHandler
+
+	// running tracks the goroutines started by run.
+	running sync.WaitGroup
+}
+
+// openFarm connects to the spreadsheet and the record store of cfg,
+// preparing the missing tabs.
+func openFarm(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*farm, error) {
+	f := &farm{cfg: cfg, logger: logger}
+
+	sheetsRepo, err := sheets.NewGoogleSheetRepository(ctx, cfg.Sheets, logger.Named("repo.sheets"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to init sheets repository: %w", err)
+	}
+	switch cfg.Sandbox.Mode {
+	case config.SandboxLogOnly:
+		logger.Warn("sandbox mode: writes are logged, not stored")
+		sheetsRepo = sheets.NewDryRunRepository(sheetsRepo, logger.Named("repo.sheets.dryrun"))
+	case config.SandboxIsolated:
+		logger.Warn("sandbox mode: using the sandbox spreadsheet and database", zap.String("spreadsheet", cfg.Sheets.SpreadsheetID), zap.String("database", cfg.MongoDB.DBName))
+	}
+	f.sheets = sheetsRepo
+	if len(cfg.WhatsApp.FarmerIDs) == 0 {
+		logger.Warn("no farmer listed in WHATSAPP_FARMER_IDS or USERS_FILE, only the seller and expense manager can log records")
+	}
+	if f.layout, err = sheets.NewLayout(cfg.Sheets); err != nil {
+		return nil, fmt.Errorf("invalid sheet layout: %w", err)
+	}
+	if err := sheetsRepo.EnsureTabs(ctx, sheets.NewEntities(sheetsRepo, f.layout).Layouts()); err != nil {
+		if errors.Is(err, sheets.ErrSchemaDrift) {
+			return nil, fmt.Errorf("spreadsheet headers do not match the expected columns: %w", err)
+		}
+		logger.Error("failed to prepare spreadsheet tabs", zap.Error(err))
+	}
+
+	if f.backend, err = openStore(ctx, cfg, logger.Named("repo.store")); err != nil {
+		return nil, fmt.Errorf("failed to init record store %s: %w", cfg.Store.Backend, err)
+	}
+	f.store = f.backend
+	if cfg.Sandbox.Mode == config.SandboxLogOnly {
+		f.store = mongodb.NewDryRunRepository(f.backend, logger.Named("repo.store.dryrun"))
+	}
+	return f, nil
+}
+
+// start builds the farm's services and handlers and starts its scheduler.
+// The handlers are left nil when their tokens are missing.
+func (f *farm) start(ctx context.Context, aiClient anthropic.Client) error {
+	cfg, logger := f.cfg, f.logger
+	// The farm's day, not the host's: records, reports, reconciliation and
+	// jobs all take "today" in TIMEZONE.
+	location, _ := cfg.Reporting.Location() // checked by config.Validate
+
+	// Inbound messages, saved entries and job runs are streamed live to the
+	// dashboard as they are written.
+	f.feed = activitysvc.NewFeed(logger.Named("svc.activity"))
+	f.store = mongodb.NewActivityRepository(f.store, f.feed)
+	store := f.store
+
+	// Appends made while Sheets is unreachable are queued in Mongo and
+	// replayed by the flusher started in run.
+	f.buffered = sheets.NewWriteBehindRepository(f.sheets, store, logger.Named("repo.sheets.queue"))
+
+	// Flags saved through the admin endpoints override FEATURE_FLAGS; a
+	// store that cannot be read leaves the FEATURE_FLAGS ones in effect.
+	f.flags = flagsvc.NewService(cfg.Flags.Flags, store, logger.Named("svc.flags"))
+	flagsCtx, cancelFlags := context.WithTimeout(ctx, 10*time.Second)
+	if err := f.flags.Refresh(flagsCtx); err != nil {
+		logger.Warn("stored feature flags not loaded", zap.Error(err))
+	}
+	cancelFlags()
+
+	reportingSvc := reportingsvc.NewService(f.buffered, f.layout, store, reportingSettings(cfg), logger.Named("svc.reporting"))
+	commandDispatcher := commandsvc.NewService(f.buffered, f.layout, store, reportingSvc, validationRules(cfg), f.flags, location, logger.Named("svc.commands"))
+
+	f.client = whatsappclient.NewClient(cfg.WhatsApp)
+	f.messaging = whatsappsvc.NewMetaWhatsAppService(cfg.WhatsApp, f.client, aiClient, commandDispatcher, store, f.flags, logger.Named("svc.whatsapp"))
+
+	// Webhooks replayed in dry-run mode go through copies of the services
+	// that read the real records but store and send nothing, with their own
+	// conversations.
+	dryStore := mongodb.NewDryRunRepository(store, logger.Named("repo.store.replay"))
+	drySheets := sheets.NewDryRunRepository(f.sheets, logger.Named("repo.sheets.replay"))
+	dryReporting := reportingsvc.NewService(drySheets, f.layout, dryStore, reportingSettings(cfg), logger.Named("svc.reporting.replay"))
+	dryCommands := commandsvc.NewService(drySheets, f.layout, dryStore, dryReporting, validationRules(cfg), f.flags, location, logger.Named("svc.commands.replay"))
+	dryMessaging := whatsappsvc.NewMetaWhatsAppService(cfg.WhatsApp, whatsappclient.NewRecordingClient(), aiClient, dryCommands, nil, f.flags, logger.Named("svc.whatsapp.replay"))
+	replayer := whatsappsvc.NewReplayer(f.messaging, dryMessaging)
+
+	var backuper scheduler.Backuper
+	if cfg.Backup.Enabled() {
+		var destinations []backupsvc.Destination
+		if cfg.Backup.Dir != "" {
+			local, err := backupsvc.NewLocalDestination(cfg.Backup.Dir, cfg.Backup.Keep)
+			if err != nil {
+				return fmt.Errorf("invalid BACKUP_DIR: %w", err)
+			}
+			destinations = append(destinations, local)
+		}
+		if cfg.Backup.DriveFolderID != "" {
+			drive, err := backupsvc.NewDriveDestination(ctx, cfg.Sheets, cfg.Backup.DriveFolderID)
+			if err != nil {
+				return fmt.Errorf("failed to init drive backups: %w", err)
+			}
+			destinations = append(destinations, drive)
+		}
+		backuper = backupsvc.NewService(store, destinations, logger.Named("svc.backup"))
+	}
+
+	var archiver scheduler.Archiver
+	if cfg.Archive.AfterMonths > 0 {
+		archiveSvc, err := archivesvc.NewService(f.sheets, f.layout, cfg.Archive, location, logger.Named("svc.archive"))
+		if err != nil {
+			return fmt.Errorf("invalid ARCHIVE_TABS: %w", err)
+		}
+		archiver = archiveSvc
+	}
+
+	var reconciler scheduler.Reconciler
+	if cfg.Reconcile.Days > 0 {
+		reconciler = reconcilesvc.NewService(f.buffered, f.layout, store, cfg.Reconcile, location, logger.Named("svc.reconcile"))
+	}
+
+	reminder := remindersvc.NewService(f.buffered, f.layout, f.messaging, cfg.Reminder, logger.Named("svc.reminder"))
+
+	// With replicas sharing the store, only the holder of the scheduler lease
+	// runs the jobs.
+	var leader scheduler.Leader
+	if cfg.Reporting.LeaseTTL > 0 {
+		f.elector = scheduler.NewElector(store, cfg.Reporting.InstanceID, cfg.Reporting.LeaseTTL, logger.Named("scheduler.lease"))
+		f.elector.Acquire(ctx)
+		leader = f.elector
+	}
+
+	f.scheduler = scheduler.NewScheduler(*cfg, reportingSvc, f.messaging, archiver, reconciler, backuper, reminder, store, leader, f.flags, location, logger.Named("scheduler"))
+	f.scheduler.Start()
+
+	if cfg.Server.AdminToken != "" {
+		f.admin = handlers.NewAdminHandler(store, store, store, backuper, f.scheduler, f.flags, f.messaging, reportingSvc, replayer, cfg.Server.AdminToken, location, logger.Named("handlers.admin"))
+		f.dashboard = handlers.NewDashboardHandler(reportingSvc, store, f.feed, cfg.Server.AdminToken, location, logger.Named("handlers.dashboard"))
+	}
+	if len(cfg.Server.APITokens) > 0 {
+		f.records = handlers.NewRecordsHandler(commandDispatcher, store, cfg.Server.APITokens, location, logger.Named("handlers.records"))
+	}
+
+	f.services = reloadable{
+		scheduler: f.scheduler,
+		messaging: []*whatsappsvc.MetaWhatsAppService{f.messaging, dryMessaging},
+		commands:  []*commandsvc.Service{commandDispatcher, dryCommands},
+		reporting: []*reportingsvc.Service{reportingSvc, dryReporting},
+		reminder:  reminder,
+		flags:     f.flags,
+	}
+	return nil
+}
+
+// run keeps the farm's write queue, flags and scheduler lease going until
+// ctx is done; wait returns once they stopped, the lease released.
+func (f *farm) run(ctx context.Context) {
+	f.running.Go(func() { f.buffered.Run(ctx, f.cfg.Sheets.QueueFlushInterval) })
+	f.running.Go(func() { f.flags.Run(ctx, f.cfg.Flags.RefreshInterval) })
+	if f.elector != nil {
+		f.running.Go(func() { f.elector.Run(ctx, f.scheduler.CatchUp) })
+	}
+}
+
+// wait waits for the goroutines of run to return.
+func (f *farm) wait() {
+	f.running.Wait()
+}
+
+// drain waits, until ctx is done, for the job runs in progress, then flushes
+// the Sheets writes queued meanwhile, so that what was accepted before the
+// shutdown is stored before the record store closes. It is called once the
+// HTTP server no longer takes requests.
+func (f *farm) drain(ctx context.Context) {
+	if err := f.scheduler.Stop(ctx); err != nil {
+		f.logger.Error("job runs cut by the shutdown", zap.Error(err))
+	}
+	flushed, err := f.buffered.Flush(ctx)
+	if flushed > 0 {
+		f.logger.Info("queued sheet writes flushed", zap.Int("writes", flushed))
+	}
+	if err != nil {
+		f.logger.Warn("queued sheet writes left for the next start", zap.Error(err))
+	}
+}
+
+// healthChecks adds the farm's dependencies to checks, their names prefixed
+// with prefix.
+func (f *farm) healthChecks(checks map[string]handlers.HealthChecker, prefix string) {
+	checks[prefix+f.cfg.Store.Backend] = f.backend
+	checks[prefix+"sheets"] = f.sheets
+	// The dev profile runs with placeholder WhatsApp settings.
+	if f.cfg.Env != config.EnvDev {
+		checks[prefix+"whatsapp"] = handlers.CachedCheck(f.client, whatsappCheckInterval)
+	}
+}
+
+// close closes the record store.
+func (f *farm) close() {
+	if err := f.backend.Close(context.Background()); err != nil {
+		f.logger.Error("failed to close record store", zap.Error(err))
+	}
+}
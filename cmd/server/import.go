@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+	reconcilesvc "github.com/mamadbah2/farmer/internal/service/reconcile"
+)
+
+// runImport implements "farmer import --from-sheets [--since YYYY-MM-DD]":
+// the sheet rows dated since the given day (all of them by default) that
+// Mongo does not hold yet are copied into it, so the Mongo reports cover the
+// farm's whole history. Days are those of location, the farm's timezone.
+func runImport(ctx context.Context, args []string, repository sheets.Repository, layout sheets.Layout, store mongodb.Repository, location *time.Location, logger *zap.Logger) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	fromSheets := flags.Bool("from-sheets", false, "copy the Eggs, Feed, Mortality, Sales, Payments and Expenses rows into MongoDB")
+	sinceFlag := flags.String("since", "", "first day imported, YYYY-MM-DD (default: whole history)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*fromSheets {
+		return errors.New("usage: farmer import --from-sheets [--since YYYY-MM-DD]")
+	}
+
+	var since time.Time
+	if *sinceFlag != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *sinceFlag, location)
+		if err != nil {
+			return fmt.Errorf("--since must be YYYY-MM-DD: %w", err)
+		}
+		since = parsed
+	}
+
+	importer := reconcilesvc.NewService(repository, layout, store, config.ReconcileConfig{}, location, logger)
+	report, err := importer.Backfill(ctx, since)
+	for _, d := range report.Discrepancies {
+		fmt.Printf("%s: %d of %d missing rows copied to MongoDB", d.Kind, d.CopiedToMongo, d.MissingInMongo)
+		if d.MissingInSheets > 0 {
+			fmt.Printf(", %d Mongo records not found in Sheets", d.MissingInSheets)
+		}
+		fmt.Println()
+	}
+	if report.Consistent() && err == nil {
+		fmt.Println("MongoDB already holds every sheet row.")
+	}
+	return err
+}
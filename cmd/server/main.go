@@ -3,27 +3,30 @@ package main
 import (
 	"context"
 	"errors"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/mamadbah2/farmer/internal/config"
-	"github.com/mamadbah2/farmer/internal/repository/mongodb"
-	"github.com/mamadbah2/farmer/internal/repository/sheets"
-	"github.com/mamadbah2/farmer/internal/scheduler"
+	"github.com/mamadbah2/farmer/internal/domain/models"
 	"github.com/mamadbah2/farmer/internal/server/handlers"
 	"github.com/mamadbah2/farmer/internal/server/router"
-	commandsvc "github.com/mamadbah2/farmer/internal/service/commands"
-	reportingsvc "github.com/mamadbah2/farmer/internal/service/reporting"
 	whatsappsvc "github.com/mamadbah2/farmer/internal/service/whatsapp"
 	"github.com/mamadbah2/farmer/pkg/clients/anthropic"
-	whatsappclient "github.com/mamadbah2/farmer/pkg/clients/whatsapp"
 	"github.com/mamadbah2/farmer/pkg/logger"
+	"github.com/mamadbah2/farmer/pkg/tracing"
 )
 
+// whatsappCheckInterval is how often /readyz asks Meta whether the WhatsApp
+// token still works; the answer is reused in between.
+const whatsappCheckInterval = 5 * time.Minute
+
 func main() {
 	cfg, err := config.Load("")
 	if err != nil {
@@ -32,45 +35,121 @@ func main() {
 
 	baseLogger := logger.Must(logger.New())
 	defer func() { _ = baseLogger.Sync() }()
+	baseLogger.Info("configuration loaded", zap.String("env", cfg.Env))
 
 	zap.ReplaceGlobals(baseLogger)
 
-	sheetsRepo, err := sheets.NewGoogleSheetRepository(context.Background(), cfg.Sheets, baseLogger.Named("repo.sheets"))
+	tracingLogger := baseLogger.Named("tracing")
+	shutdownTracing, err := tracing.Setup(tracing.Options{
+		Endpoint:    cfg.Tracing.Endpoint,
+		Headers:     cfg.Tracing.Headers,
+		ServiceName: cfg.Tracing.ServiceName,
+		Environment: cfg.Env,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		OnError:     func(err error) { tracingLogger.Warn("spans not exported", zap.Error(err)) },
+	})
 	if err != nil {
-		baseLogger.Fatal("failed to init sheets repository", zap.Error(err))
+		baseLogger.Fatal("invalid OTEL_EXPORTER_OTLP_ENDPOINT", zap.Error(err))
+	}
+	if cfg.Tracing.Endpoint != "" {
+		tracingLogger.Info("tracing enabled", zap.String("endpoint", cfg.Tracing.Endpoint), zap.Float64("sample_ratio", cfg.Tracing.SampleRatio))
+	}
+
+	if err := models.RegisterAliases(cfg.Commands.Aliases); err != nil {
+		baseLogger.Fatal("invalid COMMAND_ALIASES", zap.Error(err))
 	}
+	models.RegisterExpenseCategories(cfg.Commands.ExpenseCategories)
 
-	mongoRepo, err := mongodb.NewMongoDBRepository(context.Background(), cfg.MongoDB.URI, cfg.MongoDB.DBName)
+	// Each farm gets its own spreadsheet, store, services and scheduler; the
+	// farms of CONFIG_FILE log with their ID.
+	farmLogger := baseLogger
+	if len(cfg.Farms) > 0 {
+		farmLogger = baseLogger.With(zap.String("farm", cfg.Farm.ID))
+	}
+	defaultFarm, err := openFarm(context.Background(), cfg, farmLogger)
 	if err != nil {
-		baseLogger.Fatal("failed to init mongodb repository", zap.Error(err))
+		baseLogger.Fatal("failed to open farm", zap.String("farm", cfg.Farm.ID), zap.Error(err))
 	}
+	farms := []*farm{defaultFarm}
 	defer func() {
-		if err := mongoRepo.Close(context.Background()); err != nil {
-			baseLogger.Error("failed to close mongodb connection", zap.Error(err))
+		for _, f := range farms {
+			f.close()
 		}
 	}()
 
-	reportingSvc := reportingsvc.NewService(sheetsRepo, mongoRepo, baseLogger.Named("svc.reporting"))
-	commandDispatcher := commandsvc.NewService(sheetsRepo, mongoRepo, reportingSvc, baseLogger.Named("svc.commands"))
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		location, _ := cfg.Reporting.Location() // checked by config.Validate
+		if err := runImport(context.Background(), os.Args[2:], defaultFarm.sheets, defaultFarm.layout, defaultFarm.store, location, baseLogger.Named("import")); err != nil {
+			baseLogger.Error("import failed", zap.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	for _, farmCfg := range cfg.Farms {
+		f, err := openFarm(context.Background(), farmCfg, baseLogger.With(zap.String("farm", farmCfg.Farm.ID)))
+		if err != nil {
+			baseLogger.Fatal("failed to open farm", zap.String("farm", farmCfg.Farm.ID), zap.Error(err))
+		}
+		farms = append(farms, f)
+	}
 
 	// Initialize AI Client
 	var aiClient anthropic.Client
-	if cfg.AI.AnthropicKey != "" {
+	switch {
+	case cfg.AI.Enabled && cfg.AI.Provider == config.AIProviderFake:
+		aiClient = anthropic.NewFakeClient()
+		baseLogger.Warn("fake ai client enabled, conversations only acknowledge messages")
+	case cfg.AI.Enabled:
 		aiClient = anthropic.NewClient(cfg.AI.AnthropicKey)
 		baseLogger.Info("anthropic ai client enabled")
-	} else {
-		baseLogger.Warn("anthropic api key missing, natural language processing disabled")
+	default:
+		baseLogger.Info("ai disabled, only commands are understood")
 	}
 
-	whatsClient := whatsappclient.NewClient(cfg.WhatsApp)
-	messagingSvc := whatsappsvc.NewMetaWhatsAppService(cfg.WhatsApp, whatsClient, aiClient, commandDispatcher, baseLogger.Named("svc.whatsapp"))
-	webhookHandler := handlers.NewWebhookHandler(messagingSvc, baseLogger.Named("handlers.whatsapp"))
-	engine := router.New(webhookHandler, baseLogger.Named("router"))
+	healthChecks := map[string]handlers.HealthChecker{}
+	var others []*whatsappsvc.MetaWhatsAppService
+	var routes []router.Farm
+	services := map[string]reloadable{}
+	for i, f := range farms {
+		if err := f.start(context.Background(), aiClient); err != nil {
+			baseLogger.Fatal("failed to start farm", zap.String("farm", f.cfg.Farm.ID), zap.Error(err))
+		}
+		services[f.cfg.Farm.ID] = f.services
+		if i == 0 {
+			f.healthChecks(healthChecks, "")
+			continue
+		}
+		f.healthChecks(healthChecks, f.cfg.Farm.ID+".")
+		others = append(others, f.messaging)
+		routes = append(routes, router.Farm{ID: f.cfg.Farm.ID, Admin: f.admin, Records: f.records, Dashboard: f.dashboard})
+	}
+	if cfg.Server.AdminToken == "" {
+		baseLogger.Warn("ADMIN_API_TOKEN missing, admin endpoints and dashboard disabled")
+	}
 
-	// Initialize Scheduler
-	sched := scheduler.NewScheduler(*cfg, reportingSvc, messagingSvc, baseLogger.Named("scheduler"))
-	sched.Start()
-	defer sched.Stop()
+	sendTokens := maps.Clone(cfg.Server.SendTokens)
+	if cfg.Server.AdminToken != "" {
+		if sendTokens == nil {
+			sendTokens = map[string]string{}
+		}
+		sendTokens["admin"] = cfg.Server.AdminToken
+	}
+	if len(sendTokens) == 0 {
+		baseLogger.Warn("ADMIN_API_TOKEN and SEND_MESSAGE_TOKENS missing, /send-message refuses every request")
+	}
+	// Inbound messages go to the farm of the number or staff they come from.
+	webhookHandler := handlers.NewWebhookHandler(whatsappsvc.NewFarmRouter(defaultFarm.messaging, others...), sendTokens, baseLogger.Named("handlers.whatsapp"))
+
+	healthHandler := handlers.NewHealthHandler(healthChecks, baseLogger.Named("handlers.health"))
+	engine := router.New(webhookHandler, healthHandler, defaultFarm.admin, defaultFarm.records, defaultFarm.dashboard, routes, handlers.RateLimit{
+		PerMinute: cfg.Server.RateLimitPerMinute,
+		Burst:     cfg.Server.RateLimitBurst,
+	}, baseLogger.Named("router"))
+	// Without TRUSTED_PROXIES no X-Forwarded-For is believed.
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		baseLogger.Fatal("invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -80,9 +159,20 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// The farms' write queues, flags and leases outlive the signal: they stop
+	// once the shutdown drained the work in progress.
+	runCtx, stopRunning := context.WithCancel(context.Background())
+	defer stopRunning()
+	for _, f := range farms {
+		// Shutdown waits for open requests, the dashboard's event streams too.
+		srv.RegisterOnShutdown(f.feed.Close)
+		f.run(runCtx)
+	}
+	go reloadOnHangup(ctx, cfg, services, baseLogger.Named("config.reload"))
+
 	go func() {
 		baseLogger.Info("server starting", zap.String("port", cfg.Server.Port))
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -91,12 +181,32 @@ func main() {
 	}()
 
 	<-ctx.Done()
-	baseLogger.Info("shutdown signal received")
+	stop() // a second signal ends the process at once
+	baseLogger.Info("shutdown signal received, draining", zap.Duration("timeout", cfg.Server.ShutdownTimeout))
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
+	// No webhook is accepted any more; those in progress, which save their
+	// records and send their replies within the request, are waited for.
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		baseLogger.Error("graceful shutdown failed", zap.Error(err))
+		baseLogger.Error("requests cut by the shutdown", zap.Error(err))
+	}
+	// Then the job runs and the queued Sheets writes of every farm, and only
+	// then are the leases released and, on return, the stores closed.
+	var draining sync.WaitGroup
+	for _, f := range farms {
+		draining.Go(func() { f.drain(shutdownCtx) })
+	}
+	draining.Wait()
+	stopRunning()
+	for _, f := range farms {
+		f.wait()
+	}
+	// The drain may have used the whole timeout up.
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		tracingLogger.Warn("pending spans not exported", zap.Error(err))
 	}
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/scheduler"
+	commandsvc "github.com/mamadbah2/farmer/internal/service/commands"
+	flagsvc "github.com/mamadbah2/farmer/internal/service/flags"
+	remindersvc "github.com/mamadbah2/farmer/internal/service/reminder"
+	reportingsvc "github.com/mamadbah2/farmer/internal/service/reporting"
+	whatsappsvc "github.com/mamadbah2/farmer/internal/service/whatsapp"
+)
+
+// reloadable are the services whose settings change without a restart. The
+// messaging, command and reporting services have a dry-run copy for webhook
+// replays, reloaded with them.
+type reloadable struct {
+	scheduler *scheduler.Scheduler
+	messaging []*whatsappsvc.MetaWhatsAppService
+	commands  []*commandsvc.Service
+	reporting []*reportingsvc.Service
+	reminder  *remindersvc.Service
+	flags     *flagsvc.Service
+}
+
+// validationRules builds the command validation rules of cfg.
+func validationRules(cfg *config.Config) commandsvc.ValidationRules {
+	return commandsvc.ValidationRules{
+		FeedBagKg:        cfg.Rules.FeedBagKg,
+		EggsPerTray:      cfg.Rules.EggsPerTray,
+		MinTrayPrice:     cfg.Rules.MinTrayPrice,
+		MaxTrayPrice:     cfg.Rules.MaxTrayPrice,
+		DefaultTrayPrice: cfg.Rules.DefaultTrayPrice,
+		ConfirmAbove:     cfg.Rules.ConfirmAbove,
+		Currency:         cfg.Rules.Currency,
+		CutoffHour:       cfg.Rules.CutoffHour,
+		MortalityAlert:   cfg.Rules.MortalityAlert,
+	}
+}
+
+// reportingSettings builds the report settings of cfg.
+func reportingSettings(cfg *config.Config) reportingsvc.Settings {
+	return reportingsvc.Settings{
+		Currency:       cfg.Rules.Currency,
+		CutoffHour:     cfg.Rules.CutoffHour,
+		MortalityAlert: cfg.Rules.MortalityAlert,
+		LowFeedDays:    cfg.FeedAlert.Days,
+	}
+}
+
+// reload applies the run-time settings of cfg to the services.
+func (r reloadable) reload(cfg *config.Config) {
+	for _, commands := range r.commands {
+		commands.Reload(validationRules(cfg))
+	}
+	for _, reporting := range r.reporting {
+		reporting.Reload(reportingSettings(cfg))
+	}
+	for _, messaging := range r.messaging {
+		messaging.Reload(cfg.WhatsApp)
+	}
+	r.reminder.Reload(cfg.Reminder)
+	r.scheduler.Reload(*cfg)
+	r.flags.Reload(cfg.Flags.Flags)
+}
+
+// reloadOnHangup reads the configuration again on every SIGHUP until ctx is
+// done and applies what can change at run time to the services of each farm,
+// keyed by farm ID: jobs, recipients, staff numbers, business rules,
+// thresholds, templates, command aliases and feature flags. Settings the
+// server was built from (port, stores, credentials, timezone, enabled
+// services, the farms themselves) are only logged as needing a restart. An
+// invalid configuration is logged and the current one kept.
+func reloadOnHangup(ctx context.Context, current *config.Config, farms map[string]reloadable, logger *zap.Logger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+
+		cfg, err := config.Reload("")
+		if err != nil {
+			logger.Error("configuration not reloaded", zap.Error(err))
+			continue
+		}
+		if err := models.RegisterAliases(cfg.Commands.Aliases); err != nil {
+			logger.Error("configuration not reloaded: invalid COMMAND_ALIASES", zap.Error(err))
+			continue
+		}
+		models.RegisterExpenseCategories(cfg.Commands.ExpenseCategories)
+		for _, farmCfg := range append([]*config.Config{cfg}, cfg.Farms...) {
+			// Farms added since the start wait for a restart.
+			if services, ok := farms[farmCfg.Farm.ID]; ok {
+				services.reload(farmCfg)
+			}
+		}
+
+		if changed := restartOnly(current, cfg); len(changed) > 0 {
+			logger.Warn("configuration reloaded, restart to apply the other changes", zap.Strings("settings", changed))
+		} else {
+			logger.Info("configuration reloaded")
+		}
+	}
+}
+
+// restartOnly lists the settings that differ between current and next but
+// only apply on restart.
+func restartOnly(current, next *config.Config) []string {
+	checks := []struct {
+		name    string
+		changed bool
+	}{
+		{"APP_ENV", current.Env != next.Env},
+		{"APP_PORT", current.Server.Port != next.Server.Port},
+		{"ADMIN_API_TOKEN", current.Server.AdminToken != next.Server.AdminToken},
+		{"API_TOKENS", !maps.Equal(current.Server.APITokens, next.Server.APITokens)},
+		{"SEND_MESSAGE_TOKENS", !maps.Equal(current.Server.SendTokens, next.Server.SendTokens)},
+		{"RATE_LIMIT_PER_MINUTE", current.Server.RateLimitPerMinute != next.Server.RateLimitPerMinute || current.Server.RateLimitBurst != next.Server.RateLimitBurst},
+		{"TRUSTED_PROXIES", !slices.Equal(current.Server.TrustedProxies, next.Server.TrustedProxies)},
+		{"SHUTDOWN_TIMEOUT_SECONDS", current.Server.ShutdownTimeout != next.Server.ShutdownTimeout},
+		{"WHATSAPP_TOKEN", current.WhatsApp.AccessToken != next.WhatsApp.AccessToken},
+		{"WHATSAPP_PHONE_NUMBER_ID", current.WhatsApp.PhoneNumberID != next.WhatsApp.PhoneNumberID},
+		{"GOOGLE_SHEET_DATABASE_ID", current.Sheets.SpreadsheetID != next.Sheets.SpreadsheetID},
+		{"STORE_BACKEND", current.Store != next.Store},
+		{"MONGODB_URI", current.MongoDB.URI != next.MongoDB.URI || current.MongoDB.DBName != next.MongoDB.DBName},
+		{"TIMEZONE", current.Reporting.Timezone != next.Reporting.Timezone},
+		{"SCHEDULER_LEASE_SECONDS", current.Reporting.LeaseTTL != next.Reporting.LeaseTTL},
+		{"AI_ENABLED", current.AI.Enabled != next.AI.Enabled || current.AI.Provider != next.AI.Provider},
+		{"ANTHROPIC_API_KEY", current.AI.AnthropicKey != next.AI.AnthropicKey},
+		{"SANDBOX_MODE", current.Sandbox != next.Sandbox},
+		{"ARCHIVE_AFTER_MONTHS", current.Archive.AfterMonths != next.Archive.AfterMonths},
+		{"RECONCILE_DAYS", current.Reconcile.Days != next.Reconcile.Days},
+		{"FEATURE_FLAGS_REFRESH_SECONDS", current.Flags.RefreshInterval != next.Flags.RefreshInterval},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", current.Tracing.Endpoint != next.Tracing.Endpoint || current.Tracing.ServiceName != next.Tracing.ServiceName ||
+			current.Tracing.SampleRatio != next.Tracing.SampleRatio || !maps.Equal(current.Tracing.Headers, next.Tracing.Headers)},
+		{"BACKUP_DIR", current.Backup.Dir != next.Backup.Dir || current.Backup.DriveFolderID != next.Backup.DriveFolderID},
+		{"FARM_ID", current.Farm.ID != next.Farm.ID},
+		{"FARMS", !slices.EqualFunc(current.Farms, next.Farms, sameFarm)},
+	}
+	var changed []string
+	for _, check := range checks {
+		if check.changed {
+			changed = append(changed, check.name)
+		}
+	}
+	return changed
+}
+
+// sameFarm reports whether a and b are the same farm on the same number,
+// spreadsheet, database and backup folder.
+func sameFarm(a, b *config.Config) bool {
+	return a.Farm.ID == b.Farm.ID &&
+		a.WhatsApp.PhoneNumberID == b.WhatsApp.PhoneNumberID &&
+		a.Sheets.SpreadsheetID == b.Sheets.SpreadsheetID &&
+		a.MongoDB.DBName == b.MongoDB.DBName &&
+		a.Backup.DriveFolderID == b.Backup.DriveFolderID
+}
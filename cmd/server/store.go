@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/internal/repository/sqlstore"
+)
+
+// recordStore is the backend selected by STORE_BACKEND.
+type recordStore interface {
+	mongodb.Repository
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// openStore connects to the configured backend and prepares its indexes or
+// tables, unless writes are only logged. Mongo index failures are logged
+// only, while a relational store without its tables is unusable.
+func openStore(ctx context.Context, cfg *config.Config, logger *zap.Logger) (recordStore, error) {
+	prepare := cfg.Sandbox.Mode != config.SandboxLogOnly
+
+	if cfg.Store.Backend == config.StoreMongoDB {
+		mongoRepo, err := mongodb.NewMongoDBRepository(ctx, cfg.MongoDB)
+		if err != nil {
+			return nil, err
+		}
+		if prepare {
+			if err := mongoRepo.EnsureIndexes(ctx, cfg.MongoDB.MessageRetention); err != nil {
+				logger.Error("failed to create mongodb indexes", zap.Error(err))
+			}
+		}
+		return mongoRepo, nil
+	}
+
+	sqlRepo, err := sqlstore.NewSQLRepository(ctx, cfg.Store, cfg.MongoDB.MessageRetention)
+	if err != nil {
+		return nil, err
+	}
+	if prepare {
+		if err := sqlRepo.EnsureSchema(ctx); err != nil {
+			sqlRepo.Close(ctx)
+			return nil, err
+		}
+	}
+	return sqlRepo, nil
+}
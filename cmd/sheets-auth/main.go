@@ -0,0 +1,94 @@
+// Command sheets-auth obtains the OAuth refresh token used when
+// SHEETS_AUTH_MODE=oauth. It opens a consent URL for the Google account that
+// owns the spreadsheet, receives the code on a loopback address and prints
+// the refresh token to store in GOOGLE_OAUTH_REFRESH_TOKEN.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/oauth2"
+
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "sheets-auth:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	_ = godotenv.Load()
+
+	clientID, clientSecret := os.Getenv("GOOGLE_OAUTH_CLIENT_ID"), os.Getenv("GOOGLE_OAUTH_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return errors.New("GOOGLE_OAUTH_CLIENT_ID and GOOGLE_OAUTH_CLIENT_SECRET must be set (OAuth client of type Desktop app)")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("listen for the OAuth redirect: %w", err)
+	}
+	defer listener.Close()
+
+	conf := sheets.OAuthConfig(clientID, clientSecret)
+	conf.RedirectURL = fmt.Sprintf("http://%s/", listener.Addr())
+	state := fmt.Sprintf("farmer-%d", time.Now().UnixNano())
+
+	codes := make(chan string, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != state {
+			http.Error(w, "state mismatch", http.StatusBadRequest)
+			return
+		}
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "authorization denied: "+r.URL.Query().Get("error"), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "Authorization received, you can close this tab.")
+		select {
+		case codes <- code:
+		default:
+		}
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	// Offline access with forced consent makes Google return a refresh token
+	// even when the account already granted access.
+	fmt.Println("Open this URL with the Google account that owns the spreadsheet:")
+	fmt.Println(conf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var code string
+	select {
+	case code = <-codes:
+	case <-ctx.Done():
+		return errors.New("timed out waiting for the authorization")
+	}
+
+	token, err := conf.Exchange(ctx, code)
+	if err != nil {
+		return fmt.Errorf("exchange authorization code: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return errors.New("no refresh token returned; revoke the app's access in the Google account and retry")
+	}
+
+	fmt.Println("\nSet these in the environment:")
+	fmt.Println("SHEETS_AUTH_MODE=oauth")
+	fmt.Println("GOOGLE_OAUTH_REFRESH_TOKEN=" + token.RefreshToken)
+	return nil
+}
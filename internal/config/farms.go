@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+// DefaultFarmID is the FARM_ID of a deployment that does not set one.
+const DefaultFarmID = "default"
+
+// validFarmID keeps farm IDs usable in URLs and database names.
+var validFarmID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// FarmConfig is an entry of the `farms:` list of CONFIG_FILE: another farm
+// served by the same deployment, with its own WhatsApp number or staff, its
+// own spreadsheet and database, and its own reports. Every other setting is
+// shared with the first farm. Config.Farm only sets ID and Name.
+type FarmConfig struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// PhoneNumberID is the WhatsApp number the farm's staff write to, the
+	// first farm's when empty; farms sharing a number are told apart by
+	// their users.
+	PhoneNumberID string `yaml:"phone_number_id"`
+	SpreadsheetID string `yaml:"spreadsheet_id"`
+	// SpreadsheetsByYear are the farm's yearly workbooks, as
+	// SHEETS_SPREADSHEETS_BY_YEAR is for the first farm.
+	SpreadsheetsByYear map[int]string `yaml:"spreadsheets_by_year"`
+	// DBName defaults to MONGODB_DB_NAME suffixed with the farm ID.
+	DBName           string   `yaml:"mongodb_db_name"`
+	GroupID          string   `yaml:"group_id"`
+	ReportRecipients []string `yaml:"report_recipients"`
+	// BackupDriveFolderID enables the farm's Drive backups; BACKUP_DIR
+	// archives go to a subdirectory named after the farm.
+	BackupDriveFolderID string      `yaml:"backup_drive_folder_id"`
+	Users               []User      `yaml:"users"`
+	Jobs                []JobConfig `yaml:"jobs"`
+}
+
+// farmConfigs builds the configuration of each farm of entries from c, the
+// settings before validation, and validates it.
+func (c Config) farmConfigs(entries []FarmConfig) ([]*Config, error) {
+	farms := make([]*Config, 0, len(entries))
+	for _, entry := range entries {
+		if !validFarmID.MatchString(entry.ID) {
+			return nil, fmt.Errorf("CONFIG_FILE farms: id %q must be 1 to 32 lowercase letters, digits, - or _", entry.ID)
+		}
+		switch {
+		case entry.SpreadsheetID == "":
+			return nil, fmt.Errorf("farm %s: spreadsheet_id must be provided", entry.ID)
+		case entry.GroupID == "":
+			return nil, fmt.Errorf("farm %s: group_id must be provided", entry.ID)
+		case len(entry.Users) == 0:
+			return nil, fmt.Errorf("farm %s: users must be provided", entry.ID)
+		}
+		if err := validateUsers("farm "+entry.ID, entry.Users); err != nil {
+			return nil, err
+		}
+		for year, spreadsheetID := range entry.SpreadsheetsByYear {
+			if year < 2000 || year > 9999 || spreadsheetID == "" {
+				return nil, fmt.Errorf("farm %s: spreadsheets_by_year entries must map a year to a spreadsheet ID (got %d)", entry.ID, year)
+			}
+		}
+
+		farm := c
+		farm.Farm = FarmConfig{ID: entry.ID, Name: entry.Name}
+		if entry.PhoneNumberID != "" {
+			farm.WhatsApp.PhoneNumberID = entry.PhoneNumberID
+		}
+		farm.WhatsApp.GroupID = entry.GroupID
+		farm.WhatsApp.Users = entry.Users
+		farm.WhatsApp.ExpenseManagerID = ""
+		farm.WhatsApp.SellerID = ""
+		farm.WhatsApp.AccountantID = ""
+		farm.WhatsApp.FarmerIDs = nil
+		farm.Sheets.SpreadsheetID = entry.SpreadsheetID
+		farm.Sheets.SpreadsheetsByYear = entry.SpreadsheetsByYear
+		farm.MongoDB.DBName = entry.DBName
+		if farm.MongoDB.DBName == "" {
+			farm.MongoDB.DBName = c.MongoDB.DBName + "_" + entry.ID
+		}
+		farm.Reporting.Recipients = entry.ReportRecipients
+		farm.Reporting.AlertRecipients = nil
+		farm.Reporting.FallbackRecipients = nil
+		farm.Reminder.WorkerIDs = nil
+		farm.Reminder.OwnerID = ""
+		farm.Jobs, farm.Subscriptions = entry.Jobs, nil
+		if err := expandRecipients(farm.Jobs, nil); err != nil {
+			return nil, fmt.Errorf("farm %s: %w", entry.ID, err)
+		}
+		if farm.Backup.Dir != "" {
+			farm.Backup.Dir = filepath.Join(farm.Backup.Dir, entry.ID)
+		}
+		farm.Backup.DriveFolderID = entry.BackupDriveFolderID
+
+		if err := farm.Validate(); err != nil {
+			return nil, fmt.Errorf("farm %s: %w", entry.ID, err)
+		}
+		farms = append(farms, &farm)
+	}
+	return farms, nil
+}
+
+// validateFarms checks the farms of c are kept apart: distinct IDs,
+// spreadsheets and databases, and no sender two farms sharing a WhatsApp
+// number could both claim. Every farm gets a name to label its messages.
+func (c *Config) validateFarms() error {
+	if !validFarmID.MatchString(c.Farm.ID) {
+		return fmt.Errorf("FARM_ID %q must be 1 to 32 lowercase letters, digits, - or _", c.Farm.ID)
+	}
+	if len(c.Farms) == 0 {
+		return nil
+	}
+	switch {
+	case c.Store.Backend != StoreMongoDB:
+		return errors.New("CONFIG_FILE farms need STORE_BACKEND=mongodb, each farm keeping its records in its own database")
+	case c.Sandbox.Mode == SandboxIsolated:
+		return errors.New("CONFIG_FILE farms cannot run with SANDBOX_MODE=sandbox, which has a single sandbox spreadsheet and database")
+	}
+
+	all := append([]*Config{c}, c.Farms...)
+	ids := map[string]bool{}
+	spreadsheets := map[string]string{}
+	databases := map[string]string{}
+	for _, farm := range all {
+		id := farm.Farm.ID
+		if ids[id] {
+			return fmt.Errorf("CONFIG_FILE farms: farm %s is defined twice", id)
+		}
+		ids[id] = true
+		if farm.Farm.Name == "" {
+			farm.Farm.Name = id
+		}
+		if other, ok := spreadsheets[farm.Sheets.SpreadsheetID]; ok {
+			return fmt.Errorf("CONFIG_FILE farms: farms %s and %s share a spreadsheet", other, id)
+		}
+		spreadsheets[farm.Sheets.SpreadsheetID] = id
+		if other, ok := databases[farm.MongoDB.DBName]; ok {
+			return fmt.Errorf("CONFIG_FILE farms: farms %s and %s share the database %s", other, id, farm.MongoDB.DBName)
+		}
+		databases[farm.MongoDB.DBName] = id
+	}
+
+	for i, farm := range all {
+		for _, other := range all[i+1:] {
+			if farm.WhatsApp.PhoneNumberID != other.WhatsApp.PhoneNumberID {
+				continue
+			}
+			for _, user := range farm.WhatsApp.Staff() {
+				if slices.Contains(other.WhatsApp.Staff(), user) {
+					return fmt.Errorf("CONFIG_FILE farms: %s works on farms %s and %s, which share a WhatsApp number", user, farm.Farm.ID, other.Farm.ID)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Staff returns the numbers the farm knows: its users, farmers, expense
+// manager, seller and accountant.
+func (c WhatsAppConfig) Staff() []string {
+	var staff []string
+	add := func(id string) {
+		if id != "" && !slices.Contains(staff, id) {
+			staff = append(staff, id)
+		}
+	}
+	for _, user := range c.Users {
+		add(user.ID)
+	}
+	for _, id := range c.FarmerIDs {
+		add(id)
+	}
+	add(c.ExpenseManagerID)
+	add(c.SellerID)
+	add(c.AccountantID)
+	return staff
+}
@@ -0,0 +1,289 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE is unset, and skipped when
+// missing.
+const defaultConfigFile = "config.yaml"
+
+// fileConfig is the structured CONFIG_FILE: the staff, the job registry,
+// the vaccination calendar and the other farms, plus sections of settings
+// standing in for the variables of fileSettings.
+type fileConfig struct {
+	Users         []User            `yaml:"users"`
+	Farms         []FarmConfig      `yaml:"farms"`
+	Jobs          []JobConfig       `yaml:"jobs"`
+	Subscriptions []Subscription    `yaml:"subscriptions"`
+	Vaccinations  []VaccinationStep `yaml:"vaccinations"`
+	// Sections holds every other top-level key, e.g. reporting or sheets.
+	Sections map[string]map[string]any `yaml:",inline"`
+}
+
+// fileSetting is the variable a CONFIG_FILE key stands for. Lists are
+// joined with listSep and maps with entrySep between their `key=value`
+// entries, both "," when empty, matching what the variable's parser
+// expects.
+type fileSetting struct {
+	env      string
+	listSep  string
+	entrySep string
+}
+
+// fileSettings maps the keys of each CONFIG_FILE section to their variable.
+// Secrets are deliberately absent: they stay in the environment.
+var fileSettings = map[string]map[string]fileSetting{
+	"farm": {
+		"id":   {env: "FARM_ID"},
+		"name": {env: "FARM_NAME"},
+	},
+	"server": {
+		"port":                     {env: "APP_PORT"},
+		"rate_limit_per_minute":    {env: "RATE_LIMIT_PER_MINUTE"},
+		"rate_limit_burst":         {env: "RATE_LIMIT_BURST"},
+		"trusted_proxies":          {env: "TRUSTED_PROXIES"},
+		"shutdown_timeout_seconds": {env: "SHUTDOWN_TIMEOUT_SECONDS"},
+	},
+	"whatsapp": {
+		"group_id":           {env: "WHATSAPP_GROUP_ID"},
+		"expense_manager_id": {env: "WHATSAPP_EXPENSE_MANAGER_ID"},
+		"seller_id":          {env: "WHATSAPP_SELLER_ID"},
+		"accountant_id":      {env: "WHATSAPP_ACCOUNTANT_ID"},
+		"farmer_ids":         {env: "WHATSAPP_FARMER_IDS"},
+	},
+	"reporting": {
+		"timezone":   {env: "TIMEZONE"},
+		"schedule":   {env: "REPORT_CRON_SCHEDULE"},
+		"recipients": {env: "REPORT_RECIPIENTS"},
+	},
+	"scheduler": {
+		"catchup_hours":       {env: "JOBS_CATCHUP_HOURS"},
+		"lease_seconds":       {env: "SCHEDULER_LEASE_SECONDS"},
+		"send_retries":        {env: "JOBS_SEND_RETRIES"},
+		"send_retry_seconds":  {env: "JOBS_SEND_RETRY_SECONDS"},
+		"fallback_recipients": {env: "JOBS_FALLBACK_RECIPIENTS"},
+		"alert_recipients":    {env: "JOBS_ALERT_RECIPIENTS"},
+	},
+	"reminder": {
+		"schedule":             {env: "REMINDER_CRON_SCHEDULE"},
+		"escalation_schedule":  {env: "REMINDER_ESCALATION_CRON_SCHEDULE"},
+		"worker_ids":           {env: "REMINDER_WORKER_IDS"},
+		"owner_id":             {env: "REMINDER_OWNER_ID"},
+		"template":             {env: "REMINDER_TEMPLATE"},
+		"template_language":    {env: "REMINDER_TEMPLATE_LANGUAGE"},
+		"vaccination_schedule": {env: "VACCINATION_REMINDER_CRON_SCHEDULE"},
+		"vaccination_template": {env: "VACCINATION_REMINDER_TEMPLATE"},
+	},
+	"alerts": {
+		"feed_schedule": {env: "FEED_ALERT_CRON_SCHEDULE"},
+		"feed_days":     {env: "FEED_ALERT_DAYS"},
+		"debt_schedule": {env: "DEBT_REMINDER_CRON_SCHEDULE"},
+		"debt_days":     {env: "DEBT_REMINDER_DAYS"},
+	},
+	"maintenance": {
+		"archive_schedule":     {env: "ARCHIVE_CRON_SCHEDULE"},
+		"archive_after_months": {env: "ARCHIVE_AFTER_MONTHS"},
+		"archive_tabs":         {env: "ARCHIVE_TABS"},
+		"backup_schedule":      {env: "BACKUP_CRON_SCHEDULE"},
+		"backup_keep":          {env: "BACKUP_KEEP"},
+		"reconcile_schedule":   {env: "RECONCILE_CRON_SCHEDULE"},
+		"reconcile_days":       {env: "RECONCILE_DAYS"},
+		"reconcile_repair":     {env: "RECONCILE_REPAIR"},
+	},
+	"sheets": {
+		"tab_names":            {env: "SHEETS_TAB_NAMES"},
+		"columns":              {env: "SHEETS_COLUMNS", entrySep: ";"},
+		"spreadsheets_by_year": {env: "SHEETS_SPREADSHEETS_BY_YEAR"},
+		"requests_per_minute":  {env: "SHEETS_REQUESTS_PER_MINUTE"},
+		"request_burst":        {env: "SHEETS_REQUEST_BURST"},
+		"max_retries":          {env: "SHEETS_MAX_RETRIES"},
+		"queue_flush_seconds":  {env: "SHEETS_QUEUE_FLUSH_SECONDS"},
+	},
+	"thresholds": {
+		"feed_bag_kg":        {env: "FEED_BAG_KG"},
+		"eggs_per_tray":      {env: "EGGS_PER_TRAY"},
+		"tray_price_min":     {env: "TRAY_PRICE_MIN"},
+		"tray_price_max":     {env: "TRAY_PRICE_MAX"},
+		"default_tray_price": {env: "DEFAULT_TRAY_PRICE"},
+		"confirm_amount":     {env: "CONFIRM_AMOUNT_THRESHOLD"},
+		"currency":           {env: "CURRENCY"},
+		"cutoff_hour":        {env: "REPORT_CUTOFF_HOUR"},
+		"mortality_alert":    {env: "MORTALITY_ALERT_THRESHOLD"},
+	},
+	"flags": {
+		"features":        {env: "FEATURE_FLAGS", listSep: "|"},
+		"refresh_seconds": {env: "FEATURE_FLAGS_REFRESH_SECONDS"},
+	},
+	"tracing": {
+		"endpoint":     {env: "OTEL_EXPORTER_OTLP_ENDPOINT"},
+		"service_name": {env: "OTEL_SERVICE_NAME"},
+		"sample_ratio": {env: "OTEL_TRACES_SAMPLER_ARG"},
+	},
+	"commands": {
+		"aliases":            {env: "COMMAND_ALIASES"},
+		"expense_categories": {env: "EXPENSE_CATEGORIES", listSep: "|"},
+	},
+}
+
+// derivedEnv holds the variables set from CONFIG_FILE or a profile rather
+// than by the operator, cleared before every load so that a reload sees
+// edits to the file.
+var derivedEnv = struct {
+	sync.Mutex
+	keys map[string]bool
+}{keys: map[string]bool{}}
+
+// processEnv holds the environment the process started with, recorded by
+// the first load before any env file is read.
+var processEnv struct {
+	sync.Once
+	values map[string]string
+}
+
+// environ returns the current environment as a map.
+func environ() map[string]string {
+	values := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// clearDerivedEnv unsets the variables set by the previous load.
+func clearDerivedEnv() {
+	derivedEnv.Lock()
+	defer derivedEnv.Unlock()
+	for key := range derivedEnv.keys {
+		os.Unsetenv(key)
+	}
+	clear(derivedEnv.keys)
+}
+
+// setDerivedEnv gives every variable of values that neither the
+// environment nor a `_FILE` variant sets its value.
+func setDerivedEnv(values map[string]string) error {
+	derivedEnv.Lock()
+	defer derivedEnv.Unlock()
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set || os.Getenv(key+"_FILE") != "" {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		derivedEnv.keys[key] = true
+	}
+	return nil
+}
+
+// readConfigFile parses CONFIG_FILE, or config.yaml when it exists. It
+// returns an empty configuration when there is none.
+func readConfigFile() (*fileConfig, error) {
+	path := os.Getenv("CONFIG_FILE")
+	raw, err := os.ReadFile(getenvWithDefault("CONFIG_FILE", defaultConfigFile))
+	if path == "" && errors.Is(err, os.ErrNotExist) {
+		return &fileConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_FILE: %w", err)
+	}
+	var file fileConfig
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse CONFIG_FILE: %w", err)
+	}
+	return &file, nil
+}
+
+// settings returns the variables set by the sections of the file. Unknown
+// sections and keys are errors, so a typo is not silently ignored.
+func (f *fileConfig) settings() (map[string]string, error) {
+	values := make(map[string]string)
+	for section, keys := range f.Sections {
+		known, ok := fileSettings[section]
+		if !ok {
+			return nil, fmt.Errorf("CONFIG_FILE: unknown section %q", section)
+		}
+		for key, value := range keys {
+			setting, ok := known[key]
+			if !ok {
+				return nil, fmt.Errorf("CONFIG_FILE: unknown key %s.%s", section, key)
+			}
+			encoded, err := setting.encode(value)
+			if err != nil {
+				return nil, fmt.Errorf("CONFIG_FILE: %s.%s: %w", section, key, err)
+			}
+			values[setting.env] = encoded
+		}
+	}
+	return values, nil
+}
+
+// encode writes value in the variable's format: scalars as is, lists
+// joined and maps as sorted `key=value` entries. Maps with keys YAML does
+// not read as strings, such as the unquoted years of spreadsheets_by_year,
+// are keyed by the keys' text.
+func (s fileSetting) encode(value any) (string, error) {
+	listSep, entrySep := s.listSep, s.entrySep
+	if listSep == "" {
+		listSep = ","
+	}
+	if entrySep == "" {
+		entrySep = ","
+	}
+	if m, ok := value.(map[any]any); ok {
+		keyed := make(map[string]any, len(m))
+		for key, item := range m {
+			keyed[fmt.Sprint(key)] = item
+		}
+		value = keyed
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries := make([]string, 0, len(keys))
+		for _, key := range keys {
+			entry, err := encodeList(v[key], listSep)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", key, err)
+			}
+			entries = append(entries, key+"="+entry)
+		}
+		return strings.Join(entries, entrySep), nil
+	default:
+		return encodeList(value, listSep)
+	}
+}
+
+// encodeList writes a scalar, or a list of scalars joined with sep.
+func encodeList(value any, sep string) (string, error) {
+	items, ok := value.([]any)
+	if !ok {
+		items = []any{value}
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.(type) {
+		case []any, map[string]any, map[any]any:
+			return "", errors.New("nested lists and maps are not supported")
+		case nil:
+			parts = append(parts, "")
+		default:
+			parts = append(parts, fmt.Sprint(item))
+		}
+	}
+	return strings.Join(parts, sep), nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// secretScheme prefixes the secrets kept in a secret backend rather than in
+// the environment, e.g. secret://gcp/farmer-prod/whatsapp-token.
+const secretScheme = "secret://"
+
+// secretTimeout bounds the lookup of one secret.
+const secretTimeout = 10 * time.Second
+
+// secretBackend returns the secret named by ref, the part of a secret URI
+// after the backend name.
+type secretBackend func(ctx context.Context, ref string) (string, error)
+
+// secretBackendFor returns the backend a secret URI names.
+func secretBackendFor(name string) (secretBackend, bool) {
+	switch name {
+	case "gcp":
+		return gcpSecret, true
+	case "vault":
+		return vaultSecret, true
+	}
+	return nil, false
+}
+
+// resolveSecret returns value, or the secret it points to when it is a
+// secret URI.
+func resolveSecret(key, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, secretScheme)
+	if !ok {
+		return value, nil
+	}
+	name, ref, _ := strings.Cut(rest, "/")
+	backend, ok := secretBackendFor(name)
+	if !ok || ref == "" {
+		return "", fmt.Errorf("%s: unsupported secret URI %q, want secret://gcp/... or secret://vault/...", key, value)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	secret, err := backend(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s from %s: %w", key, name, err)
+	}
+	return strings.TrimSpace(secret), nil
+}
+
+// gcpSecret reads "<project>/<secret>[#<version>]" from Google Secret
+// Manager with the application default credentials, e.g. the VM's service
+// account. The version defaults to latest, so a rotated secret is picked up
+// at the next load.
+func gcpSecret(ctx context.Context, ref string) (string, error) {
+	path, version, _ := strings.Cut(ref, "#")
+	project, secret, ok := strings.Cut(path, "/")
+	if !ok || project == "" || secret == "" || strings.Contains(secret, "/") {
+		return "", fmt.Errorf("want secret://gcp/<project>/<secret>[#<version>], got %q", ref)
+	}
+	if version == "" {
+		version = "latest"
+	}
+	svc, err := secretmanager.NewService(ctx)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s", project, secret, version)
+	resp, err := svc.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	if resp.Payload == nil {
+		return "", errors.New("empty secret payload")
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
+
+// vaultSecret reads "<mount>/<path>[#<field>]" from a KV version 2 engine
+// of the Vault server at VAULT_ADDR with VAULT_TOKEN (or VAULT_TOKEN_FILE).
+// The field defaults to value.
+func vaultSecret(ctx context.Context, ref string) (string, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	mount, secret, ok := strings.Cut(path, "/")
+	if !ok || mount == "" || secret == "" {
+		return "", fmt.Errorf("want secret://vault/<mount>/<path>[#<field>], got %q", ref)
+	}
+	if field == "" {
+		field = "value"
+	}
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return "", errors.New("VAULT_ADDR must be provided")
+	}
+	token, err := getenvSecret("VAULT_TOKEN")
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", errors.New("VAULT_TOKEN or VAULT_TOKEN_FILE must be provided")
+	}
+
+	endpoint := addr + "/v1/" + url.PathEscape(mount) + "/data/" + (&url.URL{Path: secret}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault answered %s", resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	value, ok := body.Data.Data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("no string field %q in %s", field, path)
+	}
+	return value, nil
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Kinds of ActivityEvent.
+const (
+	// ActivityMessage carries the MessageAuditEntry of an inbound message.
+	ActivityMessage = "message"
+	// ActivityRecord carries the CommandAuditEntry of a command or API
+	// entry that saved records.
+	ActivityRecord = "record"
+	// ActivityJob carries the JobExecution of a finished job run.
+	ActivityJob = "job"
+)
+
+// ActivityEvent is something that just happened on the farm's backend,
+// streamed live to the dashboard.
+type ActivityEvent struct {
+	Type string      `json:"type"`
+	At   time.Time   `json:"at"`
+	Data interface{} `json:"data"`
+}
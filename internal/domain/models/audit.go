@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// CommandAuditEntry records the outcome of one handled command so disputed
+// numbers can be traced back to the message that produced them.
+type CommandAuditEntry struct {
+	Sender     string    `bson:"sender" json:"sender"`
+	Role       Role      `bson:"role" json:"role"`
+	Command    string    `bson:"command" json:"command"`
+	Raw        string    `bson:"raw" json:"raw"`
+	Args       []string  `bson:"args" json:"args"`
+	RecordRefs []string  `bson:"record_refs,omitempty" json:"record_refs,omitempty"`
+	Success    bool      `bson:"success" json:"success"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
+
+// AuditQuery filters command audit entries. Zero values are ignored.
+type AuditQuery struct {
+	Sender  string
+	Command string
+	From    time.Time
+	To      time.Time
+	Limit   int64
+}
+
+// Outcomes recorded on a MessageAuditEntry.
+const (
+	MessageProcessed = "processed"
+	MessageFailed    = "failed"
+)
+
+// MessageAuditEntry records one inbound WhatsApp message as it was received,
+// so an admin can see what a worker actually sent when an entry looks wrong.
+type MessageAuditEntry struct {
+	MessageID string `bson:"message_id" json:"message_id"`
+	WaID      string `bson:"wa_id" json:"wa_id"`
+	Type      string `bson:"type" json:"type"`
+	// Text is the message body, or the ID of the button or list reply.
+	Text       string    `bson:"text,omitempty" json:"text,omitempty"`
+	SentAt     time.Time `bson:"sent_at" json:"sent_at"` // WhatsApp timestamp
+	Result     string    `bson:"result" json:"result"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	ReceivedAt time.Time `bson:"received_at" json:"received_at"`
+	// Raw is the message as the webhook delivered it, to replay it through
+	// POST /admin/webhook/replay.
+	Raw *InboundMessage `bson:"raw,omitempty" json:"raw,omitempty"`
+}
+
+// MessageAuditQuery filters message audit entries. Zero values are ignored.
+type MessageAuditQuery struct {
+	WaID  string
+	From  time.Time
+	To    time.Time
+	Limit int64
+}
@@ -1,17 +1,37 @@
 package models
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
 
 // CommandType enumerates supported worker command categories.
 type CommandType string
 
 const (
-	CommandEggs      CommandType = "eggs"
-	CommandFeed      CommandType = "feed"
-	CommandMortality CommandType = "mortality"
-	CommandSales     CommandType = "sales"
-	CommandExpenses  CommandType = "expenses"
-	CommandUnknown   CommandType = "unknown"
+	CommandEggs       CommandType = "eggs"
+	CommandFeed       CommandType = "feed"
+	CommandFeedStock  CommandType = "alimentstock"
+	CommandMortality  CommandType = "mortality"
+	CommandPopulation CommandType = "population"
+	CommandTransfer   CommandType = "transfert"
+	CommandSales      CommandType = "sales"
+	CommandReception  CommandType = "reception"
+	CommandExpenses   CommandType = "expenses"
+	CommandStock      CommandType = "stock"
+	CommandVaccine    CommandType = "vaccine"
+	CommandPrice      CommandType = "prix"
+	CommandClient     CommandType = "client"
+	CommandPayment    CommandType = "paiement"
+	CommandDebts      CommandType = "dettes"
+	CommandUndo       CommandType = "undo"
+	CommandStatus     CommandType = "status"
+	CommandReport     CommandType = "report"
+	CommandWeek       CommandType = "semaine"
+	CommandMonth      CommandType = "mois"
+	CommandHelp       CommandType = "help"
+	CommandUnknown    CommandType = "unknown"
 )
 
 // Command represents a parsed worker instruction extracted from WhatsApp text.
@@ -19,6 +39,153 @@ type Command struct {
 	Type CommandType
 	Raw  string
 	Args []string
+	Role Role // Sender role, resolved by the messaging layer
+	// Confirmed marks high-value entries the sender has explicitly approved.
+	Confirmed bool
+}
+
+// CommandSpec describes a supported command: its keywords, usage example and
+// the roles allowed to use it. An empty Roles slice means every staff role;
+// Public commands are also available to guests.
+type CommandSpec struct {
+	Type        CommandType
+	Aliases     []string
+	Usage       string
+	Description string
+	Roles       []Role
+	Public      bool
+}
+
+// AllowedFor reports whether the provided role may use the command.
+func (c CommandSpec) AllowedFor(role Role) bool {
+	if c.Public {
+		return true
+	}
+	if role == RoleGuest || role == "" {
+		return false
+	}
+	if len(c.Roles) == 0 {
+		return true
+	}
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	registryMu      sync.RWMutex
+	commandRegistry []CommandSpec
+)
+
+// RegisterCommand adds a command to the registry. Commands are listed by
+// /help in registration order. Duplicate names or aliases are rejected.
+func RegisterCommand(spec CommandSpec) error {
+	if spec.Type == "" || spec.Type == CommandUnknown {
+		return fmt.Errorf("invalid command type %q", spec.Type)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	keywords := append([]string{string(spec.Type)}, spec.Aliases...)
+	for _, existing := range commandRegistry {
+		for _, keyword := range keywords {
+			if existing.matches(keyword) {
+				return fmt.Errorf("command keyword %q already registered by %q", keyword, existing.Type)
+			}
+		}
+	}
+	commandRegistry = append(commandRegistry, spec)
+	return nil
+}
+
+func (c CommandSpec) matches(keyword string) bool {
+	if string(c.Type) == keyword {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if alias == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandSpecs returns a copy of the command registry.
+func CommandSpecs() []CommandSpec {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	specs := make([]CommandSpec, len(commandRegistry))
+	copy(specs, commandRegistry)
+	return specs
+}
+
+// CommandsForRole returns the registry entries usable by the provided role.
+func CommandsForRole(role Role) []CommandSpec {
+	var specs []CommandSpec
+	for _, spec := range CommandSpecs() {
+		if spec.AllowedFor(role) {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+var (
+	customAliasesMu sync.RWMutex
+	customAliases   = map[string]CommandType{}
+)
+
+// RegisterAliases adds extra keywords (typically from configuration) on top of
+// the built-in aliases. Unknown command types are rejected.
+func RegisterAliases(aliases map[string]string) error {
+	customAliasesMu.Lock()
+	defer customAliasesMu.Unlock()
+
+	for alias, target := range aliases {
+		alias = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(alias)), "/")
+		target = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(target)), "/")
+		if alias == "" {
+			continue
+		}
+		if _, ok := specByType(CommandType(target)); !ok {
+			return fmt.Errorf("alias %q targets unknown command %q", alias, target)
+		}
+		customAliases[alias] = CommandType(target)
+	}
+	return nil
+}
+
+func specByType(t CommandType) (CommandSpec, bool) {
+	for _, spec := range CommandSpecs() {
+		if spec.Type == t {
+			return spec, true
+		}
+	}
+	return CommandSpec{}, false
+}
+
+// LookupCommand resolves a keyword (with or without leading slash) to its spec.
+func LookupCommand(keyword string) (CommandSpec, bool) {
+	keyword = strings.TrimPrefix(strings.ToLower(keyword), "/")
+
+	customAliasesMu.RLock()
+	target, ok := customAliases[keyword]
+	customAliasesMu.RUnlock()
+	if ok {
+		return specByType(target)
+	}
+
+	for _, spec := range CommandSpecs() {
+		if spec.matches(keyword) {
+			return spec, true
+		}
+	}
+	return CommandSpec{}, false
 }
 
 // ParseCommand derives a Command instance from free-form text messages.
@@ -37,19 +204,9 @@ func ParseCommand(message string) Command {
 		return cmd
 	}
 
-	head := strings.TrimPrefix(tokens[0], "/")
-	switch head {
-	case string(CommandEggs):
-		cmd.Type = CommandEggs
-	case string(CommandFeed):
-		cmd.Type = CommandFeed
-	case string(CommandMortality):
-		cmd.Type = CommandMortality
-	case string(CommandSales):
-		cmd.Type = CommandSales
-	case string(CommandExpenses):
-		cmd.Type = CommandExpenses
-	default:
+	if spec, ok := LookupCommand(tokens[0]); ok {
+		cmd.Type = spec.Type
+	} else {
 		cmd.Type = CommandUnknown
 	}
 
@@ -59,3 +216,15 @@ func ParseCommand(message string) Command {
 
 	return cmd
 }
+
+// ParseCommands splits a multi-line message into one Command per non-empty line.
+func ParseCommands(message string) []Command {
+	var cmds []Command
+	for _, line := range strings.Split(message, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cmds = append(cmds, ParseCommand(line))
+	}
+	return cmds
+}
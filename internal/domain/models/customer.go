@@ -0,0 +1,51 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Customer is a registered buyer. Sales are matched against NameKey so debts
+// accumulate under one spelling of the name.
+type Customer struct {
+	Name         string    `bson:"name" json:"name"`
+	NameKey      string    `bson:"name_key" json:"name_key"`
+	Phone        string    `bson:"phone,omitempty" json:"phone,omitempty"`
+	DefaultPrice float64   `bson:"default_price,omitempty" json:"default_price,omitempty"`
+	Notes        string    `bson:"notes,omitempty" json:"notes,omitempty"`
+	CreatedBy    string    `bson:"created_by" json:"created_by"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+}
+
+// ClientBalance is what a client still owes: unpaid sale amounts (Due) minus
+// later repayments (Repaid).
+type ClientBalance struct {
+	Client    string  `bson:"client" json:"client"`
+	ClientKey string  `bson:"_id" json:"client_key"`
+	Phone     string  `bson:"phone,omitempty" json:"phone,omitempty"`
+	Due       float64 `bson:"due" json:"due"`
+	Repaid    float64 `bson:"repaid" json:"repaid"`
+	Balance   float64 `bson:"balance" json:"balance"`
+	// OldestUnpaid is the date of the client's oldest partly unpaid sale.
+	OldestUnpaid time.Time `bson:"oldest_unpaid" json:"oldest_unpaid"`
+	LastPayment  time.Time `bson:"last_payment,omitempty" json:"last_payment,omitempty"`
+}
+
+var accentFolder = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i",
+	"ô", "o", "ö", "o",
+	"ù", "u", "û", "u", "ü", "u",
+	"ç", "c",
+)
+
+// CustomerKey normalizes a customer name for matching: lower case, accents
+// folded and whitespace collapsed ("Mamadou  Diallo" == "mamadou diallo").
+func CustomerKey(name string) string {
+	return foldKey(name)
+}
+
+func foldKey(s string) string {
+	return accentFolder.Replace(strings.Join(strings.Fields(strings.ToLower(s)), " "))
+}
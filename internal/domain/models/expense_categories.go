@@ -0,0 +1,111 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ExpenseCategoryOther collects expenses matching no category.
+const ExpenseCategoryOther = "divers"
+
+// defaultExpenseCategories maps each category to the free-text labels that
+// belong to it. EXPENSE_CATEGORIES replaces it when configured.
+var defaultExpenseCategories = map[string][]string{
+	"aliment":     {"aliments", "provende", "feed", "son", "mais", "concentre", "premix"},
+	"médicaments": {"medicament", "vaccin", "vaccins", "veterinaire", "vet", "antibiotique", "vitamines", "traitement"},
+	"salaires":    {"salaire", "paie", "main d'oeuvre", "ouvrier", "gardien"},
+	"transport":   {"carburant", "essence", "gasoil", "taxi", "moto", "livraison"},
+	"énergie":     {"electricite", "courant", "eau", "edg", "generateur"},
+	"équipement":  {"equipement", "materiel", "abreuvoir", "mangeoire", "brouette", "outil"},
+	"emballage":   {"alveoles", "plateaux", "cartons", "sachets"},
+	"divers":      {"autre", "autres"},
+}
+
+var (
+	expenseCategoriesMu sync.RWMutex
+	expenseCategories   = defaultExpenseCategories
+)
+
+// RegisterExpenseCategories replaces the expense taxonomy with configured
+// categories ("category" -> "synonym1|synonym2"). Empty input keeps the defaults.
+func RegisterExpenseCategories(categories map[string]string) {
+	if len(categories) == 0 {
+		return
+	}
+
+	taxonomy := make(map[string][]string, len(categories)+1)
+	for category, synonyms := range categories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		if category == "" {
+			continue
+		}
+		for _, synonym := range strings.Split(synonyms, "|") {
+			if synonym = strings.TrimSpace(synonym); synonym != "" {
+				taxonomy[category] = append(taxonomy[category], synonym)
+			}
+		}
+		if _, ok := taxonomy[category]; !ok {
+			taxonomy[category] = nil
+		}
+	}
+	if _, ok := taxonomy[ExpenseCategoryOther]; !ok {
+		taxonomy[ExpenseCategoryOther] = nil
+	}
+
+	expenseCategoriesMu.Lock()
+	defer expenseCategoriesMu.Unlock()
+	expenseCategories = taxonomy
+}
+
+// ExpenseCategories returns the configured category names, sorted.
+func ExpenseCategories() []string {
+	expenseCategoriesMu.RLock()
+	defer expenseCategoriesMu.RUnlock()
+
+	names := make([]string, 0, len(expenseCategories))
+	for name := range expenseCategories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NormalizeExpenseCategory maps a free-text label ("Vaccins Newcastle",
+// "provende") to its taxonomy category. Each word is tried against category
+// names and synonyms, exactly then with up to one typo. Unmatched labels
+// return ExpenseCategoryOther and false.
+func NormalizeExpenseCategory(label string) (string, bool) {
+	key := foldKey(label)
+	if key == "" {
+		return ExpenseCategoryOther, false
+	}
+
+	expenseCategoriesMu.RLock()
+	defer expenseCategoriesMu.RUnlock()
+
+	categories := make([]string, 0, len(expenseCategories))
+	for name := range expenseCategories {
+		categories = append(categories, name)
+	}
+	sort.Strings(categories)
+
+	// Multi-word synonyms ("main d'oeuvre") are matched on the whole label.
+	words := append([]string{key}, strings.Fields(key)...)
+	for _, maxDistance := range []int{0, 1} {
+		for _, word := range words {
+			for _, category := range categories {
+				for _, candidate := range append([]string{category}, expenseCategories[category]...) {
+					candidate = foldKey(candidate)
+					if maxDistance == 0 && (word == candidate || strings.Contains(key, candidate) && strings.Contains(candidate, " ")) {
+						return category, true
+					}
+					if maxDistance > 0 && len(word) > 3 && editDistance(word, candidate) <= maxDistance {
+						return category, true
+					}
+				}
+			}
+		}
+	}
+	return ExpenseCategoryOther, false
+}
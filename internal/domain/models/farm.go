@@ -2,48 +2,137 @@ package models
 
 import "time"
 
+// RecordKind names a farm record type mirrored from Sheets into its own
+// MongoDB collection.
+type RecordKind string
+
+// Farm records mirrored to MongoDB; the value is the collection name.
+const (
+	RecordEggs      RecordKind = "eggs"
+	RecordFeed      RecordKind = "feed"
+	RecordMortality RecordKind = "mortality"
+	RecordSales     RecordKind = "sales"
+	RecordPayments  RecordKind = "payments"
+	RecordExpenses  RecordKind = "expenses"
+)
+
 // EggRecord captures daily egg production metrics.
 type EggRecord struct {
-	Date     time.Time
-	Band1    int
-	Band2    int
-	Band3    int
-	Quantity int // Total
-	Notes    string
+	Date     time.Time `bson:"date" json:"date"`
+	Band1    int       `bson:"band1" json:"band1"`
+	Band2    int       `bson:"band2" json:"band2"`
+	Band3    int       `bson:"band3" json:"band3"`
+	Quantity int       `bson:"quantity" json:"quantity"` // Total
+	Notes    string    `bson:"notes,omitempty" json:"notes,omitempty"`
+}
+
+// Band returns the eggs of the given band (1-3), or 0.
+func (r EggRecord) Band(band int) int {
+	return bandCount(band, r.Band1, r.Band2, r.Band3)
 }
 
 // FeedRecord captures daily feed usage.
 type FeedRecord struct {
-	Date       time.Time
-	FeedKg     float64
-	Population int
+	Date       time.Time `bson:"date" json:"date"`
+	FeedKg     float64   `bson:"feed_kg" json:"feed_kg"`
+	Population int       `bson:"population,omitempty" json:"population,omitempty"`
 }
 
-// MortalityRecord captures mortality incidents.
-type MortalityRecord struct {
+// FeedDeliveryRecord captures feed bags added to the feed stock.
+type FeedDeliveryRecord struct {
+	Date        time.Time
+	Bags        float64
+	KgPerBag    float64
+	PricePerBag float64
+}
+
+// TotalKg returns the delivered weight.
+func (r FeedDeliveryRecord) TotalKg() float64 {
+	return r.Bags * r.KgPerBag
+}
+
+// PopulationRecord captures a head count of the live birds per band.
+type PopulationRecord struct {
 	Date  time.Time
 	Band1 int
 	Band2 int
 	Band3 int
 }
 
+// Total returns the live birds across every band.
+func (r PopulationRecord) Total() int {
+	return r.Band1 + r.Band2 + r.Band3
+}
+
+// Band returns the count of the given band (1-3), or 0.
+func (r PopulationRecord) Band(band int) int {
+	return bandCount(band, r.Band1, r.Band2, r.Band3)
+}
+
+// TransferRecord captures birds moved from one band to another.
+type TransferRecord struct {
+	Date     time.Time
+	FromBand int
+	ToBand   int
+	Quantity int
+	Reason   string
+}
+
+// MortalityRecord captures mortality incidents.
+type MortalityRecord struct {
+	Date  time.Time `bson:"date" json:"date"`
+	Band1 int       `bson:"band1" json:"band1"`
+	Band2 int       `bson:"band2" json:"band2"`
+	Band3 int       `bson:"band3" json:"band3"`
+}
+
+// Band returns the deaths of the given band (1-3), or 0.
+func (r MortalityRecord) Band(band int) int {
+	return bandCount(band, r.Band1, r.Band2, r.Band3)
+}
+
+// Total returns the deaths across every band.
+func (r MortalityRecord) Total() int {
+	return r.Band1 + r.Band2 + r.Band3
+}
+
+func bandCount(band, b1, b2, b3 int) int {
+	switch band {
+	case 1:
+		return b1
+	case 2:
+		return b2
+	case 3:
+		return b3
+	}
+	return 0
+}
+
 // SaleRecord captures sales transactions.
 type SaleRecord struct {
-	Date         time.Time
-	Client       string
-	Quantity     int
-	PricePerUnit float64
-	Paid         float64
+	Date         time.Time `bson:"date" json:"date"`
+	Client       string    `bson:"client" json:"client"`
+	Quantity     int       `bson:"quantity" json:"quantity"`
+	PricePerUnit float64   `bson:"price_per_unit" json:"price_per_unit"`
+	Paid         float64   `bson:"paid" json:"paid"`
+}
+
+// PaymentRecord captures a client repaying part of an earlier unpaid sale.
+type PaymentRecord struct {
+	Date   time.Time `bson:"date" json:"date"`
+	Client string    `bson:"client" json:"client"`
+	Amount float64   `bson:"amount" json:"amount"`
+	Notes  string    `bson:"notes,omitempty" json:"notes,omitempty"`
 }
 
 // ExpenseRecord captures operating expenses.
 type ExpenseRecord struct {
-	Date      time.Time
-	Category  string
-	Quantity  float64
-	UnitPrice float64
-	Amount    float64 // Total amount (Quantity * UnitPrice)
-	Notes     string
+	Date      time.Time `bson:"date" json:"date"`
+	Category  string    `bson:"category" json:"category"`
+	Quantity  float64   `bson:"quantity" json:"quantity"`
+	UnitPrice float64   `bson:"unit_price" json:"unit_price"`
+	Amount    float64   `bson:"amount" json:"amount"` // Total amount (Quantity * UnitPrice)
+	Notes     string    `bson:"notes,omitempty" json:"notes,omitempty"`
 }
 
 // EggReceptionRecord captures eggs received by the seller.
@@ -52,3 +141,19 @@ type EggReceptionRecord struct {
 	Quantity  int
 	UnitPrice float64
 }
+
+// VaccinationRecord captures a vaccine or treatment administered to a band.
+type VaccinationRecord struct {
+	Date    time.Time
+	Vaccine string
+	Band    int // 0 means every band
+	Notes   string
+}
+
+// EggPriceRecord captures the tray price effective from a given day.
+type EggPriceRecord struct {
+	EffectiveDate time.Time `bson:"effective_date" json:"effective_date"`
+	PricePerTray  float64   `bson:"price_per_tray" json:"price_per_tray"`
+	SetBy         string    `bson:"set_by" json:"set_by"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Feature names a capability that can be switched on for the whole farm or
+// only for some WhatsApp users while it is rolled out.
+type Feature string
+
+const (
+	// FeatureAIConversations lets free text go through the AI assistant;
+	// without it only commands are understood.
+	FeatureAIConversations Feature = "ai_conversations"
+	// FeatureScheduler runs the jobs on their schedule and catches up
+	// missed runs; jobs run from the admin endpoints still run.
+	FeatureScheduler Feature = "scheduler"
+	// FeatureMongoDualWrite copies the records written to Sheets into the
+	// record store.
+	FeatureMongoDualWrite Feature = "mongo_dual_write"
+)
+
+// Features lists every known feature with whether it is on when no flag
+// says otherwise.
+var Features = map[Feature]bool{
+	FeatureAIConversations: true,
+	FeatureScheduler:       true,
+	FeatureMongoDualWrite:  true,
+}
+
+// FeatureFlag turns a feature on for everyone, or for the listed users only.
+type FeatureFlag struct {
+	Name    Feature `bson:"_id" json:"name"`
+	Enabled bool    `bson:"enabled" json:"enabled"`
+	// Users get the feature even when it is not Enabled.
+	Users     []string  `bson:"users,omitempty" json:"users,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// EnabledFor reports whether user gets the feature. An empty user, e.g. a
+// scheduled job, only gets the features enabled for everyone.
+func (f FeatureFlag) EnabledFor(user string) bool {
+	return f.Enabled || user != "" && slices.Contains(f.Users, user)
+}
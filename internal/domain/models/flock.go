@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// FlockBand captures the placement metadata of a single laying band.
+type FlockBand struct {
+	Band           int
+	PlacementDate  time.Time
+	Breed          string
+	InitialCount   int
+	AgeAtPlacement int // Age in weeks when the birds arrived on the farm
+}
+
+// AgeInWeeks returns the age of the band's birds at the provided instant.
+func (b FlockBand) AgeInWeeks(at time.Time) int {
+	if b.PlacementDate.IsZero() || at.Before(b.PlacementDate) {
+		return b.AgeAtPlacement
+	}
+	days := int(at.Sub(b.PlacementDate).Hours() / 24)
+	return b.AgeAtPlacement + days/7
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// JobRun is the last successful run of a scheduled job, kept so runs missed
+// while the server was down can be caught up at startup.
+type JobRun struct {
+	Name        string    `bson:"_id" json:"name"`
+	LastSuccess time.Time `bson:"last_success" json:"last_success"`
+}
+
+// Lease is a named lock held by one instance until ExpiresAt, e.g. the
+// scheduler lease deciding which replica runs the jobs.
+type Lease struct {
+	Name      string    `bson:"_id" json:"name"`
+	Holder    string    `bson:"holder" json:"holder"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// What started a job execution.
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerCatchUp  = "catch-up"
+	JobTriggerManual   = "manual"
+)
+
+// JobExecution records one run of a scheduled job, successful or not, so an
+// admin can see when a job last worked and why it failed since.
+type JobExecution struct {
+	Name    string `bson:"name" json:"name"`
+	Trigger string `bson:"trigger" json:"trigger"`
+	// DueAt is the time the run was for; StartedAt differs from it for
+	// caught-up runs.
+	DueAt      time.Time `bson:"due_at" json:"due_at"`
+	StartedAt  time.Time `bson:"started_at" json:"started_at"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+	Success    bool      `bson:"success" json:"success"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	// Deliveries lists the messages the run sent, one per recipient and
+	// message, so a report that reached the group but not the owner shows.
+	Deliveries []Delivery `bson:"deliveries,omitempty" json:"deliveries,omitempty"`
+}
+
+// Delivery is the outcome of sending one scheduled message to a recipient,
+// retries included.
+type Delivery struct {
+	To       string `bson:"to" json:"to"`
+	Attempts int    `bson:"attempts" json:"attempts"`
+	Success  bool   `bson:"success" json:"success"`
+	Error    string `bson:"error,omitempty" json:"error,omitempty"`
+	// Document marks a file, such as the monthly PDF, rather than text.
+	Document bool `bson:"document,omitempty" json:"document,omitempty"`
+	// Fallback marks the copy of an undelivered message sent to a fallback
+	// recipient.
+	Fallback bool `bson:"fallback,omitempty" json:"fallback,omitempty"`
+}
+
+// JobExecutionQuery filters job executions. Zero values are ignored.
+type JobExecutionQuery struct {
+	Name  string
+	Limit int64
+}
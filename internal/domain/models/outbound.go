@@ -1,10 +1,125 @@
 package models
 
+import "strings"
+
+// OutboundType is the kind of message an OutboundMessageRequest sends.
+type OutboundType string
+
+// Outbound message types; an empty type sends text.
+const (
+	OutboundTypeText     OutboundType = "text"
+	OutboundTypeTemplate OutboundType = "template"
+	OutboundTypeImage    OutboundType = "image"
+	OutboundTypeDocument OutboundType = "document"
+)
+
 // OutboundMessageRequest represents requests to send a message manually via the API.
 type OutboundMessageRequest struct {
-	To         string `json:"to" binding:"required"`
-	Message    string `json:"message" binding:"required"`
+	To   string       `json:"to" binding:"required"`
+	Type OutboundType `json:"type,omitempty"`
+	// Message is the text, or the caption of an image or document.
+	Message    string `json:"message,omitempty"`
 	PreviewURL bool   `json:"preview_url"`
+	// Template is the approved template of a template message.
+	Template *OutboundTemplate `json:"template,omitempty"`
+	// Media is the file of an image or document message.
+	Media *OutboundMedia `json:"media,omitempty"`
+}
+
+// OutboundTemplate names an approved WhatsApp template and fills its body
+// placeholders in order.
+type OutboundTemplate struct {
+	Name string `json:"name"`
+	// Language defaults to fr.
+	Language   string   `json:"language,omitempty"`
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+// OutboundMedia is a file to send: one already uploaded to Meta (ID), a
+// public HTTPS URL Meta fetches (Link), or the content itself (Data, base64
+// in JSON, with its MimeType), uploaded first.
+type OutboundMedia struct {
+	ID       string `json:"id,omitempty"`
+	Link     string `json:"link,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	// Filename is the document name shown to the recipient; required to
+	// send a document's Data.
+	Filename string `json:"filename,omitempty"`
+}
+
+// Validate checks the request has what its type needs and returns the
+// problems found, or nil.
+func (r OutboundMessageRequest) Validate() []FieldError {
+	var problems []FieldError
+	add := func(field, message string) {
+		problems = append(problems, FieldError{Field: field, Message: message})
+	}
+
+	if r.To == "" {
+		add("to", "is required")
+	}
+	switch r.Type {
+	case "", OutboundTypeText:
+		if r.Message == "" {
+			add("message", "is required for text messages")
+		}
+	case OutboundTypeTemplate:
+		switch {
+		case r.Template == nil || r.Template.Name == "":
+			add("template.name", "is required for template messages")
+		case r.Message != "":
+			add("message", "is not sent with a template: use template.parameters")
+		}
+	case OutboundTypeImage, OutboundTypeDocument:
+		if r.Media == nil {
+			add("media", "is required for "+string(r.Type)+" messages")
+			break
+		}
+		given := 0
+		for _, set := range []bool{r.Media.ID != "", r.Media.Link != "", len(r.Media.Data) > 0} {
+			if set {
+				given++
+			}
+		}
+		if given != 1 {
+			add("media", "needs exactly one of id, link or data")
+		}
+		if r.Media.Link != "" && !strings.HasPrefix(r.Media.Link, "https://") && !strings.HasPrefix(r.Media.Link, "http://") {
+			add("media.link", "must be an http(s) URL")
+		}
+		if len(r.Media.Data) > 0 {
+			if r.Media.MimeType == "" {
+				add("media.mime_type", "is required with data")
+			}
+			if r.Type == OutboundTypeDocument && r.Media.Filename == "" {
+				add("media.filename", "is required to send a document's data")
+			}
+		}
+	default:
+		add("type", "must be text, template, image or document")
+	}
+	return problems
+}
+
+// TemplateMessageRequest asks for an approved WhatsApp template, e.g. for
+// reminders sent outside the 24h session window.
+type TemplateMessageRequest struct {
+	To       string
+	Name     string
+	Language string
+	// Parameters fill the template body placeholders in order.
+	Parameters []string
+}
+
+// DocumentMessageRequest sends a file as a WhatsApp document, e.g. the
+// monthly report PDF.
+type DocumentMessageRequest struct {
+	To       string
+	Filename string
+	MimeType string
+	Data     []byte
+	Caption  string
 }
 
 // AutomationReply describes the response that will be sent back to the worker based on
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Pending sheet write statuses.
+const (
+	PendingWriteQueued = "queued"
+	// PendingWriteSending marks a write being replayed. One left in this
+	// status was appended, or interrupted while appending, and is kept for
+	// manual recovery rather than replayed twice.
+	PendingWriteSending = "sending"
+	PendingWriteFailed  = "failed"
+)
+
+// PendingSheetWrite is a Sheets append queued while Google Sheets was
+// unreachable, replayed in creation order once it is back.
+type PendingSheetWrite struct {
+	ID         string          `bson:"_id,omitempty" json:"id"`
+	SheetRange string          `bson:"sheet_range" json:"sheet_range"`
+	Rows       [][]interface{} `bson:"rows" json:"rows"`
+	Status     string          `bson:"status" json:"status"`
+	// Error explains why a failed write was rejected by the Sheets API.
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RecordVersion is one version of a mirrored farm record in its correction
+// history. Corrections add a version pointing at the original record and
+// close the previous one; deletions only mark the current version, so the
+// history keeps every value ever stored. Reports read current versions only.
+type RecordVersion struct {
+	ID         string `json:"id"`
+	OriginalID string `json:"original_id"`
+	Version    int    `json:"version"`
+	// ChangedBy and ChangedAt are set on corrections; the first version was
+	// created by the command found in the audit log.
+	ChangedBy string     `json:"changed_by,omitempty"`
+	ChangedAt *time.Time `json:"changed_at,omitempty"`
+	Current   bool       `json:"current"`
+	DeletedBy string     `json:"deleted_by,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Record holds the version's fields as stored.
+	Record map[string]interface{} `json:"record"`
+}
+
+// NewRecord returns a pointer to an empty record of the kind, to decode a
+// correction into, or false for an unknown kind.
+func NewRecord(kind RecordKind) (interface{}, bool) {
+	switch kind {
+	case RecordEggs:
+		return &EggRecord{}, true
+	case RecordFeed:
+		return &FeedRecord{}, true
+	case RecordMortality:
+		return &MortalityRecord{}, true
+	case RecordSales:
+		return &SaleRecord{}, true
+	case RecordPayments:
+		return &PaymentRecord{}, true
+	case RecordExpenses:
+		return &ExpenseRecord{}, true
+	}
+	return nil, false
+}
+
+// Decode copies the version's fields into record, a pointer returned by
+// NewRecord for the version's kind.
+func (v RecordVersion) Decode(record interface{}) error {
+	raw, err := json.Marshal(v.Record)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, record)
+}
+
+// RecordDate points at the date of a record returned by NewRecord, or at a
+// zero time for any other value.
+func RecordDate(record interface{}) *time.Time {
+	switch r := record.(type) {
+	case *EggRecord:
+		return &r.Date
+	case *FeedRecord:
+		return &r.Date
+	case *MortalityRecord:
+		return &r.Date
+	case *SaleRecord:
+		return &r.Date
+	case *PaymentRecord:
+		return &r.Date
+	case *ExpenseRecord:
+		return &r.Date
+	}
+	return new(time.Time)
+}
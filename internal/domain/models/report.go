@@ -10,7 +10,26 @@ type DailyReport struct {
 	FeedConsumed  float64   `bson:"feed_consumed" json:"feed_consumed"`
 	SalesAmount   float64   `bson:"sales_amount" json:"sales_amount"`
 	UnpaidBalance float64   `bson:"unpaid_balance" json:"unpaid_balance"`
+	DebtCollected float64   `bson:"debt_collected" json:"debt_collected"`
 	Expenses      float64   `bson:"expenses" json:"expenses"`
 	Profit        float64   `bson:"profit" json:"profit"`
 	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
 }
+
+// MonthlyStats aggregates the records of one calendar month, computed by
+// MongoDB from the mirrored record collections.
+type MonthlyStats struct {
+	Month         time.Time `json:"month"` // first day of the month, UTC
+	Eggs          int       `json:"eggs"`
+	EggDays       int       `json:"egg_days"` // days with an egg entry
+	AvgEggsPerDay float64   `json:"avg_eggs_per_day"`
+	TraysSold     int       `json:"trays_sold"`
+	SalesAmount   float64   `json:"sales_amount"` // trays x unit price
+	SalesPaid     float64   `json:"sales_paid"`
+	AvgTrayPrice  float64   `json:"avg_tray_price"`
+	DebtCollected float64   `json:"debt_collected"`
+	Expenses      float64   `json:"expenses"`
+	// Profit is cash basis, as in the daily report: sales paid + debt
+	// collected − expenses.
+	Profit float64 `json:"profit"`
+}
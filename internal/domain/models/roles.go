@@ -0,0 +1,12 @@
+package models
+
+// Role identifies the farm responsibility attached to a WhatsApp sender.
+type Role string
+
+const (
+	RoleFarmer         Role = "farmer"
+	RoleSeller         Role = "seller"
+	RoleExpenseManager Role = "expense_manager"
+	// RoleGuest is assigned to senders that are not registered farm staff.
+	RoleGuest Role = "guest"
+)
@@ -2,11 +2,22 @@ package models
 
 import "time"
 
-// StateStockRecord captures physical assets added to inventory.
+// StateStockRecord captures physical assets added to inventory. The bson
+// names are the driver defaults the stock_items documents were first stored
+// with.
 type StateStockRecord struct {
-	Date      time.Time
+	Date      time.Time `bson:"date" json:"date"`
+	ItemName  string    `bson:"itemname" json:"item_name"`
+	Quantity  float64   `bson:"quantity" json:"quantity"`
+	UnitPrice float64   `bson:"unitprice" json:"unit_price"`
+	Condition string    `bson:"condition" json:"condition"` // "etat"
+}
+
+// StockQuery filters stock items. ItemName matches any part of the name and
+// Condition the whole condition, both case-insensitively. Zero values are
+// ignored.
+type StockQuery struct {
 	ItemName  string
-	Quantity  float64
-	UnitPrice float64
-	Condition string // "etat"
+	Condition string
+	Limit     int64
 }
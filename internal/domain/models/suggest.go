@@ -0,0 +1,69 @@
+package models
+
+import "strings"
+
+// maxSuggestionDistance bounds how far a typo may be from a known keyword.
+const maxSuggestionDistance = 2
+
+// SuggestCommand returns the known keyword (command name or alias) closest to
+// an unknown one, restricted to commands the role may use. The boolean is
+// false when nothing is close enough.
+func SuggestCommand(keyword string, role Role) (string, bool) {
+	keyword = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(keyword)), "/")
+	if keyword == "" {
+		return "", false
+	}
+
+	var candidates []string
+	allowed := map[CommandType]bool{}
+	for _, spec := range CommandsForRole(role) {
+		allowed[spec.Type] = true
+		candidates = append(candidates, string(spec.Type))
+		candidates = append(candidates, spec.Aliases...)
+	}
+	customAliasesMu.RLock()
+	for alias, target := range customAliases {
+		if allowed[target] {
+			candidates = append(candidates, alias)
+		}
+	}
+	customAliasesMu.RUnlock()
+
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, candidate := range candidates {
+		d := editDistance(keyword, candidate)
+		// Short typos need a closer match to avoid absurd suggestions.
+		if d >= len([]rune(keyword)) {
+			continue
+		}
+		if d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return best, true
+}
+
+// editDistance computes the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
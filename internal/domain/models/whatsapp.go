@@ -1,5 +1,7 @@
 package models
 
+import "fmt"
+
 // WebhookPayload mirrors the structure sent by Meta's WhatsApp Cloud API webhook callbacks.
 type WebhookPayload struct {
 	Object string         `json:"object"`
@@ -105,3 +107,69 @@ type WebhookError struct {
 	Title   string `json:"title"`
 	Message string `json:"message"`
 }
+
+// FieldError names a field of a request body, as a JSON path such as
+// entry[0].changes[0].value.messages[1].from, and what is wrong with it. The
+// field is empty for problems with the whole body.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// maxFieldErrors bounds the problems Validate reports.
+const maxFieldErrors = 20
+
+// Validate checks the fields the webhook processing relies on and returns
+// the problems found, at most 20, or nil for a usable payload. Message types
+// the service does not handle are accepted.
+func (p WebhookPayload) Validate() []FieldError {
+	var problems []FieldError
+	add := func(field, message string) {
+		if len(problems) < maxFieldErrors {
+			problems = append(problems, FieldError{Field: field, Message: message})
+		}
+	}
+
+	if p.Object != "whatsapp_business_account" {
+		add("object", "must be whatsapp_business_account")
+	}
+	if len(p.Entry) == 0 {
+		add("entry", "must not be empty")
+	}
+	for i, entry := range p.Entry {
+		for j, change := range entry.Changes {
+			path := fmt.Sprintf("entry[%d].changes[%d]", i, j)
+			for k, msg := range change.Value.Messages {
+				prefix := fmt.Sprintf("%s.value.messages[%d]", path, k)
+				if msg.ID == "" {
+					add(prefix+".id", "is required")
+				}
+				if msg.From == "" {
+					add(prefix+".from", "is required")
+				}
+				switch msg.Type {
+				case "":
+					add(prefix+".type", "is required")
+				case "text":
+					if msg.Text == nil || msg.Text.Body == "" {
+						add(prefix+".text.body", "is required for text messages")
+					}
+				case "interactive":
+					if msg.Interactive == nil || (msg.Interactive.ButtonReply == nil && msg.Interactive.ListReply == nil) {
+						add(prefix+".interactive", "needs a button_reply or list_reply")
+					}
+				}
+			}
+			for k, status := range change.Value.Statuses {
+				prefix := fmt.Sprintf("%s.value.statuses[%d]", path, k)
+				if status.ID == "" {
+					add(prefix+".id", "is required")
+				}
+				if status.Status == "" {
+					add(prefix+".status", "is required")
+				}
+			}
+		}
+	}
+	return problems
+}
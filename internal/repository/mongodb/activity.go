@@ -0,0 +1,50 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// Publisher receives the activity written to the store; see
+// internal/service/activity.
+type Publisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// ActivityRepository passes everything through to the wrapped repository and
+// publishes the inbound messages, the commands that saved records and the
+// job runs as they are written, whether or not the write succeeds.
+type ActivityRepository struct {
+	Repository
+	publisher Publisher
+}
+
+// NewActivityRepository wraps repo so its activity reaches publisher.
+func NewActivityRepository(repo Repository, publisher Publisher) *ActivityRepository {
+	return &ActivityRepository{Repository: repo, publisher: publisher}
+}
+
+// SaveMessageAudit saves the entry and publishes it as a message.
+func (r *ActivityRepository) SaveMessageAudit(ctx context.Context, entry models.MessageAuditEntry) error {
+	err := r.Repository.SaveMessageAudit(ctx, entry)
+	r.publisher.Publish(models.ActivityMessage, entry)
+	return err
+}
+
+// SaveCommandAudit saves the entry and publishes it as a record when the
+// command saved any.
+func (r *ActivityRepository) SaveCommandAudit(ctx context.Context, entry models.CommandAuditEntry) error {
+	err := r.Repository.SaveCommandAudit(ctx, entry)
+	if entry.Success && len(entry.RecordRefs) > 0 {
+		r.publisher.Publish(models.ActivityRecord, entry)
+	}
+	return err
+}
+
+// SaveJobExecution saves the run and publishes it as a job.
+func (r *ActivityRepository) SaveJobExecution(ctx context.Context, execution models.JobExecution) error {
+	err := r.Repository.SaveJobExecution(ctx, execution)
+	r.publisher.Publish(models.ActivityJob, execution)
+	return err
+}
@@ -0,0 +1,64 @@
+package mongodb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// BackupCollections lists the collections worth exporting: everything but the
+// inbound messages, which expire anyway.
+func (r *MongoDBRepository) BackupCollections() []string {
+	return []string{
+		r.collName,
+		r.stockCollName,
+		r.priceCollName,
+		r.auditCollName,
+		r.customerCollName,
+		r.pendingCollName,
+		string(models.RecordEggs),
+		string(models.RecordFeed),
+		string(models.RecordMortality),
+		string(models.RecordSales),
+		string(models.RecordPayments),
+		string(models.RecordExpenses),
+	}
+}
+
+// ExportCollection writes every document of the collection to w as relaxed
+// extended JSON, one document per line, and returns how many were written.
+// The types survive the round trip, so mongoimport can restore the output.
+func (r *MongoDBRepository) ExportCollection(ctx context.Context, name string, w io.Writer) (int, error) {
+	collection := r.client.Database(r.dbName).Collection(name)
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	defer cursor.Close(ctx)
+
+	out := bufio.NewWriter(w)
+	count := 0
+	for cursor.Next(ctx) {
+		line, err := bson.MarshalExtJSON(cursor.Current, false, false)
+		if err != nil {
+			return count, fmt.Errorf("failed to encode %s document: %w", name, err)
+		}
+		if _, err := out.Write(append(line, '\n')); err != nil {
+			return count, fmt.Errorf("failed to write %s export: %w", name, err)
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		return count, fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	if err := out.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write %s export: %w", name, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,179 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// DryRunRepository reads through to the wrapped repository but only logs
+// writes (SANDBOX_MODE=log).
+type DryRunRepository struct {
+	Repository
+	logger *zap.Logger
+}
+
+// NewDryRunRepository wraps repo so writes are logged instead of stored.
+func NewDryRunRepository(repo Repository, logger *zap.Logger) *DryRunRepository {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DryRunRepository{Repository: repo, logger: logger}
+}
+
+// SaveDailyReport logs the report.
+func (r *DryRunRepository) SaveDailyReport(ctx context.Context, report models.DailyReport) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: daily report not saved", zap.Time("date", report.Date))
+	return nil
+}
+
+// SaveStockItem logs the item and returns an empty ID.
+func (r *DryRunRepository) SaveStockItem(ctx context.Context, item models.StateStockRecord) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: stock item not saved", zap.Any("item", item))
+	return "", nil
+}
+
+// DeleteStockItem logs the deletion.
+func (r *DryRunRepository) DeleteStockItem(ctx context.Context, id string) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: stock item not deleted", zap.String("id", id))
+	return nil
+}
+
+// SaveEggPrice logs the price.
+func (r *DryRunRepository) SaveEggPrice(ctx context.Context, price models.EggPriceRecord) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: egg price not saved", zap.Float64("price_per_tray", price.PricePerTray))
+	return nil
+}
+
+// SaveCommandAudit logs the entry.
+func (r *DryRunRepository) SaveCommandAudit(ctx context.Context, entry models.CommandAuditEntry) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: command audit not saved", zap.String("command", entry.Command), zap.String("sender", entry.Sender), zap.Bool("success", entry.Success))
+	return nil
+}
+
+// SaveMessageAudit logs the entry.
+func (r *DryRunRepository) SaveMessageAudit(ctx context.Context, entry models.MessageAuditEntry) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: message audit not saved", zap.String("wa_id", entry.WaID), zap.String("message_id", entry.MessageID), zap.String("result", entry.Result))
+	return nil
+}
+
+// SaveCustomer logs the customer.
+func (r *DryRunRepository) SaveCustomer(ctx context.Context, customer models.Customer) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: customer not saved", zap.String("name", customer.Name))
+	return nil
+}
+
+// EnqueuePendingWrite logs the write; the sheets side is dry too, so this
+// is only reached if the wrappers are combined differently.
+func (r *DryRunRepository) EnqueuePendingWrite(ctx context.Context, write models.PendingSheetWrite) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: sheet write not queued", zap.String("range", write.SheetRange))
+	return nil
+}
+
+// ListPendingWrites returns nothing: the queue holds writes of real runs,
+// which a dry run must not replay, and nothing is queued while it lasts.
+// Listing the real queue would have the flusher replay the same batch
+// forever, its deletions being dry too.
+func (r *DryRunRepository) ListPendingWrites(ctx context.Context, limit int64) ([]models.PendingSheetWrite, error) {
+	return nil, nil
+}
+
+// MarkPendingWrite logs the status change.
+func (r *DryRunRepository) MarkPendingWrite(ctx context.Context, id, status string) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: pending write not marked", zap.String("id", id), zap.String("status", status))
+	return nil
+}
+
+// DeletePendingWrite logs the deletion.
+func (r *DryRunRepository) DeletePendingWrite(ctx context.Context, id string) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: pending write not deleted", zap.String("id", id))
+	return nil
+}
+
+// FailPendingWrite logs the failure.
+func (r *DryRunRepository) FailPendingWrite(ctx context.Context, id, reason string) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: pending write not marked failed", zap.String("id", id), zap.String("reason", reason))
+	return nil
+}
+
+// SaveEggRecord logs the record and returns an empty ID.
+func (r *DryRunRepository) SaveEggRecord(ctx context.Context, record models.EggRecord) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: egg record not saved", zap.Time("date", record.Date), zap.Int("quantity", record.Quantity))
+	return "", nil
+}
+
+// SaveFeedRecord logs the record and returns an empty ID.
+func (r *DryRunRepository) SaveFeedRecord(ctx context.Context, record models.FeedRecord) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: feed record not saved", zap.Time("date", record.Date), zap.Float64("feed_kg", record.FeedKg))
+	return "", nil
+}
+
+// SaveMortalityRecord logs the record and returns an empty ID.
+func (r *DryRunRepository) SaveMortalityRecord(ctx context.Context, record models.MortalityRecord) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: mortality record not saved", zap.Time("date", record.Date), zap.Int("deaths", record.Total()))
+	return "", nil
+}
+
+// SaveSaleRecord logs the record and returns an empty ID.
+func (r *DryRunRepository) SaveSaleRecord(ctx context.Context, record models.SaleRecord) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: sale record not saved", zap.Time("date", record.Date), zap.String("client", record.Client), zap.Int("quantity", record.Quantity))
+	return "", nil
+}
+
+// SaveExpenseRecord logs the record and returns an empty ID.
+func (r *DryRunRepository) SaveExpenseRecord(ctx context.Context, record models.ExpenseRecord) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: expense record not saved", zap.Time("date", record.Date), zap.String("category", record.Category), zap.Float64("amount", record.Amount))
+	return "", nil
+}
+
+// SavePaymentRecord logs the record and returns an empty ID.
+func (r *DryRunRepository) SavePaymentRecord(ctx context.Context, record models.PaymentRecord) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: payment record not saved", zap.Time("date", record.Date), zap.String("client", record.Client), zap.Float64("amount", record.Amount))
+	return "", nil
+}
+
+// DeleteRecord logs the deletion.
+func (r *DryRunRepository) DeleteRecord(ctx context.Context, kind models.RecordKind, id, by string) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: record not deleted", zap.String("kind", string(kind)), zap.String("id", id), zap.String("by", by))
+	return nil
+}
+
+// CorrectRecord logs the correction.
+func (r *DryRunRepository) CorrectRecord(ctx context.Context, kind models.RecordKind, id string, record interface{}, by string) (string, error) {
+	logger.FromContext(ctx, r.logger).Info("dry run: record not corrected", zap.String("kind", string(kind)), zap.String("id", id), zap.Any("record", record), zap.String("by", by))
+	return "", nil
+}
+
+// SaveJobRun logs the run.
+func (r *DryRunRepository) SaveJobRun(ctx context.Context, run models.JobRun) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: job run not saved", zap.String("job", run.Name), zap.Time("last_success", run.LastSuccess))
+	return nil
+}
+
+// SaveFeatureFlag logs the flag.
+func (r *DryRunRepository) SaveFeatureFlag(ctx context.Context, flag models.FeatureFlag) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: feature flag not saved", zap.String("feature", string(flag.Name)), zap.Bool("enabled", flag.Enabled), zap.Strings("users", flag.Users))
+	return nil
+}
+
+// SaveJobExecution logs the run.
+func (r *DryRunRepository) SaveJobExecution(ctx context.Context, execution models.JobExecution) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: job execution not saved", zap.String("job", execution.Name), zap.Bool("success", execution.Success), zap.String("error", execution.Error))
+	return nil
+}
+
+// AcquireLease grants every lease without storing it: a dry-run instance does
+// not compete with the real ones.
+func (r *DryRunRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	logger.FromContext(ctx, r.logger).Debug("dry run: lease not stored", zap.String("lease", name), zap.String("holder", holder))
+	return true, nil
+}
+
+// ReleaseLease does nothing, no lease having been stored.
+func (r *DryRunRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// saleDocument is a sale stored with the normalized client name the balance
+// aggregation groups on, so spellings of one client share a ledger.
+type saleDocument struct {
+	models.SaleRecord `bson:",inline"`
+	ClientKey         string `bson:"client_key"`
+}
+
+// paymentDocument is a repayment stored with its normalized client name.
+type paymentDocument struct {
+	models.PaymentRecord `bson:",inline"`
+	ClientKey            string `bson:"client_key"`
+}
+
+// SaveSaleRecord stores a copy of a sale record and returns its hex ID.
+func (r *MongoDBRepository) SaveSaleRecord(ctx context.Context, record models.SaleRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordSales, saleDocument{SaleRecord: record, ClientKey: models.CustomerKey(record.Client)})
+}
+
+// SavePaymentRecord stores a copy of a debt repayment and returns its hex ID.
+func (r *MongoDBRepository) SavePaymentRecord(ctx context.Context, record models.PaymentRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordPayments, paymentDocument{PaymentRecord: record, ClientKey: models.CustomerKey(record.Client)})
+}
+
+// GetClientBalances returns the clients who still owe money, largest balance
+// first. Each sale adds its unpaid part (never negative) and each payment
+// subtracts its amount; the registered customer name and phone are used when
+// the client is known. Only current record versions count.
+func (r *MongoDBRepository) GetClientBalances(ctx context.Context) ([]models.ClientBalance, error) {
+	collection := r.client.Database(r.dbName).Collection(string(models.RecordSales))
+
+	unpaid := bson.M{"$max": bson.A{
+		bson.M{"$subtract": bson.A{bson.M{"$multiply": bson.A{"$quantity", "$price_per_unit"}}, "$paid"}},
+		0,
+	}}
+	pipeline := bson.A{
+		bson.M{"$match": currentVersion()},
+		bson.M{"$project": bson.M{
+			"client_key": 1, "client": 1, "date": 1,
+			"due":    unpaid,
+			"repaid": bson.M{"$literal": 0},
+		}},
+		bson.M{"$unionWith": bson.M{
+			"coll": string(models.RecordPayments),
+			"pipeline": bson.A{
+				bson.M{"$match": currentVersion()},
+				bson.M{"$project": bson.M{
+					"client_key": 1, "client": 1, "date": 1,
+					"due":    bson.M{"$literal": 0},
+					"repaid": "$amount",
+				}},
+			},
+		}},
+		bson.M{"$group": bson.M{
+			"_id":    "$client_key",
+			"client": bson.M{"$last": "$client"},
+			"due":    bson.M{"$sum": "$due"},
+			"repaid": bson.M{"$sum": "$repaid"},
+			// $min and $max skip the nulls of the other entry type.
+			"oldest_unpaid": bson.M{"$min": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$due", 0}}, "$date", nil}}},
+			"last_payment":  bson.M{"$max": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$repaid", 0}}, "$date", nil}}},
+		}},
+		bson.M{"$addFields": bson.M{"balance": bson.M{"$subtract": bson.A{"$due", "$repaid"}}}},
+		bson.M{"$match": bson.M{"balance": bson.M{"$gt": 0}}},
+		bson.M{"$lookup": bson.M{
+			"from":         r.customerCollName,
+			"localField":   "_id",
+			"foreignField": "name_key",
+			"as":           "customer",
+		}},
+		bson.M{"$addFields": bson.M{
+			"client": bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$customer.name", 0}}, "$client"}},
+			"phone":  bson.M{"$arrayElemAt": bson.A{"$customer.phone", 0}},
+		}},
+		bson.M{"$sort": bson.D{{Key: "balance", Value: -1}, {Key: "_id", Value: 1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate client balances: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var balances []models.ClientBalance
+	if err := cursor.All(ctx, &balances); err != nil {
+		return nil, fmt.Errorf("failed to decode client balances: %w", err)
+	}
+	return balances, nil
+}
@@ -0,0 +1,67 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// GetEggRecords returns the mirrored egg records dated from start up to,
+// excluding, end, oldest first.
+func (r *MongoDBRepository) GetEggRecords(ctx context.Context, start, end time.Time) ([]models.EggRecord, error) {
+	return findRecords[models.EggRecord](ctx, r, models.RecordEggs, start, end)
+}
+
+// GetFeedRecords returns the mirrored feed records dated from start up to,
+// excluding, end, oldest first.
+func (r *MongoDBRepository) GetFeedRecords(ctx context.Context, start, end time.Time) ([]models.FeedRecord, error) {
+	return findRecords[models.FeedRecord](ctx, r, models.RecordFeed, start, end)
+}
+
+// GetMortalityRecords returns the mirrored mortality records dated from start
+// up to, excluding, end, oldest first.
+func (r *MongoDBRepository) GetMortalityRecords(ctx context.Context, start, end time.Time) ([]models.MortalityRecord, error) {
+	return findRecords[models.MortalityRecord](ctx, r, models.RecordMortality, start, end)
+}
+
+// GetSaleRecords returns the mirrored sales dated from start up to,
+// excluding, end, oldest first.
+func (r *MongoDBRepository) GetSaleRecords(ctx context.Context, start, end time.Time) ([]models.SaleRecord, error) {
+	return findRecords[models.SaleRecord](ctx, r, models.RecordSales, start, end)
+}
+
+// GetPaymentRecords returns the mirrored debt repayments dated from start up
+// to, excluding, end, oldest first.
+func (r *MongoDBRepository) GetPaymentRecords(ctx context.Context, start, end time.Time) ([]models.PaymentRecord, error) {
+	return findRecords[models.PaymentRecord](ctx, r, models.RecordPayments, start, end)
+}
+
+// GetExpenseRecords returns the mirrored expenses dated from start up to,
+// excluding, end, oldest first.
+func (r *MongoDBRepository) GetExpenseRecords(ctx context.Context, start, end time.Time) ([]models.ExpenseRecord, error) {
+	return findRecords[models.ExpenseRecord](ctx, r, models.RecordExpenses, start, end)
+}
+
+// findRecords reads the current versions of the records of kind dated from
+// start up to, excluding, end.
+func findRecords[T any](ctx context.Context, r *MongoDBRepository, kind models.RecordKind, start, end time.Time) ([]T, error) {
+	collection := r.client.Database(r.dbName).Collection(string(kind))
+	filter := currentVersion()
+	filter["date"] = bson.M{"$gte": start, "$lt": end}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s records: %w", kind, err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []T
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode %s records: %w", kind, err)
+	}
+	return records, nil
+}
@@ -2,34 +2,115 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
+	"github.com/mamadbah2/farmer/internal/config"
 	"github.com/mamadbah2/farmer/internal/domain/models"
 )
 
-// Repository defines the interface for report storage.
+// Repository defines the interface for report storage: reports, audits,
+// customers, the Sheets write queue and the record copies. MongoDBRepository
+// implements it, sqlstore.SQLRepository too for deployments without MongoDB.
 type Repository interface {
 	SaveDailyReport(ctx context.Context, report models.DailyReport) error
 	GetDailyReports(ctx context.Context, start, end time.Time) ([]models.DailyReport, error)
-	SaveStockItem(ctx context.Context, item models.StateStockRecord) error
+	SaveStockItem(ctx context.Context, item models.StateStockRecord) (string, error)
+	DeleteStockItem(ctx context.Context, id string) error
+	GetStockItems(ctx context.Context, query models.StockQuery) ([]models.StateStockRecord, error)
+	SaveEggPrice(ctx context.Context, price models.EggPriceRecord) error
+	GetLatestEggPrice(ctx context.Context) (*models.EggPriceRecord, error)
+	SaveCommandAudit(ctx context.Context, entry models.CommandAuditEntry) error
+	ListCommandAudits(ctx context.Context, query models.AuditQuery) ([]models.CommandAuditEntry, error)
+	SaveMessageAudit(ctx context.Context, entry models.MessageAuditEntry) error
+	ListMessageAudits(ctx context.Context, query models.MessageAuditQuery) ([]models.MessageAuditEntry, error)
+	SaveCustomer(ctx context.Context, customer models.Customer) error
+	ListCustomers(ctx context.Context) ([]models.Customer, error)
+	EnqueuePendingWrite(ctx context.Context, write models.PendingSheetWrite) error
+	ListPendingWrites(ctx context.Context, limit int64) ([]models.PendingSheetWrite, error)
+	MarkPendingWrite(ctx context.Context, id, status string) error
+	DeletePendingWrite(ctx context.Context, id string) error
+	FailPendingWrite(ctx context.Context, id, reason string) error
+	SaveEggRecord(ctx context.Context, record models.EggRecord) (string, error)
+	SaveFeedRecord(ctx context.Context, record models.FeedRecord) (string, error)
+	SaveMortalityRecord(ctx context.Context, record models.MortalityRecord) (string, error)
+	SaveSaleRecord(ctx context.Context, record models.SaleRecord) (string, error)
+	SaveExpenseRecord(ctx context.Context, record models.ExpenseRecord) (string, error)
+	SavePaymentRecord(ctx context.Context, record models.PaymentRecord) (string, error)
+	GetEggRecords(ctx context.Context, start, end time.Time) ([]models.EggRecord, error)
+	GetFeedRecords(ctx context.Context, start, end time.Time) ([]models.FeedRecord, error)
+	GetMortalityRecords(ctx context.Context, start, end time.Time) ([]models.MortalityRecord, error)
+	GetSaleRecords(ctx context.Context, start, end time.Time) ([]models.SaleRecord, error)
+	GetPaymentRecords(ctx context.Context, start, end time.Time) ([]models.PaymentRecord, error)
+	GetExpenseRecords(ctx context.Context, start, end time.Time) ([]models.ExpenseRecord, error)
+	GetClientBalances(ctx context.Context) ([]models.ClientBalance, error)
+	GetMonthlyStats(ctx context.Context, start, end time.Time) ([]models.MonthlyStats, error)
+	DeleteRecord(ctx context.Context, kind models.RecordKind, id, by string) error
+	CorrectRecord(ctx context.Context, kind models.RecordKind, id string, record interface{}, by string) (string, error)
+	GetRecordHistory(ctx context.Context, kind models.RecordKind, id string) ([]models.RecordVersion, error)
+	ListRecords(ctx context.Context, kind models.RecordKind, start, end time.Time) ([]models.RecordVersion, error)
+	SaveJobRun(ctx context.Context, run models.JobRun) error
+	ListJobRuns(ctx context.Context) ([]models.JobRun, error)
+	SaveJobExecution(ctx context.Context, execution models.JobExecution) error
+	ListJobExecutions(ctx context.Context, query models.JobExecutionQuery) ([]models.JobExecution, error)
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	SaveFeatureFlag(ctx context.Context, flag models.FeatureFlag) error
+	ListFeatureFlags(ctx context.Context) ([]models.FeatureFlag, error)
+	BackupCollections() []string
+	ExportCollection(ctx context.Context, name string, w io.Writer) (int, error)
 }
 
+// defaultAuditLimit caps audit and stock queries that do not specify a limit.
+const defaultAuditLimit = 100
+
 // MongoDBRepository implements the Repository interface for MongoDB.
 type MongoDBRepository struct {
-	client        *mongo.Client
-	dbName        string
-	collName      string
-	stockCollName string
+	client           *mongo.Client
+	dbName           string
+	collName         string
+	stockCollName    string
+	priceCollName    string
+	auditCollName    string
+	messageCollName  string
+	customerCollName string
+	pendingCollName  string
+	jobRunCollName   string
+	jobExecCollName  string
+	leaseCollName    string
+	flagCollName     string
 }
 
-// NewMongoDBRepository creates a new MongoDB repository.
-func NewMongoDBRepository(ctx context.Context, uri string, dbName string) (*MongoDBRepository, error) {
-	clientOptions := options.Client().ApplyURI(uri)
+// NewMongoDBRepository connects to cfg.URI with the configured pool size,
+// server selection timeout and read preference. The server selection timeout
+// also bounds the startup ping, so an unreachable cluster fails fast.
+func NewMongoDBRepository(ctx context.Context, cfg config.MongoDBConfig) (*MongoDBRepository, error) {
+	mode, err := readpref.ModeFromString(cfg.ReadPreference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid read preference: %w", err)
+	}
+	readPref, err := readpref.New(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid read preference: %w", err)
+	}
+
+	clientOptions := options.Client().
+		ApplyURI(cfg.URI).
+		SetServerSelectionTimeout(cfg.ServerSelectionTimeout).
+		SetReadPreference(readPref).
+		SetMonitor(newCommandMonitor())
+	if cfg.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
@@ -41,17 +122,118 @@ func NewMongoDBRepository(ctx context.Context, uri string, dbName string) (*Mong
 	}
 
 	return &MongoDBRepository{
-		client:        client,
-		dbName:        dbName,
-		collName:      "daily_reports",
-		stockCollName: "stock_items",
+		client:           client,
+		dbName:           cfg.DBName,
+		collName:         "daily_reports",
+		stockCollName:    "stock_items",
+		priceCollName:    "egg_prices",
+		auditCollName:    "command_audit",
+		messageCollName:  "message_audit",
+		customerCollName: "customers",
+		pendingCollName:  "pending_sheet_writes",
+		jobRunCollName:   "job_runs",
+		jobExecCollName:  "job_executions",
+		leaseCollName:    "leases",
+		flagCollName:     "feature_flags",
 	}, nil
 }
 
-// SaveDailyReport saves a daily report to the database.
+// EnsureIndexes creates the indexes the queries rely on. Creating an index
+// that already exists is a no-op, so it runs at every start. The unique
+// index on daily report dates fails while duplicate reports remain.
+// Inbound messages expire messageRetention after they were received.
+func (r *MongoDBRepository) EnsureIndexes(ctx context.Context, messageRetention time.Duration) error {
+	byDate := mongo.IndexModel{Keys: bson.D{{Key: "date", Value: 1}}}
+	indexes := map[string][]mongo.IndexModel{
+		r.collName: {
+			{Keys: bson.D{{Key: "date", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		r.auditCollName: {
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "sender", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		r.messageCollName: {
+			{Keys: bson.D{{Key: "wa_id", Value: 1}, {Key: "received_at", Value: -1}}},
+		},
+		r.customerCollName: {
+			{Keys: bson.D{{Key: "name_key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		r.priceCollName: {
+			{Keys: bson.D{{Key: "effective_date", Value: -1}, {Key: "created_at", Value: -1}}},
+		},
+		r.stockCollName: {
+			{Keys: bson.D{{Key: "date", Value: -1}}},
+		},
+		r.pendingCollName: {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		r.jobExecCollName: {
+			{Keys: bson.D{{Key: "started_at", Value: -1}}},
+			{Keys: bson.D{{Key: "name", Value: 1}, {Key: "started_at", Value: -1}}},
+		},
+		string(models.RecordEggs):      {byDate},
+		string(models.RecordFeed):      {byDate},
+		string(models.RecordMortality): {byDate},
+		string(models.RecordExpenses):  {byDate},
+		string(models.RecordSales): {
+			byDate,
+			{Keys: bson.D{{Key: "client_key", Value: 1}, {Key: "date", Value: 1}}},
+		},
+		string(models.RecordPayments): {
+			byDate,
+			{Keys: bson.D{{Key: "client_key", Value: 1}, {Key: "date", Value: 1}}},
+		},
+	}
+
+	var errs []error
+	for name, specs := range indexes {
+		if _, err := r.client.Database(r.dbName).Collection(name).Indexes().CreateMany(ctx, specs); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create %s indexes: %w", name, err))
+		}
+	}
+	if err := r.ensureMessageTTL(ctx, messageRetention); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ensureMessageTTL creates the TTL index on received_at, or updates its
+// expiry when the retention changed since it was created.
+func (r *MongoDBRepository) ensureMessageTTL(ctx context.Context, retention time.Duration) error {
+	db := r.client.Database(r.dbName)
+	seconds := int32(retention / time.Second)
+	ttl := mongo.IndexModel{
+		Keys:    bson.D{{Key: "received_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(seconds),
+	}
+	_, err := db.Collection(r.messageCollName).Indexes().CreateOne(ctx, ttl)
+	if err == nil {
+		return nil
+	}
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Name != "IndexOptionsConflict" {
+		return fmt.Errorf("failed to create %s ttl index: %w", r.messageCollName, err)
+	}
+
+	cmd := bson.D{
+		{Key: "collMod", Value: r.messageCollName},
+		{Key: "index", Value: bson.D{
+			{Key: "keyPattern", Value: bson.D{{Key: "received_at", Value: 1}}},
+			{Key: "expireAfterSeconds", Value: seconds},
+		}},
+	}
+	if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to update %s ttl index: %w", r.messageCollName, err)
+	}
+	return nil
+}
+
+// SaveDailyReport saves a daily report, replacing the one already stored for
+// the same date so regenerated reports are not counted twice.
 func (r *MongoDBRepository) SaveDailyReport(ctx context.Context, report models.DailyReport) error {
 	collection := r.client.Database(r.dbName).Collection(r.collName)
-	_, err := collection.InsertOne(ctx, report)
+	opts := options.Replace().SetUpsert(true)
+	_, err := collection.ReplaceOne(ctx, bson.M{"date": report.Date}, report, opts)
 	if err != nil {
 		return fmt.Errorf("failed to insert daily report: %w", err)
 	}
@@ -82,12 +264,444 @@ func (r *MongoDBRepository) GetDailyReports(ctx context.Context, start, end time
 	return reports, nil
 }
 
-// SaveStockItem saves a physical stock item to the database.
-func (r *MongoDBRepository) SaveStockItem(ctx context.Context, item models.StateStockRecord) error {
+// SaveStockItem saves a physical stock item to the database and returns its hex ID.
+func (r *MongoDBRepository) SaveStockItem(ctx context.Context, item models.StateStockRecord) (string, error) {
+	collection := r.client.Database(r.dbName).Collection(r.stockCollName)
+	result, err := collection.InsertOne(ctx, item)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert stock item: %w", err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		return id.Hex(), nil
+	}
+	return fmt.Sprint(result.InsertedID), nil
+}
+
+// DeleteStockItem removes a stock item by its hex ID.
+func (r *MongoDBRepository) DeleteStockItem(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid stock item id %s: %w", id, err)
+	}
+
+	collection := r.client.Database(r.dbName).Collection(r.stockCollName)
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": objectID}); err != nil {
+		return fmt.Errorf("failed to delete stock item: %w", err)
+	}
+	return nil
+}
+
+// GetStockItems returns the stock items matching the query, newest first.
+func (r *MongoDBRepository) GetStockItems(ctx context.Context, query models.StockQuery) ([]models.StateStockRecord, error) {
 	collection := r.client.Database(r.dbName).Collection(r.stockCollName)
-	_, err := collection.InsertOne(ctx, item)
+
+	filter := bson.M{}
+	if query.ItemName != "" {
+		filter["itemname"] = primitive.Regex{Pattern: regexp.QuoteMeta(query.ItemName), Options: "i"}
+	}
+	if query.Condition != "" {
+		filter["condition"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(query.Condition) + "$", Options: "i"}
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}}).SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stock items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var items []models.StateStockRecord
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode stock items: %w", err)
+	}
+	return items, nil
+}
+
+// SaveEggPrice stores a new tray price entry.
+func (r *MongoDBRepository) SaveEggPrice(ctx context.Context, price models.EggPriceRecord) error {
+	collection := r.client.Database(r.dbName).Collection(r.priceCollName)
+	if _, err := collection.InsertOne(ctx, price); err != nil {
+		return fmt.Errorf("failed to insert egg price: %w", err)
+	}
+	return nil
+}
+
+// GetLatestEggPrice returns the most recent tray price, or nil when none is recorded.
+func (r *MongoDBRepository) GetLatestEggPrice(ctx context.Context) (*models.EggPriceRecord, error) {
+	collection := r.client.Database(r.dbName).Collection(r.priceCollName)
+	opts := options.FindOne().SetSort(bson.D{{Key: "effective_date", Value: -1}, {Key: "created_at", Value: -1}})
+
+	var price models.EggPriceRecord
+	if err := collection.FindOne(ctx, bson.M{}, opts).Decode(&price); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find latest egg price: %w", err)
+	}
+	return &price, nil
+}
+
+// SaveCommandAudit stores the outcome of a handled command.
+func (r *MongoDBRepository) SaveCommandAudit(ctx context.Context, entry models.CommandAuditEntry) error {
+	collection := r.client.Database(r.dbName).Collection(r.auditCollName)
+	if _, err := collection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("failed to insert command audit: %w", err)
+	}
+	return nil
+}
+
+// ListCommandAudits returns audit entries matching the query, newest first.
+func (r *MongoDBRepository) ListCommandAudits(ctx context.Context, query models.AuditQuery) ([]models.CommandAuditEntry, error) {
+	collection := r.client.Database(r.dbName).Collection(r.auditCollName)
+
+	filter := bson.M{}
+	if query.Sender != "" {
+		filter["sender"] = query.Sender
+	}
+	if query.Command != "" {
+		filter["command"] = query.Command
+	}
+	created := bson.M{}
+	if !query.From.IsZero() {
+		created["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		created["$lte"] = query.To
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
-		return fmt.Errorf("failed to insert stock item: %w", err)
+		return nil, fmt.Errorf("failed to find command audits: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.CommandAuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode command audits: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveMessageAudit stores one inbound message.
+func (r *MongoDBRepository) SaveMessageAudit(ctx context.Context, entry models.MessageAuditEntry) error {
+	collection := r.client.Database(r.dbName).Collection(r.messageCollName)
+	if _, err := collection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("failed to insert message audit: %w", err)
+	}
+	return nil
+}
+
+// ListMessageAudits returns inbound messages matching the query, newest first.
+func (r *MongoDBRepository) ListMessageAudits(ctx context.Context, query models.MessageAuditQuery) ([]models.MessageAuditEntry, error) {
+	collection := r.client.Database(r.dbName).Collection(r.messageCollName)
+
+	filter := bson.M{}
+	if query.WaID != "" {
+		filter["wa_id"] = query.WaID
+	}
+	received := bson.M{}
+	if !query.From.IsZero() {
+		received["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		received["$lte"] = query.To
+	}
+	if len(received) > 0 {
+		filter["received_at"] = received
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "received_at", Value: -1}}).SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message audits: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.MessageAuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode message audits: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveCustomer creates or updates a customer, keyed by its normalized name.
+func (r *MongoDBRepository) SaveCustomer(ctx context.Context, customer models.Customer) error {
+	collection := r.client.Database(r.dbName).Collection(r.customerCollName)
+	filter := bson.M{"name_key": customer.NameKey}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := collection.ReplaceOne(ctx, filter, customer, opts); err != nil {
+		return fmt.Errorf("failed to save customer: %w", err)
+	}
+	return nil
+}
+
+// ListCustomers returns every registered customer sorted by name.
+func (r *MongoDBRepository) ListCustomers(ctx context.Context) ([]models.Customer, error) {
+	collection := r.client.Database(r.dbName).Collection(r.customerCollName)
+	opts := options.Find().SetSort(bson.D{{Key: "name_key", Value: 1}})
+
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find customers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var customers []models.Customer
+	if err := cursor.All(ctx, &customers); err != nil {
+		return nil, fmt.Errorf("failed to decode customers: %w", err)
+	}
+	return customers, nil
+}
+
+// SaveJobRun records the last successful run of a scheduled job, replacing
+// the previous one.
+func (r *MongoDBRepository) SaveJobRun(ctx context.Context, run models.JobRun) error {
+	collection := r.client.Database(r.dbName).Collection(r.jobRunCollName)
+	opts := options.Replace().SetUpsert(true)
+	if _, err := collection.ReplaceOne(ctx, bson.M{"_id": run.Name}, run, opts); err != nil {
+		return fmt.Errorf("failed to save job run: %w", err)
+	}
+	return nil
+}
+
+// ListJobRuns returns the last successful run of every job that ever
+// succeeded.
+func (r *MongoDBRepository) ListJobRuns(ctx context.Context) ([]models.JobRun, error) {
+	collection := r.client.Database(r.dbName).Collection(r.jobRunCollName)
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job runs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var runs []models.JobRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, fmt.Errorf("failed to decode job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// SaveFeatureFlag stores a feature flag, replacing the previous one.
+func (r *MongoDBRepository) SaveFeatureFlag(ctx context.Context, flag models.FeatureFlag) error {
+	collection := r.client.Database(r.dbName).Collection(r.flagCollName)
+	opts := options.Replace().SetUpsert(true)
+	if _, err := collection.ReplaceOne(ctx, bson.M{"_id": flag.Name}, flag, opts); err != nil {
+		return fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	return nil
+}
+
+// ListFeatureFlags returns every stored feature flag.
+func (r *MongoDBRepository) ListFeatureFlags(ctx context.Context) ([]models.FeatureFlag, error) {
+	collection := r.client.Database(r.dbName).Collection(r.flagCollName)
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find feature flags: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var flags []models.FeatureFlag
+	if err := cursor.All(ctx, &flags); err != nil {
+		return nil, fmt.Errorf("failed to decode feature flags: %w", err)
+	}
+	return flags, nil
+}
+
+// SaveJobExecution stores one run of a scheduled job.
+func (r *MongoDBRepository) SaveJobExecution(ctx context.Context, execution models.JobExecution) error {
+	collection := r.client.Database(r.dbName).Collection(r.jobExecCollName)
+	if _, err := collection.InsertOne(ctx, execution); err != nil {
+		return fmt.Errorf("failed to insert job execution: %w", err)
+	}
+	return nil
+}
+
+// ListJobExecutions returns the job runs matching the query, newest first.
+func (r *MongoDBRepository) ListJobExecutions(ctx context.Context, query models.JobExecutionQuery) ([]models.JobExecution, error) {
+	collection := r.client.Database(r.dbName).Collection(r.jobExecCollName)
+
+	filter := bson.M{}
+	if query.Name != "" {
+		filter["name"] = query.Name
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job executions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var executions []models.JobExecution
+	if err := cursor.All(ctx, &executions); err != nil {
+		return nil, fmt.Errorf("failed to decode job executions: %w", err)
+	}
+	return executions, nil
+}
+
+// AcquireLease takes or renews the lease name for holder until ttl from now
+// and reports whether holder has it. A lease held by another instance is only
+// taken once expired.
+func (r *MongoDBRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	collection := r.client.Database(r.dbName).Collection(r.leaseCollName)
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{bson.M{"holder": holder}, bson.M{"expires_at": bson.M{"$lt": now}}},
+	}
+	update := bson.M{"$set": bson.M{"holder": holder, "expires_at": now.Add(ttl)}}
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The upsert collided with a lease another instance still holds.
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// ReleaseLease gives up the lease name if holder has it.
+func (r *MongoDBRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	collection := r.client.Database(r.dbName).Collection(r.leaseCollName)
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": name, "holder": holder}); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// EnqueuePendingWrite stores a Sheets append to replay later.
+func (r *MongoDBRepository) EnqueuePendingWrite(ctx context.Context, write models.PendingSheetWrite) error {
+	collection := r.client.Database(r.dbName).Collection(r.pendingCollName)
+	write.ID = ""
+	write.Status = models.PendingWriteQueued
+	if _, err := collection.InsertOne(ctx, write); err != nil {
+		return fmt.Errorf("failed to queue sheet write: %w", err)
+	}
+	return nil
+}
+
+// ListPendingWrites returns up to limit queued writes, oldest first.
+func (r *MongoDBRepository) ListPendingWrites(ctx context.Context, limit int64) ([]models.PendingSheetWrite, error) {
+	collection := r.client.Database(r.dbName).Collection(r.pendingCollName)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, bson.M{"status": models.PendingWriteQueued}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending sheet writes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var writes []models.PendingSheetWrite
+	if err := cursor.All(ctx, &writes); err != nil {
+		return nil, fmt.Errorf("failed to decode pending sheet writes: %w", err)
+	}
+	return writes, nil
+}
+
+// MarkPendingWrite sets the status of a write by its hex ID.
+func (r *MongoDBRepository) MarkPendingWrite(ctx context.Context, id, status string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid pending write id %s: %w", id, err)
+	}
+
+	collection := r.client.Database(r.dbName).Collection(r.pendingCollName)
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"status": status}}); err != nil {
+		return fmt.Errorf("failed to mark pending sheet write %s: %w", status, err)
+	}
+	return nil
+}
+
+// DeletePendingWrite removes a replayed write by its hex ID.
+func (r *MongoDBRepository) DeletePendingWrite(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid pending write id %s: %w", id, err)
+	}
+
+	collection := r.client.Database(r.dbName).Collection(r.pendingCollName)
+	if _, err := collection.DeleteOne(ctx, bson.M{"_id": objectID}); err != nil {
+		return fmt.Errorf("failed to delete pending sheet write: %w", err)
+	}
+	return nil
+}
+
+// FailPendingWrite parks a write the Sheets API rejected, keeping it for
+// manual recovery instead of replaying it forever.
+func (r *MongoDBRepository) FailPendingWrite(ctx context.Context, id, reason string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid pending write id %s: %w", id, err)
+	}
+
+	collection := r.client.Database(r.dbName).Collection(r.pendingCollName)
+	update := bson.M{"$set": bson.M{"status": models.PendingWriteFailed, "error": reason}}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return fmt.Errorf("failed to mark pending sheet write failed: %w", err)
+	}
+	return nil
+}
+
+// SaveEggRecord stores a copy of an egg record and returns its hex ID.
+func (r *MongoDBRepository) SaveEggRecord(ctx context.Context, record models.EggRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordEggs, record)
+}
+
+// SaveFeedRecord stores a copy of a feed record and returns its hex ID.
+func (r *MongoDBRepository) SaveFeedRecord(ctx context.Context, record models.FeedRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordFeed, record)
+}
+
+// SaveMortalityRecord stores a copy of a mortality record and returns its hex ID.
+func (r *MongoDBRepository) SaveMortalityRecord(ctx context.Context, record models.MortalityRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordMortality, record)
+}
+
+// SaveExpenseRecord stores a copy of an expense record and returns its hex ID.
+func (r *MongoDBRepository) SaveExpenseRecord(ctx context.Context, record models.ExpenseRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordExpenses, record)
+}
+
+func (r *MongoDBRepository) insertRecord(ctx context.Context, kind models.RecordKind, record interface{}) (string, error) {
+	collection := r.client.Database(r.dbName).Collection(string(kind))
+	result, err := collection.InsertOne(ctx, record)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert %s record: %w", kind, err)
+	}
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		return id.Hex(), nil
+	}
+	return fmt.Sprint(result.InsertedID), nil
+}
+
+// Ping checks that a server matching the read preference answers.
+func (r *MongoDBRepository) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("failed to ping mongodb: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// monthTotals is one month of a record collection grouped by $group.
+type monthTotals struct {
+	Month string  `bson:"_id"` // YYYY-MM
+	Total float64 `bson:"total"`
+	Paid  float64 `bson:"paid"`
+	Units float64 `bson:"units"`
+	Days  int     `bson:"days"`
+}
+
+// GetMonthlyStats returns, per calendar month (UTC) in ascending order, the
+// totals and averages of the records dated from start up to, excluding, end.
+// Months without any record are omitted; corrected and deleted record
+// versions are ignored.
+func (r *MongoDBRepository) GetMonthlyStats(ctx context.Context, start, end time.Time) ([]models.MonthlyStats, error) {
+	eggs, err := r.sumByMonth(ctx, models.RecordEggs, start, end, "$quantity", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := r.sumByMonth(ctx, models.RecordSales, start, end,
+		bson.M{"$multiply": bson.A{"$quantity", "$price_per_unit"}}, "$paid", "$quantity")
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := r.sumByMonth(ctx, models.RecordExpenses, start, end, "$amount", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := r.sumByMonth(ctx, models.RecordPayments, start, end, "$amount", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make(map[string]*models.MonthlyStats)
+	month := func(key string) *models.MonthlyStats {
+		stats, ok := months[key]
+		if !ok {
+			first, _ := time.Parse("2006-01", key)
+			stats = &models.MonthlyStats{Month: first}
+			months[key] = stats
+		}
+		return stats
+	}
+	for _, t := range eggs {
+		stats := month(t.Month)
+		stats.Eggs = int(t.Total)
+		stats.EggDays = t.Days
+		if t.Days > 0 {
+			stats.AvgEggsPerDay = t.Total / float64(t.Days)
+		}
+	}
+	for _, t := range sales {
+		stats := month(t.Month)
+		stats.TraysSold = int(t.Units)
+		stats.SalesAmount = t.Total
+		stats.SalesPaid = t.Paid
+		if t.Units > 0 {
+			stats.AvgTrayPrice = t.Total / t.Units
+		}
+	}
+	for _, t := range expenses {
+		month(t.Month).Expenses = t.Total
+	}
+	for _, t := range payments {
+		month(t.Month).DebtCollected = t.Total
+	}
+
+	result := make([]models.MonthlyStats, 0, len(months))
+	for _, stats := range months {
+		stats.Profit = stats.SalesPaid + stats.DebtCollected - stats.Expenses
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Month.Before(result[j].Month) })
+	return result, nil
+}
+
+// sumByMonth groups a record collection by month of its date, summing the
+// total, paid and units expressions (nil sums nothing) and counting the
+// distinct days.
+func (r *MongoDBRepository) sumByMonth(ctx context.Context, kind models.RecordKind, start, end time.Time, total, paid, units interface{}) ([]monthTotals, error) {
+	collection := r.client.Database(r.dbName).Collection(string(kind))
+	zero := func(expr interface{}) interface{} {
+		if expr == nil {
+			return 0
+		}
+		return expr
+	}
+	match := currentVersion()
+	match["date"] = bson.M{"$gte": start, "$lt": end}
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$date"}},
+			"total": bson.M{"$sum": total},
+			"paid":  bson.M{"$sum": zero(paid)},
+			"units": bson.M{"$sum": zero(units)},
+			"days":  bson.M{"$addToSet": bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$date"}}},
+		}},
+		bson.M{"$set": bson.M{"days": bson.M{"$size": "$days"}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate monthly %s: %w", kind, err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals []monthTotals
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, fmt.Errorf("failed to decode monthly %s: %w", kind, err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mamadbah2/farmer/pkg/tracing"
+)
+
+var tracer = otel.Tracer("github.com/mamadbah2/farmer/internal/repository/mongodb")
+
+// commandTracer traces every MongoDB command as a "mongodb <command>" client
+// span, child of the span of the command's context. The spans of the
+// commands in flight are kept by request ID until the driver reports them
+// finished.
+type commandTracer struct {
+	spans sync.Map // int64 request ID -> trace.Span
+}
+
+func newCommandMonitor() *event.CommandMonitor {
+	t := &commandTracer{}
+	return &event.CommandMonitor{Started: t.started, Succeeded: t.succeeded, Failed: t.failed}
+}
+
+func (t *commandTracer) started(ctx context.Context, evt *event.CommandStartedEvent) {
+	// Handshakes and heartbeats run outside any traced operation.
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return
+	}
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system.name", "mongodb"),
+		attribute.String("db.namespace", evt.DatabaseName),
+		attribute.String("db.operation.name", evt.CommandName),
+	}
+	if collection, ok := evt.Command.Lookup(evt.CommandName).StringValueOK(); ok {
+		attributes = append(attributes, attribute.String("db.collection.name", collection))
+	}
+	_, span := tracer.Start(ctx, "mongodb "+evt.CommandName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	t.spans.Store(evt.RequestID, span)
+}
+
+func (t *commandTracer) succeeded(_ context.Context, evt *event.CommandSucceededEvent) {
+	t.finish(evt.RequestID, nil)
+}
+
+func (t *commandTracer) failed(_ context.Context, evt *event.CommandFailedEvent) {
+	t.finish(evt.RequestID, errors.New(evt.Failure))
+}
+
+func (t *commandTracer) finish(requestID int64, err error) {
+	if span, ok := t.spans.LoadAndDelete(requestID); ok {
+		tracing.End(span.(trace.Span), err)
+	}
+}
@@ -0,0 +1,255 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// ErrRecordNotFound reports a record ID matching no document, including
+// malformed IDs.
+var ErrRecordNotFound = errors.New("record not found")
+
+// ErrNotCurrent reports a correction or deletion of a record version that was
+// already corrected or deleted, or does not exist.
+var ErrNotCurrent = errors.New("record is not the current version")
+
+// Version fields of the farm record documents. Records saved by the commands
+// carry none of them: they are the first version of themselves.
+const (
+	fieldOriginalID = "original_id"
+	fieldVersion    = "version"
+	fieldChangedBy  = "changed_by"
+	fieldChangedAt  = "changed_at"
+	fieldSuperseded = "superseded"
+	fieldDeletedBy  = "deleted_by"
+	fieldDeletedAt  = "deleted_at"
+)
+
+// currentVersion matches the record versions reports must read: neither
+// replaced by a correction nor deleted.
+func currentVersion() bson.M {
+	return bson.M{fieldSuperseded: bson.M{"$ne": true}, fieldDeletedAt: bson.M{"$exists": false}}
+}
+
+// DeleteRecord marks the current version of a mirrored farm record deleted by
+// by. The document stays for the history; reports skip it.
+func (r *MongoDBRepository) DeleteRecord(ctx context.Context, kind models.RecordKind, id, by string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid %s record id %s: %w", kind, id, ErrRecordNotFound)
+	}
+
+	collection := r.client.Database(r.dbName).Collection(string(kind))
+	filter := currentVersion()
+	filter["_id"] = objectID
+	update := bson.M{"$set": bson.M{fieldDeletedAt: time.Now().UTC(), fieldDeletedBy: by}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s record: %w", kind, err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("delete %s record %s: %w", kind, id, ErrNotCurrent)
+	}
+	return nil
+}
+
+// CorrectRecord replaces the current version id of a farm record with record,
+// stored as the next version of the same original, and returns the new
+// version's hex ID. The previous version is kept, marked superseded.
+func (r *MongoDBRepository) CorrectRecord(ctx context.Context, kind models.RecordKind, id string, record interface{}, by string) (string, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s record id %s: %w", kind, id, ErrRecordNotFound)
+	}
+	collection := r.client.Database(r.dbName).Collection(string(kind))
+
+	// Closing the previous version first makes concurrent corrections of the
+	// same version fail instead of forking the history.
+	filter := currentVersion()
+	filter["_id"] = objectID
+	var previous bson.M
+	err = collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{fieldSuperseded: true}}).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", fmt.Errorf("correct %s record %s: %w", kind, id, ErrNotCurrent)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to supersede %s record: %w", kind, err)
+	}
+
+	document, err := toDocument(recordDocument(record))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s correction: %w", kind, err)
+	}
+	original, version := originalOf(previous)
+	document[fieldOriginalID] = original
+	document[fieldVersion] = version + 1
+	document[fieldChangedBy] = by
+	document[fieldChangedAt] = time.Now().UTC()
+
+	result, err := collection.InsertOne(ctx, document)
+	if err != nil {
+		// Reopen the previous version so the record does not vanish from
+		// the reports.
+		if _, reopenErr := collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$unset": bson.M{fieldSuperseded: ""}}); reopenErr != nil {
+			err = errors.Join(err, reopenErr)
+		}
+		return "", fmt.Errorf("failed to insert %s correction: %w", kind, err)
+	}
+	if newID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		return newID.Hex(), nil
+	}
+	return fmt.Sprint(result.InsertedID), nil
+}
+
+// GetRecordHistory returns every version of the record id belongs to, the
+// original first.
+func (r *MongoDBRepository) GetRecordHistory(ctx context.Context, kind models.RecordKind, id string) ([]models.RecordVersion, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s record id %s: %w", kind, id, ErrRecordNotFound)
+	}
+	collection := r.client.Database(r.dbName).Collection(string(kind))
+
+	var anyVersion bson.M
+	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&anyVersion)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%s record %s: %w", kind, id, ErrRecordNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s record %s: %w", kind, id, err)
+	}
+	original, _ := originalOf(anyVersion)
+
+	filter := bson.M{"$or": bson.A{bson.M{"_id": original}, bson.M{fieldOriginalID: original}}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: fieldVersion, Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s record history: %w", kind, err)
+	}
+	defer cursor.Close(ctx)
+
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode %s record history: %w", kind, err)
+	}
+
+	history := make([]models.RecordVersion, 0, len(documents))
+	for _, document := range documents {
+		history = append(history, toRecordVersion(document, original))
+	}
+	return history, nil
+}
+
+// ListRecords returns the current versions of the records of kind dated in
+// [start, end), by date, with the IDs to correct or delete them by.
+func (r *MongoDBRepository) ListRecords(ctx context.Context, kind models.RecordKind, start, end time.Time) ([]models.RecordVersion, error) {
+	if _, ok := models.NewRecord(kind); !ok {
+		return nil, fmt.Errorf("unknown record kind %s: %w", kind, ErrRecordNotFound)
+	}
+	collection := r.client.Database(r.dbName).Collection(string(kind))
+	filter := currentVersion()
+	filter["date"] = bson.M{"$gte": start, "$lt": end}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s records: %w", kind, err)
+	}
+	defer cursor.Close(ctx)
+
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode %s records: %w", kind, err)
+	}
+
+	records := make([]models.RecordVersion, 0, len(documents))
+	for _, document := range documents {
+		original, _ := originalOf(document)
+		records = append(records, toRecordVersion(document, original))
+	}
+	return records, nil
+}
+
+// recordDocument adds the normalized client name the ledger groups on to
+// sales and payments.
+func recordDocument(record interface{}) interface{} {
+	switch v := record.(type) {
+	case models.SaleRecord:
+		return saleDocument{SaleRecord: v, ClientKey: models.CustomerKey(v.Client)}
+	case *models.SaleRecord:
+		return saleDocument{SaleRecord: *v, ClientKey: models.CustomerKey(v.Client)}
+	case models.PaymentRecord:
+		return paymentDocument{PaymentRecord: v, ClientKey: models.CustomerKey(v.Client)}
+	case *models.PaymentRecord:
+		return paymentDocument{PaymentRecord: *v, ClientKey: models.CustomerKey(v.Client)}
+	}
+	return record
+}
+
+func toDocument(value interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document bson.M
+	err = bson.Unmarshal(raw, &document)
+	return document, err
+}
+
+// originalOf returns the ID of the first version of a record document and the
+// document's version number.
+func originalOf(document bson.M) (primitive.ObjectID, int) {
+	version := 1
+	switch v := document[fieldVersion].(type) {
+	case int32:
+		version = int(v)
+	case int64:
+		version = int(v)
+	}
+	if original, ok := document[fieldOriginalID].(primitive.ObjectID); ok {
+		return original, version
+	}
+	id, _ := document["_id"].(primitive.ObjectID)
+	return id, version
+}
+
+func toRecordVersion(document bson.M, original primitive.ObjectID) models.RecordVersion {
+	id, _ := document["_id"].(primitive.ObjectID)
+	_, version := originalOf(document)
+	superseded, _ := document[fieldSuperseded].(bool)
+	entry := models.RecordVersion{
+		ID:         id.Hex(),
+		OriginalID: original.Hex(),
+		Version:    version,
+		Record:     make(map[string]interface{}),
+	}
+	entry.ChangedBy, _ = document[fieldChangedBy].(string)
+	entry.DeletedBy, _ = document[fieldDeletedBy].(string)
+	if at, ok := document[fieldChangedAt].(primitive.DateTime); ok {
+		changedAt := at.Time().UTC()
+		entry.ChangedAt = &changedAt
+	}
+	if at, ok := document[fieldDeletedAt].(primitive.DateTime); ok {
+		deletedAt := at.Time().UTC()
+		entry.DeletedAt = &deletedAt
+	}
+	entry.Current = !superseded && entry.DeletedAt == nil
+
+	for key, value := range document {
+		switch key {
+		case "_id", fieldOriginalID, fieldVersion, fieldChangedBy, fieldChangedAt, fieldSuperseded, fieldDeletedBy, fieldDeletedAt:
+			continue
+		}
+		if at, ok := value.(primitive.DateTime); ok {
+			value = at.Time().UTC()
+		}
+		entry.Record[key] = value
+	}
+	return entry
+}
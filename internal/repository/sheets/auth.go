@@ -0,0 +1,39 @@
+package sheets
+
+import (
+	"context"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	driveapi "google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	sheetsapi "google.golang.org/api/sheets/v4"
+
+	"github.com/mamadbah2/farmer/internal/config"
+)
+
+// OAuthConfig returns the OAuth client used by the oauth auth mode, limited to
+// the spreadsheets scope and the Drive files the app creates (the backups).
+// cmd/sheets-auth uses it to obtain the refresh token.
+func OAuthConfig(clientID, clientSecret string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint:     google.Endpoint,
+		Scopes:       []string{sheetsapi.SpreadsheetsScope, driveapi.DriveFileScope},
+	}
+}
+
+// ClientCredentials picks the credentials of the configured auth mode, for the
+// Sheets client and the other Google APIs sharing its account. In oauth mode
+// the refresh token is exchanged for access tokens as they expire.
+func ClientCredentials(ctx context.Context, cfg config.SheetsConfig) option.ClientOption {
+	if cfg.AuthMode == config.SheetsAuthOAuth {
+		token := &oauth2.Token{RefreshToken: cfg.OAuthRefreshToken}
+		return option.WithTokenSource(OAuthConfig(cfg.OAuthClientID, cfg.OAuthClientSecret).TokenSource(ctx, token))
+	}
+	if len(cfg.CredentialsJSON) > 0 {
+		return option.WithCredentialsJSON(cfg.CredentialsJSON)
+	}
+	return option.WithCredentialsFile(cfg.CredentialsPath)
+}
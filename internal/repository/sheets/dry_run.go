@@ -0,0 +1,75 @@
+package sheets
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// DryRunRepository reads through to the wrapped repository but only logs
+// writes, for demos and staging instances running against real data
+// (SANDBOX_MODE=log). Appends return an empty range, so /undo has nothing to
+// clear.
+type DryRunRepository struct {
+	Repository
+	logger *zap.Logger
+}
+
+// NewDryRunRepository wraps repo so writes are logged instead of applied.
+func NewDryRunRepository(repo Repository, logger *zap.Logger) *DryRunRepository {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &DryRunRepository{Repository: repo, logger: logger}
+}
+
+// WriteRow logs the row.
+func (r *DryRunRepository) WriteRow(ctx context.Context, sheetRange string, values []interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, [][]interface{}{values})
+	return err
+}
+
+// AppendRow logs the row.
+func (r *DryRunRepository) AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error) {
+	return r.AppendRows(ctx, sheetRange, [][]interface{}{values})
+}
+
+// WriteRows logs the rows.
+func (r *DryRunRepository) WriteRows(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, rows)
+	return err
+}
+
+// AppendRows logs the rows.
+func (r *DryRunRepository) AppendRows(ctx context.Context, sheetRange string, rows [][]interface{}) (string, error) {
+	for _, values := range rows {
+		logger.FromContext(ctx, r.logger).Info("dry run: row not appended", zap.String("range", sheetRange), zap.Any("values", values))
+	}
+	return "", nil
+}
+
+// UpdateRow logs the update.
+func (r *DryRunRepository) UpdateRow(ctx context.Context, a1Range string, values []interface{}) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: row not updated", zap.String("range", a1Range), zap.Any("values", values))
+	return nil
+}
+
+// ClearRange logs the clear.
+func (r *DryRunRepository) ClearRange(ctx context.Context, sheetRange string) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: range not cleared", zap.String("range", sheetRange))
+	return nil
+}
+
+// DeleteRows logs the deletion.
+func (r *DryRunRepository) DeleteRows(ctx context.Context, tab string, rows []int) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: rows not deleted", zap.String("tab", tab), zap.Ints("rows", rows))
+	return nil
+}
+
+// EnsureTabs logs the layouts without creating tabs or headers.
+func (r *DryRunRepository) EnsureTabs(ctx context.Context, tabs []TabLayout) error {
+	logger.FromContext(ctx, r.logger).Info("dry run: tabs not prepared", zap.Int("tabs", len(tabs)))
+	return nil
+}
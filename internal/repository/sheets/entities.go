@@ -0,0 +1,407 @@
+package sheets
+
+import (
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// Typed repositories, one per sheet tab.
+type (
+	EggsRepository         = EntityRepository[models.EggRecord]
+	FeedRepository         = EntityRepository[models.FeedRecord]
+	FeedStockRepository    = EntityRepository[models.FeedDeliveryRecord]
+	MortalityRepository    = EntityRepository[models.MortalityRecord]
+	PopulationRepository   = EntityRepository[models.PopulationRecord]
+	TransfersRepository    = EntityRepository[models.TransferRecord]
+	SalesRepository        = EntityRepository[models.SaleRecord]
+	PaymentsRepository     = EntityRepository[models.PaymentRecord]
+	ExpensesRepository     = EntityRepository[models.ExpenseRecord]
+	ReceptionsRepository   = EntityRepository[models.EggReceptionRecord]
+	VaccinationsRepository = EntityRepository[models.VaccinationRecord]
+	PricesRepository       = EntityRepository[models.EggPriceRecord]
+	StateStockRepository   = EntityRepository[models.StateStockRecord]
+	FlockRepository        = EntityRepository[models.FlockBand]
+)
+
+// Entities groups the typed repositories sharing one Sheets adaptor.
+type Entities struct {
+	Eggs         *EggsRepository
+	Feed         *FeedRepository
+	FeedStock    *FeedStockRepository
+	Mortality    *MortalityRepository
+	Population   *PopulationRepository
+	Transfers    *TransfersRepository
+	Sales        *SalesRepository
+	Payments     *PaymentsRepository
+	Expenses     *ExpensesRepository
+	Receptions   *ReceptionsRepository
+	Vaccinations *VaccinationsRepository
+	Prices       *PricesRepository
+	StateStock   *StateStockRepository
+	Flock        *FlockRepository
+}
+
+// Layouts returns the tab and header row of every entity, for EnsureTabs.
+func (e *Entities) Layouts() []TabLayout {
+	return []TabLayout{
+		e.Eggs.Layout(), e.Feed.Layout(), e.FeedStock.Layout(), e.Mortality.Layout(),
+		e.Population.Layout(), e.Transfers.Layout(), e.Sales.Layout(), e.Payments.Layout(),
+		e.Expenses.Layout(), e.Receptions.Layout(), e.Vaccinations.Layout(), e.Prices.Layout(),
+		e.StateStock.Layout(), e.Flock.Layout(),
+	}
+}
+
+// NewEntities builds every typed repository on top of repo, with the tab
+// names and columns of layout. Appends verify the tab's header row first
+// (see ErrSchemaDrift).
+func NewEntities(repo Repository, layout Layout) *Entities {
+	guard := newHeaderGuard()
+	return &Entities{
+		Eggs:         &EggsRepository{repo: repo, schema: applyLayout(eggsSchema, layout), guard: guard},
+		Feed:         &FeedRepository{repo: repo, schema: applyLayout(feedSchema, layout), guard: guard},
+		FeedStock:    &FeedStockRepository{repo: repo, schema: applyLayout(feedStockSchema, layout), guard: guard},
+		Mortality:    &MortalityRepository{repo: repo, schema: applyLayout(mortalitySchema, layout), guard: guard},
+		Population:   &PopulationRepository{repo: repo, schema: applyLayout(populationSchema, layout), guard: guard},
+		Transfers:    &TransfersRepository{repo: repo, schema: applyLayout(transfersSchema, layout), guard: guard},
+		Sales:        &SalesRepository{repo: repo, schema: applyLayout(salesSchema, layout), guard: guard},
+		Payments:     &PaymentsRepository{repo: repo, schema: applyLayout(paymentsSchema, layout), guard: guard},
+		Expenses:     &ExpensesRepository{repo: repo, schema: applyLayout(expensesSchema, layout), guard: guard},
+		Receptions:   &ReceptionsRepository{repo: repo, schema: applyLayout(receptionsSchema, layout), guard: guard},
+		Vaccinations: &VaccinationsRepository{repo: repo, schema: applyLayout(vaccinationsSchema, layout), guard: guard},
+		Prices:       &PricesRepository{repo: repo, schema: applyLayout(pricesSchema, layout), guard: guard},
+		StateStock:   &StateStockRepository{repo: repo, schema: applyLayout(stateStockSchema, layout), guard: guard},
+		Flock:        &FlockRepository{repo: repo, schema: applyLayout(flockSchema, layout), guard: guard},
+	}
+}
+
+// Eggs: Date, Band1, Band2, Band3, Total, Notes. Total-only entries leave the
+// band columns blank; legacy rows are Date, Quantity, Notes.
+var eggsSchema = schema[models.EggRecord]{
+	sheetRange: "Eggs!A:F",
+	headers:    []string{"Date", "Band1", "Band2", "Band3", "Total", "Notes"},
+	legacy:     [][]string{{"Date (ISO)", "Quantity", "Notes"}},
+	encode: func(r models.EggRecord) []interface{} {
+		var b1, b2, b3 interface{} = r.Band1, r.Band2, r.Band3
+		if r.Band1+r.Band2+r.Band3 == 0 && r.Quantity > 0 {
+			b1, b2, b3 = "", "", ""
+		}
+		return []interface{}{formatRowDate(r.Date), b1, b2, b3, r.Quantity, r.Notes}
+	},
+	decode: func(row []interface{}) (models.EggRecord, bool) {
+		date, ok := cellDate(row, 0)
+		if !ok {
+			return models.EggRecord{}, false
+		}
+		record := models.EggRecord{Date: date, Notes: cell(row, 5)}
+		if len(row) <= 3 {
+			qty, ok := cellInt(row, 1)
+			record.Quantity = qty
+			return record, ok
+		}
+
+		bands := []*int{&record.Band1, &record.Band2, &record.Band3}
+		sum, parsed := 0, 0
+		for i, band := range bands {
+			if v, ok := cellInt(row, i+1); ok {
+				*band = v
+				sum += v
+				parsed++
+			}
+		}
+		if total, ok := cellInt(row, 4); ok {
+			record.Quantity = total
+			return record, true
+		}
+		record.Quantity = sum
+		return record, parsed > 0
+	},
+	date: func(r models.EggRecord) time.Time { return r.Date },
+}
+
+// Feed: Date, FeedKg, Population (optional, legacy head count).
+var feedSchema = schema[models.FeedRecord]{
+	sheetRange: "Feed!A:C",
+	headers:    []string{"Date", "FeedKg", "Population"},
+	encode: func(r models.FeedRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.FeedKg, r.Population}
+	},
+	decode: func(row []interface{}) (models.FeedRecord, bool) {
+		date, ok := cellDate(row, 0)
+		if !ok {
+			return models.FeedRecord{}, false
+		}
+		kg, ok := cellFloat(row, 1)
+		if !ok {
+			return models.FeedRecord{}, false
+		}
+		population, _ := cellInt(row, 2)
+		return models.FeedRecord{Date: date, FeedKg: kg, Population: population}, true
+	},
+	date: func(r models.FeedRecord) time.Time { return r.Date },
+}
+
+// FeedStock: Date, Bags, KgPerBag, PricePerBag, TotalKg.
+var feedStockSchema = schema[models.FeedDeliveryRecord]{
+	sheetRange: "FeedStock!A:E",
+	headers:    []string{"Date", "Bags", "KgPerBag", "PricePerBag", "TotalKg"},
+	encode: func(r models.FeedDeliveryRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Bags, r.KgPerBag, r.PricePerBag, r.TotalKg()}
+	},
+	decode: func(row []interface{}) (models.FeedDeliveryRecord, bool) {
+		date, ok := cellDate(row, 0)
+		if !ok {
+			return models.FeedDeliveryRecord{}, false
+		}
+		record := models.FeedDeliveryRecord{Date: date}
+		record.PricePerBag, _ = cellFloat(row, 3)
+		bags, okBags := cellFloat(row, 1)
+		perBag, okPerBag := cellFloat(row, 2)
+		if okBags && okPerBag {
+			record.Bags, record.KgPerBag = bags, perBag
+			return record, true
+		}
+		// Hand-entered rows may only carry the total weight.
+		if total, ok := cellFloat(row, 4); ok && total > 0 {
+			record.Bags, record.KgPerBag = total, 1
+			return record, true
+		}
+		return models.FeedDeliveryRecord{}, false
+	},
+	date: func(r models.FeedDeliveryRecord) time.Time { return r.Date },
+}
+
+// Mortality: Date, Band1, Band2, Band3. Legacy rows are Date, Quantity,
+// Reason, read as band 1.
+var mortalitySchema = schema[models.MortalityRecord]{
+	sheetRange: "Mortality!A:D",
+	headers:    []string{"Date", "Band1", "Band2", "Band3"},
+	legacy:     [][]string{{"Date", "Quantity", "Reason"}},
+	encode: func(r models.MortalityRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Band1, r.Band2, r.Band3}
+	},
+	decode: func(row []interface{}) (models.MortalityRecord, bool) {
+		date, ok := cellDate(row, 0)
+		if !ok {
+			return models.MortalityRecord{}, false
+		}
+		record := models.MortalityRecord{Date: date}
+		parsed := 0
+		for i, band := range []*int{&record.Band1, &record.Band2, &record.Band3} {
+			if v, ok := cellInt(row, i+1); ok {
+				*band = v
+				parsed++
+			}
+		}
+		return record, parsed > 0
+	},
+	date: func(r models.MortalityRecord) time.Time { return r.Date },
+}
+
+// Population: Date, Band1, Band2, Band3, Total.
+var populationSchema = schema[models.PopulationRecord]{
+	sheetRange: "Population!A:E",
+	headers:    []string{"Date", "Band1", "Band2", "Band3", "Total"},
+	encode: func(r models.PopulationRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Band1, r.Band2, r.Band3, r.Total()}
+	},
+	decode: func(row []interface{}) (models.PopulationRecord, bool) {
+		date, ok := cellDate(row, 0)
+		b1, ok1 := cellInt(row, 1)
+		b2, ok2 := cellInt(row, 2)
+		b3, ok3 := cellInt(row, 3)
+		if !ok || !ok1 || !ok2 || !ok3 {
+			return models.PopulationRecord{}, false
+		}
+		return models.PopulationRecord{Date: date, Band1: b1, Band2: b2, Band3: b3}, true
+	},
+	date: func(r models.PopulationRecord) time.Time { return r.Date },
+}
+
+// Transfers: Date, FromBand, ToBand, Quantity, Reason.
+var transfersSchema = schema[models.TransferRecord]{
+	sheetRange: "Transfers!A:E",
+	headers:    []string{"Date", "FromBand", "ToBand", "Quantity", "Reason"},
+	encode: func(r models.TransferRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.FromBand, r.ToBand, r.Quantity, r.Reason}
+	},
+	decode: func(row []interface{}) (models.TransferRecord, bool) {
+		date, ok := cellDate(row, 0)
+		from, ok1 := cellInt(row, 1)
+		to, ok2 := cellInt(row, 2)
+		qty, ok3 := cellInt(row, 3)
+		if !ok || !ok1 || !ok2 || !ok3 || qty <= 0 {
+			return models.TransferRecord{}, false
+		}
+		return models.TransferRecord{Date: date, FromBand: from, ToBand: to, Quantity: qty, Reason: cell(row, 4)}, true
+	},
+	date: func(r models.TransferRecord) time.Time { return r.Date },
+}
+
+// Sales: Date, Client, Quantity, PricePerUnit, Paid. A missing Paid column
+// means the sale was paid in full.
+var salesSchema = schema[models.SaleRecord]{
+	sheetRange: "Sales!A:E",
+	headers:    []string{"Date", "Client", "Quantity", "PricePerUnit", "Paid"},
+	encode: func(r models.SaleRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Client, r.Quantity, r.PricePerUnit, r.Paid}
+	},
+	decode: func(row []interface{}) (models.SaleRecord, bool) {
+		date, ok := cellDate(row, 0)
+		qty, okQty := cellInt(row, 2)
+		price, okPrice := cellFloat(row, 3)
+		if !ok || !okQty || !okPrice {
+			return models.SaleRecord{}, false
+		}
+		paid, ok := cellFloat(row, 4)
+		if !ok {
+			paid = float64(qty) * price
+		}
+		return models.SaleRecord{Date: date, Client: cell(row, 1), Quantity: qty, PricePerUnit: price, Paid: paid}, true
+	},
+	date: func(r models.SaleRecord) time.Time { return r.Date },
+}
+
+// Payments: Date, Client, Amount, Notes.
+var paymentsSchema = schema[models.PaymentRecord]{
+	sheetRange: "Payments!A:D",
+	headers:    []string{"Date", "Client", "Amount", "Notes"},
+	encode: func(r models.PaymentRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Client, r.Amount, r.Notes}
+	},
+	decode: func(row []interface{}) (models.PaymentRecord, bool) {
+		date, ok := cellDate(row, 0)
+		amount, okAmount := cellFloat(row, 2)
+		if !ok || !okAmount {
+			return models.PaymentRecord{}, false
+		}
+		return models.PaymentRecord{Date: date, Client: cell(row, 1), Amount: amount, Notes: cell(row, 3)}, true
+	},
+	date: func(r models.PaymentRecord) time.Time { return r.Date },
+}
+
+// Expenses: Date, Category, Quantity, UnitPrice, Notes. Legacy rows are
+// Date, Label, Amount.
+var expensesSchema = schema[models.ExpenseRecord]{
+	sheetRange: "Expenses!A:E",
+	headers:    []string{"Date", "Category", "Quantity", "UnitPrice", "Notes"},
+	legacy:     [][]string{{"Date", "Label", "Amount"}},
+	encode: func(r models.ExpenseRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Category, r.Quantity, r.UnitPrice, r.Notes}
+	},
+	decode: func(row []interface{}) (models.ExpenseRecord, bool) {
+		date, ok := cellDate(row, 0)
+		qty, okQty := cellFloat(row, 2)
+		if !ok || !okQty {
+			return models.ExpenseRecord{}, false
+		}
+		record := models.ExpenseRecord{Date: date, Category: cell(row, 1), Notes: cell(row, 4)}
+		if price, ok := cellFloat(row, 3); ok {
+			record.Quantity, record.UnitPrice, record.Amount = qty, price, qty*price
+		} else {
+			record.Quantity, record.UnitPrice, record.Amount = 1, qty, qty
+		}
+		return record, true
+	},
+	date: func(r models.ExpenseRecord) time.Time { return r.Date },
+}
+
+// Receptions: Date, Quantity, UnitPrice.
+var receptionsSchema = schema[models.EggReceptionRecord]{
+	sheetRange: "Receptions!A:C",
+	headers:    []string{"Date", "Quantity", "UnitPrice"},
+	// Versions before /reception wrote the same columns, without a header
+	// row, to EggReception.
+	former: []string{"EggReception"},
+	encode: func(r models.EggReceptionRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Quantity, r.UnitPrice}
+	},
+	decode: func(row []interface{}) (models.EggReceptionRecord, bool) {
+		date, ok := cellDate(row, 0)
+		qty, okQty := cellInt(row, 1)
+		if !ok || !okQty {
+			return models.EggReceptionRecord{}, false
+		}
+		price, _ := cellFloat(row, 2)
+		return models.EggReceptionRecord{Date: date, Quantity: qty, UnitPrice: price}, true
+	},
+	date: func(r models.EggReceptionRecord) time.Time { return r.Date },
+}
+
+// Vaccinations: Date, Vaccine, Band (0 = every band), Notes.
+var vaccinationsSchema = schema[models.VaccinationRecord]{
+	sheetRange: "Vaccinations!A:D",
+	headers:    []string{"Date", "Vaccine", "Band", "Notes"},
+	encode: func(r models.VaccinationRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.Vaccine, r.Band, r.Notes}
+	},
+	decode: func(row []interface{}) (models.VaccinationRecord, bool) {
+		date, ok := cellDate(row, 0)
+		if !ok || len(row) < 2 {
+			return models.VaccinationRecord{}, false
+		}
+		band, _ := cellInt(row, 2)
+		return models.VaccinationRecord{Date: date, Vaccine: cell(row, 1), Band: band, Notes: cell(row, 3)}, true
+	},
+	date: func(r models.VaccinationRecord) time.Time { return r.Date },
+}
+
+// Prices: EffectiveDate, PricePerTray, SetBy.
+var pricesSchema = schema[models.EggPriceRecord]{
+	sheetRange: "Prices!A:C",
+	headers:    []string{"EffectiveDate", "PricePerTray", "SetBy"},
+	encode: func(r models.EggPriceRecord) []interface{} {
+		return []interface{}{formatRowDate(r.EffectiveDate), r.PricePerTray, r.SetBy}
+	},
+	decode: func(row []interface{}) (models.EggPriceRecord, bool) {
+		date, _ := cellDate(row, 0)
+		price, ok := cellFloat(row, 1)
+		if !ok || price <= 0 {
+			return models.EggPriceRecord{}, false
+		}
+		return models.EggPriceRecord{EffectiveDate: date, PricePerTray: price, SetBy: cell(row, 2)}, true
+	},
+	date: func(r models.EggPriceRecord) time.Time { return r.EffectiveDate },
+}
+
+// StateStock: Date, ItemName, Quantity, UnitPrice, Condition.
+var stateStockSchema = schema[models.StateStockRecord]{
+	sheetRange: "StateStock!A:E",
+	headers:    []string{"Date", "ItemName", "Quantity", "UnitPrice", "Condition"},
+	encode: func(r models.StateStockRecord) []interface{} {
+		return []interface{}{formatRowDate(r.Date), r.ItemName, r.Quantity, r.UnitPrice, r.Condition}
+	},
+	decode: func(row []interface{}) (models.StateStockRecord, bool) {
+		date, ok := cellDate(row, 0)
+		qty, okQty := cellFloat(row, 2)
+		if !ok || !okQty {
+			return models.StateStockRecord{}, false
+		}
+		price, _ := cellFloat(row, 3)
+		return models.StateStockRecord{Date: date, ItemName: cell(row, 1), Quantity: qty, UnitPrice: price, Condition: cell(row, 4)}, true
+	},
+	date: func(r models.StateStockRecord) time.Time { return r.Date },
+}
+
+// Flock: Band, PlacementDate, Breed, InitialCount, AgeAtPlacement (weeks).
+// Rows are keyed by band rather than dated.
+var flockSchema = schema[models.FlockBand]{
+	sheetRange: "Flock!A:E",
+	headers:    []string{"Band", "PlacementDate", "Breed", "InitialCount", "AgeAtPlacement"},
+	encode: func(b models.FlockBand) []interface{} {
+		return []interface{}{b.Band, formatRowDate(b.PlacementDate), b.Breed, b.InitialCount, b.AgeAtPlacement}
+	},
+	decode: func(row []interface{}) (models.FlockBand, bool) {
+		band, okBand := cellInt(row, 0)
+		placement, okPlacement := cellDate(row, 1)
+		initial, okInitial := cellInt(row, 3)
+		if !okBand || band < 1 || band > 3 || !okPlacement || !okInitial || initial <= 0 {
+			return models.FlockBand{}, false
+		}
+		age, _ := cellInt(row, 4)
+		if age < 0 {
+			age = 0
+		}
+		return models.FlockBand{Band: band, PlacementDate: placement, Breed: cell(row, 2), InitialCount: initial, AgeAtPlacement: age}, true
+	},
+}
@@ -0,0 +1,266 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schema maps one record type to its sheet tab: the A1 range holding the
+// rows, how a record becomes a row and how a row is read back.
+type schema[T any] struct {
+	sheetRange string
+	// headers is the expected first row of the tab.
+	headers []string
+	// legacy are the first rows of earlier versions of the tab, whose rows
+	// decode still reads.
+	legacy [][]string
+	// former are earlier titles of the tab, renamed by EnsureTabs.
+	former []string
+	encode func(T) []interface{}
+	// decode returns false for header, blank or malformed rows.
+	decode func(row []interface{}) (T, bool)
+	// date returns the day a record belongs to; nil for undated tabs.
+	date func(T) time.Time
+	// mapped is set when a configured column map placed the fields.
+	mapped bool
+}
+
+// EntityRepository reads and appends one record type on top of the raw Sheets
+// adaptor, so callers deal in models instead of row indexes.
+type EntityRepository[T any] struct {
+	repo   Repository
+	schema schema[T]
+	guard  *headerGuard
+}
+
+// headerCheckInterval is how long a verified header row is trusted before the
+// next write checks it again.
+const headerCheckInterval = 5 * time.Minute
+
+// headerGuard remembers when each tab's header row was last verified, so
+// writes check for layout drift without reading row 1 every time.
+type headerGuard struct {
+	mu      sync.Mutex
+	checked map[string]time.Time
+}
+
+func newHeaderGuard() *headerGuard {
+	return &headerGuard{checked: make(map[string]time.Time)}
+}
+
+// checkHeaders returns ErrSchemaDrift when the tab's header row no longer
+// matches the columns encode writes.
+func (r *EntityRepository[T]) checkHeaders(ctx context.Context) error {
+	layout := r.Layout()
+	if r.guard == nil || len(layout.Headers) == 0 {
+		return nil
+	}
+	r.guard.mu.Lock()
+	last := r.guard.checked[layout.Title]
+	r.guard.mu.Unlock()
+	if time.Since(last) < headerCheckInterval {
+		return nil
+	}
+
+	rows, err := r.repo.ReadRange(ctx, headerRange(layout.Title))
+	if errors.Is(err, ErrUnavailable) {
+		// The write is about to be queued or to fail; check again once Sheets
+		// is back.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check %s headers: %w", layout.Title, err)
+	}
+	var row []interface{}
+	if len(rows) > 0 {
+		row = rows[0]
+	}
+	if err := layout.Check(row); err != nil {
+		return err
+	}
+
+	r.guard.mu.Lock()
+	r.guard.checked[layout.Title] = time.Now()
+	r.guard.mu.Unlock()
+	return nil
+}
+
+// Range returns the A1 range of the entity's tab.
+func (r *EntityRepository[T]) Range() string {
+	return r.schema.sheetRange
+}
+
+// Layout returns the tab name and header row the entity expects.
+func (r *EntityRepository[T]) Layout() TabLayout {
+	return TabLayout{Title: tabTitle(r.schema.sheetRange), Headers: r.schema.headers, Legacy: r.schema.legacy, Mapped: r.schema.mapped, Former: r.schema.former}
+}
+
+// Append writes the record as a new row and returns the A1 range written. It
+// fails with ErrSchemaDrift instead of writing when the tab's header row no
+// longer matches the expected columns.
+func (r *EntityRepository[T]) Append(ctx context.Context, record T) (string, error) {
+	if err := r.checkHeaders(ctx); err != nil {
+		return "", err
+	}
+	return r.repo.AppendRow(ctx, r.schema.sheetRange, r.schema.encode(record))
+}
+
+// AppendAll writes the records with a single append call.
+func (r *EntityRepository[T]) AppendAll(ctx context.Context, records []T) (string, error) {
+	if err := r.checkHeaders(ctx); err != nil {
+		return "", err
+	}
+	rows := make([][]interface{}, len(records))
+	for i, record := range records {
+		rows[i] = r.schema.encode(record)
+	}
+	return r.repo.AppendRows(ctx, r.schema.sheetRange, rows)
+}
+
+// List returns every readable record in sheet order; malformed rows are skipped.
+func (r *EntityRepository[T]) List(ctx context.Context) ([]T, error) {
+	rows, err := r.repo.ReadRange(ctx, r.schema.sheetRange)
+	if err != nil {
+		return nil, err
+	}
+	return r.decodeRows(rows), nil
+}
+
+func (r *EntityRepository[T]) decodeRows(rows [][]interface{}) []T {
+	records := make([]T, 0, len(rows))
+	for _, row := range rows {
+		if record, ok := r.schema.decode(row); ok {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Between returns the records dated from start's day through end's day,
+// inclusive, in sheet order. A zero start or end leaves that side open.
+// Undated tabs return every record. With a start day only the recent rows
+// are read (see ReadSince), keeping daily reports fast as the tab grows.
+func (r *EntityRepository[T]) Between(ctx context.Context, start, end time.Time) ([]T, error) {
+	if r.schema.date == nil {
+		return r.List(ctx)
+	}
+	rows, err := r.repo.ReadSince(ctx, r.schema.sheetRange, start)
+	if err != nil {
+		return nil, err
+	}
+	records := r.decodeRows(rows)
+	filtered := records[:0]
+	for _, record := range records {
+		date := r.schema.date(record)
+		if (start.IsZero() || !date.Before(startOfDay(start))) && (end.IsZero() || !date.After(startOfDay(end))) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered, nil
+}
+
+// HasRecordOn reports whether a record is dated on day.
+func (r *EntityRepository[T]) HasRecordOn(ctx context.Context, day time.Time) (bool, error) {
+	records, err := r.Between(ctx, day, day)
+	return len(records) > 0, err
+}
+
+// Latest returns the last readable record of the tab.
+func (r *EntityRepository[T]) Latest(ctx context.Context) (T, bool, error) {
+	var latest T
+	records, err := r.List(ctx)
+	if err != nil || len(records) == 0 {
+		return latest, false, err
+	}
+	return records[len(records)-1], true, nil
+}
+
+// ErrRowNotFound reports a record whose row is not in its tab: archived,
+// changed by hand, or still queued during an outage.
+var ErrRowNotFound = errors.New("sheet row not found")
+
+// Find returns the A1 range of the first row dated on the record's day that
+// holds the record. Rows are compared as decoded, so cell formatting does not
+// matter. Undated tabs have no rows to find.
+func (r *EntityRepository[T]) Find(ctx context.Context, record T) (string, error) {
+	if r.schema.date == nil {
+		return "", fmt.Errorf("%w: tab %s has no dates", ErrRowNotFound, tabTitle(r.schema.sheetRange))
+	}
+	date := r.schema.date(record)
+	matches, err := r.repo.FindRows(ctx, r.schema.sheetRange, RowQuery{Date: date})
+	if err != nil {
+		return "", err
+	}
+	want := r.schema.encode(record)
+	for _, match := range matches {
+		if found, ok := r.schema.decode(match.Values); ok && SameRow(r.schema.encode(found), want) {
+			return match.Range, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s on %s", ErrRowNotFound, tabTitle(r.schema.sheetRange), formatRowDate(date))
+}
+
+// Update overwrites the row at a1Range, as returned by Find or Append, with
+// the record. Like Append it fails with ErrSchemaDrift when the tab's header
+// row no longer matches.
+func (r *EntityRepository[T]) Update(ctx context.Context, a1Range string, record T) error {
+	if err := r.checkHeaders(ctx); err != nil {
+		return err
+	}
+	return r.repo.UpdateRow(ctx, a1Range, r.schema.encode(record))
+}
+
+// SameRow reports whether two rows hold the same cells, compared as trimmed
+// text or, when both parse, as numbers, so 45000.0 matches "45000". Missing
+// trailing cells count as blank.
+func SameRow(a, b []interface{}) bool {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		x, y := cell(a, i), cell(b, i)
+		if x == y {
+			continue
+		}
+		fx, errX := strconv.ParseFloat(x, 64)
+		fy, errY := strconv.ParseFloat(y, 64)
+		if errX != nil || errY != nil || fx != fy {
+			return false
+		}
+	}
+	return true
+}
+
+// rowDateLayout is the date format written in the first column of every row.
+const rowDateLayout = "02/01/2006"
+
+func formatRowDate(t time.Time) string {
+	return t.Format(rowDateLayout)
+}
+
+// cell returns the trimmed text of column i, or "" past the end of the row.
+func cell(row []interface{}, i int) string {
+	if i >= len(row) || row[i] == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(row[i]))
+}
+
+func cellInt(row []interface{}, i int) (int, bool) {
+	v, err := strconv.Atoi(cell(row, i))
+	return v, err == nil
+}
+
+func cellFloat(row []interface{}, i int) (float64, bool) {
+	v, err := strconv.ParseFloat(cell(row, i), 64)
+	return v, err == nil
+}
+
+func cellDate(row []interface{}, i int) (time.Time, bool) {
+	if i >= len(row) {
+		return time.Time{}, false
+	}
+	return parseRowDate(row[i])
+}
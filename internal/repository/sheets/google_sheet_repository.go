@@ -3,63 +3,150 @@ package sheets
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/api/option"
 	sheetsapi "google.golang.org/api/sheets/v4"
 
 	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/pkg/logger"
 )
 
 // Repository defines the persistence operations supported by the Google Sheets adapter.
 type Repository interface {
 	WriteRow(ctx context.Context, sheetRange string, values []interface{}) error
+	AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error)
+	WriteRows(ctx context.Context, sheetRange string, rows [][]interface{}) error
+	AppendRows(ctx context.Context, sheetRange string, rows [][]interface{}) (string, error)
+	UpdateRow(ctx context.Context, a1Range string, values []interface{}) error
 	ReadRange(ctx context.Context, sheetRange string) ([][]interface{}, error)
+	ReadSince(ctx context.Context, sheetRange string, since time.Time) ([][]interface{}, error)
+	FindRows(ctx context.Context, sheetRange string, query RowQuery) ([]RowMatch, error)
+	ClearRange(ctx context.Context, sheetRange string) error
+	EnsureTabs(ctx context.Context, tabs []TabLayout) error
+	DeleteRows(ctx context.Context, tab string, rows []int) error
+	Ping(ctx context.Context) error
 }
 
 // GoogleSheetRepository implements the Repository interface using the official Google Sheets API.
 type GoogleSheetRepository struct {
 	service       *sheetsapi.Service
 	spreadsheetID string
+	maxRetries    int
+	limiter       *tokenBucket
+	index         *rowIndex
 	logger        *zap.Logger
 }
 
 // NewGoogleSheetRepository builds a Google Sheets backed repository instance.
+// When cfg.SpreadsheetsByYear is set, records are routed to those workbooks by
+// year, the primary spreadsheet keeping the other years.
 func NewGoogleSheetRepository(ctx context.Context, cfg config.SheetsConfig, logger *zap.Logger) (Repository, error) {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 
-	service, err := sheetsapi.NewService(ctx, option.WithCredentialsFile(cfg.CredentialsPath), option.WithScopes(sheetsapi.SpreadsheetsScope))
+	service, err := sheetsapi.NewService(ctx, ClientCredentials(ctx, cfg), option.WithScopes(sheetsapi.SpreadsheetsScope))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize sheets client: %w", err)
 	}
 
-	return &GoogleSheetRepository{
+	primary := &GoogleSheetRepository{
 		service:       service,
 		spreadsheetID: cfg.SpreadsheetID,
+		maxRetries:    cfg.MaxRetries,
+		limiter:       newTokenBucket(cfg.RequestsPerMinute, cfg.RequestBurst),
+		index:         newRowIndex(),
 		logger:        logger,
-	}, nil
+	}
+	if len(cfg.SpreadsheetsByYear) == 0 {
+		return primary, nil
+	}
+	return newYearRouter(primary, cfg.SpreadsheetsByYear, logger), nil
 }
 
 // WriteRow appends the provided values to the supplied sheet range.
 func (r *GoogleSheetRepository) WriteRow(ctx context.Context, sheetRange string, values []interface{}) error {
+	_, err := r.AppendRow(ctx, sheetRange, values)
+	return err
+}
+
+// AppendRow appends the provided values and returns the A1 range of the written row.
+func (r *GoogleSheetRepository) AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error) {
+	return r.AppendRows(ctx, sheetRange, [][]interface{}{values})
+}
+
+// WriteRows appends several rows to the supplied sheet range in one API call.
+func (r *GoogleSheetRepository) WriteRows(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, rows)
+	return err
+}
+
+// AppendRows appends several rows in a single Append call, so a batch costs one
+// write request against the Sheets quota, and returns the A1 range covering
+// the written rows. A server error or timeout fails with ErrWriteUncertain, as
+// the rows may have been written anyway.
+func (r *GoogleSheetRepository) AppendRows(ctx context.Context, sheetRange string, rows [][]interface{}) (string, error) {
 	if sheetRange == "" {
-		return fmt.Errorf("sheetRange must not be empty")
+		return "", fmt.Errorf("sheetRange must not be empty")
+	}
+	if len(rows) == 0 {
+		return "", nil
 	}
 
-	payload := &sheetsapi.ValueRange{Values: [][]interface{}{values}}
+	payload := &sheetsapi.ValueRange{Values: rows}
 
 	call := r.service.Spreadsheets.Values.Append(r.spreadsheetID, sheetRange, payload).
 		ValueInputOption("USER_ENTERED").
-		InsertDataOption("INSERT_ROWS").
-		Context(ctx)
+		InsertDataOption("INSERT_ROWS")
+
+	// Only the errors of requests that never reached Sheets are retried: a
+	// retried append that had landed would add the rows twice.
+	var resp *sheetsapi.AppendValuesResponse
+	err := r.withRetryIf(ctx, "append", unsent, func(ctx context.Context) (err error) {
+		resp, err = call.Context(ctx).Do()
+		return err
+	})
+	if err != nil && !unsent(err) && unavailable(ctx, err) {
+		return "", fmt.Errorf("append %d row(s) into range %s: %w: %w", len(rows), sheetRange, ErrWriteUncertain, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("append %d row(s) into range %s: %w", len(rows), sheetRange, err)
+	}
+
+	updatedRange := ""
+	if resp.Updates != nil {
+		updatedRange = resp.Updates.UpdatedRange
+	}
+
+	logger.FromContext(ctx, r.logger).Debug("rows appended to sheet", zap.String("range", sheetRange), zap.Int("rows", len(rows)), zap.String("updated_range", updatedRange))
+	return updatedRange, nil
+}
+
+// UpdateRow overwrites an existing row in place, e.g. "Sales!A12:E12" returned
+// by AppendRow. Values are written from the first cell of the range.
+func (r *GoogleSheetRepository) UpdateRow(ctx context.Context, a1Range string, values []interface{}) error {
+	if a1Range == "" {
+		return fmt.Errorf("a1Range must not be empty")
+	}
 
-	if _, err := call.Do(); err != nil {
-		return fmt.Errorf("append row into range %s: %w", sheetRange, err)
+	payload := &sheetsapi.ValueRange{Values: [][]interface{}{values}}
+	call := r.service.Spreadsheets.Values.Update(r.spreadsheetID, a1Range, payload).
+		ValueInputOption("USER_ENTERED")
+
+	var resp *sheetsapi.UpdateValuesResponse
+	err := r.withRetry(ctx, "update", func(ctx context.Context) (err error) {
+		resp, err = call.Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("update row %s: %w", a1Range, err)
 	}
+	// The row's date may have changed; rebuild the index on the next read.
+	r.index.forget(tabTitle(a1Range))
 
-	r.logger.Debug("row appended to sheet", zap.String("range", sheetRange))
+	logger.FromContext(ctx, r.logger).Debug("row updated", zap.String("range", a1Range), zap.String("updated_range", resp.UpdatedRange))
 	return nil
 }
 
@@ -69,10 +156,48 @@ func (r *GoogleSheetRepository) ReadRange(ctx context.Context, sheetRange string
 		return nil, fmt.Errorf("sheetRange must not be empty")
 	}
 
-	resp, err := r.service.Spreadsheets.Values.Get(r.spreadsheetID, sheetRange).Context(ctx).Do()
+	var resp *sheetsapi.ValueRange
+	err := r.withRetry(ctx, "read", func(ctx context.Context) (err error) {
+		resp, err = r.service.Spreadsheets.Values.Get(r.spreadsheetID, sheetRange).Context(ctx).Do()
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("read range %s: %w", sheetRange, err)
 	}
 
+	if bounds, err := parseColumnBounds(sheetRange); err == nil && bounds.startRow == 1 {
+		r.index.record(bounds.tab, 1, resp.Values)
+	}
 	return resp.Values, nil
 }
+
+// ClearRange blanks the values of the provided range, keeping the rows in place.
+func (r *GoogleSheetRepository) ClearRange(ctx context.Context, sheetRange string) error {
+	if sheetRange == "" {
+		return fmt.Errorf("sheetRange must not be empty")
+	}
+
+	err := r.withRetry(ctx, "clear", func(ctx context.Context) error {
+		_, err := r.service.Spreadsheets.Values.Clear(r.spreadsheetID, sheetRange, &sheetsapi.ClearValuesRequest{}).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("clear range %s: %w", sheetRange, err)
+	}
+
+	logger.FromContext(ctx, r.logger).Debug("range cleared", zap.String("range", sheetRange))
+	return nil
+}
+
+// Ping fetches the spreadsheet ID through the metadata endpoint, the
+// cheapest call that proves the credentials can open the workbook. It is not
+// retried, so a probe reports an outage instead of waiting it out.
+func (r *GoogleSheetRepository) Ping(ctx context.Context) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	if _, err := r.service.Spreadsheets.Get(r.spreadsheetID).Fields("spreadsheetId").Context(ctx).Do(); err != nil {
+		return fmt.Errorf("get spreadsheet %s: %w", r.spreadsheetID, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package sheets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mamadbah2/farmer/internal/config"
+)
+
+// Layout adapts the default tabs to an existing spreadsheet: tabs may be
+// renamed and their fields moved to other columns. The zero Layout keeps the
+// default names and columns.
+type Layout struct {
+	tabs map[string]string
+	// columns holds, per default tab name, the 0-based sheet column of each
+	// field in default order; -1 marks a field the tab does not keep.
+	columns map[string][]int
+	// labels holds, per default tab name, the header expected above each
+	// mapped field in default order; blank entries keep the default header.
+	labels map[string][]string
+}
+
+// NewLayout validates the tab names and column maps of cfg against the
+// default schemas. The first field (the date, or Flock's band) must stay in
+// column A, as date-windowed reads, yearly routing and archival read it there.
+func NewLayout(cfg config.SheetsConfig) (Layout, error) {
+	widths := make(map[string]int)
+	for _, layout := range NewEntities(nil, Layout{}).Layouts() {
+		widths[layout.Title] = len(layout.Headers)
+	}
+
+	layout := Layout{tabs: make(map[string]string), columns: make(map[string][]int), labels: make(map[string][]string)}
+	titles := make(map[string]string)
+	for name, title := range cfg.TabNames {
+		if _, ok := widths[name]; !ok {
+			return Layout{}, fmt.Errorf("SHEETS_TAB_NAMES: unknown tab %s", name)
+		}
+		if strings.ContainsAny(title, "![]") {
+			return Layout{}, fmt.Errorf("SHEETS_TAB_NAMES: tab name %q may not contain !, [ or ]", title)
+		}
+		layout.tabs[name] = title
+	}
+	for name := range widths {
+		title := layout.Title(name)
+		if other, ok := titles[title]; ok {
+			return Layout{}, fmt.Errorf("SHEETS_TAB_NAMES: %s and %s both use tab %s", other, name, title)
+		}
+		titles[title] = name
+	}
+
+	for name, letters := range cfg.Columns {
+		width, ok := widths[name]
+		if !ok {
+			return Layout{}, fmt.Errorf("SHEETS_COLUMNS: unknown tab %s", name)
+		}
+		if len(letters) != width {
+			return Layout{}, fmt.Errorf("SHEETS_COLUMNS: tab %s has %d fields, got %d columns", name, width, len(letters))
+		}
+		columns := make([]int, width)
+		labels := make([]string, width)
+		used := make(map[int]bool)
+		for i, letter := range letters {
+			letter, labels[i], _ = strings.Cut(letter, ":")
+			if letter == "-" {
+				if labels[i] != "" {
+					return Layout{}, fmt.Errorf("SHEETS_COLUMNS: tab %s labels a field it does not keep (%s)", name, labels[i])
+				}
+				columns[i] = -1
+				continue
+			}
+			if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+				return Layout{}, fmt.Errorf("SHEETS_COLUMNS: tab %s column %q must be a letter A-Z or -", name, letter)
+			}
+			column := int(letter[0] - 'A')
+			if used[column] {
+				return Layout{}, fmt.Errorf("SHEETS_COLUMNS: tab %s uses column %s twice", name, letter)
+			}
+			used[column] = true
+			columns[i] = column
+		}
+		if columns[0] != 0 {
+			return Layout{}, fmt.Errorf("SHEETS_COLUMNS: tab %s must keep its first field in column A", name)
+		}
+		layout.columns[name] = columns
+		layout.labels[name] = labels
+	}
+	return layout, nil
+}
+
+// Title returns the tab used for the default tab name.
+func (l Layout) Title(name string) string {
+	if title, ok := l.tabs[name]; ok {
+		return title
+	}
+	return name
+}
+
+// applyLayout renames the schema's tab and moves its fields to the mapped
+// columns, under their configured labels.
+func applyLayout[T any](s schema[T], layout Layout) schema[T] {
+	name := tabTitle(s.sheetRange)
+	columns, mapped := layout.columns[name]
+	if !mapped {
+		if title := layout.Title(name); title != name {
+			s.sheetRange = title + s.sheetRange[len(name):]
+		}
+		return s
+	}
+
+	width := 0
+	for _, column := range columns {
+		width = max(width, column+1)
+	}
+	labels := layout.labels[name]
+	headers := make([]string, width)
+	for field, column := range columns {
+		if column < 0 {
+			continue
+		}
+		headers[column] = s.headers[field]
+		if field < len(labels) && labels[field] != "" {
+			headers[column] = labels[field]
+		}
+	}
+
+	encode, decode := s.encode, s.decode
+	s.sheetRange = fmt.Sprintf("%s!A:%c", layout.Title(name), 'A'+width-1)
+	s.headers = headers
+	s.legacy = nil
+	s.former = nil
+	s.mapped = true
+	s.encode = func(record T) []interface{} {
+		fields := encode(record)
+		row := make([]interface{}, width)
+		for i := range row {
+			row[i] = ""
+		}
+		for field, column := range columns {
+			if column >= 0 && field < len(fields) {
+				row[column] = fields[field]
+			}
+		}
+		return row
+	}
+	s.decode = func(row []interface{}) (T, bool) {
+		fields := make([]interface{}, len(columns))
+		last := 0
+		for field, column := range columns {
+			fields[field] = ""
+			if column >= 0 && column < len(row) {
+				fields[field] = row[column]
+				if cell(row, column) != "" {
+					last = field + 1
+				}
+			}
+		}
+		// Trailing blank fields are dropped as on an unmapped row, so
+		// decoders telling legacy short rows apart still can.
+		return decode(fields[:last])
+	}
+	return s
+}
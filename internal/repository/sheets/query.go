@@ -0,0 +1,159 @@
+package sheets
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the date formats found in the first column of data rows:
+// DD/MM/YYYY as written by the command dispatcher, ISO dates from manual edits.
+var dateLayouts = []string{"02/01/2006", "2006-01-02"}
+
+// RowQuery selects data rows by the date in their first column and,
+// optionally, by the value of another column.
+type RowQuery struct {
+	// Date keeps rows dated on that day; zero matches any day.
+	Date time.Time
+	// Since keeps rows dated on or after that day; zero means no lower bound.
+	Since time.Time
+	// Before keeps rows dated before that day; zero means no upper bound.
+	Before time.Time
+	// KeyColumn is the 0-based column compared with Key (e.g. 1 for the
+	// client of a Sales row). Key is ignored when empty.
+	KeyColumn int
+	Key       string
+}
+
+// RowMatch is a data row matching a RowQuery.
+type RowMatch struct {
+	// Row is the 1-based sheet row number.
+	Row int
+	// Range is the A1 range of the row, usable with UpdateRow or ClearRange.
+	Range string
+	// Date is the row's first-column date, zero when it has none.
+	Date   time.Time
+	Values []interface{}
+}
+
+// FindRows reads sheetRange and returns the rows matching the query, in
+// sheet order. Rows without a parseable date never match a dated query.
+func (r *GoogleSheetRepository) FindRows(ctx context.Context, sheetRange string, query RowQuery) ([]RowMatch, error) {
+	rows, err := r.ReadRange(ctx, sheetRange)
+	if err != nil {
+		return nil, err
+	}
+	return matchRows(sheetRange, rows, query)
+}
+
+func matchRows(sheetRange string, rows [][]interface{}, query RowQuery) ([]RowMatch, error) {
+	bounds, err := parseColumnBounds(sheetRange)
+	if err != nil {
+		return nil, err
+	}
+
+	dated := !query.Date.IsZero() || !query.Since.IsZero() || !query.Before.IsZero()
+	key := strings.ToLower(strings.TrimSpace(query.Key))
+
+	var matches []RowMatch
+	for i, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		date, ok := parseRowDate(row[0])
+		if dated {
+			if !ok {
+				continue
+			}
+			if !query.Date.IsZero() && !sameDay(date, query.Date) {
+				continue
+			}
+			if !query.Since.IsZero() && date.Before(startOfDay(query.Since)) {
+				continue
+			}
+			if !query.Before.IsZero() && !date.Before(startOfDay(query.Before)) {
+				continue
+			}
+		}
+		if key != "" {
+			if query.KeyColumn >= len(row) || strings.ToLower(strings.TrimSpace(fmt.Sprint(row[query.KeyColumn]))) != key {
+				continue
+			}
+		}
+
+		number := bounds.startRow + i
+		matches = append(matches, RowMatch{
+			Row:    number,
+			Range:  fmt.Sprintf("%s!%s%d:%s%d", bounds.tab, bounds.startCol, number, bounds.endCol, number),
+			Date:   date,
+			Values: row,
+		})
+	}
+	return matches, nil
+}
+
+// columnBounds describes an A1 range such as "Sales!A:E" or "Sales!A2:E".
+type columnBounds struct {
+	tab      string
+	startCol string
+	endCol   string
+	startRow int
+}
+
+func parseColumnBounds(sheetRange string) (columnBounds, error) {
+	sep := strings.LastIndex(sheetRange, "!")
+	if sep < 0 {
+		return columnBounds{}, fmt.Errorf("range %s has no sheet name", sheetRange)
+	}
+	bounds := columnBounds{tab: sheetRange[:sep], startRow: 1}
+
+	first, last, ok := strings.Cut(sheetRange[sep+1:], ":")
+	if !ok {
+		last = first
+	}
+	bounds.startCol, bounds.startRow = splitColumnRow(first, 1)
+	bounds.endCol, _ = splitColumnRow(last, 0)
+	if bounds.startCol == "" || bounds.endCol == "" {
+		return columnBounds{}, fmt.Errorf("range %s must name its columns", sheetRange)
+	}
+	return bounds, nil
+}
+
+// splitColumnRow splits "A12" into "A" and 12; a missing row yields fallback.
+func splitColumnRow(cell string, fallback int) (string, int) {
+	i := strings.IndexFunc(cell, func(r rune) bool { return r >= '0' && r <= '9' })
+	if i < 0 {
+		return cell, fallback
+	}
+	row, err := strconv.Atoi(cell[i:])
+	if err != nil {
+		return cell[:i], fallback
+	}
+	return cell[:i], row
+}
+
+func parseRowDate(value interface{}) (time.Time, bool) {
+	str := strings.TrimSpace(fmt.Sprint(value))
+	if len(str) > 10 {
+		str = str[:10]
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
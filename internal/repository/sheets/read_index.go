@@ -0,0 +1,116 @@
+package sheets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// rowIndexTTL bounds how long a tab's date index is trusted before a
+	// full read rebuilds it, picking up rows inserted or deleted by hand.
+	rowIndexTTL = 15 * time.Minute
+	// readSinceMargin is the number of rows read before the first indexed
+	// match, absorbing a few rows deleted by hand since the index was built.
+	readSinceMargin = 20
+)
+
+// tabIndex records the date of each row of a tab (zero for header or
+// undated rows), by 0-based row position.
+type tabIndex struct {
+	dates   []time.Time
+	scanned time.Time
+}
+
+// rowIndex keeps one tabIndex per tab, built from full reads.
+type rowIndex struct {
+	mu   sync.Mutex
+	tabs map[string]*tabIndex
+}
+
+func newRowIndex() *rowIndex {
+	return &rowIndex{tabs: make(map[string]*tabIndex)}
+}
+
+// record stores the dates of rows read from firstRow (1-based). A read from
+// row 1 replaces the tab's index; later windows extend it.
+func (x *rowIndex) record(tab string, firstRow int, rows [][]interface{}) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	index, ok := x.tabs[tab]
+	if firstRow == 1 {
+		index = &tabIndex{scanned: time.Now()}
+		x.tabs[tab] = index
+	} else if !ok || firstRow-1 > len(index.dates) {
+		return
+	}
+
+	index.dates = index.dates[:firstRow-1]
+	for _, row := range rows {
+		var date time.Time
+		if len(row) > 0 {
+			date, _ = parseRowDate(row[0])
+		}
+		index.dates = append(index.dates, date)
+	}
+}
+
+// firstRowSince returns the 1-based row to read from to get every row dated
+// on or after since, or false when the tab has no fresh index.
+func (x *rowIndex) firstRowSince(tab string, since time.Time) (int, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	index, ok := x.tabs[tab]
+	if !ok || time.Since(index.scanned) > rowIndexTTL {
+		return 0, false
+	}
+	since = startOfDay(since)
+	first := len(index.dates) + 1
+	for i, date := range index.dates {
+		if !date.IsZero() && !date.Before(since) {
+			first = i + 1
+			break
+		}
+	}
+	return max(first-readSinceMargin, 1), true
+}
+
+func (x *rowIndex) forget(tab string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.tabs, tab)
+}
+
+// ReadSince returns at least every row of sheetRange dated on or after since,
+// reading only the tail of the tab when its date index is fresh. Older rows
+// may be included, so callers still filter by date. A zero since, a range
+// without column bounds or a missing index fall back to a full ReadRange,
+// which rebuilds the index.
+func (r *GoogleSheetRepository) ReadSince(ctx context.Context, sheetRange string, since time.Time) ([][]interface{}, error) {
+	bounds, err := parseColumnBounds(sheetRange)
+	if since.IsZero() || err != nil || bounds.startRow != 1 {
+		return r.ReadRange(ctx, sheetRange)
+	}
+	first, ok := r.index.firstRowSince(bounds.tab, since)
+	if !ok || first == 1 {
+		return r.ReadRange(ctx, sheetRange)
+	}
+
+	window := fmt.Sprintf("%s!%s%d:%s", bounds.tab, bounds.startCol, first, bounds.endCol)
+	var rows [][]interface{}
+	err = r.withRetry(ctx, "read", func(ctx context.Context) error {
+		resp, err := r.service.Spreadsheets.Values.Get(r.spreadsheetID, window).Context(ctx).Do()
+		if err == nil {
+			rows = resp.Values
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read range %s: %w", window, err)
+	}
+	r.index.record(bounds.tab, first, rows)
+	return rows, nil
+}
@@ -0,0 +1,111 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+
+	"github.com/mamadbah2/farmer/pkg/logger"
+	"github.com/mamadbah2/farmer/pkg/tracing"
+)
+
+var tracer = otel.Tracer("github.com/mamadbah2/farmer/internal/repository/sheets")
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 16 * time.Second
+)
+
+// withRetry runs call, retrying quota (429) and server (5xx) errors with
+// exponential backoff and jitter, up to r.maxRetries extra attempts. Other
+// errors and context cancellation return immediately. Every attempt first
+// waits for the shared rate limiter. The whole operation, waits included, is
+// traced as one "sheets <op>" span; call gets its context so the API
+// requests are traced below it.
+func (r *GoogleSheetRepository) withRetry(ctx context.Context, op string, call func(ctx context.Context) error) error {
+	return r.withRetryIf(ctx, op, retryable, call)
+}
+
+// withRetryIf is withRetry retrying only the errors retry accepts, e.g.
+// unsent for appends, which must not be sent twice.
+func (r *GoogleSheetRepository) withRetryIf(ctx context.Context, op string, retry func(error) bool, call func(ctx context.Context) error) (err error) {
+	ctx, span := tracer.Start(ctx, "sheets "+op)
+	attempts := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("sheets.attempts", attempts))
+		tracing.End(span, err)
+	}()
+
+	for attempt := 0; ; attempt++ {
+		if err := r.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		attempts++
+		err := call(ctx)
+		if err == nil || attempt >= r.maxRetries || !retry(err) {
+			return err
+		}
+
+		delay := backoff(attempt)
+		logger.FromContext(ctx, r.logger).Warn("sheets call failed, retrying",
+			zap.String("op", op),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether the Sheets API error is transient.
+func retryable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+}
+
+// unsent reports whether a failed call certainly never reached Sheets, so an
+// append can be sent again without writing its rows twice: a quota error
+// (429), refused before any processing, or a connection that could not be
+// opened (refused, unreachable, DNS failure). A server error or timeout may
+// come after the rows were written.
+func unsent(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// backoff returns the wait before retry number attempt+1: a random duration
+// between half and all of the exponential delay, capped at retryMaxDelay.
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,261 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// yearWorkbook is a spreadsheet holding the records dated in one year.
+type yearWorkbook struct {
+	year int
+	repo *GoogleSheetRepository
+}
+
+// yearRouter spreads the farm data over several spreadsheets by year of the
+// record date. Records of unmapped years, and undated rows such as Flock,
+// stay in the primary spreadsheet. Reads concatenate the primary workbook
+// and then the yearly ones in ascending order, so keep history in the
+// primary and map new years to new workbooks.
+//
+// Ranges returned for a yearly workbook are qualified with its year, e.g.
+// "[2025]Eggs!A12:F12", so UpdateRow and ClearRange reach the right file;
+// unqualified ranges address the primary workbook. Appends, tab layouts and
+// row deletions given a qualified range or title target that workbook only.
+type yearRouter struct {
+	primary   *GoogleSheetRepository
+	workbooks []yearWorkbook
+	logger    *zap.Logger
+}
+
+func newYearRouter(primary *GoogleSheetRepository, byYear map[int]string, logger *zap.Logger) *yearRouter {
+	router := &yearRouter{primary: primary, logger: logger}
+	for year, spreadsheetID := range byYear {
+		if spreadsheetID == primary.spreadsheetID {
+			continue
+		}
+		workbook := *primary
+		workbook.spreadsheetID = spreadsheetID
+		workbook.index = newRowIndex()
+		router.workbooks = append(router.workbooks, yearWorkbook{year: year, repo: &workbook})
+	}
+	sort.Slice(router.workbooks, func(i, j int) bool { return router.workbooks[i].year < router.workbooks[j].year })
+	return router
+}
+
+// WriteRow appends the row to the workbook of its date.
+func (r *yearRouter) WriteRow(ctx context.Context, sheetRange string, values []interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, [][]interface{}{values})
+	return err
+}
+
+// AppendRow appends the row to the workbook of its date.
+func (r *yearRouter) AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error) {
+	return r.AppendRows(ctx, sheetRange, [][]interface{}{values})
+}
+
+// WriteRows appends the rows, one call per workbook involved.
+func (r *yearRouter) WriteRows(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, rows)
+	return err
+}
+
+// AppendRows appends each row to the workbook of its date. When the rows span
+// several workbooks no single range covers them and "" is returned.
+func (r *yearRouter) AppendRows(ctx context.Context, sheetRange string, rows [][]interface{}) (string, error) {
+	if year, plain := splitQualifiedRange(sheetRange); plain != sheetRange {
+		written, err := r.workbook(year).AppendRows(ctx, plain, rows)
+		return qualifyRange(year, written), err
+	}
+
+	var order []int
+	grouped := make(map[int][][]interface{})
+	for _, row := range rows {
+		year := r.yearOf(row)
+		if _, ok := grouped[year]; !ok {
+			order = append(order, year)
+		}
+		grouped[year] = append(grouped[year], row)
+	}
+
+	var updatedRange string
+	for _, year := range order {
+		written, err := r.workbook(year).AppendRows(ctx, sheetRange, grouped[year])
+		if err != nil {
+			return "", err
+		}
+		updatedRange = qualifyRange(year, written)
+	}
+	if len(order) > 1 {
+		logger.FromContext(ctx, r.logger).Warn("rows spread over several workbooks", zap.String("range", sheetRange), zap.Ints("years", order))
+		return "", nil
+	}
+	return updatedRange, nil
+}
+
+// UpdateRow overwrites a row of the workbook named by the range qualifier.
+func (r *yearRouter) UpdateRow(ctx context.Context, a1Range string, values []interface{}) error {
+	year, a1Range := splitQualifiedRange(a1Range)
+	return r.workbook(year).UpdateRow(ctx, a1Range, values)
+}
+
+// ClearRange blanks a range of the workbook named by the range qualifier.
+func (r *yearRouter) ClearRange(ctx context.Context, sheetRange string) error {
+	year, sheetRange := splitQualifiedRange(sheetRange)
+	return r.workbook(year).ClearRange(ctx, sheetRange)
+}
+
+// ReadRange returns the rows of every workbook, primary first.
+func (r *yearRouter) ReadRange(ctx context.Context, sheetRange string) ([][]interface{}, error) {
+	rows, err := r.primary.ReadRange(ctx, sheetRange)
+	if err != nil {
+		return nil, err
+	}
+	for _, workbook := range r.workbooks {
+		more, err := workbook.repo.ReadRange(ctx, sheetRange)
+		if err != nil {
+			return nil, fmt.Errorf("%d workbook: %w", workbook.year, err)
+		}
+		rows = append(rows, more...)
+	}
+	return rows, nil
+}
+
+// ReadSince returns the recent rows of the primary workbook and of the yearly
+// workbooks from since's year on; older years cannot hold recent rows.
+func (r *yearRouter) ReadSince(ctx context.Context, sheetRange string, since time.Time) ([][]interface{}, error) {
+	rows, err := r.primary.ReadSince(ctx, sheetRange, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, workbook := range r.workbooks {
+		if !since.IsZero() && workbook.year < since.Year() {
+			continue
+		}
+		more, err := workbook.repo.ReadSince(ctx, sheetRange, since)
+		if err != nil {
+			return nil, fmt.Errorf("%d workbook: %w", workbook.year, err)
+		}
+		rows = append(rows, more...)
+	}
+	return rows, nil
+}
+
+// FindRows searches every workbook; matches carry qualified ranges.
+func (r *yearRouter) FindRows(ctx context.Context, sheetRange string, query RowQuery) ([]RowMatch, error) {
+	matches, err := r.primary.FindRows(ctx, sheetRange, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, workbook := range r.workbooks {
+		more, err := workbook.repo.FindRows(ctx, sheetRange, query)
+		if err != nil {
+			return nil, fmt.Errorf("%d workbook: %w", workbook.year, err)
+		}
+		for i := range more {
+			more[i].Range = qualifyRange(workbook.year, more[i].Range)
+		}
+		matches = append(matches, more...)
+	}
+	return matches, nil
+}
+
+// EnsureTabs prepares plain tabs in every workbook and qualified ones in
+// their own workbook.
+func (r *yearRouter) EnsureTabs(ctx context.Context, tabs []TabLayout) error {
+	plain := make([]TabLayout, 0, len(tabs))
+	qualified := make(map[int][]TabLayout)
+	for _, tab := range tabs {
+		year, title := splitQualifiedRange(tab.Title)
+		if title == tab.Title {
+			plain = append(plain, tab)
+			continue
+		}
+		qualified[year] = append(qualified[year], TabLayout{Title: title, Headers: tab.Headers})
+	}
+
+	errs := []error{r.primary.EnsureTabs(ctx, plain)}
+	for _, workbook := range r.workbooks {
+		if err := workbook.repo.EnsureTabs(ctx, append(plain[:len(plain):len(plain)], qualified[workbook.year]...)); err != nil {
+			errs = append(errs, fmt.Errorf("%d workbook: %w", workbook.year, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteRows deletes rows of the workbook named by the tab qualifier.
+func (r *yearRouter) DeleteRows(ctx context.Context, tab string, rows []int) error {
+	year, tab := splitQualifiedRange(tab)
+	return r.workbook(year).DeleteRows(ctx, tab, rows)
+}
+
+// Ping checks the primary and every yearly workbook.
+func (r *yearRouter) Ping(ctx context.Context) error {
+	errs := []error{r.primary.Ping(ctx)}
+	for _, workbook := range r.workbooks {
+		if err := workbook.repo.Ping(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%d workbook: %w", workbook.year, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// yearOf returns the year of the row's date when a workbook is mapped to it,
+// 0 (the primary workbook) otherwise.
+func (r *yearRouter) yearOf(row []interface{}) int {
+	if len(row) == 0 {
+		return 0
+	}
+	date, ok := parseRowDate(row[0])
+	if !ok {
+		return 0
+	}
+	for _, workbook := range r.workbooks {
+		if workbook.year == date.Year() {
+			return workbook.year
+		}
+	}
+	return 0
+}
+
+func (r *yearRouter) workbook(year int) *GoogleSheetRepository {
+	for _, workbook := range r.workbooks {
+		if workbook.year == year {
+			return workbook.repo
+		}
+	}
+	return r.primary
+}
+
+// qualifyRange prefixes a range of a yearly workbook with its year.
+func qualifyRange(year int, a1Range string) string {
+	if year == 0 || a1Range == "" {
+		return a1Range
+	}
+	return fmt.Sprintf("[%d]%s", year, a1Range)
+}
+
+// splitQualifiedRange returns the year qualifier of a range (0 when absent)
+// and the plain A1 range.
+func splitQualifiedRange(a1Range string) (int, string) {
+	if !strings.HasPrefix(a1Range, "[") {
+		return 0, a1Range
+	}
+	end := strings.Index(a1Range, "]")
+	if end < 0 {
+		return 0, a1Range
+	}
+	year, err := strconv.Atoi(a1Range[1:end])
+	if err != nil {
+		return 0, a1Range
+	}
+	return year, a1Range[end+1:]
+}
@@ -0,0 +1,341 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	sheetsapi "google.golang.org/api/sheets/v4"
+
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// ErrSchemaDrift reports a tab whose header row no longer matches the columns
+// the application writes, e.g. after a column was renamed or inserted.
+var ErrSchemaDrift = errors.New("sheet layout drift")
+
+// TabLayout is a sheet tab and the header row its first line should hold.
+type TabLayout struct {
+	Title   string
+	Headers []string
+	// Legacy are the header rows of earlier versions of the tab, whose rows
+	// are still read. A tab set up by an older version keeps its header row:
+	// relabelling it would misname the older rows.
+	Legacy [][]string
+	// Mapped tabs follow a configured column map. Their Headers are the
+	// configured labels at the mapped columns; blank entries are columns
+	// the app does not use, whose header is not checked.
+	Mapped bool
+	// Former are earlier titles of the tab. A spreadsheet still holding one
+	// and not the tab gets it renamed, keeping its rows.
+	Former []string
+}
+
+// EnsureTabs creates the missing tabs and writes their header rows, so a fresh
+// spreadsheet works without manual setup. A missing tab whose former title is
+// present is renamed instead, with a header row inserted above its data when
+// it had none. Existing tabs get their header row written when it is blank or
+// a prefix of the expected one (a column added since). Any other first row is left untouched, as it may hold data, and
+// reported as ErrSchemaDrift once the other tabs are set up.
+func (r *GoogleSheetRepository) EnsureTabs(ctx context.Context, tabs []TabLayout) error {
+	if len(tabs) == 0 {
+		return nil
+	}
+
+	var spreadsheet *sheetsapi.Spreadsheet
+	err := r.withRetry(ctx, "get spreadsheet", func(ctx context.Context) (err error) {
+		spreadsheet, err = r.service.Spreadsheets.Get(r.spreadsheetID).Fields("sheets.properties(sheetId,title)").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("list tabs: %w", err)
+	}
+
+	existing := make(map[string]int64, len(spreadsheet.Sheets))
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil {
+			existing[sheet.Properties.Title] = sheet.Properties.SheetId
+		}
+	}
+
+	var created []string
+	var renamed []tabRename
+	var requests []*sheetsapi.Request
+	for _, tab := range tabs {
+		if _, ok := existing[tab.Title]; ok {
+			for _, former := range tab.Former {
+				if _, ok := existing[former]; ok {
+					logger.FromContext(ctx, r.logger).Warn("former sheet tab left beside its successor, move its rows by hand",
+						zap.String("tab", tab.Title), zap.String("former", former))
+				}
+			}
+			continue
+		}
+		if former, ok := formerTab(tab, existing); ok {
+			renamed = append(renamed, tabRename{from: former, sheetID: existing[former], tab: tab})
+			requests = append(requests, &sheetsapi.Request{UpdateSheetProperties: &sheetsapi.UpdateSheetPropertiesRequest{
+				Properties: &sheetsapi.SheetProperties{SheetId: existing[former], Title: tab.Title},
+				Fields:     "title",
+			}})
+			delete(existing, former)
+			continue
+		}
+		created = append(created, tab.Title)
+		requests = append(requests, &sheetsapi.Request{
+			AddSheet: &sheetsapi.AddSheetRequest{Properties: &sheetsapi.SheetProperties{Title: tab.Title}},
+		})
+	}
+
+	// Tabs of older versions were written without a header row: a blank
+	// first row is inserted above their data for the header written below.
+	if len(renamed) > 0 {
+		ranges := make([]string, len(renamed))
+		for i, rename := range renamed {
+			ranges[i] = headerRange(rename.from)
+		}
+		var current *sheetsapi.BatchGetValuesResponse
+		err := r.withRetry(ctx, "read former headers", func(ctx context.Context) (err error) {
+			current, err = r.service.Spreadsheets.Values.BatchGet(r.spreadsheetID).Ranges(ranges...).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("read header rows: %w", err)
+		}
+		for i, rename := range renamed {
+			var row []interface{}
+			if i < len(current.ValueRanges) && len(current.ValueRanges[i].Values) > 0 {
+				row = current.ValueRanges[i].Values[0]
+			}
+			if headerPrefix(row, rename.tab.Headers) || rename.tab.Check(row) == nil {
+				continue
+			}
+			requests = append(requests, &sheetsapi.Request{InsertDimension: &sheetsapi.InsertDimensionRequest{
+				Range: &sheetsapi.DimensionRange{SheetId: rename.sheetID, Dimension: "ROWS", StartIndex: 0, EndIndex: 1},
+			}})
+		}
+	}
+
+	if len(requests) > 0 {
+		err := r.withRetry(ctx, "prepare tabs", func(ctx context.Context) error {
+			_, err := r.service.Spreadsheets.BatchUpdate(r.spreadsheetID, &sheetsapi.BatchUpdateSpreadsheetRequest{Requests: requests}).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("prepare tabs %s: %w", strings.Join(append(created, renamedTitles(renamed)...), ", "), err)
+		}
+		for _, rename := range renamed {
+			logger.FromContext(ctx, r.logger).Info("sheet tab renamed", zap.String("from", rename.from), zap.String("to", rename.tab.Title))
+		}
+		if len(created) > 0 {
+			logger.FromContext(ctx, r.logger).Info("sheet tabs created", zap.Strings("tabs", created))
+		}
+	}
+
+	ranges := make([]string, len(tabs))
+	for i, tab := range tabs {
+		ranges[i] = headerRange(tab.Title)
+	}
+	var current *sheetsapi.BatchGetValuesResponse
+	err = r.withRetry(ctx, "read headers", func(ctx context.Context) (err error) {
+		current, err = r.service.Spreadsheets.Values.BatchGet(r.spreadsheetID).Ranges(ranges...).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("read header rows: %w", err)
+	}
+
+	var updates []*sheetsapi.ValueRange
+	var drift []error
+	for i, tab := range tabs {
+		var row []interface{}
+		if i < len(current.ValueRanges) && len(current.ValueRanges[i].Values) > 0 {
+			row = current.ValueRanges[i].Values[0]
+		}
+		if len(row) >= len(tab.Headers) || !headerPrefix(row, tab.Headers) {
+			if err := tab.Check(row); err != nil {
+				drift = append(drift, err)
+			} else if tab.legacy(row) {
+				logger.FromContext(ctx, r.logger).Info("legacy header row kept", zap.String("tab", tab.Title))
+			}
+			continue
+		}
+		values := make([]interface{}, len(tab.Headers))
+		for j, header := range tab.Headers {
+			values[j] = header
+		}
+		updates = append(updates, &sheetsapi.ValueRange{Range: headerRange(tab.Title), Values: [][]interface{}{values}})
+	}
+	if len(updates) == 0 {
+		return errors.Join(drift...)
+	}
+
+	err = r.withRetry(ctx, "write headers", func(ctx context.Context) error {
+		_, err := r.service.Spreadsheets.Values.BatchUpdate(r.spreadsheetID, &sheetsapi.BatchUpdateValuesRequest{
+			ValueInputOption: "RAW",
+			Data:             updates,
+		}).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("write header rows: %w", err)
+	}
+	logger.FromContext(ctx, r.logger).Info("sheet header rows written", zap.Int("tabs", len(updates)))
+	return errors.Join(drift...)
+}
+
+// tabRename is a former tab renamed by EnsureTabs.
+type tabRename struct {
+	from    string
+	sheetID int64
+	tab     TabLayout
+}
+
+// formerTab returns the first former title of tab present in the
+// spreadsheet.
+func formerTab(tab TabLayout, existing map[string]int64) (string, bool) {
+	for _, former := range tab.Former {
+		if _, ok := existing[former]; ok {
+			return former, true
+		}
+	}
+	return "", false
+}
+
+func renamedTitles(renamed []tabRename) []string {
+	titles := make([]string, len(renamed))
+	for i, rename := range renamed {
+		titles[i] = rename.from + " -> " + rename.tab.Title
+	}
+	return titles
+}
+
+// Check returns ErrSchemaDrift unless row starts with the expected headers
+// or with one of the legacy ones, compared case-insensitively. Extra trailing
+// columns are allowed since appends never reach them. Mapped tabs are checked
+// at their mapped columns only.
+func (t TabLayout) Check(row []interface{}) error {
+	if t.Mapped && mappedHeaders(row, t.Headers) || startsWith(row, t.Headers) || t.legacy(row) {
+		return nil
+	}
+	found := "no header row"
+	if len(row) > 0 {
+		cells := make([]string, len(row))
+		for i := range row {
+			cells[i] = cell(row, i)
+		}
+		found = "found " + strings.Join(cells, ", ")
+	}
+	if t.Mapped {
+		return fmt.Errorf("%w: tab %s expects headers %s at its mapped columns, %s (label columns in SHEETS_COLUMNS, e.g. C:Bande 1)", ErrSchemaDrift, t.Title, strings.Join(t.Headers, ", "), found)
+	}
+	return fmt.Errorf("%w: tab %s expects headers %s, %s", ErrSchemaDrift, t.Title, strings.Join(t.Headers, ", "), found)
+}
+
+// legacy reports whether row is one of the tab's legacy header rows.
+func (t TabLayout) legacy(row []interface{}) bool {
+	for _, headers := range t.Legacy {
+		if startsWith(row, headers) {
+			return true
+		}
+	}
+	return false
+}
+
+// startsWith reports whether row starts with headers, compared
+// case-insensitively.
+func startsWith(row []interface{}, headers []string) bool {
+	return len(row) >= len(headers) && headerPrefix(row[:len(headers)], headers)
+}
+
+// mappedHeaders reports whether row holds every non-blank header at its
+// column, compared case-insensitively. Other columns may hold anything.
+func mappedHeaders(row []interface{}, headers []string) bool {
+	for i, header := range headers {
+		if header != "" && !strings.EqualFold(cell(row, i), header) {
+			return false
+		}
+	}
+	return true
+}
+
+func headerRange(title string) string {
+	return title + "!1:1"
+}
+
+// headerPrefix reports whether row holds the first cells of headers, compared
+// case-insensitively. A blank row matches.
+func headerPrefix(row []interface{}, headers []string) bool {
+	if len(row) > len(headers) {
+		return false
+	}
+	for i := range row {
+		if !strings.EqualFold(cell(row, i), headers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tabTitle returns the tab name of an A1 range: "Eggs" for "Eggs!A:F".
+func tabTitle(sheetRange string) string {
+	if i := strings.LastIndex(sheetRange, "!"); i >= 0 {
+		return sheetRange[:i]
+	}
+	return sheetRange
+}
+
+// DeleteRows removes the given 1-based rows of a tab, shifting the rows below
+// up, in a single batch update. Rows appended meanwhile are unaffected since
+// they land below the deleted ones.
+func (r *GoogleSheetRepository) DeleteRows(ctx context.Context, tab string, rows []int) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var spreadsheet *sheetsapi.Spreadsheet
+	err := r.withRetry(ctx, "get spreadsheet", func(ctx context.Context) (err error) {
+		spreadsheet, err = r.service.Spreadsheets.Get(r.spreadsheetID).Fields("sheets.properties(sheetId,title)").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("list tabs: %w", err)
+	}
+	var sheetID int64 = -1
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil && sheet.Properties.Title == tab {
+			sheetID = sheet.Properties.SheetId
+		}
+	}
+	if sheetID < 0 {
+		return fmt.Errorf("delete rows: tab %s not found", tab)
+	}
+
+	// Delete bottom-up so earlier requests do not shift later ones.
+	sorted := append([]int(nil), rows...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	var requests []*sheetsapi.Request
+	for i := 0; i < len(sorted); {
+		end := sorted[i]
+		start := end
+		for i++; i < len(sorted) && sorted[i] >= start-1; i++ {
+			start = min(start, sorted[i])
+		}
+		requests = append(requests, &sheetsapi.Request{DeleteDimension: &sheetsapi.DeleteDimensionRequest{
+			Range: &sheetsapi.DimensionRange{SheetId: sheetID, Dimension: "ROWS", StartIndex: int64(start - 1), EndIndex: int64(end)},
+		}})
+	}
+
+	err = r.withRetry(ctx, "delete rows", func(ctx context.Context) error {
+		_, err := r.service.Spreadsheets.BatchUpdate(r.spreadsheetID, &sheetsapi.BatchUpdateSpreadsheetRequest{Requests: requests}).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("delete %d row(s) of %s: %w", len(rows), tab, err)
+	}
+	r.index.forget(tab)
+	logger.FromContext(ctx, r.logger).Debug("rows deleted", zap.String("tab", tab), zap.Int("rows", len(rows)))
+	return nil
+}
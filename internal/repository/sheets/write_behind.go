@@ -0,0 +1,249 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// ErrUnavailable wraps the errors of calls that failed because Google Sheets
+// could not be reached (network errors, quota or server errors left after
+// retries).
+var ErrUnavailable = errors.New("google sheets unavailable")
+
+// ErrWriteUncertain wraps the errors of appends that failed after reaching
+// Sheets (server errors, timeouts): the rows may have been written, so they
+// are neither retried nor queued.
+var ErrWriteUncertain = errors.New("google sheets may have written the rows")
+
+// pendingFlushBatch bounds the queued writes loaded per flush round.
+const pendingFlushBatch = 50
+
+// WriteQueue persists the appends made while Sheets is unreachable.
+type WriteQueue interface {
+	EnqueuePendingWrite(ctx context.Context, write models.PendingSheetWrite) error
+	ListPendingWrites(ctx context.Context, limit int64) ([]models.PendingSheetWrite, error)
+	MarkPendingWrite(ctx context.Context, id, status string) error
+	DeletePendingWrite(ctx context.Context, id string) error
+	FailPendingWrite(ctx context.Context, id, reason string) error
+}
+
+// WriteBehindRepository queues appends in a WriteQueue when Sheets is
+// unreachable and replays them, in order, once it is back, so entries made
+// during an outage are not lost. Only appends that certainly never reached
+// Sheets are queued; one that may have landed fails with ErrWriteUncertain
+// rather than risk a duplicate row. While writes are queued, new appends join
+// the queue instead of overtaking it. Other calls pass through, with outage
+// errors wrapped in ErrUnavailable.
+type WriteBehindRepository struct {
+	Repository
+	queue  WriteQueue
+	logger *zap.Logger
+
+	// mu orders enqueues against the flusher emptying the queue.
+	mu      sync.Mutex
+	backlog bool
+	// flushMu keeps a single flush running.
+	flushMu sync.Mutex
+}
+
+// NewWriteBehindRepository decorates repo with the write-behind queue.
+func NewWriteBehindRepository(repo Repository, queue WriteQueue, logger *zap.Logger) *WriteBehindRepository {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &WriteBehindRepository{Repository: repo, queue: queue, logger: logger}
+}
+
+// WriteRow appends the row, queueing it during an outage.
+func (r *WriteBehindRepository) WriteRow(ctx context.Context, sheetRange string, values []interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, [][]interface{}{values})
+	return err
+}
+
+// AppendRow appends the row, queueing it during an outage.
+func (r *WriteBehindRepository) AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error) {
+	return r.AppendRows(ctx, sheetRange, [][]interface{}{values})
+}
+
+// WriteRows appends the rows, queueing them during an outage.
+func (r *WriteBehindRepository) WriteRows(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, rows)
+	return err
+}
+
+// AppendRows appends the rows, or queues them when Sheets could not be
+// reached or earlier writes are still queued. Queued rows return an empty
+// range, as their position is not known yet.
+func (r *WriteBehindRepository) AppendRows(ctx context.Context, sheetRange string, rows [][]interface{}) (string, error) {
+	if len(rows) == 0 {
+		return "", nil
+	}
+	if queued, err := r.enqueueIfBacklog(ctx, sheetRange, rows); queued || err != nil {
+		return "", err
+	}
+
+	updatedRange, err := r.Repository.AppendRows(ctx, sheetRange, rows)
+	if err == nil || !queueable(ctx, err) {
+		return updatedRange, r.wrap(ctx, err)
+	}
+
+	logger.FromContext(ctx, r.logger).Warn("sheets unreachable, queueing write", zap.String("range", sheetRange), zap.Int("rows", len(rows)), zap.Error(err))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if qerr := r.enqueue(ctx, sheetRange, rows); qerr != nil {
+		return "", errors.Join(fmt.Errorf("%w: %w", ErrUnavailable, err), qerr)
+	}
+	r.backlog = true
+	return "", nil
+}
+
+func (r *WriteBehindRepository) enqueueIfBacklog(ctx context.Context, sheetRange string, rows [][]interface{}) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.backlog {
+		return false, nil
+	}
+	return true, r.enqueue(ctx, sheetRange, rows)
+}
+
+func (r *WriteBehindRepository) enqueue(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	// The queue must outlive a request cancelled by the slow failed call.
+	return r.queue.EnqueuePendingWrite(context.WithoutCancel(ctx), models.PendingSheetWrite{
+		SheetRange: sheetRange,
+		Rows:       rows,
+		CreatedAt:  time.Now().UTC(),
+	})
+}
+
+// ReadRange reads through, wrapping outage errors in ErrUnavailable.
+func (r *WriteBehindRepository) ReadRange(ctx context.Context, sheetRange string) ([][]interface{}, error) {
+	rows, err := r.Repository.ReadRange(ctx, sheetRange)
+	return rows, r.wrap(ctx, err)
+}
+
+// ReadSince reads through, wrapping outage errors in ErrUnavailable.
+func (r *WriteBehindRepository) ReadSince(ctx context.Context, sheetRange string, since time.Time) ([][]interface{}, error) {
+	rows, err := r.Repository.ReadSince(ctx, sheetRange, since)
+	return rows, r.wrap(ctx, err)
+}
+
+func (r *WriteBehindRepository) wrap(ctx context.Context, err error) error {
+	if err != nil && unavailable(ctx, err) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// Flush replays the queued writes oldest first and returns how many were
+// written. It stops at the first write that could not reach Sheets, leaving
+// the rest queued. Writes the Sheets API rejects, or that may have been
+// written despite failing (ErrWriteUncertain), are parked as failed rather
+// than retried. Each write is marked as sending before it is appended, so one
+// whose deletion fails is not appended twice.
+func (r *WriteBehindRepository) Flush(ctx context.Context) (int, error) {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	flushed := 0
+	for {
+		writes, err := r.queue.ListPendingWrites(ctx, pendingFlushBatch)
+		if err != nil {
+			return flushed, err
+		}
+		if len(writes) == 0 {
+			r.mu.Lock()
+			// Re-check under the lock: a write may have been queued since.
+			writes, err = r.queue.ListPendingWrites(ctx, 1)
+			if err == nil && len(writes) == 0 {
+				r.backlog = false
+			}
+			r.mu.Unlock()
+			if err != nil || len(writes) == 0 {
+				return flushed, err
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		r.backlog = true
+		r.mu.Unlock()
+
+		for _, write := range writes {
+			// Marked first, so a write appended but not deleted is never
+			// listed, and replayed, again.
+			if err := r.queue.MarkPendingWrite(ctx, write.ID, models.PendingWriteSending); err != nil {
+				return flushed, err
+			}
+			if _, err := r.Repository.AppendRows(ctx, write.SheetRange, write.Rows); err != nil {
+				if unsent(err) {
+					if err := r.queue.MarkPendingWrite(context.WithoutCancel(ctx), write.ID, models.PendingWriteQueued); err != nil {
+						logger.FromContext(ctx, r.logger).Error("queued sheet write not requeued, left as sending", zap.String("id", write.ID), zap.String("range", write.SheetRange), zap.Error(err))
+					}
+					return flushed, fmt.Errorf("%w: %w", ErrUnavailable, err)
+				}
+				logger.FromContext(ctx, r.logger).Error("queued sheet write rejected or uncertain, parked as failed", zap.String("id", write.ID), zap.String("range", write.SheetRange), zap.Error(err))
+				if err := r.queue.FailPendingWrite(context.WithoutCancel(ctx), write.ID, err.Error()); err != nil {
+					return flushed, err
+				}
+				if ctx.Err() != nil {
+					return flushed, ctx.Err()
+				}
+				continue
+			}
+			flushed++
+			if err := r.queue.DeletePendingWrite(ctx, write.ID); err != nil {
+				// Marked as sending, the write is not replayed; the flush
+				// goes on and the entry stays behind as a leftover.
+				logger.FromContext(ctx, r.logger).Warn("replayed sheet write not deleted", zap.String("id", write.ID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Run flushes the queue every interval until ctx is done.
+func (r *WriteBehindRepository) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		flushed, err := r.Flush(ctx)
+		if flushed > 0 {
+			logger.FromContext(ctx, r.logger).Info("queued sheet writes flushed", zap.Int("writes", flushed))
+		}
+		if err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx, r.logger).Warn("queued sheet writes not flushed yet", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queueable reports whether a failed append can be queued and replayed
+// later: it never reached Sheets and the caller was not cancelled.
+func queueable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && unsent(err)
+}
+
+// unavailable reports whether err means Sheets could not be reached, as
+// opposed to a rejected request or a cancelled caller.
+func unavailable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		return false
+	}
+	if retryable(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,216 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/option"
+	sheetsapi "google.golang.org/api/sheets/v4"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// fakeSheetsAPI counts the appends reaching the Sheets API and answers each
+// with respond, given the append's number from 1.
+type fakeSheetsAPI struct {
+	mu      sync.Mutex
+	appends int
+	respond func(w http.ResponseWriter, r *http.Request, n int)
+}
+
+func (f *fakeSheetsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.appends++
+	n := f.appends
+	f.mu.Unlock()
+	f.respond(w, r, n)
+}
+
+func (f *fakeSheetsAPI) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.appends
+}
+
+func landed(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"updates": {"updatedRange": "Sales!A2:E2"}}`)
+}
+
+// memQueue is a WriteQueue in memory.
+type memQueue struct {
+	writes []models.PendingSheetWrite
+}
+
+func (q *memQueue) EnqueuePendingWrite(ctx context.Context, write models.PendingSheetWrite) error {
+	write.ID = fmt.Sprint(len(q.writes) + 1)
+	write.Status = models.PendingWriteQueued
+	q.writes = append(q.writes, write)
+	return nil
+}
+
+func (q *memQueue) ListPendingWrites(ctx context.Context, limit int64) ([]models.PendingSheetWrite, error) {
+	var queued []models.PendingSheetWrite
+	for _, write := range q.writes {
+		if write.Status == models.PendingWriteQueued && int64(len(queued)) < limit {
+			queued = append(queued, write)
+		}
+	}
+	return queued, nil
+}
+
+func (q *memQueue) MarkPendingWrite(ctx context.Context, id, status string) error {
+	for i := range q.writes {
+		if q.writes[i].ID == id {
+			q.writes[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (q *memQueue) DeletePendingWrite(ctx context.Context, id string) error {
+	for i := range q.writes {
+		if q.writes[i].ID == id {
+			q.writes = append(q.writes[:i], q.writes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *memQueue) FailPendingWrite(ctx context.Context, id, reason string) error {
+	for i := range q.writes {
+		if q.writes[i].ID == id {
+			q.writes[i].Status = models.PendingWriteFailed
+			q.writes[i].Error = reason
+		}
+	}
+	return nil
+}
+
+// newWriteBehind returns the write-behind queue over a Google Sheets adaptor
+// talking to api, allowed 3 retries.
+func newWriteBehind(t *testing.T, api *fakeSheetsAPI) (*WriteBehindRepository, *memQueue) {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	queue := &memQueue{}
+	return NewWriteBehindRepository(newAdaptor(t, server.URL), queue, nil), queue
+}
+
+func newAdaptor(t *testing.T, endpoint string) *GoogleSheetRepository {
+	t.Helper()
+	service, err := sheetsapi.NewService(context.Background(), option.WithEndpoint(endpoint+"/"), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatalf("sheets client: %v", err)
+	}
+	return &GoogleSheetRepository{service: service, spreadsheetID: "sheet", maxRetries: 3, index: newRowIndex(), logger: zap.NewNop()}
+}
+
+var saleRow = []interface{}{"15/10/2026", "Binta", 10, 45000, 0}
+
+func TestAppendTimedOutAfterLandingIsNeitherRetriedNorQueued(t *testing.T) {
+	answered := make(chan struct{})
+	defer close(answered)
+	api := &fakeSheetsAPI{respond: func(w http.ResponseWriter, r *http.Request, n int) {
+		// The row is written, but the answer comes too late.
+		<-answered
+		landed(w)
+	}}
+	repo, queue := newWriteBehind(t, api)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := repo.AppendRow(ctx, "Sales!A:E", saleRow)
+	if !errors.Is(err, ErrWriteUncertain) {
+		t.Fatalf("AppendRow error = %v, want ErrWriteUncertain", err)
+	}
+	if api.count() != 1 || len(queue.writes) != 0 {
+		t.Errorf("%d append(s) sent and %d queued, want the one sent only", api.count(), len(queue.writes))
+	}
+}
+
+func TestAppendFailingWithAServerErrorIsNeitherRetriedNorQueued(t *testing.T) {
+	api := &fakeSheetsAPI{respond: func(w http.ResponseWriter, r *http.Request, n int) {
+		http.Error(w, `{"error": {"code": 503, "message": "backend error"}}`, http.StatusServiceUnavailable)
+	}}
+	repo, queue := newWriteBehind(t, api)
+
+	_, err := repo.AppendRow(context.Background(), "Sales!A:E", saleRow)
+	if !errors.Is(err, ErrWriteUncertain) || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("AppendRow error = %v, want ErrWriteUncertain and ErrUnavailable", err)
+	}
+	if api.count() != 1 || len(queue.writes) != 0 {
+		t.Errorf("%d append(s) sent and %d queued, want the one sent only", api.count(), len(queue.writes))
+	}
+}
+
+func TestAppendOverQuotaIsRetried(t *testing.T) {
+	api := &fakeSheetsAPI{respond: func(w http.ResponseWriter, r *http.Request, n int) {
+		if n == 1 {
+			http.Error(w, `{"error": {"code": 429, "message": "quota exceeded"}}`, http.StatusTooManyRequests)
+			return
+		}
+		landed(w)
+	}}
+	repo, queue := newWriteBehind(t, api)
+
+	updated, err := repo.AppendRow(context.Background(), "Sales!A:E", saleRow)
+	if err != nil || updated != "Sales!A2:E2" {
+		t.Fatalf("AppendRow = %q, %v, want the row written on the retry", updated, err)
+	}
+	if api.count() != 2 || len(queue.writes) != 0 {
+		t.Errorf("%d append(s) sent and %d queued, want 2 sent", api.count(), len(queue.writes))
+	}
+}
+
+func TestAppendWithoutConnectionIsQueuedAndReplayed(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	listener.Close() // connections are refused
+	adaptor := newAdaptor(t, "http://"+listener.Addr().String())
+	adaptor.maxRetries = 1
+	queue := &memQueue{}
+	down := NewWriteBehindRepository(adaptor, queue, nil)
+
+	updated, err := down.AppendRow(context.Background(), "Sales!A:E", saleRow)
+	if err != nil || updated != "" {
+		t.Fatalf("AppendRow = %q, %v, want the row queued", updated, err)
+	}
+	if len(queue.writes) != 1 {
+		t.Fatalf("%d write(s) queued, want 1", len(queue.writes))
+	}
+
+	api := &fakeSheetsAPI{respond: func(w http.ResponseWriter, r *http.Request, n int) { landed(w) }}
+	back, _ := newWriteBehind(t, api)
+	back.queue = queue
+	flushed, err := back.Flush(context.Background())
+	if err != nil || flushed != 1 || api.count() != 1 || len(queue.writes) != 0 {
+		t.Errorf("Flush = %d, %v with %d append(s) sent and %d left, want the write replayed once", flushed, err, api.count(), len(queue.writes))
+	}
+}
+
+func TestReplayFailingAfterReachingSheetsIsParked(t *testing.T) {
+	api := &fakeSheetsAPI{respond: func(w http.ResponseWriter, r *http.Request, n int) {
+		http.Error(w, `{"error": {"code": 500, "message": "internal error"}}`, http.StatusInternalServerError)
+	}}
+	repo, queue := newWriteBehind(t, api)
+	queue.EnqueuePendingWrite(context.Background(), models.PendingSheetWrite{SheetRange: "Sales!A:E", Rows: [][]interface{}{saleRow}})
+
+	if _, err := repo.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if api.count() != 1 || queue.writes[0].Status != models.PendingWriteFailed {
+		t.Errorf("%d append(s) sent, write %s, want one sent and the write parked", api.count(), queue.writes[0].Status)
+	}
+}
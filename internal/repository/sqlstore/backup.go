@@ -0,0 +1,94 @@
+package sqlstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// BackupCollections lists the tables worth exporting: everything but the
+// inbound messages, which expire anyway.
+func (r *SQLRepository) BackupCollections() []string {
+	tables := []string{tableReports, tableStock, tablePrices, tableAudit, tableCustomers, tablePending}
+	for _, kind := range recordTables {
+		tables = append(tables, string(kind))
+	}
+	return tables
+}
+
+// ExportCollection writes every row of the table to w as one JSON object per
+// line, the stored document with the row's other columns added (times in
+// Unix milliseconds), and returns how many were written.
+func (r *SQLRepository) ExportCollection(ctx context.Context, name string, w io.Writer) (int, error) {
+	if !r.knownTable(name) {
+		return 0, fmt.Errorf("failed to export %s: unknown table", name)
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT * FROM `+name+` ORDER BY id`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to export %s: %w", name, err)
+	}
+
+	out := bufio.NewWriter(w)
+	count := 0
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return count, fmt.Errorf("failed to export %s: %w", name, err)
+		}
+		document := make(map[string]interface{})
+		for i, column := range columns {
+			value := values[i]
+			if raw, ok := value.([]byte); ok {
+				value = string(raw)
+			}
+			switch {
+			case value == nil:
+			case column == "data":
+				data, _ := value.(string)
+				if err := json.Unmarshal([]byte(data), &document); err != nil {
+					return count, fmt.Errorf("failed to decode %s row: %w", name, err)
+				}
+			case column == "id":
+				document["_id"] = value
+			default:
+				document[column] = value
+			}
+		}
+		line, err := json.Marshal(document)
+		if err != nil {
+			return count, fmt.Errorf("failed to encode %s row: %w", name, err)
+		}
+		if _, err := out.Write(append(line, '\n')); err != nil {
+			return count, fmt.Errorf("failed to write %s export: %w", name, err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	if err := out.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write %s export: %w", name, err)
+	}
+	return count, nil
+}
+
+// knownTable guards the table names spliced into the export query.
+func (r *SQLRepository) knownTable(name string) bool {
+	for _, table := range r.BackupCollections() {
+		if table == name {
+			return true
+		}
+	}
+	return false
+}
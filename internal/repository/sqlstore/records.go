@@ -0,0 +1,445 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+// currentVersion matches the record versions reports must read: neither
+// replaced by a correction nor deleted.
+const currentVersion = `superseded = 0 AND deleted_at IS NULL`
+
+// SaveEggRecord stores a copy of an egg record and returns its ID.
+func (r *SQLRepository) SaveEggRecord(ctx context.Context, record models.EggRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordEggs, record)
+}
+
+// SaveFeedRecord stores a copy of a feed record and returns its ID.
+func (r *SQLRepository) SaveFeedRecord(ctx context.Context, record models.FeedRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordFeed, record)
+}
+
+// SaveMortalityRecord stores a copy of a mortality record and returns its ID.
+func (r *SQLRepository) SaveMortalityRecord(ctx context.Context, record models.MortalityRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordMortality, record)
+}
+
+// SaveSaleRecord stores a copy of a sale record and returns its ID.
+func (r *SQLRepository) SaveSaleRecord(ctx context.Context, record models.SaleRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordSales, record)
+}
+
+// SaveExpenseRecord stores a copy of an expense record and returns its ID.
+func (r *SQLRepository) SaveExpenseRecord(ctx context.Context, record models.ExpenseRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordExpenses, record)
+}
+
+// SavePaymentRecord stores a copy of a debt repayment and returns its ID.
+func (r *SQLRepository) SavePaymentRecord(ctx context.Context, record models.PaymentRecord) (string, error) {
+	return r.insertRecord(ctx, models.RecordPayments, record)
+}
+
+// GetEggRecords returns the mirrored egg records dated from start up to,
+// excluding, end, oldest first.
+func (r *SQLRepository) GetEggRecords(ctx context.Context, start, end time.Time) ([]models.EggRecord, error) {
+	return findRecords[models.EggRecord](ctx, r, models.RecordEggs, start, end)
+}
+
+// GetFeedRecords returns the mirrored feed records dated from start up to,
+// excluding, end, oldest first.
+func (r *SQLRepository) GetFeedRecords(ctx context.Context, start, end time.Time) ([]models.FeedRecord, error) {
+	return findRecords[models.FeedRecord](ctx, r, models.RecordFeed, start, end)
+}
+
+// GetMortalityRecords returns the mirrored mortality records dated from start
+// up to, excluding, end, oldest first.
+func (r *SQLRepository) GetMortalityRecords(ctx context.Context, start, end time.Time) ([]models.MortalityRecord, error) {
+	return findRecords[models.MortalityRecord](ctx, r, models.RecordMortality, start, end)
+}
+
+// GetSaleRecords returns the mirrored sales dated from start up to,
+// excluding, end, oldest first.
+func (r *SQLRepository) GetSaleRecords(ctx context.Context, start, end time.Time) ([]models.SaleRecord, error) {
+	return findRecords[models.SaleRecord](ctx, r, models.RecordSales, start, end)
+}
+
+// GetPaymentRecords returns the mirrored debt repayments dated from start up
+// to, excluding, end, oldest first.
+func (r *SQLRepository) GetPaymentRecords(ctx context.Context, start, end time.Time) ([]models.PaymentRecord, error) {
+	return findRecords[models.PaymentRecord](ctx, r, models.RecordPayments, start, end)
+}
+
+// GetExpenseRecords returns the mirrored expenses dated from start up to,
+// excluding, end, oldest first.
+func (r *SQLRepository) GetExpenseRecords(ctx context.Context, start, end time.Time) ([]models.ExpenseRecord, error) {
+	return findRecords[models.ExpenseRecord](ctx, r, models.RecordExpenses, start, end)
+}
+
+// findRecords reads the current versions of the records of kind dated from
+// start up to, excluding, end.
+func findRecords[T any](ctx context.Context, r *SQLRepository, kind models.RecordKind, start, end time.Time) ([]T, error) {
+	records, err := queryDocuments[T](ctx, r, nil,
+		`SELECT id, data FROM `+string(kind)+` WHERE `+currentVersion+` AND date >= ? AND date < ? ORDER BY date, id`,
+		millis(start), millis(end))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s records: %w", kind, err)
+	}
+	return records, nil
+}
+
+// GetClientBalances returns the clients who still owe money, largest balance
+// first, computed like the Mongo aggregation: each sale adds its unpaid part
+// (never negative) and each payment subtracts its amount; the registered
+// customer name and phone are used when the client is known. Only current
+// record versions count.
+func (r *SQLRepository) GetClientBalances(ctx context.Context) ([]models.ClientBalance, error) {
+	sales, err := queryDocuments[models.SaleRecord](ctx, r, nil,
+		`SELECT id, data FROM sales WHERE `+currentVersion+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sales for client balances: %w", err)
+	}
+	payments, err := queryDocuments[models.PaymentRecord](ctx, r, nil,
+		`SELECT id, data FROM payments WHERE `+currentVersion+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find payments for client balances: %w", err)
+	}
+
+	ledger := make(map[string]*models.ClientBalance)
+	account := func(client string) *models.ClientBalance {
+		key := models.CustomerKey(client)
+		balance, ok := ledger[key]
+		if !ok {
+			balance = &models.ClientBalance{ClientKey: key}
+			ledger[key] = balance
+		}
+		balance.Client = client
+		return balance
+	}
+	for _, sale := range sales {
+		balance := account(sale.Client)
+		due := math.Max(float64(sale.Quantity)*sale.PricePerUnit-sale.Paid, 0)
+		balance.Due += due
+		if due > 0 && (balance.OldestUnpaid.IsZero() || sale.Date.Before(balance.OldestUnpaid)) {
+			balance.OldestUnpaid = sale.Date
+		}
+	}
+	for _, payment := range payments {
+		balance := account(payment.Client)
+		balance.Repaid += payment.Amount
+		if payment.Amount > 0 && payment.Date.After(balance.LastPayment) {
+			balance.LastPayment = payment.Date
+		}
+	}
+
+	customers, err := r.ListCustomers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]models.Customer, len(customers))
+	for _, customer := range customers {
+		known[customer.NameKey] = customer
+	}
+
+	var balances []models.ClientBalance
+	for key, balance := range ledger {
+		balance.Balance = balance.Due - balance.Repaid
+		if balance.Balance <= 0 {
+			continue
+		}
+		if customer, ok := known[key]; ok {
+			balance.Client = customer.Name
+			balance.Phone = customer.Phone
+		}
+		balances = append(balances, *balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Balance != balances[j].Balance {
+			return balances[i].Balance > balances[j].Balance
+		}
+		return balances[i].ClientKey < balances[j].ClientKey
+	})
+	return balances, nil
+}
+
+// GetMonthlyStats returns, per calendar month (UTC) in ascending order, the
+// totals and averages of the records dated from start up to, excluding, end.
+// Months without any record are omitted; corrected and deleted record
+// versions are ignored.
+func (r *SQLRepository) GetMonthlyStats(ctx context.Context, start, end time.Time) ([]models.MonthlyStats, error) {
+	eggs, err := r.GetEggRecords(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := r.GetSaleRecords(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := r.GetExpenseRecords(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := r.GetPaymentRecords(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make(map[string]*models.MonthlyStats)
+	month := func(date time.Time) *models.MonthlyStats {
+		first := time.Date(date.UTC().Year(), date.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+		key := first.Format("2006-01")
+		stats, ok := months[key]
+		if !ok {
+			stats = &models.MonthlyStats{Month: first}
+			months[key] = stats
+		}
+		return stats
+	}
+	eggDays := make(map[string]bool)
+	for _, record := range eggs {
+		stats := month(record.Date)
+		stats.Eggs += record.Quantity
+		if day := record.Date.UTC().Format("2006-01-02"); !eggDays[day] {
+			eggDays[day] = true
+			stats.EggDays++
+		}
+	}
+	for _, record := range sales {
+		stats := month(record.Date)
+		stats.TraysSold += record.Quantity
+		stats.SalesAmount += float64(record.Quantity) * record.PricePerUnit
+		stats.SalesPaid += record.Paid
+	}
+	for _, record := range expenses {
+		month(record.Date).Expenses += record.Amount
+	}
+	for _, record := range payments {
+		month(record.Date).DebtCollected += record.Amount
+	}
+
+	result := make([]models.MonthlyStats, 0, len(months))
+	for _, stats := range months {
+		if stats.EggDays > 0 {
+			stats.AvgEggsPerDay = float64(stats.Eggs) / float64(stats.EggDays)
+		}
+		if stats.TraysSold > 0 {
+			stats.AvgTrayPrice = stats.SalesAmount / float64(stats.TraysSold)
+		}
+		stats.Profit = stats.SalesPaid + stats.DebtCollected - stats.Expenses
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Month.Before(result[j].Month) })
+	return result, nil
+}
+
+// DeleteRecord marks the current version of a mirrored farm record deleted by
+// by. The row stays for the history; reports skip it.
+func (r *SQLRepository) DeleteRecord(ctx context.Context, kind models.RecordKind, id, by string) error {
+	if !knownKind(kind) {
+		return fmt.Errorf("unknown record kind %s: %w", kind, mongodb.ErrRecordNotFound)
+	}
+	result, err := r.exec(ctx, `UPDATE `+string(kind)+` SET deleted_at = ?, deleted_by = ? WHERE id = ? AND `+currentVersion,
+		millis(time.Now()), by, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s record: %w", kind, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("delete %s record %s: %w", kind, id, mongodb.ErrNotCurrent)
+	}
+	return nil
+}
+
+// CorrectRecord replaces the current version id of a farm record with record,
+// stored as the next version of the same original, and returns the new
+// version's ID. Both steps run in one transaction.
+func (r *SQLRepository) CorrectRecord(ctx context.Context, kind models.RecordKind, id string, record interface{}, by string) (string, error) {
+	if !knownKind(kind) {
+		return "", fmt.Errorf("unknown record kind %s: %w", kind, mongodb.ErrRecordNotFound)
+	}
+	data, date, clientKey, err := recordColumns(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s correction: %w", kind, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to correct %s record: %w", kind, err)
+	}
+	defer tx.Rollback()
+
+	var originalID sql.NullString
+	var version int
+	err = tx.QueryRowContext(ctx, r.rebind(`SELECT original_id, version FROM `+string(kind)+` WHERE id = ? AND `+currentVersion), id).
+		Scan(&originalID, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("correct %s record %s: %w", kind, id, mongodb.ErrNotCurrent)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find %s record %s: %w", kind, id, err)
+	}
+	original := id
+	if originalID.Valid {
+		original = originalID.String
+	}
+
+	// The guarded update makes concurrent corrections of the same version
+	// fail instead of forking the history.
+	result, err := tx.ExecContext(ctx, r.rebind(`UPDATE `+string(kind)+` SET superseded = 1 WHERE id = ? AND `+currentVersion), id)
+	if err != nil {
+		return "", fmt.Errorf("failed to supersede %s record: %w", kind, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return "", fmt.Errorf("correct %s record %s: %w", kind, id, mongodb.ErrNotCurrent)
+	}
+
+	newVersion := newID()
+	_, err = tx.ExecContext(ctx, r.rebind(`INSERT INTO `+string(kind)+`
+		(id, date, client_key, original_id, version, changed_by, changed_at, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		newVersion, millis(date), clientKey, original, version+1, by, millis(time.Now()), data)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert %s correction: %w", kind, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to correct %s record: %w", kind, err)
+	}
+	return newVersion, nil
+}
+
+// GetRecordHistory returns every version of the record id belongs to, the
+// original first.
+func (r *SQLRepository) GetRecordHistory(ctx context.Context, kind models.RecordKind, id string) ([]models.RecordVersion, error) {
+	if !knownKind(kind) {
+		return nil, fmt.Errorf("unknown record kind %s: %w", kind, mongodb.ErrRecordNotFound)
+	}
+	var originalID sql.NullString
+	err := r.db.QueryRowContext(ctx, r.rebind(`SELECT original_id FROM `+string(kind)+` WHERE id = ?`), id).Scan(&originalID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s record %s: %w", kind, id, mongodb.ErrRecordNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s record %s: %w", kind, id, err)
+	}
+	original := id
+	if originalID.Valid {
+		original = originalID.String
+	}
+
+	history, err := r.queryVersions(ctx, kind, `WHERE id = ? OR original_id = ? ORDER BY version`, original, original)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s record history: %w", kind, err)
+	}
+	return history, nil
+}
+
+// ListRecords returns the current versions of the records of kind dated in
+// [start, end), by date, with the IDs to correct or delete them by.
+func (r *SQLRepository) ListRecords(ctx context.Context, kind models.RecordKind, start, end time.Time) ([]models.RecordVersion, error) {
+	if !knownKind(kind) {
+		return nil, fmt.Errorf("unknown record kind %s: %w", kind, mongodb.ErrRecordNotFound)
+	}
+	records, err := r.queryVersions(ctx, kind, `WHERE `+currentVersion+` AND date >= ? AND date < ? ORDER BY date, id`,
+		millis(start), millis(end))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s records: %w", kind, err)
+	}
+	return records, nil
+}
+
+// queryVersions reads the record versions of kind selected by where, the
+// rest of the query after the table.
+func (r *SQLRepository) queryVersions(ctx context.Context, kind models.RecordKind, where string, args ...interface{}) ([]models.RecordVersion, error) {
+	rows, err := r.db.QueryContext(ctx, r.rebind(`SELECT id, original_id, version, changed_by, changed_at, superseded, deleted_by, deleted_at, data
+		FROM `+string(kind)+` `+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.RecordVersion{}
+	for rows.Next() {
+		var (
+			entry                            models.RecordVersion
+			originalID, changedBy, deletedBy sql.NullString
+			changedAt, deletedAt             sql.NullInt64
+			superseded                       int
+			data                             string
+		)
+		if err := rows.Scan(&entry.ID, &originalID, &entry.Version, &changedBy, &changedAt, &superseded, &deletedBy, &deletedAt, &data); err != nil {
+			return nil, err
+		}
+		entry.OriginalID = entry.ID
+		if originalID.Valid {
+			entry.OriginalID = originalID.String
+		}
+		entry.ChangedBy = changedBy.String
+		entry.DeletedBy = deletedBy.String
+		if changedAt.Valid {
+			at := time.UnixMilli(changedAt.Int64).UTC()
+			entry.ChangedAt = &at
+		}
+		if deletedAt.Valid {
+			at := time.UnixMilli(deletedAt.Int64).UTC()
+			entry.DeletedAt = &at
+		}
+		entry.Current = superseded == 0 && entry.DeletedAt == nil
+		if err := json.Unmarshal([]byte(data), &entry.Record); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", entry.ID, err)
+		}
+		versions = append(versions, entry)
+	}
+	return versions, rows.Err()
+}
+
+func (r *SQLRepository) insertRecord(ctx context.Context, kind models.RecordKind, record interface{}) (string, error) {
+	data, date, clientKey, err := recordColumns(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s record: %w", kind, err)
+	}
+	id := newID()
+	_, err = r.exec(ctx, `INSERT INTO `+string(kind)+` (id, date, client_key, data) VALUES (?, ?, ?, ?)`,
+		id, millis(date), clientKey, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert %s record: %w", kind, err)
+	}
+	return id, nil
+}
+
+// recordColumns encodes a farm record and extracts the columns queried: its
+// date and, for sales and payments, the normalized client name the ledger
+// groups on.
+func recordColumns(record interface{}) (string, time.Time, string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", time.Time{}, "", err
+	}
+	var fields struct {
+		Date   time.Time `json:"date"`
+		Client string    `json:"client"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", time.Time{}, "", err
+	}
+	clientKey := ""
+	if fields.Client != "" {
+		clientKey = models.CustomerKey(fields.Client)
+	}
+	return string(data), fields.Date, clientKey, nil
+}
+
+// knownKind guards the table names spliced into the record queries.
+func knownKind(kind models.RecordKind) bool {
+	for _, known := range recordTables {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+func TestCorrectedRecordsKeepTheirHistory(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	id, err := r.SaveSaleRecord(ctx, models.SaleRecord{Date: day, Client: "Binta", Quantity: 10, PricePerUnit: 45000})
+	if err != nil {
+		t.Fatalf("SaveSaleRecord: %v", err)
+	}
+	fixed, err := r.CorrectRecord(ctx, models.RecordSales, id, models.SaleRecord{Date: day, Client: "Binta", Quantity: 12, PricePerUnit: 45000}, "office")
+	if err != nil {
+		t.Fatalf("CorrectRecord: %v", err)
+	}
+	if _, err := r.CorrectRecord(ctx, models.RecordSales, id, models.SaleRecord{Date: day}, "office"); !errors.Is(err, mongodb.ErrNotCurrent) {
+		t.Errorf("correcting the superseded version: %v, want ErrNotCurrent", err)
+	}
+
+	sales, err := r.GetSaleRecords(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetSaleRecords: %v", err)
+	}
+	if len(sales) != 1 || sales[0].Quantity != 12 {
+		t.Errorf("sales = %+v, want only the correction", sales)
+	}
+
+	history, err := r.GetRecordHistory(ctx, models.RecordSales, fixed)
+	if err != nil {
+		t.Fatalf("GetRecordHistory: %v", err)
+	}
+	if len(history) != 2 || history[0].ID != id || history[0].Current || history[1].Version != 2 ||
+		history[1].OriginalID != id || history[1].ChangedBy != "office" || !history[1].Current {
+		t.Errorf("history = %+v, want the original then office's correction", history)
+	}
+	if _, err := r.GetRecordHistory(ctx, models.RecordSales, "unknown"); !errors.Is(err, mongodb.ErrRecordNotFound) {
+		t.Errorf("history of an unknown record: %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestDeletedRecordsLeaveTheReports(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	id, err := r.SaveEggRecord(ctx, models.EggRecord{Date: day, Quantity: 120})
+	if err != nil {
+		t.Fatalf("SaveEggRecord: %v", err)
+	}
+	if err := r.DeleteRecord(ctx, models.RecordEggs, id, "office"); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	if err := r.DeleteRecord(ctx, models.RecordEggs, id, "office"); !errors.Is(err, mongodb.ErrNotCurrent) {
+		t.Errorf("deleting twice: %v, want ErrNotCurrent", err)
+	}
+	if err := r.DeleteRecord(ctx, "flock", id, "office"); !errors.Is(err, mongodb.ErrRecordNotFound) {
+		t.Errorf("deleting an unknown kind: %v, want ErrRecordNotFound", err)
+	}
+
+	listed, err := r.ListRecords(ctx, models.RecordEggs, day, day.AddDate(0, 0, 1))
+	if err != nil || len(listed) != 0 {
+		t.Errorf("ListRecords = %+v, %v, want none", listed, err)
+	}
+	history, err := r.GetRecordHistory(ctx, models.RecordEggs, id)
+	if err != nil || len(history) != 1 || history[0].DeletedBy != "office" || history[0].DeletedAt == nil {
+		t.Errorf("history = %+v, %v, want the version deleted by office", history, err)
+	}
+}
+
+func TestClientBalancesNetSalesAgainstPayments(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	if err := r.SaveCustomer(ctx, models.Customer{Name: "Binta Diallo", NameKey: models.CustomerKey("binta diallo"), Phone: "224600000009"}); err != nil {
+		t.Fatalf("SaveCustomer: %v", err)
+	}
+	for _, sale := range []models.SaleRecord{
+		{Date: day, Client: "Binta Diallo", Quantity: 10, PricePerUnit: 45000, Paid: 150000},
+		{Date: day.AddDate(0, 0, 1), Client: "binta diallo", Quantity: 2, PricePerUnit: 45000, Paid: 90000},
+		{Date: day, Client: "Awa", Quantity: 1, PricePerUnit: 45000},
+	} {
+		if _, err := r.SaveSaleRecord(ctx, sale); err != nil {
+			t.Fatalf("SaveSaleRecord: %v", err)
+		}
+	}
+	for _, payment := range []models.PaymentRecord{
+		{Date: day.AddDate(0, 0, 2), Client: "Binta Diallo", Amount: 100000},
+		{Date: day.AddDate(0, 0, 2), Client: "Awa", Amount: 45000},
+	} {
+		if _, err := r.SavePaymentRecord(ctx, payment); err != nil {
+			t.Fatalf("SavePaymentRecord: %v", err)
+		}
+	}
+
+	balances, err := r.GetClientBalances(ctx)
+	if err != nil {
+		t.Fatalf("GetClientBalances: %v", err)
+	}
+	if len(balances) != 1 {
+		t.Fatalf("balances = %+v, want Binta only, Awa has paid", balances)
+	}
+	binta := balances[0]
+	if binta.Client != "Binta Diallo" || binta.Phone != "224600000009" || binta.Due != 300000 || binta.Repaid != 100000 ||
+		binta.Balance != 200000 || !binta.OldestUnpaid.Equal(day) || !binta.LastPayment.Equal(day.AddDate(0, 0, 2)) {
+		t.Errorf("balance = %+v, want 200000 owed by the registered Binta Diallo", binta)
+	}
+}
+
+func TestMonthlyStatsGroupByMonth(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	october := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, egg := range []models.EggRecord{
+		{Date: october.AddDate(0, 0, 1), Quantity: 100},
+		{Date: october.AddDate(0, 0, 1).Add(time.Hour), Quantity: 20},
+		{Date: october.AddDate(0, 0, 2), Quantity: 80},
+		{Date: october.AddDate(0, 1, 0), Quantity: 90},
+	} {
+		if _, err := r.SaveEggRecord(ctx, egg); err != nil {
+			t.Fatalf("SaveEggRecord: %v", err)
+		}
+	}
+	if _, err := r.SaveSaleRecord(ctx, models.SaleRecord{Date: october, Client: "Awa", Quantity: 4, PricePerUnit: 45000, Paid: 100000}); err != nil {
+		t.Fatalf("SaveSaleRecord: %v", err)
+	}
+	if _, err := r.SaveExpenseRecord(ctx, models.ExpenseRecord{Date: october, Category: "feed", Amount: 30000}); err != nil {
+		t.Fatalf("SaveExpenseRecord: %v", err)
+	}
+	if _, err := r.SavePaymentRecord(ctx, models.PaymentRecord{Date: october, Client: "Awa", Amount: 80000}); err != nil {
+		t.Fatalf("SavePaymentRecord: %v", err)
+	}
+
+	stats, err := r.GetMonthlyStats(ctx, october, october.AddDate(0, 2, 0))
+	if err != nil {
+		t.Fatalf("GetMonthlyStats: %v", err)
+	}
+	if len(stats) != 2 || !stats[0].Month.Equal(october) {
+		t.Fatalf("stats = %+v, want October then November", stats)
+	}
+	oct := stats[0]
+	if oct.Eggs != 200 || oct.EggDays != 2 || oct.AvgEggsPerDay != 100 || oct.TraysSold != 4 || oct.AvgTrayPrice != 45000 ||
+		oct.DebtCollected != 80000 || oct.Expenses != 30000 || oct.Profit != 150000 {
+		t.Errorf("October = %+v", oct)
+	}
+	if stats[1].Eggs != 90 || stats[1].Profit != 0 {
+		t.Errorf("November = %+v", stats[1])
+	}
+}
@@ -0,0 +1,654 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+// defaultListLimit caps audit and stock queries that do not specify a limit,
+// as in the Mongo store.
+const defaultListLimit = 100
+
+// Table names, the same as the Mongo collections so exports and audit
+// references read alike whatever the backend.
+const (
+	tableReports   = "daily_reports"
+	tableStock     = "stock_items"
+	tablePrices    = "egg_prices"
+	tableAudit     = "command_audit"
+	tableCustomers = "customers"
+	tablePending   = "pending_sheet_writes"
+)
+
+// recordTables are the tables of the mirrored farm records.
+var recordTables = []models.RecordKind{
+	models.RecordEggs,
+	models.RecordFeed,
+	models.RecordMortality,
+	models.RecordSales,
+	models.RecordPayments,
+	models.RecordExpenses,
+}
+
+// driverNames are the database/sql drivers of the backends, registered by the
+// driver packages linked in with the postgres and sqlite build tags.
+var driverNames = map[string]string{
+	config.StorePostgres: "pgx",
+	config.StoreSQLite:   "sqlite",
+}
+
+var _ mongodb.Repository = (*SQLRepository)(nil)
+
+// SQLRepository implements mongodb.Repository over PostgreSQL or SQLite. Each
+// table keeps the document as JSON next to the columns queries filter and
+// sort on; times are stored as Unix milliseconds so both databases compare
+// them the same way.
+type SQLRepository struct {
+	db               *sql.DB
+	backend          string
+	messageRetention time.Duration
+}
+
+// NewSQLRepository opens cfg.DSN with the driver of cfg.Backend and pings it.
+// Inbound messages older than messageRetention are deleted as new ones
+// arrive.
+func NewSQLRepository(ctx context.Context, cfg config.StoreConfig, messageRetention time.Duration) (*SQLRepository, error) {
+	driver, ok := driverNames[cfg.Backend]
+	if !ok {
+		return nil, fmt.Errorf("unsupported store backend %q", cfg.Backend)
+	}
+	db, err := sql.Open(driver, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database (is the binary built with -tags %s?): %w", cfg.Backend, cfg.Backend, err)
+	}
+	if cfg.Backend == config.StoreSQLite {
+		// SQLite allows a single writer; queue the writes in the pool
+		// instead of failing them with "database is locked".
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping %s database: %w", cfg.Backend, err)
+	}
+	return &SQLRepository{db: db, backend: cfg.Backend, messageRetention: messageRetention}, nil
+}
+
+// EnsureSchema creates the tables and indexes the queries rely on. Existing
+// ones are kept, so it runs at every start.
+func (r *SQLRepository) EnsureSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS daily_reports (id TEXT PRIMARY KEY, date BIGINT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS daily_reports_date ON daily_reports (date)`,
+		`CREATE TABLE IF NOT EXISTS stock_items (id TEXT PRIMARY KEY, date BIGINT NOT NULL, item_key TEXT NOT NULL, condition_key TEXT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS stock_items_date ON stock_items (date)`,
+		`CREATE TABLE IF NOT EXISTS egg_prices (id TEXT PRIMARY KEY, effective_date BIGINT NOT NULL, created_at BIGINT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS egg_prices_effective_date ON egg_prices (effective_date, created_at)`,
+		`CREATE TABLE IF NOT EXISTS command_audit (id TEXT PRIMARY KEY, created_at BIGINT NOT NULL, sender TEXT NOT NULL, command TEXT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS command_audit_created_at ON command_audit (created_at)`,
+		`CREATE INDEX IF NOT EXISTS command_audit_sender ON command_audit (sender, created_at)`,
+		`CREATE TABLE IF NOT EXISTS message_audit (id TEXT PRIMARY KEY, received_at BIGINT NOT NULL, wa_id TEXT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS message_audit_received_at ON message_audit (received_at)`,
+		`CREATE INDEX IF NOT EXISTS message_audit_wa_id ON message_audit (wa_id, received_at)`,
+		`CREATE TABLE IF NOT EXISTS customers (id TEXT PRIMARY KEY, name_key TEXT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS customers_name_key ON customers (name_key)`,
+		`CREATE TABLE IF NOT EXISTS pending_sheet_writes (id TEXT PRIMARY KEY, created_at BIGINT NOT NULL, status TEXT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS pending_sheet_writes_status ON pending_sheet_writes (status, created_at)`,
+		`CREATE TABLE IF NOT EXISTS job_runs (name TEXT PRIMARY KEY, last_success BIGINT NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS job_executions (id TEXT PRIMARY KEY, started_at BIGINT NOT NULL, name TEXT NOT NULL, data TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS job_executions_started_at ON job_executions (started_at)`,
+		`CREATE INDEX IF NOT EXISTS job_executions_name ON job_executions (name, started_at)`,
+		`CREATE TABLE IF NOT EXISTS leases (name TEXT PRIMARY KEY, holder TEXT NOT NULL, expires_at BIGINT NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS feature_flags (id TEXT PRIMARY KEY, data TEXT NOT NULL)`,
+	}
+	for _, kind := range recordTables {
+		table := string(kind)
+		statements = append(statements,
+			`CREATE TABLE IF NOT EXISTS `+table+` (
+				id TEXT PRIMARY KEY,
+				date BIGINT NOT NULL,
+				client_key TEXT NOT NULL DEFAULT '',
+				original_id TEXT,
+				version INTEGER NOT NULL DEFAULT 1,
+				changed_by TEXT,
+				changed_at BIGINT,
+				superseded INTEGER NOT NULL DEFAULT 0,
+				deleted_by TEXT,
+				deleted_at BIGINT,
+				data TEXT NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS `+table+`_date ON `+table+` (date)`,
+			`CREATE INDEX IF NOT EXISTS `+table+`_original_id ON `+table+` (original_id)`,
+		)
+		if kind == models.RecordSales || kind == models.RecordPayments {
+			statements = append(statements, `CREATE INDEX IF NOT EXISTS `+table+`_client_key ON `+table+` (client_key, date)`)
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := r.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create %s schema: %w", r.backend, err)
+		}
+	}
+	return nil
+}
+
+// SaveDailyReport saves a daily report, replacing the one already stored for
+// the same date so regenerated reports are not counted twice.
+func (r *SQLRepository) SaveDailyReport(ctx context.Context, report models.DailyReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode daily report: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO daily_reports (id, date, data) VALUES (?, ?, ?)
+		ON CONFLICT (date) DO UPDATE SET data = excluded.data`,
+		newID(), millis(report.Date), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert daily report: %w", err)
+	}
+	return nil
+}
+
+// GetDailyReports retrieves daily reports within a date range.
+func (r *SQLRepository) GetDailyReports(ctx context.Context, start, end time.Time) ([]models.DailyReport, error) {
+	reports, err := queryDocuments[models.DailyReport](ctx, r, nil,
+		`SELECT id, data FROM daily_reports WHERE date >= ? AND date <= ? ORDER BY date`, millis(start), millis(end))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find daily reports: %w", err)
+	}
+	return reports, nil
+}
+
+// SaveStockItem saves a physical stock item and returns its ID.
+func (r *SQLRepository) SaveStockItem(ctx context.Context, item models.StateStockRecord) (string, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode stock item: %w", err)
+	}
+	id := newID()
+	_, err = r.exec(ctx, `INSERT INTO stock_items (id, date, item_key, condition_key, data) VALUES (?, ?, ?, ?, ?)`,
+		id, millis(item.Date), strings.ToLower(item.ItemName), strings.ToLower(item.Condition), string(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to insert stock item: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteStockItem removes a stock item by its ID.
+func (r *SQLRepository) DeleteStockItem(ctx context.Context, id string) error {
+	if _, err := r.exec(ctx, `DELETE FROM stock_items WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete stock item: %w", err)
+	}
+	return nil
+}
+
+// GetStockItems returns the stock items matching the query, newest first.
+func (r *SQLRepository) GetStockItems(ctx context.Context, query models.StockQuery) ([]models.StateStockRecord, error) {
+	var where []string
+	var args []interface{}
+	if query.ItemName != "" {
+		where = append(where, `item_key LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(strings.ToLower(query.ItemName))+"%")
+	}
+	if query.Condition != "" {
+		where = append(where, `condition_key = ?`)
+		args = append(args, strings.ToLower(query.Condition))
+	}
+	args = append(args, limitOf(query.Limit))
+
+	items, err := queryDocuments[models.StateStockRecord](ctx, r, nil,
+		`SELECT id, data FROM stock_items`+whereClause(where)+` ORDER BY date DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stock items: %w", err)
+	}
+	return items, nil
+}
+
+// SaveEggPrice stores a new tray price entry.
+func (r *SQLRepository) SaveEggPrice(ctx context.Context, price models.EggPriceRecord) error {
+	data, err := json.Marshal(price)
+	if err != nil {
+		return fmt.Errorf("failed to encode egg price: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO egg_prices (id, effective_date, created_at, data) VALUES (?, ?, ?, ?)`,
+		newID(), millis(price.EffectiveDate), millis(price.CreatedAt), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert egg price: %w", err)
+	}
+	return nil
+}
+
+// GetLatestEggPrice returns the most recent tray price, or nil when none is recorded.
+func (r *SQLRepository) GetLatestEggPrice(ctx context.Context) (*models.EggPriceRecord, error) {
+	prices, err := queryDocuments[models.EggPriceRecord](ctx, r, nil,
+		`SELECT id, data FROM egg_prices ORDER BY effective_date DESC, created_at DESC LIMIT 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find latest egg price: %w", err)
+	}
+	if len(prices) == 0 {
+		return nil, nil
+	}
+	return &prices[0], nil
+}
+
+// SaveCommandAudit stores the outcome of a handled command.
+func (r *SQLRepository) SaveCommandAudit(ctx context.Context, entry models.CommandAuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode command audit: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO command_audit (id, created_at, sender, command, data) VALUES (?, ?, ?, ?, ?)`,
+		newID(), millis(entry.CreatedAt), entry.Sender, entry.Command, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert command audit: %w", err)
+	}
+	return nil
+}
+
+// ListCommandAudits returns audit entries matching the query, newest first.
+func (r *SQLRepository) ListCommandAudits(ctx context.Context, query models.AuditQuery) ([]models.CommandAuditEntry, error) {
+	var where []string
+	var args []interface{}
+	if query.Sender != "" {
+		where = append(where, `sender = ?`)
+		args = append(args, query.Sender)
+	}
+	if query.Command != "" {
+		where = append(where, `command = ?`)
+		args = append(args, query.Command)
+	}
+	if !query.From.IsZero() {
+		where = append(where, `created_at >= ?`)
+		args = append(args, millis(query.From))
+	}
+	if !query.To.IsZero() {
+		where = append(where, `created_at <= ?`)
+		args = append(args, millis(query.To))
+	}
+	args = append(args, limitOf(query.Limit))
+
+	entries, err := queryDocuments[models.CommandAuditEntry](ctx, r, nil,
+		`SELECT id, data FROM command_audit`+whereClause(where)+` ORDER BY created_at DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find command audits: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveMessageAudit stores one inbound message and deletes the expired ones,
+// standing in for the Mongo TTL index.
+func (r *SQLRepository) SaveMessageAudit(ctx context.Context, entry models.MessageAuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode message audit: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO message_audit (id, received_at, wa_id, data) VALUES (?, ?, ?, ?)`,
+		newID(), millis(entry.ReceivedAt), entry.WaID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert message audit: %w", err)
+	}
+	if r.messageRetention > 0 {
+		expired := millis(time.Now().Add(-r.messageRetention))
+		if _, err := r.exec(ctx, `DELETE FROM message_audit WHERE received_at < ?`, expired); err != nil {
+			return fmt.Errorf("failed to expire message audits: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListMessageAudits returns inbound messages matching the query, newest first.
+func (r *SQLRepository) ListMessageAudits(ctx context.Context, query models.MessageAuditQuery) ([]models.MessageAuditEntry, error) {
+	var where []string
+	var args []interface{}
+	if query.WaID != "" {
+		where = append(where, `wa_id = ?`)
+		args = append(args, query.WaID)
+	}
+	if !query.From.IsZero() {
+		where = append(where, `received_at >= ?`)
+		args = append(args, millis(query.From))
+	}
+	if !query.To.IsZero() {
+		where = append(where, `received_at <= ?`)
+		args = append(args, millis(query.To))
+	}
+	args = append(args, limitOf(query.Limit))
+
+	entries, err := queryDocuments[models.MessageAuditEntry](ctx, r, nil,
+		`SELECT id, data FROM message_audit`+whereClause(where)+` ORDER BY received_at DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message audits: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveCustomer creates or updates a customer, keyed by its normalized name.
+func (r *SQLRepository) SaveCustomer(ctx context.Context, customer models.Customer) error {
+	data, err := json.Marshal(customer)
+	if err != nil {
+		return fmt.Errorf("failed to encode customer: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO customers (id, name_key, data) VALUES (?, ?, ?)
+		ON CONFLICT (name_key) DO UPDATE SET data = excluded.data`,
+		newID(), customer.NameKey, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save customer: %w", err)
+	}
+	return nil
+}
+
+// ListCustomers returns every registered customer sorted by name.
+func (r *SQLRepository) ListCustomers(ctx context.Context) ([]models.Customer, error) {
+	customers, err := queryDocuments[models.Customer](ctx, r, nil, `SELECT id, data FROM customers ORDER BY name_key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find customers: %w", err)
+	}
+	return customers, nil
+}
+
+// EnqueuePendingWrite stores a Sheets append to replay later.
+func (r *SQLRepository) EnqueuePendingWrite(ctx context.Context, write models.PendingSheetWrite) error {
+	write.ID = ""
+	write.Status = models.PendingWriteQueued
+	data, err := json.Marshal(write)
+	if err != nil {
+		return fmt.Errorf("failed to encode sheet write: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO pending_sheet_writes (id, created_at, status, data) VALUES (?, ?, ?, ?)`,
+		newID(), millis(write.CreatedAt), write.Status, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to queue sheet write: %w", err)
+	}
+	return nil
+}
+
+// ListPendingWrites returns up to limit queued writes, oldest first.
+func (r *SQLRepository) ListPendingWrites(ctx context.Context, limit int64) ([]models.PendingSheetWrite, error) {
+	setID := func(write *models.PendingSheetWrite, id string) { write.ID = id }
+	writes, err := queryDocuments(ctx, r, setID,
+		`SELECT id, data FROM pending_sheet_writes WHERE status = ? ORDER BY created_at, id LIMIT ?`,
+		models.PendingWriteQueued, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending sheet writes: %w", err)
+	}
+	return writes, nil
+}
+
+// DeletePendingWrite removes a replayed write by its ID.
+func (r *SQLRepository) DeletePendingWrite(ctx context.Context, id string) error {
+	if _, err := r.exec(ctx, `DELETE FROM pending_sheet_writes WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete pending sheet write: %w", err)
+	}
+	return nil
+}
+
+// MarkPendingWrite sets the status of a write by its ID.
+func (r *SQLRepository) MarkPendingWrite(ctx context.Context, id, status string) error {
+	return r.updatePendingWrite(ctx, id, func(write *models.PendingSheetWrite) {
+		write.Status = status
+	})
+}
+
+// FailPendingWrite parks a write the Sheets API rejected, keeping it for
+// manual recovery instead of replaying it forever.
+func (r *SQLRepository) FailPendingWrite(ctx context.Context, id, reason string) error {
+	return r.updatePendingWrite(ctx, id, func(write *models.PendingSheetWrite) {
+		write.Status = models.PendingWriteFailed
+		write.Error = reason
+	})
+}
+
+// updatePendingWrite applies update to the write stored under id, if any.
+func (r *SQLRepository) updatePendingWrite(ctx context.Context, id string, update func(*models.PendingSheetWrite)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update pending sheet write: %w", err)
+	}
+	defer tx.Rollback()
+
+	var data string
+	err = tx.QueryRowContext(ctx, r.rebind(`SELECT data FROM pending_sheet_writes WHERE id = ?`), id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update pending sheet write: %w", err)
+	}
+	var write models.PendingSheetWrite
+	if err := json.Unmarshal([]byte(data), &write); err != nil {
+		return fmt.Errorf("failed to decode pending sheet write: %w", err)
+	}
+	update(&write)
+	updated, err := json.Marshal(write)
+	if err != nil {
+		return fmt.Errorf("failed to encode pending sheet write: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, r.rebind(`UPDATE pending_sheet_writes SET status = ?, data = ? WHERE id = ?`),
+		write.Status, string(updated), id)
+	if err != nil {
+		return fmt.Errorf("failed to update pending sheet write: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SaveJobRun records the last successful run of a scheduled job, replacing
+// the previous one.
+func (r *SQLRepository) SaveJobRun(ctx context.Context, run models.JobRun) error {
+	_, err := r.exec(ctx, `INSERT INTO job_runs (name, last_success) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET last_success = excluded.last_success`,
+		run.Name, millis(run.LastSuccess))
+	if err != nil {
+		return fmt.Errorf("failed to save job run: %w", err)
+	}
+	return nil
+}
+
+// ListJobRuns returns the last successful run of every job that ever
+// succeeded.
+func (r *SQLRepository) ListJobRuns(ctx context.Context) ([]models.JobRun, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT name, last_success FROM job_runs`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.JobRun
+	for rows.Next() {
+		var run models.JobRun
+		var lastSuccess int64
+		if err := rows.Scan(&run.Name, &lastSuccess); err != nil {
+			return nil, fmt.Errorf("failed to decode job runs: %w", err)
+		}
+		run.LastSuccess = time.UnixMilli(lastSuccess).UTC()
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// SaveFeatureFlag stores a feature flag, replacing the previous one.
+func (r *SQLRepository) SaveFeatureFlag(ctx context.Context, flag models.FeatureFlag) error {
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return fmt.Errorf("failed to encode feature flag: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO feature_flags (id, data) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data`,
+		string(flag.Name), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	return nil
+}
+
+// ListFeatureFlags returns every stored feature flag.
+func (r *SQLRepository) ListFeatureFlags(ctx context.Context) ([]models.FeatureFlag, error) {
+	flags, err := queryDocuments[models.FeatureFlag](ctx, r, nil, `SELECT id, data FROM feature_flags`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find feature flags: %w", err)
+	}
+	return flags, nil
+}
+
+// SaveJobExecution stores one run of a scheduled job.
+func (r *SQLRepository) SaveJobExecution(ctx context.Context, execution models.JobExecution) error {
+	data, err := json.Marshal(execution)
+	if err != nil {
+		return fmt.Errorf("failed to encode job execution: %w", err)
+	}
+	_, err = r.exec(ctx, `INSERT INTO job_executions (id, started_at, name, data) VALUES (?, ?, ?, ?)`,
+		newID(), millis(execution.StartedAt), execution.Name, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert job execution: %w", err)
+	}
+	return nil
+}
+
+// ListJobExecutions returns the job runs matching the query, newest first.
+func (r *SQLRepository) ListJobExecutions(ctx context.Context, query models.JobExecutionQuery) ([]models.JobExecution, error) {
+	var where []string
+	var args []interface{}
+	if query.Name != "" {
+		where = append(where, `name = ?`)
+		args = append(args, query.Name)
+	}
+	args = append(args, limitOf(query.Limit))
+
+	executions, err := queryDocuments[models.JobExecution](ctx, r, nil,
+		`SELECT id, data FROM job_executions`+whereClause(where)+` ORDER BY started_at DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job executions: %w", err)
+	}
+	return executions, nil
+}
+
+// AcquireLease takes or renews the lease name for holder until ttl from now
+// and reports whether holder has it. A lease held by another instance is only
+// taken once expired.
+func (r *SQLRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	result, err := r.exec(ctx, `INSERT INTO leases (name, holder, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE leases.holder = excluded.holder OR leases.expires_at < ?`,
+		name, holder, millis(now.Add(ttl)), millis(now))
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return affected > 0, nil
+}
+
+// ReleaseLease gives up the lease name if holder has it.
+func (r *SQLRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	if _, err := r.exec(ctx, `DELETE FROM leases WHERE name = ? AND holder = ?`, name, holder); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// Ping checks that the database answers.
+func (r *SQLRepository) Ping(ctx context.Context) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping %s database: %w", r.backend, err)
+	}
+	return nil
+}
+
+// Close closes the database connections.
+func (r *SQLRepository) Close(ctx context.Context) error {
+	return r.db.Close()
+}
+
+func (r *SQLRepository) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.db.ExecContext(ctx, r.rebind(query), args...)
+}
+
+// rebind turns the ? placeholders of a query into PostgreSQL's $1, $2...
+func (r *SQLRepository) rebind(query string) string {
+	if r.backend != config.StorePostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// queryDocuments runs a query selecting id and data and decodes the data of
+// each row; setID, when given, copies the row ID into the document.
+func queryDocuments[T any](ctx context.Context, r *SQLRepository, setID func(*T, string), query string, args ...interface{}) ([]T, error) {
+	rows, err := r.db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []T
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		var document T
+		if err := json.Unmarshal([]byte(data), &document); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", id, err)
+		}
+		if setID != nil {
+			setID(&document, id)
+		}
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
+
+// newID returns an ID shaped like a Mongo ObjectID: 24 hex characters that
+// sort by creation time, so references look the same on every backend.
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+func limitOf(limit int64) int64 {
+	if limit <= 0 {
+		return defaultListLimit
+	}
+	return limit
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// escapeLike escapes the LIKE wildcards of a user-provided substring.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
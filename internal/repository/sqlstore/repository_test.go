@@ -0,0 +1,290 @@
+package sqlstore
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// newTestRepository opens a fresh SQLite database in a temporary directory,
+// the schema created twice to check it is idempotent.
+func newTestRepository(t *testing.T) *SQLRepository {
+	t.Helper()
+	ctx := context.Background()
+	dsn := "file:" + filepath.Join(t.TempDir(), "farmer.db")
+	r, err := NewSQLRepository(ctx, config.StoreConfig{Backend: config.StoreSQLite, DSN: dsn}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSQLRepository: %v", err)
+	}
+	t.Cleanup(func() { r.Close(ctx) })
+	for i := 0; i < 2; i++ {
+		if err := r.EnsureSchema(ctx); err != nil {
+			t.Fatalf("EnsureSchema: %v", err)
+		}
+	}
+	return r
+}
+
+func TestDailyReportsAreReplacedPerDate(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, eggs := range []int{100, 120} {
+		if err := r.SaveDailyReport(ctx, models.DailyReport{Date: day, EggsCollected: eggs}); err != nil {
+			t.Fatalf("SaveDailyReport: %v", err)
+		}
+	}
+	reports, err := r.GetDailyReports(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetDailyReports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].EggsCollected != 120 {
+		t.Errorf("reports = %+v, want only the regenerated one", reports)
+	}
+}
+
+func TestStockItemsFilterOnNameAndCondition(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	items := []models.StateStockRecord{
+		{Date: day, ItemName: "Mangeoire", Condition: "Bon"},
+		{Date: day.AddDate(0, 0, 1), ItemName: "Abreuvoir", Condition: "Bon"},
+		{Date: day.AddDate(0, 0, 2), ItemName: "Mangeoire_2", Condition: "Usé"},
+	}
+	var first string
+	for i, item := range items {
+		id, err := r.SaveStockItem(ctx, item)
+		if err != nil {
+			t.Fatalf("SaveStockItem: %v", err)
+		}
+		if i == 0 {
+			first = id
+		}
+	}
+
+	found, err := r.GetStockItems(ctx, models.StockQuery{ItemName: "mangeoire", Condition: "bon"})
+	if err != nil {
+		t.Fatalf("GetStockItems: %v", err)
+	}
+	if len(found) != 1 || found[0].ItemName != "Mangeoire" {
+		t.Errorf("found %+v, want the good feeder only", found)
+	}
+	// The underscore is matched literally, not as a LIKE wildcard.
+	found, err = r.GetStockItems(ctx, models.StockQuery{ItemName: "e_2"})
+	if err != nil || len(found) != 1 {
+		t.Errorf("found %+v (%v), want Mangeoire_2", found, err)
+	}
+
+	if err := r.DeleteStockItem(ctx, first); err != nil {
+		t.Fatalf("DeleteStockItem: %v", err)
+	}
+	found, _ = r.GetStockItems(ctx, models.StockQuery{})
+	if len(found) != 2 || found[0].ItemName != "Mangeoire_2" {
+		t.Errorf("found %+v, want the two others, newest first", found)
+	}
+}
+
+func TestLatestEggPriceIsTheLastEffective(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	if price, err := r.GetLatestEggPrice(ctx); err != nil || price != nil {
+		t.Fatalf("GetLatestEggPrice = %+v, %v, want none", price, err)
+	}
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for _, price := range []models.EggPriceRecord{
+		{EffectiveDate: day.AddDate(0, 0, 10), CreatedAt: day, PricePerTray: 45000},
+		{EffectiveDate: day, CreatedAt: day.AddDate(0, 0, 1), PricePerTray: 40000},
+	} {
+		if err := r.SaveEggPrice(ctx, price); err != nil {
+			t.Fatalf("SaveEggPrice: %v", err)
+		}
+	}
+	price, err := r.GetLatestEggPrice(ctx)
+	if err != nil || price == nil || price.PricePerTray != 45000 {
+		t.Errorf("GetLatestEggPrice = %+v, %v, want 45000", price, err)
+	}
+}
+
+func TestCommandAuditsFilterOnSenderAndTime(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	for i, sender := range []string{"224600000001", "224600000002", "224600000001"} {
+		entry := models.CommandAuditEntry{Sender: sender, Command: "eggs", CreatedAt: at.Add(time.Duration(i) * time.Hour)}
+		if err := r.SaveCommandAudit(ctx, entry); err != nil {
+			t.Fatalf("SaveCommandAudit: %v", err)
+		}
+	}
+	entries, err := r.ListCommandAudits(ctx, models.AuditQuery{Sender: "224600000001", From: at.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("ListCommandAudits: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].CreatedAt.Equal(at.Add(2*time.Hour)) {
+		t.Errorf("entries = %+v, want the 10:00 one", entries)
+	}
+	entries, _ = r.ListCommandAudits(ctx, models.AuditQuery{Limit: 2})
+	if len(entries) != 2 || entries[0].Sender != "224600000001" {
+		t.Errorf("entries = %+v, want the two newest", entries)
+	}
+}
+
+func TestMessageAuditsExpireAfterTheRetention(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	old := models.MessageAuditEntry{WaID: "224600000001", ReceivedAt: time.Now().Add(-2 * time.Hour)}
+	fresh := models.MessageAuditEntry{WaID: "224600000001", ReceivedAt: time.Now()}
+	for _, entry := range []models.MessageAuditEntry{old, fresh} {
+		if err := r.SaveMessageAudit(ctx, entry); err != nil {
+			t.Fatalf("SaveMessageAudit: %v", err)
+		}
+	}
+	entries, err := r.ListMessageAudits(ctx, models.MessageAuditQuery{WaID: "224600000001"})
+	if err != nil {
+		t.Fatalf("ListMessageAudits: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("entries = %+v, want only the fresh one", entries)
+	}
+}
+
+func TestCustomersAreKeyedByName(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	for _, phone := range []string{"", "224600000009"} {
+		customer := models.Customer{Name: "Binta", NameKey: models.CustomerKey("Binta"), Phone: phone}
+		if err := r.SaveCustomer(ctx, customer); err != nil {
+			t.Fatalf("SaveCustomer: %v", err)
+		}
+	}
+	customers, err := r.ListCustomers(ctx)
+	if err != nil {
+		t.Fatalf("ListCustomers: %v", err)
+	}
+	if len(customers) != 1 || customers[0].Phone != "224600000009" {
+		t.Errorf("customers = %+v, want Binta updated", customers)
+	}
+}
+
+func TestPendingWritesQueueInOrder(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	for i, sheetRange := range []string{"Production!A:F", "Sales!A:E", "Expenses!A:F"} {
+		write := models.PendingSheetWrite{SheetRange: sheetRange, Rows: [][]interface{}{{"15/10/2026"}}, CreatedAt: at.Add(time.Duration(i) * time.Minute)}
+		if err := r.EnqueuePendingWrite(ctx, write); err != nil {
+			t.Fatalf("EnqueuePendingWrite: %v", err)
+		}
+	}
+	writes, err := r.ListPendingWrites(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListPendingWrites: %v", err)
+	}
+	if len(writes) != 3 || writes[0].SheetRange != "Production!A:F" || writes[0].ID == "" {
+		t.Fatalf("writes = %+v, want the three, oldest first, with their IDs", writes)
+	}
+
+	if err := r.DeletePendingWrite(ctx, writes[0].ID); err != nil {
+		t.Fatalf("DeletePendingWrite: %v", err)
+	}
+	if err := r.FailPendingWrite(ctx, writes[1].ID, "400 invalid range"); err != nil {
+		t.Fatalf("FailPendingWrite: %v", err)
+	}
+	if err := r.MarkPendingWrite(ctx, writes[2].ID, models.PendingWriteFailed); err != nil {
+		t.Fatalf("MarkPendingWrite: %v", err)
+	}
+	if err := r.MarkPendingWrite(ctx, "unknown", models.PendingWriteQueued); err != nil {
+		t.Errorf("MarkPendingWrite of an unknown write: %v", err)
+	}
+	if writes, _ := r.ListPendingWrites(ctx, 10); len(writes) != 0 {
+		t.Errorf("writes = %+v, want none left to replay", writes)
+	}
+
+	var export bytes.Buffer
+	if _, err := r.ExportCollection(ctx, tablePending, &export); err != nil {
+		t.Fatalf("ExportCollection: %v", err)
+	}
+	if !strings.Contains(export.String(), `"error":"400 invalid range"`) {
+		t.Errorf("export = %s, want the failure kept for recovery", export.String())
+	}
+}
+
+func TestJobRunsAndFlagsAreReplaced(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	for _, run := range []models.JobRun{{Name: "daily_report", LastSuccess: at}, {Name: "daily_report", LastSuccess: at.Add(time.Hour)}} {
+		if err := r.SaveJobRun(ctx, run); err != nil {
+			t.Fatalf("SaveJobRun: %v", err)
+		}
+	}
+	runs, err := r.ListJobRuns(ctx)
+	if err != nil || len(runs) != 1 || !runs[0].LastSuccess.Equal(at.Add(time.Hour)) {
+		t.Errorf("ListJobRuns = %+v, %v, want the last success", runs, err)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		if err := r.SaveFeatureFlag(ctx, models.FeatureFlag{Name: "ai", Enabled: enabled}); err != nil {
+			t.Fatalf("SaveFeatureFlag: %v", err)
+		}
+	}
+	flags, err := r.ListFeatureFlags(ctx)
+	if err != nil || len(flags) != 1 || flags[0].Enabled {
+		t.Errorf("ListFeatureFlags = %+v, %v, want ai off", flags, err)
+	}
+
+	for _, name := range []string{"daily_report", "reconcile"} {
+		if err := r.SaveJobExecution(ctx, models.JobExecution{Name: name, StartedAt: at}); err != nil {
+			t.Fatalf("SaveJobExecution: %v", err)
+		}
+	}
+	executions, err := r.ListJobExecutions(ctx, models.JobExecutionQuery{Name: "reconcile"})
+	if err != nil || len(executions) != 1 {
+		t.Errorf("ListJobExecutions = %+v, %v, want the reconcile run", executions, err)
+	}
+}
+
+func TestLeasesGoToOneHolderUntilTheyExpire(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	acquire := func(holder string, ttl time.Duration) bool {
+		t.Helper()
+		ok, err := r.AcquireLease(ctx, "scheduler", holder, ttl)
+		if err != nil {
+			t.Fatalf("AcquireLease: %v", err)
+		}
+		return ok
+	}
+	if !acquire("a", time.Minute) || !acquire("a", time.Minute) {
+		t.Fatal("a could not take and renew the free lease")
+	}
+	if acquire("b", time.Minute) {
+		t.Fatal("b took the lease a holds")
+	}
+	if err := r.ReleaseLease(ctx, "scheduler", "b"); err != nil {
+		t.Fatalf("ReleaseLease: %v", err)
+	}
+	if acquire("b", time.Minute) {
+		t.Fatal("b released the lease a holds")
+	}
+	if !acquire("a", -time.Second) || !acquire("b", time.Minute) {
+		t.Error("b could not take the expired lease")
+	}
+}
@@ -0,0 +1,142 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// deliveryTimeout bounds a job's sends, retries included.
+const deliveryTimeout = 10 * time.Minute
+
+// deliveryLogKey carries the deliveryLog of a run in its context.
+type deliveryLogKey struct{}
+
+// deliveryLog collects the deliveries of a run for its JobExecution.
+type deliveryLog struct {
+	mu         sync.Mutex
+	deliveries []models.Delivery
+}
+
+// withDeliveryLog returns a context recording the deliveries made with it
+// in the returned log.
+func withDeliveryLog(ctx context.Context) (context.Context, *deliveryLog) {
+	log := &deliveryLog{}
+	return context.WithValue(ctx, deliveryLogKey{}, log), log
+}
+
+// record adds delivery to the log of ctx, if any.
+func record(ctx context.Context, delivery models.Delivery) {
+	log, ok := ctx.Value(deliveryLogKey{}).(*deliveryLog)
+	if !ok {
+		return
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.deliveries = append(log.deliveries, delivery)
+}
+
+// list returns the deliveries recorded so far.
+func (l *deliveryLog) list() []models.Delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.deliveries)
+}
+
+// broadcast sends message to every recipient, retrying failed sends, and
+// returns how many received it. The fallback recipients get a copy of a
+// message some recipients never received. With FARM_NAME, or several farms,
+// the message starts with the farm's name.
+func (s *Scheduler) broadcast(ctx context.Context, recipients []string, message string) int {
+	if name := s.cfg.Load().Farm.Name; name != "" {
+		message = "🏡 " + name + "\n" + message
+	}
+	sent := 0
+	var failed []string
+	for _, recipient := range recipients {
+		req := models.OutboundMessageRequest{To: recipient, Message: message}
+		err := s.deliver(ctx, models.Delivery{To: recipient}, func(ctx context.Context) error { return s.messagingSvc.SendOutbound(ctx, req) })
+		if err != nil {
+			s.logger.Error("failed to send scheduled message", zap.String("to", recipient), zap.Error(err))
+			failed = append(failed, recipient)
+			continue
+		}
+		sent++
+	}
+	if len(failed) > 0 {
+		s.fallback(ctx, failed, message)
+	}
+	return sent
+}
+
+// deliver calls send until it succeeds, retrying up to SendRetries times
+// with a delay starting at SendRetryDelay and doubling after each attempt,
+// then records the outcome as delivery. It gives up early when ctx is done.
+func (s *Scheduler) deliver(ctx context.Context, delivery models.Delivery, send func(ctx context.Context) error) error {
+	err := s.attempt(ctx, &delivery, send)
+	delivery.Success = err == nil
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	record(ctx, delivery)
+	return err
+}
+
+// attempt runs the retry loop of deliver, counting the attempts in delivery.
+func (s *Scheduler) attempt(ctx context.Context, delivery *models.Delivery, send func(ctx context.Context) error) error {
+	reporting := s.cfg.Load().Reporting
+	delay := reporting.SendRetryDelay
+	for {
+		err := send(ctx)
+		delivery.Attempts++
+		if err == nil || delivery.Attempts > reporting.SendRetries {
+			return err
+		}
+		s.logger.Warn("scheduled message not delivered, retrying",
+			zap.String("to", delivery.To),
+			zap.Int("attempt", delivery.Attempts),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// fallback sends the fallback recipients, except those that just failed, the
+// message the failed recipients did not receive. It is not retried.
+func (s *Scheduler) fallback(ctx context.Context, failed []string, message string) {
+	text := fmt.Sprintf("⚠️ Message non délivré à %s :\n\n%s", strings.Join(failed, ", "), message)
+	for _, recipient := range s.cfg.Load().Reporting.FallbackRecipients {
+		if slices.Contains(failed, recipient) {
+			continue
+		}
+		req := models.OutboundMessageRequest{To: recipient, Message: text}
+		delivery := models.Delivery{To: recipient, Attempts: 1, Fallback: true}
+		err := s.messagingSvc.SendOutbound(ctx, req)
+		delivery.Success = err == nil
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		record(ctx, delivery)
+		if err != nil {
+			s.logger.Error("failed to send fallback copy", zap.String("to", recipient), zap.Error(err))
+			continue
+		}
+		s.logger.Info("undelivered message sent to fallback recipient", zap.String("to", recipient), zap.Strings("failed", failed))
+	}
+}
@@ -0,0 +1,372 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var (
+	// ErrJobNotFound reports a name matching no job of the registry.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobUnavailable reports a job whose action's service is disabled,
+	// e.g. an archive job with ARCHIVE_AFTER_MONTHS=0.
+	ErrJobUnavailable = errors.New("job action is disabled")
+	// ErrJobRunning reports a job whose previous run has not finished.
+	ErrJobRunning = errors.New("job is already running")
+	// ErrStopped reports a run requested after the scheduler was stopped.
+	ErrStopped = errors.New("scheduler is stopped")
+)
+
+// JobStatus describes a job of the registry for the admin endpoints.
+type JobStatus struct {
+	Name       string   `json:"name"`
+	Schedule   string   `json:"schedule"`
+	Action     string   `json:"action"`
+	Recipients []string `json:"recipients,omitempty"`
+	// Available is false when the service behind the action is disabled.
+	Available bool `json:"available"`
+	// Enabled is whether the job runs on its schedule.
+	Enabled bool       `json:"enabled"`
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// job is a registry entry and its runtime state.
+type job struct {
+	cfg config.JobConfig
+	// run is nil when the action's service is disabled. Its deliveries are
+	// recorded through ctx.
+	run func(ctx context.Context, at time.Time) error
+	// entryID is the cron entry of a scheduled job, 0 otherwise.
+	entryID cron.EntryID
+	// disabled is set when the job was taken off the schedule through the
+	// admin, as opposed to a schedule that failed to parse.
+	disabled bool
+	// running is held for the duration of a run, scheduled or requested. It
+	// is shared with the job replacing this one on Reload.
+	running *sync.Mutex
+}
+
+// schedule adds j to the cron. Callers hold s.mu.
+func (s *Scheduler) schedule(j *job) error {
+	id, err := s.cron.AddFunc(j.cfg.Schedule, func() {
+		if !s.leads() {
+			s.logger.Debug("not the scheduler leader, run skipped", zap.String("job", j.cfg.Name))
+			return
+		}
+		if !s.scheduling() {
+			s.logger.Info("scheduler feature off, run skipped", zap.String("job", j.cfg.Name))
+			return
+		}
+		if !j.running.TryLock() {
+			s.logger.Warn("previous run still going, run skipped", zap.String("job", j.cfg.Name))
+			return
+		}
+		defer j.running.Unlock()
+		s.exec(j, time.Now().In(s.location), models.JobTriggerSchedule)
+	})
+	if err != nil {
+		return err
+	}
+	j.entryID = id
+	return nil
+}
+
+// Jobs returns the status of every job of the registry, in registry order.
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, s.status(j))
+	}
+	return statuses
+}
+
+// RunJob starts the job name in the background, whether it is enabled or
+// not and whether this instance leads or not, e.g. to send again a report
+// that failed.
+func (s *Scheduler) RunJob(name string) error {
+	return s.RunJobAt(name, time.Now())
+}
+
+// RunJobAt is RunJob for a run due at at rather than now, e.g. to send the
+// daily report of a past day.
+func (s *Scheduler) RunJobAt(name string, at time.Time) error {
+	j, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	if !j.running.TryLock() {
+		return ErrJobRunning
+	}
+	if !s.begin() {
+		j.running.Unlock()
+		return ErrStopped
+	}
+
+	s.logger.Info("job run requested", zap.String("job", name), zap.Time("for", at))
+	go func() {
+		defer s.inflight.Done()
+		defer j.running.Unlock()
+		s.exec(j, at.In(s.location), models.JobTriggerManual)
+	}()
+	return nil
+}
+
+// exec runs j for the time it was due at, records the execution with the
+// deliveries it made and, on success, the last run; a failure is sent to the
+// alert recipients. Callers hold j.running.
+func (s *Scheduler) exec(j *job, at time.Time, trigger string) {
+	started := time.Now()
+	runCtx, deliveries := withDeliveryLog(context.Background())
+	runErr := j.run(runCtx, at)
+	execution := models.JobExecution{
+		Name:       j.cfg.Name,
+		Trigger:    trigger,
+		DueAt:      at.UTC(),
+		StartedAt:  started.UTC(),
+		DurationMs: time.Since(started).Milliseconds(),
+		Success:    runErr == nil,
+		Deliveries: deliveries.list(),
+	}
+	if runErr != nil {
+		execution.Error = runErr.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	if runErr != nil {
+		s.alert(ctx, execution)
+	}
+	if s.runs == nil {
+		return
+	}
+	if err := s.runs.SaveJobExecution(ctx, execution); err != nil {
+		s.logger.Warn("failed to record job execution", zap.String("job", j.cfg.Name), zap.Error(err))
+	}
+	if runErr != nil {
+		return
+	}
+	// A report sent again for a past day must not move the last success back
+	// before the runs since, which the next catch-up would then send again.
+	if last, err := s.lastSuccess(ctx, j.cfg.Name); err != nil {
+		s.logger.Warn("failed to load job runs, last run not recorded", zap.String("job", j.cfg.Name), zap.Error(err))
+		return
+	} else if !at.After(last) {
+		return
+	}
+	if err := s.runs.SaveJobRun(ctx, models.JobRun{Name: j.cfg.Name, LastSuccess: at.UTC()}); err != nil {
+		s.logger.Warn("failed to record job run", zap.String("job", j.cfg.Name), zap.Error(err))
+	}
+}
+
+// lastSuccess returns the time the last successful run of the job name was
+// due at, zero when it never succeeded.
+func (s *Scheduler) lastSuccess(ctx context.Context, name string) (time.Time, error) {
+	runs, err := s.runs.ListJobRuns(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, run := range runs {
+		if run.Name == name {
+			return run.LastSuccess, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// alert tells the alert recipients that a job run failed, so an expired
+// token or a revoked sheet permission does not go unnoticed for days. A
+// failure of WhatsApp itself can only be logged.
+func (s *Scheduler) alert(ctx context.Context, execution models.JobExecution) {
+	message := fmt.Sprintf("🚨 Échec de la tâche « %s » (%s, prévue le %s) : %s",
+		execution.Name, execution.Trigger, execution.DueAt.In(s.location).Format("02/01/2006 15:04"), execution.Error)
+	if s.broadcast(ctx, s.cfg.Load().Reporting.AlertRecipients, message) == 0 {
+		s.logger.Error("job failure alert not delivered", zap.String("job", execution.Name), zap.String("error", execution.Error))
+	}
+}
+
+// CatchUp runs once, for the time it was due, every enabled job that missed
+// a run since its last success and within the catch-up window. A job that
+// never succeeded is not caught up: there is no telling whether it was
+// missed. Start calls it, and so does the elector when this instance takes
+// the lease over. Nothing is caught up while the scheduler feature is off,
+// nor once Stop was called.
+func (s *Scheduler) CatchUp() {
+	if !s.begin() {
+		return
+	}
+	defer s.inflight.Done()
+
+	window := s.cfg.Load().Reporting.CatchUpWindow
+	if s.runs == nil || window <= 0 || !s.scheduling() {
+		return
+	}
+	now := time.Now().In(s.location)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	runs, err := s.runs.ListJobRuns(ctx)
+	cancel()
+	if err != nil {
+		s.logger.Error("failed to load job runs, missed runs not caught up", zap.Error(err))
+		return
+	}
+	lastSuccess := make(map[string]time.Time, len(runs))
+	for _, run := range runs {
+		lastSuccess[run.Name] = run.LastSuccess
+	}
+
+	windowStart := now.Add(-window)
+	for _, j := range s.registry() {
+		s.mu.Lock()
+		enabled := j.entryID != 0
+		s.mu.Unlock()
+		last, ok := lastSuccess[j.cfg.Name]
+		if !enabled || !ok {
+			continue
+		}
+		schedule, err := cron.ParseStandard(j.cfg.Schedule)
+		if err != nil {
+			continue
+		}
+		if last.Before(windowStart) {
+			last = windowStart
+		}
+		missed, ok := lastDue(schedule, last.In(s.location), now)
+		if !ok {
+			continue
+		}
+		if !j.running.TryLock() {
+			continue
+		}
+		s.logger.Info("catching up missed run", zap.String("job", j.cfg.Name), zap.Time("due", missed))
+		s.exec(j, missed, models.JobTriggerCatchUp)
+		j.running.Unlock()
+	}
+}
+
+// lastDue returns the last time schedule was due after after and up to now.
+func lastDue(schedule cron.Schedule, after, now time.Time) (time.Time, bool) {
+	var due time.Time
+	for next := schedule.Next(after); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		due = next
+	}
+	return due, !due.IsZero()
+}
+
+// SetJobEnabled adds the job name to the schedule or removes it. The change
+// lasts until the next restart, which schedules the registry again.
+func (s *Scheduler) SetJobEnabled(name string, enabled bool) (JobStatus, error) {
+	j, err := s.find(name)
+	if err != nil {
+		return JobStatus{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case enabled && j.entryID == 0:
+		if err := s.schedule(j); err != nil {
+			return JobStatus{}, err
+		}
+	case !enabled && j.entryID != 0:
+		s.cron.Remove(j.entryID)
+		j.entryID = 0
+	}
+	j.disabled = !enabled
+	s.logger.Info("job schedule changed", zap.String("job", name), zap.Bool("enabled", enabled))
+	return s.status(j), nil
+}
+
+// Reload applies cfg without restarting: the settings read at run time
+// (recipients, retries, thresholds) and the registry. A job keeps its state
+// by name: one disabled through the admin stays disabled and a run in
+// progress finishes with the old settings before the new schedule applies.
+// Added jobs are scheduled, removed ones dropped. The timezone and the
+// services behind the actions are only read at startup.
+func (s *Scheduler) Reload(cfg config.Config) {
+	s.cfg.Store(&cfg)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := make(map[string]*job, len(s.jobs))
+	for _, j := range s.jobs {
+		previous[j.cfg.Name] = j
+	}
+
+	jobs := make([]*job, 0, len(cfg.Jobs))
+	for _, jobCfg := range cfg.Jobs {
+		j := &job{cfg: jobCfg, run: s.action(jobCfg), running: &sync.Mutex{}}
+		if old, ok := previous[jobCfg.Name]; ok {
+			j.running = old.running
+			j.disabled = old.disabled
+			if old.entryID != 0 {
+				s.cron.Remove(old.entryID)
+			}
+			delete(previous, jobCfg.Name)
+		}
+		jobs = append(jobs, j)
+		if j.disabled || j.run == nil {
+			continue
+		}
+		if err := s.schedule(j); err != nil {
+			s.logger.Error("failed to schedule job", zap.String("job", j.cfg.Name), zap.String("schedule", j.cfg.Schedule), zap.Error(err))
+		}
+	}
+	for _, old := range previous {
+		if old.entryID != 0 {
+			s.cron.Remove(old.entryID)
+		}
+	}
+	s.jobs = jobs
+	s.logger.Info("scheduler reloaded", zap.Int("jobs", len(jobs)))
+}
+
+// registry returns the current jobs; Reload replaces them.
+func (s *Scheduler) registry() []*job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs
+}
+
+// find returns the runnable job name.
+func (s *Scheduler) find(name string) (*job, error) {
+	for _, j := range s.registry() {
+		if j.cfg.Name != name {
+			continue
+		}
+		if j.run == nil {
+			return nil, ErrJobUnavailable
+		}
+		return j, nil
+	}
+	return nil, ErrJobNotFound
+}
+
+// status describes j. Callers hold s.mu.
+func (s *Scheduler) status(j *job) JobStatus {
+	status := JobStatus{
+		Name:       j.cfg.Name,
+		Schedule:   j.cfg.Schedule,
+		Action:     j.cfg.Action,
+		Recipients: j.cfg.Recipients,
+		Available:  j.run != nil,
+		Enabled:    j.entryID != 0,
+	}
+	if status.Enabled {
+		if next := s.cron.Entry(j.entryID).Next; !next.IsZero() {
+			status.NextRun = &next
+		}
+	}
+	return status
+}
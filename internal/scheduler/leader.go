@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// leaseName is the lease the replicas compete for.
+const leaseName = "scheduler"
+
+// Leader tells whether this instance runs the scheduled jobs.
+type Leader interface {
+	IsLeader() bool
+}
+
+// LeaseStore keeps the leases; see mongodb.Repository.
+type LeaseStore interface {
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// Elector holds the scheduler lease in the shared store, so that among the
+// replicas of a deployment a single one runs the jobs. The lease is renewed
+// every third of its TTL; when the holder stops renewing, another replica
+// takes it over once it expires.
+type Elector struct {
+	store  LeaseStore
+	holder string
+	ttl    time.Duration
+	leader atomic.Bool
+	logger *zap.Logger
+}
+
+var _ Leader = (*Elector)(nil)
+
+// NewElector builds an elector competing as holder for a lease lasting ttl.
+func NewElector(store LeaseStore, holder string, ttl time.Duration, logger *zap.Logger) *Elector {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Elector{store: store, holder: holder, ttl: ttl, logger: logger}
+}
+
+// IsLeader reports whether this instance held the lease at the last attempt.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Acquire tries once to take or renew the lease and reports whether this
+// instance just became the leader. A store error gives the leadership up, as
+// the lease may expire meanwhile.
+func (e *Elector) Acquire(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	held, err := e.store.AcquireLease(ctx, leaseName, e.holder, e.ttl)
+	if err != nil {
+		e.logger.Warn("failed to renew scheduler lease", zap.String("holder", e.holder), zap.Error(err))
+		held = false
+	}
+	was := e.leader.Swap(held)
+	switch {
+	case held && !was:
+		e.logger.Info("scheduler lease acquired, running the jobs", zap.String("holder", e.holder))
+	case !held && was:
+		e.logger.Warn("scheduler lease lost, jobs left to another instance", zap.String("holder", e.holder))
+	}
+	return held && !was
+}
+
+// Run renews the lease until ctx is done, calling onElected each time this
+// instance becomes the leader, then releases the lease so another replica
+// takes over without waiting for it to expire.
+func (e *Elector) Run(ctx context.Context, onElected func()) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if e.leader.Swap(false) {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := e.store.ReleaseLease(releaseCtx, leaseName, e.holder); err != nil {
+					e.logger.Warn("failed to release scheduler lease", zap.Error(err))
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+			if e.Acquire(ctx) && onElected != nil {
+				onElected()
+			}
+		}
+	}
+}
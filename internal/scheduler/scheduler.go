@@ -2,6 +2,11 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -9,78 +14,428 @@ import (
 
 	"github.com/mamadbah2/farmer/internal/config"
 	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/service/backup"
+	"github.com/mamadbah2/farmer/internal/service/reconcile"
 	"github.com/mamadbah2/farmer/internal/service/reporting"
 	"github.com/mamadbah2/farmer/internal/service/whatsapp"
 )
 
+// Archiver moves old sheet rows to archive tabs; see internal/service/archive.
+type Archiver interface {
+	Run(ctx context.Context) (int, error)
+}
+
+// Reconciler compares recent Sheets rows with their Mongo copies; see
+// internal/service/reconcile.
+type Reconciler interface {
+	Run(ctx context.Context) (reconcile.Report, error)
+}
+
+// Backuper exports the Mongo collections; see internal/service/backup.
+type Backuper interface {
+	Run(ctx context.Context) (backup.Result, error)
+}
+
+// Reminder chases the missing daily entries of a day and announces the
+// next day's vaccinations; see internal/service/reminder.
+type Reminder interface {
+	Remind(ctx context.Context, day time.Time, workers []string) error
+	Escalate(ctx context.Context, day time.Time, owners []string) error
+	RemindVaccinations(ctx context.Context, day time.Time, workers []string) error
+}
+
+// RunStore keeps the history and the last successful run of every job; see
+// mongodb.Repository.
+type RunStore interface {
+	SaveJobRun(ctx context.Context, run models.JobRun) error
+	ListJobRuns(ctx context.Context) ([]models.JobRun, error)
+	SaveJobExecution(ctx context.Context, execution models.JobExecution) error
+}
+
+// Flags tells whether a feature is on; see internal/service/flags.
+type Flags interface {
+	Enabled(feature models.Feature, user string) bool
+}
+
 // Scheduler manages scheduled tasks.
 type Scheduler struct {
 	cron         *cron.Cron
-	reportingSvc *reporting.Service
+	reportingSvc reporting.Provider
 	messagingSvc whatsapp.MessagingService
-	cfg          config.Config
-	logger       *zap.Logger
+	archiver     Archiver
+	reconciler   Reconciler
+	backuper     Backuper
+	reminder     Reminder
+	runs         RunStore
+	leader       Leader
+	flags        Flags
+	location     *time.Location
+	// cfg is replaced as a whole by Reload.
+	cfg    atomic.Pointer[config.Config]
+	logger *zap.Logger
+
+	// mu guards the scheduling state of jobs and stopped.
+	mu      sync.Mutex
+	jobs    []*job
+	stopped bool
+	// inflight counts the requested runs and catch-ups in progress; the
+	// cron tracks the scheduled ones.
+	inflight sync.WaitGroup
 }
 
-// NewScheduler creates a new scheduler instance.
-func NewScheduler(cfg config.Config, reportingSvc *reporting.Service, messagingSvc whatsapp.MessagingService, logger *zap.Logger) *Scheduler {
+// NewScheduler creates a new scheduler running the jobs of cfg.Jobs. A nil
+// archiver, reconciler, backuper or reminder disables the jobs with the
+// matching action; a nil runs disables the run history and the catch-up of
+// missed runs. With a
+// leader, scheduled runs only happen while this instance is the leader; nil
+// runs them unconditionally. With flags, scheduled runs and catch-ups only
+// happen while the scheduler feature is on; runs requested through RunJob
+// always happen. Every cron expression (standard 5 fields) is read in
+// location, the farm's timezone (UTC when nil), and jobs run for times in it.
+func NewScheduler(cfg config.Config, reportingSvc reporting.Provider, messagingSvc whatsapp.MessagingService, archiver Archiver, reconciler Reconciler, backuper Backuper, reminder Reminder, runs RunStore, leader Leader, flags Flags, location *time.Location, logger *zap.Logger) *Scheduler {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
+	if location == nil {
+		location = time.UTC
+	}
+	c := cron.New(cron.WithLocation(location))
 
-	// Create a cron instance with a custom location if needed, or use default (Local)
-	// Here we use the standard parser which supports seconds if configured, but standard cron is minute-based.
-	// robfig/cron/v3 default parser is standard cron (5 fields: min, hour, dom, month, dow).
-	c := cron.New()
-
-	return &Scheduler{
+	s := &Scheduler{
 		cron:         c,
 		reportingSvc: reportingSvc,
 		messagingSvc: messagingSvc,
-		cfg:          cfg,
+		archiver:     archiver,
+		reconciler:   reconciler,
+		backuper:     backuper,
+		reminder:     reminder,
+		runs:         runs,
+		leader:       leader,
+		flags:        flags,
+		location:     location,
 		logger:       logger,
 	}
+	s.cfg.Store(&cfg)
+	for _, jobCfg := range cfg.Jobs {
+		s.jobs = append(s.jobs, &job{cfg: jobCfg, run: s.action(jobCfg), running: &sync.Mutex{}})
+	}
+	return s
 }
 
-// Start starts the scheduler.
+// Start schedules every job of the registry and starts the scheduler. A job
+// whose action is disabled or whose schedule does not parse is logged and
+// skipped. Runs missed while the server was down are then caught up in the
+// background if this instance leads.
 func (s *Scheduler) Start() {
-	s.logger.Info("starting scheduler")
+	s.logger.Info("starting scheduler", zap.Int("jobs", len(s.jobs)))
 
-	// Schedule weekly report for Friday at 20:00
-	// Cron expression: "0 20 * * 5" (At 20:00 on Friday)
-	_, err := s.cron.AddFunc("0 20 * * 5", s.sendWeeklyReport)
-	if err != nil {
-		s.logger.Error("failed to schedule weekly report", zap.Error(err))
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if j.run == nil {
+			s.logger.Warn("job action disabled, not scheduled", zap.String("job", j.cfg.Name), zap.String("action", j.cfg.Action))
+			continue
+		}
+		if err := s.schedule(j); err != nil {
+			s.logger.Error("failed to schedule job", zap.String("job", j.cfg.Name), zap.String("schedule", j.cfg.Schedule), zap.Error(err))
+		}
 	}
+	s.mu.Unlock()
 
 	s.cron.Start()
+
+	if s.leads() {
+		go s.CatchUp()
+	}
+}
+
+// begin counts a requested run or a catch-up as in progress, for Stop to
+// wait for; it returns false once Stop was called.
+func (s *Scheduler) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return false
+	}
+	s.inflight.Add(1)
+	return true
+}
+
+// leads reports whether this instance runs the scheduled jobs.
+func (s *Scheduler) leads() bool {
+	return s.leader == nil || s.leader.IsLeader()
+}
+
+// scheduling reports whether the scheduler feature is on.
+func (s *Scheduler) scheduling() bool {
+	return s.flags == nil || s.flags.Enabled(models.FeatureScheduler, "")
 }
 
-// Stop stops the scheduler.
-func (s *Scheduler) Stop() {
+// Stop stops scheduling runs and waits until the runs in progress,
+// scheduled, requested or caught up, are over or ctx is done. A report being
+// sent is thus not cut halfway by the store closing behind it.
+func (s *Scheduler) Stop(ctx context.Context) error {
 	s.logger.Info("stopping scheduler")
-	s.cron.Stop()
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	scheduled := s.cron.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		<-scheduled.Done()
+		s.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("job runs still in progress: %w", ctx.Err())
+	}
+}
+
+// action returns the function running job for the time it was due at, or
+// nil when the service behind its action is disabled.
+func (s *Scheduler) action(job config.JobConfig) func(ctx context.Context, at time.Time) error {
+	switch job.Action {
+	case config.JobDailyReport:
+		return func(ctx context.Context, at time.Time) error {
+			return s.sendReport(ctx, job, at, s.reportingSvc.GenerateDailyReport)
+		}
+	case config.JobWeeklyReport:
+		return func(ctx context.Context, at time.Time) error {
+			return s.sendReport(ctx, job, at, s.reportingSvc.GenerateWeeklyReport)
+		}
+	case config.JobMonthlyReport:
+		return func(ctx context.Context, at time.Time) error { return s.sendMonthlyReport(ctx, job, at) }
+	case config.JobFeedAlert:
+		return func(ctx context.Context, at time.Time) error { return s.alertLowFeed(ctx, at, job.Recipients) }
+	case config.JobDebtReminder:
+		return func(ctx context.Context, at time.Time) error { return s.remindDebts(ctx, at, job.Recipients) }
+	case config.JobArchive:
+		if s.archiver != nil {
+			return func(ctx context.Context, _ time.Time) error { return s.archiveRows(ctx) }
+		}
+	case config.JobReconcile:
+		if s.reconciler != nil {
+			return func(ctx context.Context, _ time.Time) error { return s.reconcileStores(ctx, job.Recipients) }
+		}
+	case config.JobBackup:
+		if s.backuper != nil {
+			return func(ctx context.Context, _ time.Time) error { return s.backupMongo(ctx) }
+		}
+	case config.JobRemind:
+		if s.reminder != nil {
+			return func(ctx context.Context, at time.Time) error { return s.remindMissingEntries(ctx, at, job.Recipients) }
+		}
+	case config.JobEscalate:
+		if s.reminder != nil {
+			return func(ctx context.Context, at time.Time) error {
+				return s.escalateMissingEntries(ctx, at, job.Recipients)
+			}
+		}
+	case config.JobVaccinationReminder:
+		if s.reminder != nil {
+			return func(ctx context.Context, at time.Time) error { return s.remindVaccinations(ctx, at, job.Recipients) }
+		}
+	}
+	return nil
 }
 
-func (s *Scheduler) sendWeeklyReport() {
-	s.logger.Info("generating weekly report")
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+// sendReport broadcasts the report generate builds for at to the job's
+// recipients; a failed send does not stop the others. It fails when no
+// recipient received the report.
+func (s *Scheduler) sendReport(ctx context.Context, job config.JobConfig, at time.Time, generate func(ctx context.Context, now time.Time) (string, error)) error {
+	s.logger.Info("generating report", zap.String("job", job.Name), zap.Time("for", at))
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
 	defer cancel()
 
-	report, err := s.reportingSvc.GenerateWeeklyReport(ctx, time.Now())
+	report, err := generate(ctx, at)
 	if err != nil {
-		s.logger.Error("failed to generate weekly report", zap.Error(err))
-		return
+		s.logger.Error("failed to generate report", zap.String("job", job.Name), zap.Error(err))
+		return err
+	}
+	sent := s.broadcast(ctx, job.Recipients, report)
+	s.logger.Info("report sent", zap.String("job", job.Name), zap.Int("recipients", sent))
+	if sent == 0 {
+		return errors.New("no recipient received the report")
 	}
+	return nil
+}
+
+// sendMonthlyReport sends the job's recipients the report of the month of
+// the day before at, so a job running on the 1st sends the month just ended:
+// the PDF, then the summary text. A recipient the PDF could not reach still
+// gets the text, but the run fails so the alert recipients hear of it.
+func (s *Scheduler) sendMonthlyReport(ctx context.Context, job config.JobConfig, at time.Time) error {
+	month := at.AddDate(0, 0, -1)
+	s.logger.Info("generating monthly report", zap.String("job", job.Name), zap.Time("for", month))
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
 
-	req := models.OutboundMessageRequest{
-		To:      s.cfg.WhatsApp.ExpenseManagerID,
-		Message: report,
+	summary, err := s.reportingSvc.GenerateMonthlyReport(ctx, month)
+	if err != nil {
+		s.logger.Error("failed to generate report", zap.String("job", job.Name), zap.Error(err))
+		return err
+	}
+	var errs []error
+	document, err := s.reportingSvc.GenerateMonthlyReportPDF(ctx, month)
+	if err != nil {
+		s.logger.Error("failed to render monthly report pdf", zap.String("job", job.Name), zap.Error(err))
+		errs = append(errs, fmt.Errorf("failed to render pdf: %w", err))
+	}
+
+	if document != nil {
+		filename := fmt.Sprintf("rapport-%s.pdf", month.Format("2006-01"))
+		for _, recipient := range job.Recipients {
+			req := models.DocumentMessageRequest{
+				To:       recipient,
+				Filename: filename,
+				MimeType: "application/pdf",
+				Data:     document,
+				Caption:  strings.TrimSpace(fmt.Sprintf("Rapport mensuel %s %s", month.Format("01/2006"), s.cfg.Load().Farm.Name)),
+			}
+			err := s.deliver(ctx, models.Delivery{To: recipient, Document: true}, func(ctx context.Context) error { return s.messagingSvc.SendDocument(ctx, req) })
+			if err != nil {
+				s.logger.Error("failed to send monthly report pdf", zap.String("to", recipient), zap.Error(err))
+				errs = append(errs, fmt.Errorf("failed to send pdf to %s: %w", recipient, err))
+			}
+		}
+	}
+	sent := s.broadcast(ctx, job.Recipients, summary)
+	s.logger.Info("report sent", zap.String("job", job.Name), zap.Int("recipients", sent))
+	if sent == 0 {
+		errs = append(errs, errors.New("no recipient received the report"))
 	}
+	return errors.Join(errs...)
+}
+
+// alertLowFeed tells the recipients when the feed left runs under
+// FEED_ALERT_DAYS of consumption; a sufficient stock is only logged.
+func (s *Scheduler) alertLowFeed(ctx context.Context, at time.Time, recipients []string) error {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	alert, err := s.reportingSvc.FeedStockAlert(ctx, at, s.cfg.Load().FeedAlert.Days)
+	if err != nil {
+		s.logger.Error("feed stock check failed", zap.Error(err))
+		return err
+	}
+	if alert == "" {
+		s.logger.Info("feed stock sufficient", zap.Int("min_days", s.cfg.Load().FeedAlert.Days))
+		return nil
+	}
+	if s.broadcast(ctx, recipients, alert) == 0 {
+		return errors.New("no recipient received the feed alert")
+	}
+	return nil
+}
+
+// remindDebts sends the recipients the clients owing money for
+// DEBT_REMINDER_DAYS or more; nothing is sent when none is overdue.
+func (s *Scheduler) remindDebts(ctx context.Context, at time.Time, recipients []string) error {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	message, err := s.reportingSvc.OverdueDebts(ctx, at, s.cfg.Load().Debts.MinAgeDays)
+	if err != nil {
+		s.logger.Error("overdue debts check failed", zap.Error(err))
+		return err
+	}
+	if message == "" {
+		s.logger.Info("no overdue debt", zap.Int("min_days", s.cfg.Load().Debts.MinAgeDays))
+		return nil
+	}
+	if s.broadcast(ctx, recipients, message) == 0 {
+		return errors.New("no recipient received the debt reminder")
+	}
+	return nil
+}
+
+func (s *Scheduler) archiveRows(ctx context.Context) error {
+	s.logger.Info("archiving old sheet rows")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	moved, err := s.archiver.Run(ctx)
+	if err != nil {
+		s.logger.Error("sheet archival failed", zap.Int("rows_moved", moved), zap.Error(err))
+		return err
+	}
+	s.logger.Info("sheet archival done", zap.Int("rows_moved", moved))
+	return nil
+}
+
+// reconcileStores compares Sheets with Mongo and tells the recipients when
+// they disagree; consistent runs are only logged.
+func (s *Scheduler) reconcileStores(ctx context.Context, recipients []string) error {
+	s.logger.Info("reconciling sheets and mongodb")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	report, err := s.reconciler.Run(ctx)
+	if err != nil {
+		s.logger.Error("sheets/mongo reconciliation failed", zap.Error(err))
+	}
+	if report.Consistent() {
+		if err == nil {
+			s.logger.Info("sheets and mongodb are consistent")
+		}
+		return err
+	}
+
+	if s.broadcast(ctx, recipients, report.Message()) == 0 {
+		err = errors.Join(err, errors.New("no recipient received the reconciliation report"))
+	}
+	return err
+}
+
+func (s *Scheduler) backupMongo(ctx context.Context) error {
+	s.logger.Info("backing up mongodb")
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	result, err := s.backuper.Run(ctx)
+	if err != nil {
+		s.logger.Error("mongodb backup failed", zap.String("name", result.Name), zap.Strings("locations", result.Locations), zap.Error(err))
+		return err
+	}
+	s.logger.Info("mongodb backup done", zap.String("name", result.Name), zap.Any("documents", result.Documents))
+	return nil
+}
+
+func (s *Scheduler) remindMissingEntries(ctx context.Context, day time.Time, workers []string) error {
+	s.logger.Info("checking the day's entries", zap.Time("day", day))
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if err := s.reminder.Remind(ctx, day, workers); err != nil {
+		s.logger.Error("missing-entry reminder failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *Scheduler) escalateMissingEntries(ctx context.Context, day time.Time, owners []string) error {
+	s.logger.Info("checking the day's entries before escalating", zap.Time("day", day))
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if err := s.reminder.Escalate(ctx, day, owners); err != nil {
+		s.logger.Error("missing-entry escalation failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *Scheduler) remindVaccinations(ctx context.Context, day time.Time, workers []string) error {
+	s.logger.Info("checking the next day's vaccinations", zap.Time("day", day))
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
 
-	if err := s.messagingSvc.SendOutbound(ctx, req); err != nil {
-		s.logger.Error("failed to send weekly report", zap.Error(err))
-	} else {
-		s.logger.Info("weekly report sent successfully")
+	if err := s.reminder.RemindVaccinations(ctx, day, workers); err != nil {
+		s.logger.Error("vaccination reminder failed", zap.Error(err))
+		return err
 	}
+	return nil
 }
@@ -0,0 +1,647 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/internal/scheduler"
+	"github.com/mamadbah2/farmer/internal/service/backup"
+	"github.com/mamadbah2/farmer/internal/service/flags"
+	"github.com/mamadbah2/farmer/internal/service/whatsapp"
+)
+
+// AuditReader exposes the command and inbound message audit logs and the
+// job run history to the admin endpoints.
+type AuditReader interface {
+	ListCommandAudits(ctx context.Context, query models.AuditQuery) ([]models.CommandAuditEntry, error)
+	ListMessageAudits(ctx context.Context, query models.MessageAuditQuery) ([]models.MessageAuditEntry, error)
+	ListJobExecutions(ctx context.Context, query models.JobExecutionQuery) ([]models.JobExecution, error)
+}
+
+// StockReader exposes the inventory to the admin endpoints.
+type StockReader interface {
+	GetStockItems(ctx context.Context, query models.StockQuery) ([]models.StateStockRecord, error)
+}
+
+// RecordEditor corrects and deletes the mirrored farm records, keeping every
+// version.
+type RecordEditor interface {
+	CorrectRecord(ctx context.Context, kind models.RecordKind, id string, record interface{}, by string) (string, error)
+	DeleteRecord(ctx context.Context, kind models.RecordKind, id, by string) error
+	GetRecordHistory(ctx context.Context, kind models.RecordKind, id string) ([]models.RecordVersion, error)
+}
+
+// Backuper exports the Mongo collections; see internal/service/backup.
+type Backuper interface {
+	Run(ctx context.Context) (backup.Result, error)
+}
+
+// JobManager lists and drives the scheduled jobs; see internal/scheduler.
+type JobManager interface {
+	Jobs() []scheduler.JobStatus
+	RunJob(name string) error
+	RunJobAt(name string, at time.Time) error
+	SetJobEnabled(name string, enabled bool) (scheduler.JobStatus, error)
+}
+
+// FlagManager lists and sets the feature flags; see internal/service/flags.
+type FlagManager interface {
+	List() []models.FeatureFlag
+	Set(ctx context.Context, flag models.FeatureFlag) (models.FeatureFlag, error)
+}
+
+// ReportGenerator builds the daily report; see internal/service/reporting.
+type ReportGenerator interface {
+	GenerateDailyReport(ctx context.Context, reportDate time.Time) (string, error)
+}
+
+// SessionManager lists and resets the WhatsApp conversations in progress;
+// see internal/service/whatsapp.
+type SessionManager interface {
+	Sessions() []whatsapp.Session
+	ResetSession(waID string) bool
+}
+
+// WebhookReplayer processes stored webhook messages again; see
+// internal/service/whatsapp.
+type WebhookReplayer interface {
+	Replay(ctx context.Context, payload models.WebhookPayload, dryRun bool) whatsapp.ReplayResult
+}
+
+// Bodies of the admin answers; see also OpenAPIHandler.
+type (
+	auditResponse struct {
+		Entries []models.CommandAuditEntry `json:"entries"`
+	}
+	messagesResponse struct {
+		Entries []models.MessageAuditEntry `json:"entries"`
+	}
+	stockResponse struct {
+		Items []models.StateStockRecord `json:"items"`
+	}
+	historyResponse struct {
+		Versions []models.RecordVersion `json:"versions"`
+	}
+	// versionResponse carries the ID of the version a correction stored.
+	versionResponse struct {
+		ID string `json:"id"`
+	}
+	// startedResponse acknowledges work started in the background: a
+	// backup, a job run or the daily report run of Job.
+	startedResponse struct {
+		Status string `json:"status"`
+		Job    string `json:"job,omitempty"`
+	}
+	jobsResponse struct {
+		Jobs []scheduler.JobStatus `json:"jobs"`
+	}
+	jobResponse struct {
+		Job scheduler.JobStatus `json:"job"`
+	}
+	runsResponse struct {
+		Runs []models.JobExecution `json:"runs"`
+	}
+	reportResponse struct {
+		Report string `json:"report"`
+	}
+	flagsResponse struct {
+		Flags []models.FeatureFlag `json:"flags"`
+	}
+	flagResponse struct {
+		Flag models.FeatureFlag `json:"flag"`
+	}
+	sessionsResponse struct {
+		Sessions []whatsapp.Session `json:"sessions"`
+	}
+)
+
+// backupTimeout bounds a backup started from the admin endpoint.
+const backupTimeout = 30 * time.Minute
+
+// AdminHandler serves token-protected maintenance endpoints.
+type AdminHandler struct {
+	audits   AuditReader
+	stock    StockReader
+	records  RecordEditor
+	backuper Backuper
+	jobs     JobManager
+	flags    FlagManager
+	sessions SessionManager
+	reports  ReportGenerator
+	replayer WebhookReplayer
+	token    string
+	// location is the farm's timezone, in which dates are read.
+	location *time.Location
+	logger   *zap.Logger
+}
+
+// NewAdminHandler constructs the admin HTTP handler. Requests must carry
+// "Authorization: Bearer <token>". A nil backuper disables POST /admin/backup.
+func NewAdminHandler(audits AuditReader, stock StockReader, records RecordEditor, backuper Backuper, jobs JobManager, flags FlagManager, sessions SessionManager, reports ReportGenerator, replayer WebhookReplayer, token string, location *time.Location, logger *zap.Logger) *AdminHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return &AdminHandler{audits: audits, stock: stock, records: records, backuper: backuper, jobs: jobs, flags: flags, sessions: sessions, reports: reports, replayer: replayer, token: token, location: location, logger: logger}
+}
+
+// RequireToken rejects requests without the configured bearer token.
+func (h *AdminHandler) RequireToken(c *gin.Context) {
+	TokenAuth{"admin": h.token}.Require(c)
+}
+
+// ListAudits returns command audit entries filtered by the sender, command,
+// from/to (YYYY-MM-DD) and limit query parameters.
+func (h *AdminHandler) ListAudits(c *gin.Context) {
+	query := models.AuditQuery{
+		Sender:  c.Query("sender"),
+		Command: c.Query("command"),
+	}
+
+	var err error
+	if query.From, err = parseQueryDate(c.Query("from")); err != nil {
+		replyError(c, http.StatusBadRequest, "from must be YYYY-MM-DD")
+		return
+	}
+	if query.To, err = parseQueryDate(c.Query("to")); err != nil {
+		replyError(c, http.StatusBadRequest, "to must be YYYY-MM-DD")
+		return
+	}
+	if !query.To.IsZero() {
+		// Make the upper bound inclusive of the whole day.
+		query.To = query.To.Add(24*time.Hour - time.Nanosecond)
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit <= 0 {
+			replyError(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		query.Limit = limit
+	}
+
+	entries, err := h.audits.ListCommandAudits(c.Request.Context(), query)
+	if err != nil {
+		requestLogger(c, h.logger).Error("failed listing command audits", zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to load audit log")
+		return
+	}
+	if entries == nil {
+		entries = []models.CommandAuditEntry{}
+	}
+
+	c.JSON(http.StatusOK, auditResponse{Entries: entries})
+}
+
+// ListMessages returns the inbound messages filtered by the wa_id, from/to
+// (YYYY-MM-DD) and limit query parameters, newest first.
+func (h *AdminHandler) ListMessages(c *gin.Context) {
+	query := models.MessageAuditQuery{WaID: c.Query("wa_id")}
+
+	var err error
+	if query.From, err = parseQueryDate(c.Query("from")); err != nil {
+		replyError(c, http.StatusBadRequest, "from must be YYYY-MM-DD")
+		return
+	}
+	if query.To, err = parseQueryDate(c.Query("to")); err != nil {
+		replyError(c, http.StatusBadRequest, "to must be YYYY-MM-DD")
+		return
+	}
+	if !query.To.IsZero() {
+		query.To = query.To.Add(24*time.Hour - time.Nanosecond)
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit <= 0 {
+			replyError(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		query.Limit = limit
+	}
+
+	entries, err := h.audits.ListMessageAudits(c.Request.Context(), query)
+	if err != nil {
+		requestLogger(c, h.logger).Error("failed listing message audits", zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to load message log")
+		return
+	}
+	if entries == nil {
+		entries = []models.MessageAuditEntry{}
+	}
+
+	c.JSON(http.StatusOK, messagesResponse{Entries: entries})
+}
+
+// ListStock returns the stock items filtered by the item (part of the name),
+// condition and limit query parameters, newest first.
+func (h *AdminHandler) ListStock(c *gin.Context) {
+	query := models.StockQuery{
+		ItemName:  c.Query("item"),
+		Condition: c.Query("condition"),
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit <= 0 {
+			replyError(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		query.Limit = limit
+	}
+
+	items, err := h.stock.GetStockItems(c.Request.Context(), query)
+	if err != nil {
+		requestLogger(c, h.logger).Error("failed listing stock items", zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to load stock items")
+		return
+	}
+	if items == nil {
+		items = []models.StateStockRecord{}
+	}
+
+	c.JSON(http.StatusOK, stockResponse{Items: items})
+}
+
+// RecordHistory returns every version of the record :id of kind :kind, the
+// original first.
+func (h *AdminHandler) RecordHistory(c *gin.Context) {
+	kind := models.RecordKind(c.Param("kind"))
+	if _, ok := models.NewRecord(kind); !ok {
+		replyError(c, http.StatusNotFound, "unknown record kind")
+		return
+	}
+
+	history, err := h.records.GetRecordHistory(c.Request.Context(), kind, c.Param("id"))
+	if err != nil {
+		h.recordError(c, "failed loading record history", err)
+		return
+	}
+	c.JSON(http.StatusOK, historyResponse{Versions: history})
+}
+
+// correctionRequest is the body of a record correction: who corrects it and
+// the full corrected record, in the JSON form of its kind. CorrectRecord
+// checks both are present itself, to answer with its own message; the
+// openapi tag marks them required in the document.
+type correctionRequest struct {
+	ChangedBy string          `json:"changed_by" openapi:"required"`
+	Record    json.RawMessage `json:"record" openapi:"required"`
+}
+
+// CorrectRecord stores the body's record as the next version of the current
+// version :id and returns the new version's ID.
+func (h *AdminHandler) CorrectRecord(c *gin.Context) {
+	kind := models.RecordKind(c.Param("kind"))
+	record, ok := models.NewRecord(kind)
+	if !ok {
+		replyError(c, http.StatusNotFound, "unknown record kind")
+		return
+	}
+
+	var req correctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ChangedBy == "" || len(req.Record) == 0 {
+		replyError(c, http.StatusBadRequest, "changed_by and record are required")
+		return
+	}
+	if err := json.Unmarshal(req.Record, record); err != nil {
+		replyError(c, http.StatusBadRequest, "invalid record")
+		return
+	}
+
+	id, err := h.records.CorrectRecord(c.Request.Context(), kind, c.Param("id"), record, req.ChangedBy)
+	if err != nil {
+		h.recordError(c, "failed correcting record", err)
+		return
+	}
+	c.JSON(http.StatusOK, versionResponse{ID: id})
+}
+
+// DeleteRecord marks the current version :id deleted by the "by" query
+// parameter; the versions stay in the history.
+func (h *AdminHandler) DeleteRecord(c *gin.Context) {
+	kind := models.RecordKind(c.Param("kind"))
+	if _, ok := models.NewRecord(kind); !ok {
+		replyError(c, http.StatusNotFound, "unknown record kind")
+		return
+	}
+	by := c.Query("by")
+	if by == "" {
+		replyError(c, http.StatusBadRequest, "by is required")
+		return
+	}
+
+	if err := h.records.DeleteRecord(c.Request.Context(), kind, c.Param("id"), by); err != nil {
+		h.recordError(c, "failed deleting record", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// StartBackup starts a backup in the background and answers 202 at once, as
+// exporting and uploading outlasts the HTTP timeouts. The outcome is logged.
+func (h *AdminHandler) StartBackup(c *gin.Context) {
+	if h.backuper == nil {
+		replyError(c, http.StatusNotFound, "backups are not configured")
+		return
+	}
+
+	// The backup outlives the request: keep its ID for the outcome's logs.
+	logger := requestLogger(c, h.logger)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), backupTimeout)
+		defer cancel()
+		result, err := h.backuper.Run(ctx)
+		if errors.Is(err, backup.ErrRunning) {
+			logger.Warn("backup requested while another one runs")
+			return
+		}
+		if err != nil {
+			logger.Error("requested backup failed", zap.String("name", result.Name), zap.Strings("locations", result.Locations), zap.Error(err))
+			return
+		}
+		logger.Info("requested backup done", zap.String("name", result.Name), zap.Any("documents", result.Documents))
+	}()
+	c.JSON(http.StatusAccepted, startedResponse{Status: "backup started"})
+}
+
+// ListJobs returns the scheduled jobs with their next run time.
+func (h *AdminHandler) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, jobsResponse{Jobs: h.jobs.Jobs()})
+}
+
+// JobHistory returns the job runs filtered by the job and limit query
+// parameters, newest first.
+func (h *AdminHandler) JobHistory(c *gin.Context) {
+	query := models.JobExecutionQuery{Name: c.Query("job")}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit <= 0 {
+			replyError(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		query.Limit = limit
+	}
+
+	executions, err := h.audits.ListJobExecutions(c.Request.Context(), query)
+	if err != nil {
+		requestLogger(c, h.logger).Error("failed listing job executions", zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to load job history")
+		return
+	}
+	if executions == nil {
+		executions = []models.JobExecution{}
+	}
+
+	c.JSON(http.StatusOK, runsResponse{Runs: executions})
+}
+
+// RunJob starts the job :name in the background and answers 202; the outcome
+// is recorded like a scheduled run.
+func (h *AdminHandler) RunJob(c *gin.Context) {
+	if err := h.jobs.RunJob(c.Param("name")); err != nil {
+		h.jobError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, startedResponse{Status: "job started"})
+}
+
+// DailyReport sends the daily report of the date query parameter
+// (YYYY-MM-DD, default today) to the recipients of the daily report job
+// given by the job query parameter, the first one by default. Like RunJob it
+// runs in the background, answers 202 and is recorded and alerted like a
+// scheduled run. With send=false the report is generated and returned
+// instead, sent to no one. Either way it is saved to daily_reports.
+func (h *AdminHandler) DailyReport(c *gin.Context) {
+	now := time.Now().In(h.location)
+	at := now
+	if raw := c.Query("date"); raw != "" {
+		day, err := time.ParseInLocation("2006-01-02", raw, h.location)
+		if err != nil {
+			replyError(c, http.StatusBadRequest, "date must be YYYY-MM-DD")
+			return
+		}
+		if day.After(now) {
+			replyError(c, http.StatusBadRequest, "date must not be in the future")
+			return
+		}
+		// The end of the day, past REPORT_CUTOFF_HOUR, so the report covers
+		// day itself.
+		at = time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 0, 0, h.location)
+	}
+	send, err := strconv.ParseBool(c.DefaultQuery("send", "true"))
+	if err != nil {
+		replyError(c, http.StatusBadRequest, "send must be true or false")
+		return
+	}
+
+	if !send {
+		report, err := h.reports.GenerateDailyReport(c.Request.Context(), at)
+		if err != nil {
+			requestLogger(c, h.logger).Error("failed generating daily report", zap.Error(err))
+			replyError(c, http.StatusBadGateway, "unable to generate report")
+			return
+		}
+		c.JSON(http.StatusOK, reportResponse{Report: report})
+		return
+	}
+
+	name := c.Query("job")
+	if name == "" {
+		for _, job := range h.jobs.Jobs() {
+			if job.Action == config.JobDailyReport {
+				name = job.Name
+				break
+			}
+		}
+		if name == "" {
+			replyError(c, http.StatusNotFound, "no daily report job")
+			return
+		}
+	} else if !slices.ContainsFunc(h.jobs.Jobs(), func(job scheduler.JobStatus) bool {
+		return job.Name == name && job.Action == config.JobDailyReport
+	}) {
+		replyError(c, http.StatusNotFound, "no daily report job by this name")
+		return
+	}
+
+	if err := h.jobs.RunJobAt(name, at); err != nil {
+		h.jobError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, startedResponse{Status: "report started", Job: name})
+}
+
+// EnableJob puts the job :name back on its schedule.
+func (h *AdminHandler) EnableJob(c *gin.Context) {
+	h.setJobEnabled(c, true)
+}
+
+// DisableJob takes the job :name off its schedule until it is enabled again
+// or the server restarts.
+func (h *AdminHandler) DisableJob(c *gin.Context) {
+	h.setJobEnabled(c, false)
+}
+
+func (h *AdminHandler) setJobEnabled(c *gin.Context, enabled bool) {
+	status, err := h.jobs.SetJobEnabled(c.Param("name"), enabled)
+	if err != nil {
+		h.jobError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, jobResponse{Job: status})
+}
+
+// ListFlags returns the feature flags in effect.
+func (h *AdminHandler) ListFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, flagsResponse{Flags: h.flags.List()})
+}
+
+// flagRequest is the body of PUT /admin/flags/:name.
+type flagRequest struct {
+	Enabled bool     `json:"enabled"`
+	Users   []string `json:"users"`
+}
+
+// SetFlag turns the feature :name on for everyone, or for the body's users
+// only, on every instance. The flag overrides FEATURE_FLAGS until set again.
+func (h *AdminHandler) SetFlag(c *gin.Context) {
+	var req flagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		replyError(c, http.StatusBadRequest, "invalid flag")
+		return
+	}
+
+	flag, err := h.flags.Set(c.Request.Context(), models.FeatureFlag{
+		Name:    models.Feature(c.Param("name")),
+		Enabled: req.Enabled,
+		Users:   req.Users,
+	})
+	switch {
+	case errors.Is(err, flags.ErrUnknownFeature):
+		replyError(c, http.StatusNotFound, "unknown feature")
+	case err != nil:
+		requestLogger(c, h.logger).Error("failed setting feature flag", zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to set flag")
+	default:
+		c.JSON(http.StatusOK, flagResponse{Flag: flag})
+	}
+}
+
+// ListSessions returns the conversations in progress and the commands
+// awaiting confirmation on this instance, most recently active first.
+func (h *AdminHandler) ListSessions(c *gin.Context) {
+	c.JSON(http.StatusOK, sessionsResponse{Sessions: h.sessions.Sessions()})
+}
+
+// ResetSession forgets the conversation and pending command of :waID, so a
+// user stuck in a conversation starts afresh with their next message.
+func (h *AdminHandler) ResetSession(c *gin.Context) {
+	waID := c.Param("waID")
+	if !h.sessions.ResetSession(waID) {
+		replyError(c, http.StatusNotFound, "no session for this number")
+		return
+	}
+	requestLogger(c, h.logger).Info("conversation session reset", zap.String("wa_id", waID))
+	c.Status(http.StatusNoContent)
+}
+
+// replayRequest is the body of POST /admin/webhook/replay: the raw field
+// of a GET /admin/messages entry, or a whole webhook payload.
+type replayRequest struct {
+	Message *models.InboundMessage `json:"message"`
+	Payload *models.WebhookPayload `json:"payload"`
+	Mode    string                 `json:"mode"`
+}
+
+// Replay modes of POST /admin/webhook/replay.
+const (
+	replayDryRun = "dry_run"
+	replayReal   = "real"
+)
+
+// ReplayWebhook processes a stored message again to reproduce a parsing or
+// AI issue. In the default dry_run mode nothing is saved or sent and the
+// replies are returned; in real mode the message is handled like a new
+// delivery from Meta.
+func (h *AdminHandler) ReplayWebhook(c *gin.Context) {
+	var req replayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		replyError(c, http.StatusBadRequest, "invalid replay request")
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = replayDryRun
+	}
+	if req.Mode != replayDryRun && req.Mode != replayReal {
+		replyError(c, http.StatusBadRequest, "mode must be dry_run or real")
+		return
+	}
+	if (req.Message == nil) == (req.Payload == nil) {
+		replyError(c, http.StatusBadRequest, "provide either message or payload")
+		return
+	}
+
+	payload := req.Payload
+	if req.Message != nil {
+		payload = &models.WebhookPayload{
+			Object: "whatsapp_business_account",
+			Entry: []models.WebhookEntry{{Changes: []models.WebhookChange{{
+				Field: "messages",
+				Value: models.WebhookValue{MessagingProduct: "whatsapp", Messages: []models.InboundMessage{*req.Message}},
+			}}}},
+		}
+	}
+	if problems := payload.Validate(); len(problems) > 0 {
+		replyInvalid(c, problems)
+		return
+	}
+
+	result := h.replayer.Replay(c.Request.Context(), *payload, req.Mode == replayDryRun)
+	requestLogger(c, h.logger).Info("webhook replayed", zap.String("mode", req.Mode), zap.Bool("failed", result.Error != ""))
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AdminHandler) jobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		replyError(c, http.StatusNotFound, "job not found")
+	case errors.Is(err, scheduler.ErrJobUnavailable), errors.Is(err, scheduler.ErrJobRunning):
+		replyError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, scheduler.ErrStopped):
+		replyError(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		requestLogger(c, h.logger).Error("failed changing job", zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to change job")
+	}
+}
+
+func (h *AdminHandler) recordError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, mongodb.ErrRecordNotFound):
+		replyError(c, http.StatusNotFound, "record not found")
+	case errors.Is(err, mongodb.ErrNotCurrent):
+		replyError(c, http.StatusConflict, "record is not the current version")
+	default:
+		requestLogger(c, h.logger).Error(msg, zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to process record")
+	}
+}
+
+func parseQueryDate(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// callerKey is the gin context key of the name of the authenticated caller.
+const callerKey = "caller"
+
+// TokenAuth maps the name of each caller allowed on a route group to their
+// bearer token.
+type TokenAuth map[string]string
+
+// Require rejects requests without "Authorization: Bearer <token>" of one of
+// the callers, and records the caller's name for Caller. Empty tokens never
+// match, so a group without tokens rejects everything.
+func (a TokenAuth) Require(c *gin.Context) {
+	provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	caller := ""
+	for name, token := range a {
+		if ok && token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+			caller = name
+		}
+	}
+	if caller == "" {
+		replyError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	c.Set(callerKey, caller)
+	c.Next()
+}
+
+// Caller returns the name of the caller authenticated by TokenAuth.
+func Caller(c *gin.Context) string {
+	return c.GetString(callerKey)
+}
+
+// RequireLogin is Require for browsers: it takes HTTP Basic credentials, a
+// caller's name and their token, and asks for them with WWW-Authenticate.
+func (a TokenAuth) RequireLogin(c *gin.Context) {
+	name, provided, ok := c.Request.BasicAuth()
+	token, known := a[name]
+	if !ok || !known || token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		c.Header("WWW-Authenticate", `Basic realm="farmer", charset="UTF-8"`)
+		replyError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	c.Set(callerKey, name)
+	c.Next()
+}
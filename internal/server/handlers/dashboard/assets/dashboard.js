@@ -0,0 +1,235 @@
+// Draws the dashboard from /dashboard/data and lists the live activity of
+// /dashboard/events. The browser resends the Basic login the page was opened
+// with.
+(function () {
+  "use strict";
+
+  const SVG = "http://www.w3.org/2000/svg";
+  let currency = "";
+
+  function number(value, digits) {
+    return Number(value || 0).toLocaleString(undefined, { maximumFractionDigits: digits || 0 });
+  }
+
+  function money(value) {
+    return number(value) + " " + currency;
+  }
+
+  function day(value) {
+    if (!value || value.startsWith("0001-")) {
+      return "";
+    }
+    return new Date(value).toLocaleDateString();
+  }
+
+  function dateTime(value) {
+    return value ? new Date(value).toLocaleString() : "";
+  }
+
+  function cell(row, text, className) {
+    const td = document.createElement("td");
+    td.textContent = text;
+    if (className) {
+      td.className = className;
+    }
+    row.appendChild(td);
+  }
+
+  function renderToday(today) {
+    const tiles = [
+      ["Eggs collected", number(today.eggs_collected)],
+      ["Mortality", number(today.mortality)],
+      ["Feed consumed", number(today.feed_consumed, 1) + " kg"],
+      ["Sales", money(today.sales_amount)],
+      ["Unpaid", money(today.unpaid_balance)],
+      ["Debt collected", money(today.debt_collected)],
+      ["Expenses", money(today.expenses)],
+      ["Profit", money(today.profit)],
+    ];
+    const container = document.getElementById("today");
+    container.replaceChildren();
+    for (const [label, value] of tiles) {
+      const tile = document.createElement("div");
+      tile.className = "tile";
+      tile.innerHTML = '<div class="label"></div><div class="value"></div>';
+      tile.querySelector(".label").textContent = label;
+      tile.querySelector(".value").textContent = value;
+      container.appendChild(tile);
+    }
+  }
+
+  // renderChart draws one bar per report, negative values below the axis.
+  function renderChart(id, reports, field) {
+    const svg = document.getElementById(id);
+    svg.replaceChildren();
+    const width = 300, height = 130, bottom = 12;
+    svg.setAttribute("viewBox", "0 0 " + width + " " + height);
+    if (reports.length === 0) {
+      const text = document.createElementNS(SVG, "text");
+      text.setAttribute("x", 4);
+      text.setAttribute("y", 16);
+      text.textContent = "No daily report yet";
+      svg.appendChild(text);
+      return;
+    }
+
+    const values = reports.map((r) => r[field] || 0);
+    const max = Math.max(0, ...values), min = Math.min(0, ...values);
+    const span = max - min || 1;
+    const plot = height - bottom;
+    const zero = plot * max / span;
+    const step = width / values.length;
+
+    values.forEach((value, i) => {
+      const bar = document.createElementNS(SVG, "rect");
+      const size = plot * Math.abs(value) / span;
+      bar.setAttribute("x", i * step + 1);
+      bar.setAttribute("y", value >= 0 ? zero - size : zero);
+      bar.setAttribute("width", Math.max(step - 2, 1));
+      bar.setAttribute("height", size);
+      bar.setAttribute("class", value < 0 ? "bar negative" : "bar");
+      const title = document.createElementNS(SVG, "title");
+      title.textContent = day(reports[i].date) + ": " + number(value, 1);
+      bar.appendChild(title);
+      svg.appendChild(bar);
+    });
+
+    const axis = document.createElementNS(SVG, "line");
+    axis.setAttribute("x1", 0);
+    axis.setAttribute("x2", width);
+    axis.setAttribute("y1", zero);
+    axis.setAttribute("y2", zero);
+    axis.setAttribute("class", "axis");
+    svg.appendChild(axis);
+
+    for (const [i, anchor] of [[0, "start"], [reports.length - 1, "end"]]) {
+      const label = document.createElementNS(SVG, "text");
+      label.setAttribute("x", anchor === "start" ? 0 : width);
+      label.setAttribute("y", height - 2);
+      label.setAttribute("text-anchor", anchor);
+      label.textContent = day(reports[i].date);
+      svg.appendChild(label);
+    }
+  }
+
+  function renderDebts(debts, outstanding) {
+    document.getElementById("outstanding").textContent = "(" + money(outstanding) + ")";
+    const body = document.getElementById("debts");
+    body.replaceChildren();
+    for (const debt of debts) {
+      const row = document.createElement("tr");
+      cell(row, debt.client);
+      cell(row, debt.phone || "");
+      cell(row, money(debt.balance), "num");
+      cell(row, day(debt.oldest_unpaid));
+      cell(row, day(debt.last_payment));
+      body.appendChild(row);
+    }
+  }
+
+  function renderMessages(messages) {
+    const body = document.getElementById("messages");
+    body.replaceChildren();
+    for (const message of messages) {
+      const row = document.createElement("tr");
+      cell(row, dateTime(message.received_at));
+      cell(row, message.wa_id);
+      cell(row, message.type);
+      cell(row, message.text || "");
+      cell(row, message.error ? message.result + ": " + message.error : message.result,
+        message.result === "failed" ? "failed" : "");
+      body.appendChild(row);
+    }
+  }
+
+  async function load() {
+    const response = await fetch("data", { credentials: "same-origin" });
+    if (!response.ok) {
+      const problem = await response.json().catch(() => ({}));
+      throw new Error((problem.error || response.statusText) +
+        (problem.request_id ? " (ref. " + problem.request_id + ")" : ""));
+    }
+    const data = await response.json();
+    currency = data.currency;
+    document.getElementById("date").textContent = day(data.date + "T00:00:00");
+    renderToday(data.today);
+    renderChart("chart-eggs", data.reports, "eggs_collected");
+    renderChart("chart-mortality", data.reports, "mortality");
+    renderChart("chart-feed", data.reports, "feed_consumed");
+    renderChart("chart-profit", data.reports, "profit");
+    renderDebts(data.debts, data.outstanding);
+    renderMessages(data.messages);
+
+    const warnings = document.getElementById("warnings");
+    warnings.textContent = data.warnings.join(", ");
+    warnings.hidden = data.warnings.length === 0;
+  }
+
+  function refresh() {
+    load().catch((err) => {
+      const warnings = document.getElementById("warnings");
+      warnings.textContent = "Dashboard unavailable: " + err.message;
+      warnings.hidden = false;
+    });
+  }
+
+  const maxActivity = 100;
+  let pendingRefresh = null;
+
+  function describe(event) {
+    const data = event.data;
+    switch (event.type) {
+      case "message":
+        return "💬 " + data.wa_id + ": " + (data.text || data.type) +
+          (data.result === "failed" ? " (failed: " + data.error + ")" : "");
+      case "record":
+        return "📝 " + data.sender + " saved " + data.command + (data.raw ? ": " + data.raw : "");
+      case "job":
+        return (data.success ? "✅ " : "❌ ") + "job " + data.name + " (" + data.trigger + ")" +
+          (data.success ? "" : ": " + data.error);
+    }
+    return event.type;
+  }
+
+  function showActivity(event) {
+    const list = document.getElementById("activity");
+    const item = document.createElement("li");
+    const time = document.createElement("time");
+    time.textContent = new Date(event.at).toLocaleTimeString();
+    item.appendChild(time);
+    item.appendChild(document.createTextNode(describe(event)));
+    if (event.type === "job" && !event.data.success) {
+      item.className = "failed";
+    }
+    list.prepend(item);
+    while (list.children.length > maxActivity) {
+      list.lastElementChild.remove();
+    }
+
+    // New entries change today's figures; wait for a burst to settle.
+    clearTimeout(pendingRefresh);
+    pendingRefresh = setTimeout(refresh, 2000);
+  }
+
+  // subscribe follows the live activity; EventSource reconnects by itself.
+  function subscribe() {
+    const live = document.getElementById("live");
+    const source = new EventSource("events");
+    source.addEventListener("ready", () => {
+      live.textContent = "live";
+      live.className = "";
+    });
+    source.onerror = () => {
+      live.textContent = "reconnecting…";
+      live.className = "offline";
+    };
+    for (const type of ["message", "record", "job"]) {
+      source.addEventListener(type, (e) => showActivity(JSON.parse(e.data)));
+    }
+  }
+
+  refresh();
+  subscribe();
+  // Today's figures move as records come in.
+  setInterval(refresh, 5 * 60 * 1000);
+})();
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"embed"
+	"io"
+	"io/fs"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// dashboardFiles are the page of the dashboard and, under assets/, its
+// script and stylesheet.
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// Metrics computes the totals of a day from the records; see
+// internal/service/reporting.
+type Metrics interface {
+	DailyMetrics(ctx context.Context, day time.Time) (models.DailyReport, error)
+	Currency() string
+}
+
+// DashboardStore exposes the saved daily reports, the client balances and
+// the inbound messages to the dashboard.
+type DashboardStore interface {
+	GetDailyReports(ctx context.Context, start, end time.Time) ([]models.DailyReport, error)
+	GetClientBalances(ctx context.Context) ([]models.ClientBalance, error)
+	ListMessageAudits(ctx context.Context, query models.MessageAuditQuery) ([]models.MessageAuditEntry, error)
+}
+
+// ActivityFeed streams what happens on the backend as it happens; see
+// internal/service/activity.
+type ActivityFeed interface {
+	Subscribe() (<-chan models.ActivityEvent, func())
+}
+
+const (
+	// dashboardDays is the period charted by the dashboard.
+	dashboardDays = 30
+	// dashboardMessages is the number of recent messages listed.
+	dashboardMessages = 20
+	// eventsKeepAlive is how often an idle event stream sends a comment, so
+	// proxies do not close it.
+	eventsKeepAlive = 25 * time.Second
+)
+
+// dashboardData is what the dashboard page draws; see Data.
+type dashboardData struct {
+	Date        string                     `json:"date"` // YYYY-MM-DD
+	Currency    string                     `json:"currency"`
+	Today       models.DailyReport         `json:"today"`
+	Reports     []models.DailyReport       `json:"reports"`
+	Debts       []models.ClientBalance     `json:"debts"`
+	Outstanding float64                    `json:"outstanding"`
+	Messages    []models.MessageAuditEntry `json:"messages"`
+	Warnings    []string                   `json:"warnings"`
+}
+
+// DashboardHandler serves the owner's dashboard: a page embedded in the
+// binary and the JSON it draws, behind the admin token as a Basic login.
+type DashboardHandler struct {
+	metrics  Metrics
+	store    DashboardStore
+	feed     ActivityFeed
+	token    string
+	location *time.Location
+	page     []byte
+	assets   http.FileSystem
+	logger   *zap.Logger
+}
+
+// NewDashboardHandler constructs the dashboard handler. Browsers log in as
+// "admin" with token as the password; days are read in location. A nil feed
+// disables GET /dashboard/events.
+func NewDashboardHandler(metrics Metrics, store DashboardStore, feed ActivityFeed, token string, location *time.Location, logger *zap.Logger) *DashboardHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	// Both are embedded above, so neither can fail.
+	page, _ := fs.ReadFile(dashboardFiles, "dashboard/index.html")
+	assets, _ := fs.Sub(dashboardFiles, "dashboard/assets")
+	return &DashboardHandler{
+		metrics:  metrics,
+		store:    store,
+		feed:     feed,
+		token:    token,
+		location: location,
+		page:     page,
+		assets:   http.FS(assets),
+		logger:   logger,
+	}
+}
+
+// RequireLogin asks browsers for the admin login.
+func (h *DashboardHandler) RequireLogin(c *gin.Context) {
+	TokenAuth{"admin": h.token}.RequireLogin(c)
+}
+
+// Page serves the dashboard page.
+func (h *DashboardHandler) Page(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", h.page)
+}
+
+// Asset serves the script or stylesheet :filepath of the page.
+func (h *DashboardHandler) Asset(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	c.FileFromFS(c.Param("filepath"), h.assets)
+}
+
+// Data returns what the page shows: today's totals computed from the
+// records, the daily reports of the last 30 days by date, the clients who
+// owe money and the latest inbound messages. Those the store cannot load
+// are left empty, with a warning, so the rest still shows.
+func (h *DashboardHandler) Data(c *gin.Context) {
+	ctx := c.Request.Context()
+	now := time.Now().In(h.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, h.location)
+
+	metrics, err := h.metrics.DailyMetrics(ctx, today)
+	if err != nil {
+		requestLogger(c, h.logger).Error("failed computing today's metrics", zap.Error(err))
+		replyError(c, http.StatusBadGateway, "unable to read today's records")
+		return
+	}
+
+	warnings := []string{}
+	reports, err := h.store.GetDailyReports(ctx, today.AddDate(0, 0, -dashboardDays), today)
+	if err != nil {
+		requestLogger(c, h.logger).Warn("failed loading daily reports", zap.Error(err))
+		warnings = append(warnings, "daily reports unavailable")
+	}
+	if reports == nil {
+		reports = []models.DailyReport{}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Date.Before(reports[j].Date) })
+
+	debts, err := h.store.GetClientBalances(ctx)
+	if err != nil {
+		requestLogger(c, h.logger).Warn("failed loading client balances", zap.Error(err))
+		warnings = append(warnings, "client balances unavailable")
+	}
+	if debts == nil {
+		debts = []models.ClientBalance{}
+	}
+	var outstanding float64
+	for _, debt := range debts {
+		outstanding += debt.Balance
+	}
+
+	messages, err := h.store.ListMessageAudits(ctx, models.MessageAuditQuery{Limit: dashboardMessages})
+	if err != nil {
+		requestLogger(c, h.logger).Warn("failed loading messages", zap.Error(err))
+		warnings = append(warnings, "messages unavailable")
+	}
+	if messages == nil {
+		messages = []models.MessageAuditEntry{}
+	}
+
+	c.JSON(http.StatusOK, dashboardData{
+		Date:        today.Format("2006-01-02"),
+		Currency:    h.metrics.Currency(),
+		Today:       metrics,
+		Reports:     reports,
+		Debts:       debts,
+		Outstanding: outstanding,
+		Messages:    messages,
+		Warnings:    warnings,
+	})
+}
+
+// Events streams the activity feed as server-sent events, one "message",
+// "record" or "job" event per inbound message, saved entry or job run, with
+// the models.ActivityEvent as data. It lasts until the client leaves or the
+// feed closes.
+func (h *DashboardHandler) Events(c *gin.Context) {
+	if h.feed == nil {
+		replyError(c, http.StatusNotFound, "live activity disabled")
+		return
+	}
+	events, unsubscribe := h.feed.Subscribe()
+	defer unsubscribe()
+
+	// The server's write timeout would cut the stream.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		requestLogger(c, h.logger).Debug("write deadline kept on event stream", zap.Error(err))
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", gin.H{"request_id": logger.RequestID(c.Request.Context())})
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
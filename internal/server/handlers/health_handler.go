@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// healthCheckTimeout bounds each dependency check, so a hung backend fails
+// the probe instead of stalling it.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthChecker is a dependency the readiness probe pings.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+// CachedCheck wraps checker so it is asked at most once per ttl, the probes
+// in between getting its last answer; for checks that cost API quota, such
+// as the WhatsApp token. A check cut short by the probe's timeout is not
+// kept.
+func CachedCheck(checker HealthChecker, ttl time.Duration) HealthChecker {
+	return &cachedCheck{checker: checker, ttl: ttl}
+}
+
+type cachedCheck struct {
+	checker HealthChecker
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+func (c *cachedCheck) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.checked.IsZero() && time.Since(c.checked) < c.ttl {
+		return c.err
+	}
+	err := c.checker.Ping(ctx)
+	if ctx.Err() == nil {
+		c.checked, c.err = time.Now(), err
+	}
+	return err
+}
+
+// healthResponse is the answer of the probes: "ok" or "unavailable" and,
+// from /readyz, "ok" or "error" per dependency.
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// HealthHandler serves the liveness and readiness probes.
+type HealthHandler struct {
+	checks map[string]HealthChecker
+	logger *zap.Logger
+}
+
+// NewHealthHandler constructs the probe handler. checks maps a dependency
+// name, as reported by /readyz, to its checker.
+func NewHealthHandler(checks map[string]HealthChecker, logger *zap.Logger) *HealthHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &HealthHandler{checks: checks, logger: logger}
+}
+
+// Live reports that the process is up, without touching any dependency.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Ready pings every dependency concurrently and answers 503 when one of them
+// fails, so orchestrators stop routing traffic to an instance with broken
+// credentials or an unreachable database. The endpoint is public, so errors
+// are only logged.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	checks := make(map[string]string, len(h.checks))
+	healthy := true
+	logger := requestLogger(c, h.logger)
+	for name, checker := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := "ok"
+			if err := checker.Ping(ctx); err != nil {
+				logger.Warn("dependency health check failed", zap.String("dependency", name), zap.Error(err))
+				status = "error"
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			checks[name] = status
+			if status != "ok" {
+				healthy = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !healthy {
+		c.JSON(http.StatusServiceUnavailable, healthResponse{Status: "unavailable", Checks: checks})
+		return
+	}
+	c.JSON(http.StatusOK, healthResponse{Status: "ok", Checks: checks})
+}
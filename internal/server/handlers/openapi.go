@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/service/whatsapp"
+)
+
+// openAPIVersion is the version of the HTTP API described, the /api/v1 one.
+const openAPIVersion = "1.0.0"
+
+// Security schemes of the document, one per group of tokens.
+const (
+	authAdmin     = "adminToken"
+	authAPI       = "apiToken"
+	authSend      = "sendToken"
+	authDashboard = "dashboardLogin"
+)
+
+// recordKinds are the record kinds of the /api/v1 and /admin/records paths.
+var recordKinds = []models.RecordKind{
+	models.RecordEggs, models.RecordFeed, models.RecordMortality,
+	models.RecordSales, models.RecordPayments, models.RecordExpenses,
+}
+
+// operation describes a route for the OpenAPI document. Bodies are Go
+// values of the types the handler decodes or encodes, or ready-made schemas
+// (map[string]any); path parameters are taken from the gin path.
+type operation struct {
+	method, path string
+	id           string
+	tag          string
+	summary      string
+	// auth is the security scheme, empty for public routes.
+	auth      string
+	limited   bool // rate limited per client IP
+	query     []param
+	enums     map[string][]string // values of path parameters
+	body      any
+	responses []response
+}
+
+type param struct {
+	name, format, description string
+}
+
+type response struct {
+	status      int
+	description string
+	body        any
+	contentType string // default application/json
+}
+
+// OpenAPIHandler serves the OpenAPI 3 document of the HTTP API. Its schemas
+// are built from the request and response types of the handlers, so the
+// document follows them.
+type OpenAPIHandler struct {
+	document []byte
+}
+
+// NewOpenAPIHandler builds the document once.
+func NewOpenAPIHandler() *OpenAPIHandler {
+	document, err := json.MarshalIndent(openAPIDocument(), "", "  ")
+	if err != nil {
+		// The document is made of maps of strings, slices and numbers.
+		panic(err)
+	}
+	return &OpenAPIHandler{document: document}
+}
+
+// Spec serves the document.
+func (h *OpenAPIHandler) Spec(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.document)
+}
+
+// operations lists the routes registered by router.New, but the dashboard
+// assets and this document.
+func operations() []operation {
+	var records []any
+	for _, kind := range recordKinds {
+		record, _ := models.NewRecord(kind)
+		records = append(records, record)
+	}
+	var features []string
+	for feature := range models.Features {
+		features = append(features, string(feature))
+	}
+	slices.Sort(features)
+	limit := param{name: "limit", format: "int64", description: "Number of entries, 100 by default."}
+	from := param{name: "from", format: "date", description: "First day, YYYY-MM-DD."}
+	to := param{name: "to", format: "date", description: "Last day, YYYY-MM-DD."}
+	badRequest := response{status: http.StatusBadRequest, description: "Invalid parameters or body.", body: errorResponse{}}
+	notFound := response{status: http.StatusNotFound, description: "Unknown kind, record or job.", body: errorResponse{}}
+	conflict := response{status: http.StatusConflict, description: "Not the current version, or a job already running or disabled.", body: errorResponse{}}
+	rowConflict := response{status: http.StatusConflict, description: "Not the current version, or its sheet row is gone (archived, changed by hand or still queued).", body: errorResponse{}}
+	failed := response{status: http.StatusInternalServerError, description: "The store failed.", body: errorResponse{}}
+	uncertain := response{status: http.StatusGatewayTimeout, description: "Google Sheets failed after it may have written the row: check the sheet before retrying.", body: errorResponse{}}
+	started := response{status: http.StatusAccepted, description: "Started in the background.", body: startedResponse{}}
+
+	return []operation{
+		{method: http.MethodGet, path: "/webhook", id: "verifyWebhook", tag: "webhook", summary: "Meta's webhook verification challenge.",
+			query: []param{{name: "hub.mode"}, {name: "hub.verify_token"}, {name: "hub.challenge"}},
+			responses: []response{
+				{status: http.StatusOK, description: "The challenge.", body: map[string]any{"type": "string"}, contentType: "text/plain"},
+				{status: http.StatusForbidden, description: "Wrong mode or verify token.", body: map[string]any{"type": "string"}, contentType: "text/plain"},
+			}},
+		{method: http.MethodPost, path: "/webhook", id: "receiveWebhook", tag: "webhook", summary: "Receive a WhatsApp webhook notification from Meta.", limited: true,
+			body: models.WebhookPayload{},
+			responses: []response{
+				{status: http.StatusOK, description: "Handled."},
+				{status: http.StatusBadRequest, description: "Malformed or incomplete payload, with the problems in details.", body: errorResponse{}},
+				{status: http.StatusRequestEntityTooLarge, description: "Body over 1 MiB.", body: errorResponse{}},
+				{status: http.StatusUnsupportedMediaType, description: "Body not application/json.", body: errorResponse{}},
+				{status: http.StatusInternalServerError, description: "A message could not be handled.", body: errorResponse{}},
+			}},
+		{method: http.MethodPost, path: "/send-message", id: "sendMessage", tag: "webhook", summary: "Send a WhatsApp text, template, image or document message.", auth: authSend, limited: true,
+			body: models.OutboundMessageRequest{},
+			responses: []response{
+				{status: http.StatusAccepted, description: "Sent."},
+				{status: http.StatusBadRequest, description: "Invalid body, with the problems in details.", body: errorResponse{}},
+				{status: http.StatusRequestEntityTooLarge, description: "Body over 16 MiB: send large files by link.", body: errorResponse{}},
+				{status: http.StatusBadGateway, description: "WhatsApp refused the message.", body: errorResponse{}},
+			}},
+		{method: http.MethodGet, path: "/healthz", id: "live", tag: "health", summary: "Liveness probe.",
+			responses: []response{{status: http.StatusOK, description: "The process is up.", body: healthResponse{}}}},
+		{method: http.MethodGet, path: "/readyz", id: "ready", tag: "health", summary: "Readiness probe: the status of each dependency.",
+			responses: []response{
+				{status: http.StatusOK, description: "Every dependency answers.", body: healthResponse{}},
+				{status: http.StatusServiceUnavailable, description: "A dependency failed.", body: healthResponse{}},
+			}},
+
+		{method: http.MethodGet, path: "/admin/audit", id: "listAudits", tag: "admin", summary: "Command audit log, newest first.", auth: authAdmin,
+			query:     []param{{name: "sender"}, {name: "command"}, from, to, limit},
+			responses: []response{{status: http.StatusOK, description: "The entries.", body: auditResponse{}}, badRequest, failed}},
+		{method: http.MethodGet, path: "/admin/messages", id: "listMessages", tag: "admin", summary: "Inbound WhatsApp messages as received, newest first.", auth: authAdmin,
+			query:     []param{{name: "wa_id"}, from, to, limit},
+			responses: []response{{status: http.StatusOK, description: "The messages.", body: messagesResponse{}}, badRequest, failed}},
+		{method: http.MethodGet, path: "/admin/stock", id: "listStock", tag: "admin", summary: "Stock items, newest first.", auth: authAdmin,
+			query:     []param{{name: "item", description: "Part of the item name."}, {name: "condition"}, limit},
+			responses: []response{{status: http.StatusOK, description: "The items.", body: stockResponse{}}, badRequest, failed}},
+		{method: http.MethodGet, path: "/admin/records/:kind/:id/history", id: "recordHistory", tag: "admin", summary: "Every version of a mirrored record, the original first.", auth: authAdmin,
+			responses: []response{{status: http.StatusOK, description: "The versions.", body: historyResponse{}}, notFound, failed}},
+		{method: http.MethodPut, path: "/admin/records/:kind/:id", id: "correctRecord", tag: "admin", summary: "Store a corrected record as the next version of the current one.", auth: authAdmin,
+			body:      correctionRequest{},
+			responses: []response{{status: http.StatusOK, description: "The new version.", body: versionResponse{}}, badRequest, notFound, conflict, failed}},
+		{method: http.MethodDelete, path: "/admin/records/:kind/:id", id: "deleteRecord", tag: "admin", summary: "Mark the current version of a record deleted.", auth: authAdmin,
+			query:     []param{{name: "by", description: "Who deletes it (required)."}},
+			responses: []response{{status: http.StatusNoContent, description: "Deleted."}, badRequest, notFound, conflict, failed}},
+		{method: http.MethodPost, path: "/admin/backup", id: "startBackup", tag: "admin", summary: "Start a backup of the record store.", auth: authAdmin,
+			responses: []response{started, {status: http.StatusNotFound, description: "No backup destination.", body: errorResponse{}}}},
+		{method: http.MethodGet, path: "/admin/jobs", id: "listJobs", tag: "admin", summary: "The scheduled jobs and their next run.", auth: authAdmin,
+			responses: []response{{status: http.StatusOK, description: "The jobs.", body: jobsResponse{}}}},
+		{method: http.MethodGet, path: "/admin/jobs/history", id: "jobHistory", tag: "admin", summary: "Job runs, newest first.", auth: authAdmin,
+			query:     []param{{name: "job"}, limit},
+			responses: []response{{status: http.StatusOK, description: "The runs.", body: runsResponse{}}, badRequest, failed}},
+		{method: http.MethodPost, path: "/admin/jobs/:name/run", id: "runJob", tag: "admin", summary: "Run a job now.", auth: authAdmin,
+			responses: []response{started, notFound, conflict}},
+		{method: http.MethodPost, path: "/admin/jobs/:name/enable", id: "enableJob", tag: "admin", summary: "Put a job back on its schedule.", auth: authAdmin,
+			responses: []response{{status: http.StatusOK, description: "The job.", body: jobResponse{}}, notFound}},
+		{method: http.MethodPost, path: "/admin/jobs/:name/disable", id: "disableJob", tag: "admin", summary: "Take a job off its schedule until the next restart.", auth: authAdmin,
+			responses: []response{{status: http.StatusOK, description: "The job.", body: jobResponse{}}, notFound}},
+		{method: http.MethodPost, path: "/admin/reports/daily", id: "dailyReport", tag: "admin", summary: "Send the daily report of a day, or return it with send=false.", auth: authAdmin,
+			query: []param{
+				{name: "date", format: "date", description: "The day, today by default."},
+				{name: "job", description: "The daily report job, the first one by default."},
+				{name: "send", description: "false returns the report instead of sending it."},
+			},
+			responses: []response{
+				{status: http.StatusOK, description: "The report, with send=false.", body: reportResponse{}},
+				started, badRequest, notFound, conflict,
+				{status: http.StatusBadGateway, description: "The report could not be generated.", body: errorResponse{}},
+			}},
+		{method: http.MethodGet, path: "/admin/flags", id: "listFlags", tag: "admin", summary: "The feature flags in effect.", auth: authAdmin,
+			responses: []response{{status: http.StatusOK, description: "The flags.", body: flagsResponse{}}}},
+		{method: http.MethodPut, path: "/admin/flags/:name", id: "setFlag", tag: "admin", summary: "Set a feature flag on every replica.", auth: authAdmin,
+			enums: map[string][]string{"name": features},
+			body:  flagRequest{},
+			responses: []response{
+				{status: http.StatusOK, description: "The flag.", body: flagResponse{}}, badRequest,
+				{status: http.StatusNotFound, description: "Unknown feature.", body: errorResponse{}}, failed,
+			}},
+		{method: http.MethodGet, path: "/admin/sessions", id: "listSessions", tag: "admin", summary: "Conversations in progress and commands awaiting confirmation on this replica.", auth: authAdmin,
+			responses: []response{{status: http.StatusOK, description: "The sessions.", body: sessionsResponse{}}}},
+		{method: http.MethodDelete, path: "/admin/sessions/:waID", id: "resetSession", tag: "admin", summary: "Forget a number's conversation and pending command.", auth: authAdmin,
+			responses: []response{{status: http.StatusNoContent, description: "Reset."}, {status: http.StatusNotFound, description: "No session for the number.", body: errorResponse{}}}},
+		{method: http.MethodPost, path: "/admin/webhook/replay", id: "replayWebhook", tag: "admin", summary: "Process a stored message again, by default without saving or sending anything.", auth: authAdmin,
+			body:      replayRequest{},
+			responses: []response{{status: http.StatusOK, description: "The outcome and, in dry_run mode, the replies.", body: whatsapp.ReplayResult{}}, badRequest}},
+
+		{method: http.MethodGet, path: "/dashboard/", id: "dashboardPage", tag: "dashboard", summary: "The owner's dashboard page.", auth: authDashboard,
+			responses: []response{{status: http.StatusOK, description: "The page.", body: map[string]any{"type": "string"}, contentType: "text/html"}}},
+		{method: http.MethodGet, path: "/dashboard/data", id: "dashboardData", tag: "dashboard", summary: "Today's totals, the last 30 daily reports, debts and latest messages.", auth: authDashboard,
+			responses: []response{{status: http.StatusOK, description: "What the page draws.", body: dashboardData{}}, {status: http.StatusBadGateway, description: "Today's records could not be read.", body: errorResponse{}}}},
+		{method: http.MethodGet, path: "/dashboard/events", id: "dashboardEvents", tag: "dashboard", summary: "Live activity as server-sent events: ready, then message, record and job events.", auth: authDashboard,
+			responses: []response{
+				{status: http.StatusOK, description: "The event stream; each event's data is an ActivityEvent.", body: models.ActivityEvent{}, contentType: "text/event-stream"},
+				{status: http.StatusNotFound, description: "Live activity disabled.", body: errorResponse{}},
+			}},
+
+		{method: http.MethodGet, path: "/api/v1/:kind", id: "listRecords", tag: "records", summary: "Current records of a kind dated between from and to, by date.", auth: authAPI,
+			query:     []param{{name: "from", format: "date", description: "First day, 30 days before to by default."}, {name: "to", format: "date", description: "Last day, today by default."}},
+			responses: []response{{status: http.StatusOK, description: "The records.", body: recordsResponse{}}, badRequest, notFound, failed}},
+		{method: http.MethodPost, path: "/api/v1/:kind", id: "createRecord", tag: "records", summary: "Enter a record like the WhatsApp command: checked, written to Sheets and mirrored.", auth: authAPI,
+			body:      oneOf(records),
+			responses: []response{{status: http.StatusCreated, description: "The saved record and the ID of its mirrored copy.", body: savedRecordResponse{}}, badRequest, notFound, uncertain, failed}},
+		{method: http.MethodPut, path: "/api/v1/:kind/:id", id: "updateRecord", tag: "records", summary: "Overwrite the record's sheet row and store the fix as the next version of the current one.", auth: authAPI,
+			body:      oneOf(records),
+			responses: []response{{status: http.StatusOK, description: "The record and its new version's ID.", body: savedRecordResponse{}}, badRequest, notFound, rowConflict, failed}},
+		{method: http.MethodDelete, path: "/api/v1/:kind/:id", id: "voidRecord", tag: "records", summary: "Clear the record's sheet row and void its current version.", auth: authAPI,
+			responses: []response{{status: http.StatusNoContent, description: "Voided."}, notFound, rowConflict, failed}},
+	}
+}
+
+// DocumentedRoutes returns the method and path of every operation of the
+// document, for checking them against the routes router.New registers.
+func DocumentedRoutes() []gin.RouteInfo {
+	var routes []gin.RouteInfo
+	for _, op := range operations() {
+		routes = append(routes, gin.RouteInfo{Method: op.method, Path: op.path})
+	}
+	return routes
+}
+
+// oneOf marks a body as one of values, the schema of their types.
+type oneOf []any
+
+// pathParam matches the parameters of a gin path.
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIDocument builds the document of operations.
+func openAPIDocument() map[string]any {
+	schemas := &schemaSet{components: map[string]any{}, names: map[reflect.Type]string{}}
+	kinds := make([]string, 0, len(recordKinds))
+	for _, kind := range recordKinds {
+		kinds = append(kinds, string(kind))
+	}
+
+	paths := map[string]any{}
+	for _, op := range operations() {
+		path := pathParam.ReplaceAllString(op.path, "{$1}")
+		var params []any
+		for _, match := range pathParam.FindAllStringSubmatch(op.path, -1) {
+			schema := map[string]any{"type": "string"}
+			if values, ok := op.enums[match[1]]; ok {
+				schema["enum"] = values
+			} else if match[1] == "kind" {
+				schema["enum"] = kinds
+			}
+			params = append(params, map[string]any{"name": match[1], "in": "path", "required": true, "schema": schema})
+		}
+		for _, p := range op.query {
+			schema := map[string]any{"type": "string"}
+			switch p.format {
+			case "int64":
+				schema = map[string]any{"type": "integer", "format": "int64", "minimum": 1}
+			case "date":
+				schema["format"] = "date"
+			}
+			param := map[string]any{"name": p.name, "in": "query", "schema": schema}
+			if p.description != "" {
+				param["description"] = p.description
+			}
+			params = append(params, param)
+		}
+
+		responses := map[string]any{}
+		all := op.responses
+		if op.auth != "" {
+			all = append(all, response{status: http.StatusUnauthorized, description: "Missing or wrong credentials.", body: errorResponse{}})
+		}
+		if op.limited {
+			all = append(all, response{status: http.StatusTooManyRequests, description: "Rate limit of the client IP reached; see Retry-After.", body: errorResponse{}})
+		}
+		for _, r := range all {
+			answer := map[string]any{"description": r.description}
+			if r.body != nil {
+				answer["content"] = schemas.content(r.body, r.contentType)
+			}
+			responses[strconv.Itoa(r.status)] = answer
+		}
+
+		entry := map[string]any{
+			"operationId": op.id,
+			"summary":     op.summary,
+			"tags":        []string{op.tag},
+			"responses":   responses,
+		}
+		if len(params) > 0 {
+			entry["parameters"] = params
+		}
+		if op.body != nil {
+			entry["requestBody"] = map[string]any{"required": true, "content": schemas.content(op.body, "")}
+		}
+		if op.auth != "" {
+			entry["security"] = []any{map[string]any{op.auth: []string{}}}
+		}
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(op.method)] = entry
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Farmer API",
+			"version": openAPIVersion,
+			"description": "WhatsApp webhook, outbound messages, probes, admin, dashboard and records API of the Farmer backend. " +
+				"Errors answer {error, request_id}, plus details for invalid payloads; every response carries X-Request-ID. " +
+				"The admin, dashboard and records routes of the other farms of a multi-farm deployment are the same under /farms/{farm}.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				authAdmin:     map[string]any{"type": "http", "scheme": "bearer", "description": "ADMIN_API_TOKEN."},
+				authAPI:       map[string]any{"type": "http", "scheme": "bearer", "description": "One of API_TOKENS; its name is recorded as the author."},
+				authSend:      map[string]any{"type": "http", "scheme": "bearer", "description": "One of SEND_MESSAGE_TOKENS, or ADMIN_API_TOKEN."},
+				authDashboard: map[string]any{"type": "http", "scheme": "basic", "description": "User admin, ADMIN_API_TOKEN as the password."},
+			},
+		},
+	}
+}
+
+// schemaSet builds the schemas of Go types, named struct types becoming
+// components referenced by name.
+type schemaSet struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+// content is the content map of a body of value, a Go value, a ready-made
+// schema or a oneOf, as contentType (application/json when empty).
+func (s *schemaSet) content(value any, contentType string) map[string]any {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	return map[string]any{contentType: map[string]any{"schema": s.schemaOf(value)}}
+}
+
+func (s *schemaSet) schemaOf(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case oneOf:
+		var schemas []any
+		for _, item := range v {
+			schemas = append(schemas, s.schema(reflect.TypeOf(item)))
+		}
+		return map[string]any{"oneOf": schemas}
+	}
+	return s.schema(reflect.TypeOf(value))
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	bytesType      = reflect.TypeOf([]byte{})
+)
+
+// schema describes the JSON encoding of t.
+func (s *schemaSet) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	case t == bytesType:
+		return map[string]any{"type": "string", "format": "byte"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		return s.object(t)
+	}
+	// Interfaces hold any JSON value.
+	return map[string]any{}
+}
+
+// object describes a struct: inline when anonymous, otherwise as a
+// component, named after the type and, on a clash, its package.
+func (s *schemaSet) object(t reflect.Type) map[string]any {
+	if t.Name() == "" {
+		return s.properties(t)
+	}
+	name, ok := s.names[t]
+	if !ok {
+		name = componentName(t)
+		if _, taken := s.components[name]; taken {
+			pkg := t.PkgPath()
+			name = componentName(t) + "_" + pkg[strings.LastIndex(pkg, "/")+1:]
+		}
+		s.names[t] = name
+		// Registered before its fields, for types referring to themselves.
+		s.components[name] = nil
+		s.components[name] = s.properties(t)
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// componentName exports the name of t, as the handlers' types are not.
+func componentName(t reflect.Type) string {
+	return strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+}
+
+// properties describes the fields of a struct as encoding/json sees them:
+// unexported and "-" fields left out, embedded structs flattened. Fields
+// with a required binding, or tagged openapi:"required" when the handler
+// checks them itself, are required.
+func (s *schemaSet) properties(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for field := range fields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schema(field.Type)
+		if slices.Contains(strings.Split(field.Tag.Get("binding"), ","), "required") || field.Tag.Get("openapi") == "required" {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields yields the JSON fields of the struct t.
+func fields(t reflect.Type) func(yield func(reflect.StructField) bool) {
+	return func(yield func(reflect.StructField) bool) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			switch {
+			case tag == "-":
+				continue
+			case field.Anonymous && tag == "":
+				embedded := field.Type
+				if embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					for inner := range fields(embedded) {
+						if !yield(inner) {
+							return
+						}
+					}
+					continue
+				}
+			case !field.IsExported():
+				continue
+			}
+			if !yield(field) {
+				return
+			}
+		}
+	}
+}
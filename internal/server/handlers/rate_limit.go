@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit sizes the token bucket each client IP gets on a public endpoint:
+// PerMinute requests a minute, up to Burst at once. A non-positive PerMinute
+// disables the limit.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+// ipLimiter keeps one token bucket per client IP. Buckets that have filled up
+// again are dropped, so memory follows the recent clients only.
+type ipLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*ipBucket
+	burst     float64
+	interval  time.Duration // time to refill one token
+	lastSweep time.Time
+}
+
+type ipBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newIPLimiter(limit RateLimit) *ipLimiter {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return &ipLimiter{
+		buckets:   map[string]*ipBucket{},
+		burst:     float64(burst),
+		interval:  time.Minute / time.Duration(limit.PerMinute),
+		lastSweep: time.Now(),
+	}
+}
+
+// take takes a token from ip's bucket and returns the tokens left, or, when
+// the bucket is empty, how long until the next one is due.
+func (l *ipLimiter) take(ip string, now time.Time) (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > time.Minute {
+		l.sweep(now)
+	}
+	bucket, ok := l.buckets[ip]
+	if !ok {
+		bucket = &ipBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+float64(now.Sub(bucket.last))/float64(l.interval))
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return 0, time.Duration((1 - bucket.tokens) * float64(l.interval))
+	}
+	bucket.tokens--
+	return int(bucket.tokens), 0
+}
+
+// sweep drops the buckets that would be full by now.
+func (l *ipLimiter) sweep(now time.Time) {
+	full := time.Duration(l.burst * float64(l.interval))
+	for ip, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= full {
+			delete(l.buckets, ip)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Middleware limits each client IP, as gin resolves it behind the trusted
+// proxies, to limit. Every answer carries X-RateLimit-Limit and
+// X-RateLimit-Remaining; refused requests get 429 and Retry-After, in
+// seconds. Each call makes an independent set of buckets.
+func (limit RateLimit) Middleware() gin.HandlerFunc {
+	if limit.PerMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limiter := newIPLimiter(limit)
+	perMinute := strconv.Itoa(limit.PerMinute)
+	return func(c *gin.Context) {
+		remaining, wait := limiter.take(c.ClientIP(), time.Now())
+		c.Header("X-RateLimit-Limit", perMinute)
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			replyError(c, http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/internal/service/commands"
+)
+
+// RecordWriter saves, corrects and voids farm records in Sheets and their
+// mirrored copies, like the WhatsApp commands do; see
+// internal/service/commands.
+type RecordWriter interface {
+	SaveRecord(ctx context.Context, sender string, kind models.RecordKind, record interface{}) (string, error)
+	UpdateRecord(ctx context.Context, sender string, kind models.RecordKind, id string, record interface{}) (string, error)
+	VoidRecord(ctx context.Context, sender string, kind models.RecordKind, id string) error
+	Rules() commands.ValidationRules
+}
+
+// RecordStore lists the mirrored farm records.
+type RecordStore interface {
+	ListRecords(ctx context.Context, kind models.RecordKind, start, end time.Time) ([]models.RecordVersion, error)
+}
+
+// Bodies of the records API answers; see also OpenAPIHandler.
+type (
+	recordsResponse struct {
+		Records []models.RecordVersion `json:"records"`
+	}
+	// savedRecordResponse carries a saved record, dated, and the ID of its
+	// mirrored version.
+	savedRecordResponse struct {
+		ID     string      `json:"id"`
+		Record interface{} `json:"record"`
+	}
+)
+
+// defaultListDays is the period listed when the query has no from date.
+const defaultListDays = 30
+
+// RecordsHandler serves the /api/v1 farm record endpoints, for entering and
+// fixing data from a browser. Every change is made on behalf of the name of
+// the caller's token.
+type RecordsHandler struct {
+	writer   RecordWriter
+	store    RecordStore
+	tokens   TokenAuth
+	location *time.Location
+	logger   *zap.Logger
+}
+
+// NewRecordsHandler constructs the records API handler. tokens maps each
+// user's name to their bearer token; dates are read in location.
+func NewRecordsHandler(writer RecordWriter, store RecordStore, tokens map[string]string, location *time.Location, logger *zap.Logger) *RecordsHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	return &RecordsHandler{writer: writer, store: store, tokens: tokens, location: location, logger: logger}
+}
+
+// RequireToken rejects requests without one of the API_TOKENS.
+func (h *RecordsHandler) RequireToken(c *gin.Context) {
+	h.tokens.Require(c)
+}
+
+// List returns the current records of kind :kind dated between the from and
+// to query parameters (YYYY-MM-DD, both included), by date. to defaults to
+// today and from to 30 days before to.
+func (h *RecordsHandler) List(c *gin.Context) {
+	kind, ok := h.kind(c)
+	if !ok {
+		return
+	}
+
+	to := time.Now().In(h.location)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, h.location)
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, h.location)
+		if err != nil {
+			replyError(c, http.StatusBadRequest, "invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -defaultListDays)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, h.location)
+		if err != nil {
+			replyError(c, http.StatusBadRequest, "invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		replyError(c, http.StatusBadRequest, "from must not be after to")
+		return
+	}
+
+	records, err := h.store.ListRecords(c.Request.Context(), kind, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		h.recordError(c, "failed listing records", err)
+		return
+	}
+	c.JSON(http.StatusOK, recordsResponse{Records: records})
+}
+
+// Create saves the body, a record in the JSON form of kind :kind, like the
+// WhatsApp command would: validated, written to Sheets and mirrored. A
+// record without a date is dated now, or the previous day before
+// REPORT_CUTOFF_HOUR. It answers the mirrored copy's ID,
+// empty when the record was not mirrored.
+func (h *RecordsHandler) Create(c *gin.Context) {
+	kind, ok := h.kind(c)
+	if !ok {
+		return
+	}
+	record, ok := h.bindRecord(c, kind)
+	if !ok {
+		return
+	}
+
+	id, err := h.writer.SaveRecord(c.Request.Context(), Caller(c), kind, record)
+	if err != nil {
+		h.recordError(c, "failed saving record", err)
+		return
+	}
+	c.JSON(http.StatusCreated, savedRecordResponse{ID: id, Record: record})
+}
+
+// Update replaces the current version :id with the body's full record,
+// checked like a new one: its sheet row is overwritten and the mirrored copy
+// gets a new version, whose ID is answered.
+func (h *RecordsHandler) Update(c *gin.Context) {
+	kind, ok := h.kind(c)
+	if !ok {
+		return
+	}
+	record, ok := h.bindRecord(c, kind)
+	if !ok {
+		return
+	}
+
+	id, err := h.writer.UpdateRecord(c.Request.Context(), Caller(c), kind, c.Param("id"), record)
+	if err != nil {
+		h.recordError(c, "failed correcting record", err)
+		return
+	}
+	c.JSON(http.StatusOK, savedRecordResponse{ID: id, Record: record})
+}
+
+// Void clears the sheet row of the current version :id and marks the
+// version deleted; it stays in the history.
+func (h *RecordsHandler) Void(c *gin.Context) {
+	kind, ok := h.kind(c)
+	if !ok {
+		return
+	}
+	if err := h.writer.VoidRecord(c.Request.Context(), Caller(c), kind, c.Param("id")); err != nil {
+		h.recordError(c, "failed voiding record", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// kind returns the record kind of the path, answering 404 for an unknown
+// one.
+func (h *RecordsHandler) kind(c *gin.Context) (models.RecordKind, bool) {
+	kind := models.RecordKind(c.Param("kind"))
+	if _, ok := models.NewRecord(kind); !ok {
+		replyError(c, http.StatusNotFound, "unknown record kind")
+		return "", false
+	}
+	return kind, true
+}
+
+// bindRecord decodes the body into a record of kind, dated like a command
+// when it has no date. Dates after today are rejected, as by the commands.
+func (h *RecordsHandler) bindRecord(c *gin.Context, kind models.RecordKind) (interface{}, bool) {
+	record, _ := models.NewRecord(kind)
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(record); err != nil {
+		replyError(c, http.StatusBadRequest, "invalid record")
+		return nil, false
+	}
+
+	now := time.Now().In(h.location)
+	date := models.RecordDate(record)
+	switch {
+	case date.IsZero():
+		*date = h.writer.Rules().RecordDate(now)
+	case date.In(h.location).Format("2006-01-02") > now.Format("2006-01-02"):
+		replyError(c, http.StatusBadRequest, "date is in the future")
+		return nil, false
+	}
+	return record, true
+}
+
+func (h *RecordsHandler) recordError(c *gin.Context, msg string, err error) {
+	var validation *commands.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		replyError(c, http.StatusBadRequest, validation.Message)
+	case errors.Is(err, mongodb.ErrRecordNotFound):
+		replyError(c, http.StatusNotFound, "record not found")
+	case errors.Is(err, mongodb.ErrNotCurrent):
+		replyError(c, http.StatusConflict, "record is not the current version")
+	case errors.Is(err, commands.ErrRowNotFound):
+		replyError(c, http.StatusConflict, "the record's sheet row was not found, fix the sheet by hand")
+	case errors.Is(err, commands.ErrWriteUncertain):
+		replyError(c, http.StatusGatewayTimeout, "google sheets did not confirm the write, check the sheet before retrying")
+	default:
+		requestLogger(c, h.logger).Error(msg, zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "unable to process record")
+	}
+}
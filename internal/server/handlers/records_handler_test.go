@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/internal/service/commands"
+)
+
+// fakeRecordWriter records the last correction or void and answers err.
+type fakeRecordWriter struct {
+	err    error
+	sender string
+	kind   models.RecordKind
+	id     string
+	record interface{}
+}
+
+func (f *fakeRecordWriter) SaveRecord(ctx context.Context, sender string, kind models.RecordKind, record interface{}) (string, error) {
+	return "", f.err
+}
+
+func (f *fakeRecordWriter) UpdateRecord(ctx context.Context, sender string, kind models.RecordKind, id string, record interface{}) (string, error) {
+	f.sender, f.kind, f.id, f.record = sender, kind, id, record
+	if f.err != nil {
+		return "", f.err
+	}
+	return "v2", nil
+}
+
+func (f *fakeRecordWriter) VoidRecord(ctx context.Context, sender string, kind models.RecordKind, id string) error {
+	f.sender, f.kind, f.id = sender, kind, id
+	return f.err
+}
+
+func (f *fakeRecordWriter) Rules() commands.ValidationRules {
+	return commands.ValidationRules{}
+}
+
+func serveRecords(writer RecordWriter, method, path, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	handler := NewRecordsHandler(writer, nil, map[string]string{"office": "secret"}, time.UTC, nil)
+	engine := gin.New()
+	api := engine.Group("/api/v1", handler.RequireToken)
+	api.PUT("/:kind/:id", handler.Update)
+	api.DELETE("/:kind/:id", handler.Void)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRecordsUpdateCorrectsOnBehalfOfTheCaller(t *testing.T) {
+	writer := &fakeRecordWriter{}
+	rec := serveRecords(writer, http.MethodPut, "/api/v1/sales/v1",
+		`{"date": "2026-10-15T12:00:00Z", "client": "Binta", "quantity": 10, "price_per_unit": 45000, "paid": 450000}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var body savedRecordResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.ID != "v2" {
+		t.Errorf("body = %s, want the new version's ID", rec.Body)
+	}
+	sale, ok := writer.record.(*models.SaleRecord)
+	if writer.sender != "office" || writer.kind != models.RecordSales || writer.id != "v1" || !ok || sale.Paid != 450000 {
+		t.Errorf("UpdateRecord(%q, %q, %q, %+v), want office's fix of sales v1", writer.sender, writer.kind, writer.id, writer.record)
+	}
+}
+
+func TestRecordsUpdateRejectsUnknownFields(t *testing.T) {
+	writer := &fakeRecordWriter{}
+	rec := serveRecords(writer, http.MethodPut, "/api/v1/sales/v1", `{"client": "Binta", "quantity": 10, "total": 1}`)
+
+	if rec.Code != http.StatusBadRequest || writer.id != "" {
+		t.Errorf("status = %d with writer called for %q, want 400 before any write", rec.Code, writer.id)
+	}
+}
+
+func TestRecordsVoidVoidsOnBehalfOfTheCaller(t *testing.T) {
+	writer := &fakeRecordWriter{}
+	rec := serveRecords(writer, http.MethodDelete, "/api/v1/eggs/v1", "")
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if writer.sender != "office" || writer.kind != models.RecordEggs || writer.id != "v1" {
+		t.Errorf("VoidRecord(%q, %q, %q), want office voiding eggs v1", writer.sender, writer.kind, writer.id)
+	}
+}
+
+func TestRecordsChangesAnswerTheWriterErrors(t *testing.T) {
+	body := `{"date": "2026-10-15T12:00:00Z", "client": "Binta", "quantity": 10, "price_per_unit": 45000}`
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"unknown id", mongodb.ErrRecordNotFound, http.StatusNotFound},
+		{"superseded", mongodb.ErrNotCurrent, http.StatusConflict},
+		{"row gone", commands.ErrRowNotFound, http.StatusConflict},
+		{"unconfirmed", commands.ErrWriteUncertain, http.StatusGatewayTimeout},
+		{"invalid", &commands.ValidationError{Field: "price", Message: "Price too low."}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		for _, method := range []string{http.MethodPut, http.MethodDelete} {
+			rec := serveRecords(&fakeRecordWriter{err: tc.err}, method, "/api/v1/sales/v1", body)
+			if rec.Code != tc.status {
+				t.Errorf("%s %s: status = %d, want %d", tc.name, method, rec.Code, tc.status)
+			}
+		}
+	}
+}
+
+func TestRecordsChangesOfUnknownKindsAreNotFound(t *testing.T) {
+	writer := &fakeRecordWriter{}
+	rec := serveRecords(writer, http.MethodDelete, "/api/v1/flock/v1", "")
+	if rec.Code != http.StatusNotFound || writer.id != "" {
+		t.Errorf("status = %d with writer called for %q, want 404", rec.Code, writer.id)
+	}
+}
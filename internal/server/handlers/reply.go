@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// errorResponse is the body of every error answer. Details lists the
+// problems of an invalid payload.
+type errorResponse struct {
+	Error     string              `json:"error"`
+	Details   []models.FieldError `json:"details,omitempty"`
+	RequestID string              `json:"request_id"`
+}
+
+// replyError answers {"error": message, "request_id": ...} and stops the
+// chain. The request ID is the one of the request's log lines, so a caller
+// reporting the error can quote it.
+func replyError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, errorResponse{Error: message, RequestID: logger.RequestID(c.Request.Context())})
+}
+
+// replyInvalid answers 400 with the problems found in the body:
+// {"error": "invalid payload", "details": [{"field": ..., "message": ...}],
+// "request_id": ...}.
+func replyInvalid(c *gin.Context, problems []models.FieldError) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse{
+		Error:     "invalid payload",
+		Details:   problems,
+		RequestID: logger.RequestID(c.Request.Context()),
+	})
+}
+
+// requestLogger returns base with the request's ID, see logger.FromContext.
+func requestLogger(c *gin.Context, base *zap.Logger) *zap.Logger {
+	return logger.FromContext(c.Request.Context(), base)
+}
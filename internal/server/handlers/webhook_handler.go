@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,18 +14,29 @@ import (
 	service "github.com/mamadbah2/farmer/internal/service/whatsapp"
 )
 
+// maxWebhookBody bounds the webhook body; Meta's notifications weigh a few
+// KB.
+const maxWebhookBody = 1 << 20
+
+// maxSendBody bounds the /send-message body, which may carry a file in
+// base64; larger files are sent by link.
+const maxSendBody = 16 << 20
+
 // WebhookHandler handles inbound and outbound WhatsApp HTTP events.
 type WebhookHandler struct {
-	svc    service.MessagingService
-	logger *zap.Logger
+	svc        service.MessagingService
+	sendTokens TokenAuth
+	logger     *zap.Logger
 }
 
-// NewWebhookHandler constructs the HTTP handler adapter.
-func NewWebhookHandler(svc service.MessagingService, logger *zap.Logger) *WebhookHandler {
+// NewWebhookHandler constructs the HTTP handler adapter. sendTokens maps the
+// callers allowed to send messages through /send-message to their bearer
+// token; without any the endpoint refuses every request.
+func NewWebhookHandler(svc service.MessagingService, sendTokens map[string]string, logger *zap.Logger) *WebhookHandler {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &WebhookHandler{svc: svc, logger: logger}
+	return &WebhookHandler{svc: svc, sendTokens: sendTokens, logger: logger}
 }
 
 // Verify responds to Meta's webhook verification challenge.
@@ -32,7 +47,7 @@ func (h *WebhookHandler) Verify(c *gin.Context) {
 
 	resp, err := h.svc.VerifyWebhookToken(mode, token, challenge)
 	if err != nil {
-		h.logger.Warn("webhook verification failed", zap.Error(err))
+		requestLogger(c, h.logger).Warn("webhook verification failed", zap.Error(err))
 		c.String(http.StatusForbidden, "verification failed")
 		return
 	}
@@ -40,38 +55,96 @@ func (h *WebhookHandler) Verify(c *gin.Context) {
 	c.String(http.StatusOK, resp)
 }
 
-// Receive ingests webhook POST callbacks from Meta.
+// Receive ingests webhook POST callbacks from Meta. The body must be JSON of
+// at most 1 MiB (415 and 413 otherwise) and pass
+// models.WebhookPayload.Validate; malformed payloads are answered 400 with
+// the problems found, see replyInvalid.
 func (h *WebhookHandler) Receive(c *gin.Context) {
+	if c.ContentType() != "application/json" {
+		replyError(c, http.StatusUnsupportedMediaType, "content type must be application/json")
+		return
+	}
+	if c.Request.ContentLength > maxWebhookBody {
+		replyError(c, http.StatusRequestEntityTooLarge, "payload too large")
+		return
+	}
+
 	var payload models.WebhookPayload
-	if err := c.ShouldBindJSON(&payload); err != nil {
-		h.logger.Warn("invalid webhook payload", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody)
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			replyError(c, http.StatusRequestEntityTooLarge, "payload too large")
+			return
+		}
+		requestLogger(c, h.logger).Warn("invalid webhook payload", zap.Error(err))
+		replyInvalid(c, []models.FieldError{decodeProblem(err)})
+		return
+	}
+	if problems := payload.Validate(); len(problems) > 0 {
+		requestLogger(c, h.logger).Warn("invalid webhook payload", zap.Any("problems", problems))
+		replyInvalid(c, problems)
 		return
 	}
 
 	if err := h.svc.HandleWebhook(c.Request.Context(), payload); err != nil {
-		h.logger.Error("failed processing webhook", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process webhook"})
+		requestLogger(c, h.logger).Error("failed processing webhook", zap.Error(err))
+		replyError(c, http.StatusInternalServerError, "failed to process webhook")
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
-// SendMessage allows sending outbound automation or manual responses.
+// RequireSendToken rejects requests without one of the send tokens.
+func (h *WebhookHandler) RequireSendToken(c *gin.Context) {
+	h.sendTokens.Require(c)
+}
+
+// SendMessage allows sending outbound automation or manual responses: text,
+// templates, images and documents. Requests failing
+// models.OutboundMessageRequest.Validate are answered 400 with the problems.
 func (h *WebhookHandler) SendMessage(c *gin.Context) {
 	var req models.OutboundMessageRequest
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSendBody)
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("invalid outbound payload", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			replyError(c, http.StatusRequestEntityTooLarge, "payload too large")
+			return
+		}
+		requestLogger(c, h.logger).Warn("invalid outbound payload", zap.Error(err))
+		replyError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if problems := req.Validate(); len(problems) > 0 {
+		replyInvalid(c, problems)
 		return
 	}
 
 	if err := h.svc.SendOutbound(c.Request.Context(), req); err != nil {
-		h.logger.Error("failed sending outbound", zap.Error(err))
-		c.JSON(http.StatusBadGateway, gin.H{"error": "unable to send message"})
+		requestLogger(c, h.logger).Error("failed sending outbound", zap.String("caller", Caller(c)), zap.Error(err))
+		replyError(c, http.StatusBadGateway, "unable to send message")
 		return
 	}
+	requestLogger(c, h.logger).Info("outbound message sent", zap.String("caller", Caller(c)), zap.String("to", req.To), zap.String("type", string(req.Type)))
 
 	c.Status(http.StatusAccepted)
 }
+
+// decodeProblem describes a JSON decoding error as a field error.
+func decodeProblem(err error) models.FieldError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return models.FieldError{Message: "body is empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return models.FieldError{Message: "JSON ends early"}
+	case errors.As(err, &syntaxErr):
+		return models.FieldError{Message: fmt.Sprintf("malformed JSON at byte %d", syntaxErr.Offset)}
+	case errors.As(err, &typeErr):
+		return models.FieldError{Field: typeErr.Field, Message: "has the wrong type: got a JSON " + typeErr.Value}
+	}
+	return models.FieldError{Message: "malformed JSON"}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	service "github.com/mamadbah2/farmer/internal/service/whatsapp"
+)
+
+// fakeMessaging records the payloads handed to the service.
+type fakeMessaging struct {
+	service.MessagingService
+	payloads []models.WebhookPayload
+}
+
+func (f *fakeMessaging) HandleWebhook(ctx context.Context, payload models.WebhookPayload) error {
+	f.payloads = append(f.payloads, payload)
+	return nil
+}
+
+func postWebhook(svc service.MessagingService, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/webhook", NewWebhookHandler(svc, nil, nil).Receive)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestReceiveAcceptsTheDocumentedPayload posts the README example, which has
+// no changes[].field.
+func TestReceiveAcceptsTheDocumentedPayload(t *testing.T) {
+	svc := &fakeMessaging{}
+	rec := postWebhook(svc, `{
+  "object": "whatsapp_business_account",
+  "entry": [
+    {
+      "id": "123",
+      "changes": [
+        {
+          "value": {
+            "messages": [
+              {
+                "from": "2348012345678",
+                "id": "wamid.HBg",
+                "timestamp": "1732025600",
+                "type": "text",
+                "text": { "body": "/eggs 120 trays" }
+              }
+            ]
+          }
+        }
+      ]
+    }
+  ]
+}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if len(svc.payloads) != 1 || svc.payloads[0].Entry[0].Changes[0].Value.Messages[0].Text.Body != "/eggs 120 trays" {
+		t.Errorf("payloads = %+v, want the message handed to the service", svc.payloads)
+	}
+}
+
+func TestReceiveRejectsMessagesWithoutSender(t *testing.T) {
+	svc := &fakeMessaging{}
+	rec := postWebhook(svc, `{"object": "whatsapp_business_account", "entry": [{"changes": [{"value": {"messages": [{"id": "wamid.HBg", "type": "text", "text": {"body": "/eggs 120"}}]}}]}]}`)
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "entry[0].changes[0].value.messages[0].from") {
+		t.Errorf("status = %d, body %s, want 400 naming the sender", rec.Code, rec.Body)
+	}
+	if len(svc.payloads) != 0 {
+		t.Errorf("the invalid payload reached the service")
+	}
+}
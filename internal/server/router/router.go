@@ -1,36 +1,167 @@
 package router
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"github.com/mamadbah2/farmer/internal/server/handlers"
+	"github.com/mamadbah2/farmer/pkg/logger"
 )
 
-// New wires the Gin engine with required routes and middlewares.
-func New(handler *handlers.WebhookHandler, logger *zap.Logger) *gin.Engine {
+// requestIDHeader carries the request ID, both ways.
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID bounds the IDs accepted from callers, so a proxy's ID is
+// kept but nothing odd reaches the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Farm holds the handlers of another farm of the deployment, served under
+// /farms/<ID>. Nil handlers leave their routes out, as for New.
+type Farm struct {
+	ID        string
+	Admin     *handlers.AdminHandler
+	Records   *handlers.RecordsHandler
+	Dashboard *handlers.DashboardHandler
+}
+
+// New wires the Gin engine with required routes and middlewares. Admin,
+// dashboard and records API routes are only registered when their handler
+// is non-nil; those of the other farms are under /farms/<ID>.
+// /webhook and /send-message are each limited per client IP to limit.
+func New(handler *handlers.WebhookHandler, health *handlers.HealthHandler, admin *handlers.AdminHandler, records *handlers.RecordsHandler, dashboard *handlers.DashboardHandler, farms []Farm, limit handlers.RateLimit, logger *zap.Logger) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(requestIDMiddleware())
+	r.Use(tracingMiddleware())
 	r.Use(zapLoggerMiddleware(logger))
 
-	r.GET("/webhook", handler.Verify)
-	r.POST("/webhook", handler.Receive)
-	r.POST("/send-message", handler.SendMessage)
-	r.GET("/healthz", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	webhookLimit := limit.Middleware()
+	r.GET("/webhook", webhookLimit, handler.Verify)
+	r.POST("/webhook", webhookLimit, handler.Receive)
+	r.POST("/send-message", limit.Middleware(), handler.RequireSendToken, handler.SendMessage)
+	r.GET("/healthz", health.Live)
+	r.GET("/readyz", health.Ready)
+	r.GET("/openapi.json", handlers.NewOpenAPIHandler().Spec)
+
+	farmRoutes(&r.RouterGroup, admin, records, dashboard)
+	for _, farm := range farms {
+		farmRoutes(r.Group("/farms/"+farm.ID), farm.Admin, farm.Records, farm.Dashboard)
+	}
 
 	if logger != nil {
-		logger.Info("router initialized")
+		logger.Info("router initialized", zap.Int("farms", len(farms)+1))
 	}
 
 	return r
 }
 
+// farmRoutes registers the admin, dashboard and records API routes of a
+// farm on r.
+func farmRoutes(r *gin.RouterGroup, admin *handlers.AdminHandler, records *handlers.RecordsHandler, dashboard *handlers.DashboardHandler) {
+	if admin != nil {
+		adminGroup := r.Group("/admin", admin.RequireToken)
+		adminGroup.GET("/audit", admin.ListAudits)
+		adminGroup.GET("/messages", admin.ListMessages)
+		adminGroup.GET("/stock", admin.ListStock)
+		adminGroup.GET("/records/:kind/:id/history", admin.RecordHistory)
+		adminGroup.PUT("/records/:kind/:id", admin.CorrectRecord)
+		adminGroup.DELETE("/records/:kind/:id", admin.DeleteRecord)
+		adminGroup.POST("/backup", admin.StartBackup)
+		adminGroup.GET("/jobs", admin.ListJobs)
+		adminGroup.GET("/jobs/history", admin.JobHistory)
+		adminGroup.POST("/jobs/:name/run", admin.RunJob)
+		adminGroup.POST("/jobs/:name/enable", admin.EnableJob)
+		adminGroup.POST("/jobs/:name/disable", admin.DisableJob)
+		adminGroup.POST("/reports/daily", admin.DailyReport)
+		adminGroup.GET("/flags", admin.ListFlags)
+		adminGroup.PUT("/flags/:name", admin.SetFlag)
+		adminGroup.GET("/sessions", admin.ListSessions)
+		adminGroup.DELETE("/sessions/:waID", admin.ResetSession)
+		adminGroup.POST("/webhook/replay", admin.ReplayWebhook)
+	}
+
+	if dashboard != nil {
+		dashboardGroup := r.Group("/dashboard", dashboard.RequireLogin)
+		dashboardGroup.GET("/", dashboard.Page)
+		dashboardGroup.GET("/data", dashboard.Data)
+		dashboardGroup.GET("/events", dashboard.Events)
+		dashboardGroup.GET("/assets/*filepath", dashboard.Asset)
+	}
+
+	if records != nil {
+		apiGroup := r.Group("/api/v1", records.RequireToken)
+		apiGroup.GET("/:kind", records.List)
+		apiGroup.POST("/:kind", records.Create)
+		apiGroup.PUT("/:kind/:id", records.Update)
+		apiGroup.DELETE("/:kind/:id", records.Void)
+	}
+}
+
+// requestIDMiddleware keeps the caller's X-Request-ID, or assigns a random
+// one, echoes it in the response and hands it down through the request
+// context, where logger.FromContext and the error replies pick it up.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// newRequestID returns 16 random hex characters.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// tracingMiddleware starts the server span of every request, continuing the
+// trace of the caller's traceparent header, and hands it down through the
+// request context.
+func tracingMiddleware() gin.HandlerFunc {
+	tracer := otel.Tracer("github.com/mamadbah2/farmer/internal/server")
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+			))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
 func zapLoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 	if logger == nil {
 		logger = zap.NewNop()
@@ -40,11 +171,17 @@ func zapLoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		start := time.Now()
 		c.Next()
 
-		logger.Info("request completed",
+		fields := []zap.Field{
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
 			zap.Int("status", c.Writer.Status()),
 			zap.Duration("duration", time.Since(start)),
-			zap.String("client_ip", c.ClientIP()))
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("request_id", c.Writer.Header().Get(requestIDHeader)),
+		}
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsSampled() {
+			fields = append(fields, zap.String("trace_id", span.TraceID().String()))
+		}
+		logger.Info("request completed", fields...)
 	}
 }
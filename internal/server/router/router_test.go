@@ -0,0 +1,41 @@
+package router
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mamadbah2/farmer/internal/server/handlers"
+)
+
+// TestOpenAPIDocumentsEveryRoute keeps /openapi.json in step with New: every
+// route but the document itself and the dashboard assets is documented, and
+// nothing else is.
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	engine := New(&handlers.WebhookHandler{}, &handlers.HealthHandler{}, &handlers.AdminHandler{}, &handlers.RecordsHandler{}, &handlers.DashboardHandler{}, nil, handlers.RateLimit{}, nil)
+
+	var registered []string
+	for _, route := range engine.Routes() {
+		if route.Path == "/openapi.json" || strings.HasPrefix(route.Path, "/dashboard/assets/") {
+			continue
+		}
+		registered = append(registered, route.Method+" "+route.Path)
+	}
+	var documented []string
+	for _, route := range handlers.DocumentedRoutes() {
+		documented = append(documented, route.Method+" "+route.Path)
+	}
+	slices.Sort(registered)
+	slices.Sort(documented)
+
+	for _, route := range registered {
+		if !slices.Contains(documented, route) {
+			t.Errorf("route %s is not documented in /openapi.json", route)
+		}
+	}
+	for _, route := range documented {
+		if !slices.Contains(registered, route) {
+			t.Errorf("/openapi.json documents %s, which New does not register", route)
+		}
+	}
+}
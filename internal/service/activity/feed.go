@@ -0,0 +1,84 @@
+package activity
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// subscriberBuffer is the number of events a subscriber may lag behind
+// before further events are dropped for it.
+const subscriberBuffer = 64
+
+// Feed fans the activity of this process out to its live subscribers, e.g.
+// the dashboard's event streams. Nothing is kept: a subscriber only sees the
+// events published while it is subscribed.
+type Feed struct {
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan models.ActivityEvent]struct{}
+	closed      bool
+}
+
+// NewFeed returns a feed without subscribers.
+func NewFeed(logger *zap.Logger) *Feed {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Feed{logger: logger, subscribers: map[chan models.ActivityEvent]struct{}{}}
+}
+
+// Publish hands an event of kind eventType to every subscriber. It never
+// blocks: a subscriber too slow to keep up misses the event.
+func (f *Feed) Publish(eventType string, data interface{}) {
+	event := models.ActivityEvent{Type: eventType, At: time.Now().UTC(), Data: data}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for events := range f.subscribers {
+		select {
+		case events <- event:
+		default:
+			f.logger.Warn("activity subscriber lagging, event dropped", zap.String("type", eventType))
+		}
+	}
+}
+
+// Subscribe returns the channel receiving the events published from now on
+// and the function ending the subscription. The channel is closed when the
+// subscription ends or the feed is closed.
+func (f *Feed) Subscribe() (<-chan models.ActivityEvent, func()) {
+	events := make(chan models.ActivityEvent, subscriberBuffer)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		close(events)
+		return events, func() {}
+	}
+	f.subscribers[events] = struct{}{}
+
+	return events, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subscribers[events]; ok {
+			delete(f.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// Close ends every subscription, so the streams reading them finish, e.g.
+// when the HTTP server shuts down.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for events := range f.subscribers {
+		delete(f.subscribers, events)
+		close(events)
+	}
+}
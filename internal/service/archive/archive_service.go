@@ -0,0 +1,165 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+// archivableTabs are the tabs only ever read over bounded periods. Tabs whose
+// whole history feeds a balance or a stock (Feed, FeedStock, Mortality,
+// Population, Transfers, Sales, Payments, Prices, Flock) must stay complete.
+var archivableTabs = map[string]bool{
+	"Eggs":         true,
+	"Expenses":     true,
+	"Receptions":   true,
+	"Vaccinations": true,
+	"StateStock":   true,
+}
+
+// Service moves old rows of the hot tabs into monthly archive tabs such as
+// "Eggs_2025-01", keeping the tabs read by reports small.
+type Service struct {
+	repo        sheets.Repository
+	tabs        []sheets.TabLayout
+	afterMonths int
+	now         func() time.Time
+	logger      *zap.Logger
+}
+
+// NewService builds the archiver for the configured tabs, named by their
+// default names and found through layout. It rejects tabs that are unknown or
+// whose full history is needed by reports. Months are those of location,
+// the farm's timezone (UTC when nil).
+func NewService(repository sheets.Repository, layout sheets.Layout, cfg config.ArchiveConfig, location *time.Location, logger *zap.Logger) (*Service, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	layouts := make(map[string]sheets.TabLayout)
+	for _, tab := range sheets.NewEntities(repository, layout).Layouts() {
+		layouts[tab.Title] = tab
+	}
+	tabs := make([]sheets.TabLayout, 0, len(cfg.Tabs))
+	for _, title := range cfg.Tabs {
+		if !archivableTabs[title] {
+			return nil, fmt.Errorf("tab %s cannot be archived (archivable: Eggs, Expenses, Receptions, Vaccinations, StateStock)", title)
+		}
+		tabs = append(tabs, layouts[layout.Title(title)])
+	}
+
+	return &Service{
+		repo:        repository,
+		tabs:        tabs,
+		afterMonths: cfg.AfterMonths,
+		now:         func() time.Time { return time.Now().In(location) },
+		logger:      logger,
+	}, nil
+}
+
+// Cutoff returns the first day kept in the hot tabs: the first of the month
+// afterMonths months before the month of now.
+func (s *Service) Cutoff(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month()-time.Month(s.afterMonths), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Run archives the rows dated before the cutoff in every configured tab and
+// returns how many rows moved. A failing tab does not stop the others.
+func (s *Service) Run(ctx context.Context) (int, error) {
+	cutoff := s.Cutoff(s.now())
+	moved := 0
+	var errs []error
+	for _, tab := range s.tabs {
+		n, err := s.archiveTab(ctx, tab, cutoff)
+		moved += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("archive %s: %w", tab.Title, err))
+			continue
+		}
+		if n > 0 {
+			s.logger.Info("rows archived", zap.String("tab", tab.Title), zap.Int("rows", n), zap.Time("cutoff", cutoff))
+		}
+	}
+	return moved, errors.Join(errs...)
+}
+
+// archiveGroup is the rows of one workbook tab going to one archive tab.
+type archiveGroup struct {
+	source  string // tab title, qualified with its workbook when routed
+	archive string
+	rows    []int
+	values  [][]interface{}
+}
+
+// archiveTab appends the old rows to their monthly archive tabs, then deletes
+// them from the hot tab. Rows are only deleted once their archive append
+// succeeded, so a failure never loses data (at worst a row is in both tabs).
+func (s *Service) archiveTab(ctx context.Context, tab sheets.TabLayout, cutoff time.Time) (int, error) {
+	lastColumn := string(rune('A' + len(tab.Headers) - 1))
+	matches, err := s.repo.FindRows(ctx, fmt.Sprintf("%s!A:%s", tab.Title, lastColumn), sheets.RowQuery{Before: cutoff})
+	if err != nil {
+		return 0, err
+	}
+	if len(matches) == 0 {
+		return 0, nil
+	}
+
+	var order []string
+	groups := make(map[string]*archiveGroup)
+	for _, match := range matches {
+		// Keep the workbook qualifier of routed ranges, e.g. "[2025]Eggs".
+		source := match.Range[:strings.LastIndex(match.Range, "!")]
+		archive := fmt.Sprintf("%s_%s", source, match.Date.Format("2006-01"))
+		group, ok := groups[archive]
+		if !ok {
+			group = &archiveGroup{source: source, archive: archive}
+			groups[archive] = group
+			order = append(order, archive)
+		}
+		group.rows = append(group.rows, match.Row)
+		group.values = append(group.values, match.Values)
+	}
+
+	layouts := make([]sheets.TabLayout, len(order))
+	for i, archive := range order {
+		layouts[i] = sheets.TabLayout{Title: archive, Headers: tab.Headers, Mapped: tab.Mapped}
+	}
+	if err := s.repo.EnsureTabs(ctx, layouts); err != nil {
+		return 0, err
+	}
+
+	archived := make(map[string][]int)
+	var sources []string
+	var errs []error
+	for _, archive := range order {
+		group := groups[archive]
+		if _, err := s.repo.AppendRows(ctx, fmt.Sprintf("%s!A:%s", archive, lastColumn), group.values); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, ok := archived[group.source]; !ok {
+			sources = append(sources, group.source)
+		}
+		archived[group.source] = append(archived[group.source], group.rows...)
+	}
+
+	moved := 0
+	for _, source := range sources {
+		if err := s.repo.DeleteRows(ctx, source, archived[source]); err != nil {
+			errs = append(errs, fmt.Errorf("rows copied to the archive but not removed: %w", err))
+			continue
+		}
+		moved += len(archived[source])
+	}
+	return moved, errors.Join(errs...)
+}
@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+// ErrRunning reports a backup requested while another one is in progress.
+var ErrRunning = errors.New("a backup is already running")
+
+// archivePrefix starts the name of every archive; pruning relies on it.
+const archivePrefix = "farmer-backup-"
+
+// Destination stores finished archives somewhere other than the cluster.
+type Destination interface {
+	// Store saves the archive under name and returns where it went.
+	Store(ctx context.Context, name string, archive io.Reader) (string, error)
+}
+
+// Result describes one backup.
+type Result struct {
+	Name string `json:"name"`
+	// Documents counts the documents exported per collection.
+	Documents map[string]int `json:"documents"`
+	// Locations lists where the archive was stored.
+	Locations []string `json:"locations"`
+}
+
+// Service exports the Mongo collections into a gzipped tar archive holding
+// one <collection>.jsonl file each, and hands it to every destination, so the
+// farm's data survives the loss of the cluster.
+type Service struct {
+	store        mongodb.Repository
+	destinations []Destination
+	running      sync.Mutex
+	now          func() time.Time
+	logger       *zap.Logger
+}
+
+// NewService builds the backup job storing archives in destinations.
+func NewService(store mongodb.Repository, destinations []Destination, logger *zap.Logger) *Service {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Service{
+		store:        store,
+		destinations: destinations,
+		now:          time.Now,
+		logger:       logger,
+	}
+}
+
+// Run exports every collection and stores the archive. A collection failing
+// to export aborts the backup, as a partial archive would be mistaken for a
+// complete one; a failing destination does not stop the others.
+func (s *Service) Run(ctx context.Context) (Result, error) {
+	if !s.running.TryLock() {
+		return Result{}, ErrRunning
+	}
+	defer s.running.Unlock()
+
+	result := Result{
+		Name:      archivePrefix + s.now().UTC().Format("20060102-150405") + ".tar.gz",
+		Documents: make(map[string]int),
+	}
+
+	archive, err := os.CreateTemp("", archivePrefix+"*.tar.gz")
+	if err != nil {
+		return result, fmt.Errorf("create backup archive: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := s.writeArchive(ctx, archive, result.Documents); err != nil {
+		return result, err
+	}
+
+	var errs []error
+	for _, destination := range s.destinations {
+		if _, err := archive.Seek(0, io.SeekStart); err != nil {
+			return result, fmt.Errorf("rewind backup archive: %w", err)
+		}
+		location, err := destination.Store(ctx, result.Name, archive)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Locations = append(result.Locations, location)
+	}
+	if len(result.Locations) > 0 {
+		s.logger.Info("backup stored", zap.String("name", result.Name), zap.Strings("locations", result.Locations))
+	}
+	return result, errors.Join(errs...)
+}
+
+// writeArchive exports the collections into w, counting their documents.
+func (s *Service) writeArchive(ctx context.Context, w io.Writer, documents map[string]int) error {
+	compressed := gzip.NewWriter(w)
+	files := tar.NewWriter(compressed)
+	modified := s.now()
+
+	for _, name := range s.store.BackupCollections() {
+		// tar needs the size up front; the collections are small enough to
+		// be exported in memory one at a time.
+		var export bytes.Buffer
+		count, err := s.store.ExportCollection(ctx, name, &export)
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name:    name + ".jsonl",
+			Mode:    0o600,
+			Size:    int64(export.Len()),
+			ModTime: modified,
+		}
+		if err := files.WriteHeader(header); err != nil {
+			return fmt.Errorf("write backup archive: %w", err)
+		}
+		if _, err := io.Copy(files, &export); err != nil {
+			return fmt.Errorf("write backup archive: %w", err)
+		}
+		documents[name] = count
+	}
+
+	if err := files.Close(); err != nil {
+		return fmt.Errorf("write backup archive: %w", err)
+	}
+	if err := compressed.Close(); err != nil {
+		return fmt.Errorf("write backup archive: %w", err)
+	}
+	return nil
+}
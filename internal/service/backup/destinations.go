@@ -0,0 +1,139 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	driveapi "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+// LocalDestination writes archives to a directory, keeping the most recent
+// ones only.
+type LocalDestination struct {
+	dir  string
+	keep int
+}
+
+// NewLocalDestination stores archives in dir, creating it if needed. Only the
+// keep most recent archives are kept; 0 keeps them all.
+func NewLocalDestination(dir string, keep int) (*LocalDestination, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create backup directory %s: %w", dir, err)
+	}
+	return &LocalDestination{dir: dir, keep: keep}, nil
+}
+
+// Store writes the archive under a temporary name first, so an interrupted
+// copy never looks like a complete archive, then prunes the oldest ones.
+func (d *LocalDestination) Store(ctx context.Context, name string, archive io.Reader) (string, error) {
+	path := filepath.Join(d.dir, name)
+	file, err := os.CreateTemp(d.dir, ".partial-"+name)
+	if err != nil {
+		return "", fmt.Errorf("store backup locally: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, archive); err != nil {
+		file.Close()
+		return "", fmt.Errorf("store backup locally: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("store backup locally: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", fmt.Errorf("store backup locally: %w", err)
+	}
+
+	if err := d.prune(); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// prune removes the archives beyond the keep most recent. Archive names end
+// with their UTC timestamp, so they sort chronologically.
+func (d *LocalDestination) prune() error {
+	if d.keep <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return fmt.Errorf("prune backups: %w", err)
+	}
+	var archives []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), archivePrefix) && strings.HasSuffix(entry.Name(), ".tar.gz") {
+			archives = append(archives, entry.Name())
+		}
+	}
+	if len(archives) <= d.keep {
+		return nil
+	}
+	sort.Strings(archives)
+	for _, name := range archives[:len(archives)-d.keep] {
+		if err := os.Remove(filepath.Join(d.dir, name)); err != nil {
+			return fmt.Errorf("prune backups: %w", err)
+		}
+	}
+	return nil
+}
+
+// DriveDestination uploads archives to a Google Drive folder.
+type DriveDestination struct {
+	files    *driveapi.FilesService
+	folderID string
+}
+
+// NewDriveDestination uploads to folderID with the Sheets credentials. With
+// a service account the folder must sit in a shared drive the account is a
+// member of: service accounts have no storage quota, so Drive refuses their
+// uploads to a My Drive folder, even one shared with them. In oauth mode the
+// folder may be in the user's My Drive, and the refresh token must have been
+// issued with the drive.file scope.
+func NewDriveDestination(ctx context.Context, cfg config.SheetsConfig, folderID string) (*DriveDestination, error) {
+	service, err := driveapi.NewService(ctx, sheets.ClientCredentials(ctx, cfg), option.WithScopes(driveapi.DriveFileScope))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create drive service: %w", err)
+	}
+	return &DriveDestination{files: service.Files, folderID: folderID}, nil
+}
+
+// Store uploads the archive and returns its Drive file ID.
+func (d *DriveDestination) Store(ctx context.Context, name string, archive io.Reader) (string, error) {
+	file := &driveapi.File{Name: name, Parents: []string{d.folderID}, MimeType: "application/gzip"}
+	created, err := d.files.Create(file).Media(archive).SupportsAllDrives(true).Fields("id").Context(ctx).Do()
+	if quotaExceeded(err) {
+		return "", fmt.Errorf("upload backup to drive: %w (a service account can only upload to a shared drive folder)", err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("upload backup to drive: %w", err)
+	}
+	return "drive:" + created.Id, nil
+}
+
+// quotaExceeded reports whether err is Drive refusing an upload for lack of
+// storage quota, as it does for a service account writing to My Drive.
+func quotaExceeded(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "storageQuotaExceeded" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// auditCommand persists the outcome of a handled command to the command_audit
+// collection. Failures are logged only; auditing never blocks a worker's entry.
+func (s *Service) auditCommand(ctx context.Context, cmd models.Command, sender string, refs []recordRef, cmdErr error) {
+	if s.mongoRepo == nil {
+		return
+	}
+
+	entry := models.CommandAuditEntry{
+		Sender:     sender,
+		Role:       cmd.Role,
+		Command:    string(cmd.Type),
+		Raw:        cmd.Raw,
+		Args:       cmd.Args,
+		RecordRefs: refStrings(refs),
+		Success:    cmdErr == nil,
+		CreatedAt:  s.now().UTC(),
+	}
+	if cmdErr != nil {
+		entry.Error = cmdErr.Error()
+	}
+
+	// The audit must land even when the request context was cancelled mid-way.
+	if err := s.mongoRepo.SaveCommandAudit(context.WithoutCancel(ctx), entry); err != nil {
+		logger.FromContext(ctx, s.logger).Error("failed to save command audit", zap.Error(err), zap.String("command", entry.Command), zap.String("sender", sender))
+	}
+}
+
+func refStrings(refs []recordRef) []string {
+	var out []string
+	for _, ref := range refs {
+		if ref.SheetRange != "" {
+			out = append(out, ref.SheetRange)
+		}
+		if ref.StockID != "" {
+			out = append(out, "stock_items/"+ref.StockID)
+		}
+		if ref.RecordID != "" {
+			out = append(out, string(ref.RecordKind)+"/"+ref.RecordID)
+		}
+	}
+	return out
+}
@@ -4,372 +4,259 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"github.com/mamadbah2/farmer/internal/domain/models"
 	"github.com/mamadbah2/farmer/internal/repository/mongodb"
 	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+	"github.com/mamadbah2/farmer/pkg/logger"
+	"github.com/mamadbah2/farmer/pkg/tracing"
 )
 
+var tracer = otel.Tracer("github.com/mamadbah2/farmer/internal/service/commands")
+
 // ErrInvalidArguments indicates the command payload could not be parsed.
 var ErrInvalidArguments = errors.New("invalid command arguments")
 
 // ErrUnsupportedCommand indicates we do not yet support the requested command.
 var ErrUnsupportedCommand = errors.New("unsupported command")
 
-const (
-	eggsWriteRange         = "Eggs!A:F"
-	feedWriteRange         = "Feed!A:C"
-	mortalityWriteRange    = "Mortality!A:D"
-	salesWriteRange        = "Sales!A:E"
-	expenseWriteRange      = "Expenses!A:E"
-	stateStockWriteRange   = "StateStock!A:E"
-	eggReceptionWriteRange = "EggReception!A:C"
-	dateFormat             = "02/01/2006"
-)
+// ErrSchemaDrift is returned when a sheet's header row no longer matches the
+// columns the dispatcher writes; nothing is stored until the sheet is fixed.
+var ErrSchemaDrift = repo.ErrSchemaDrift
+
+// ErrWriteUncertain is returned when Sheets failed after it may have written
+// the rows; sending the command again could log it twice.
+var ErrWriteUncertain = repo.ErrWriteUncertain
+
+// ErrRowNotFound is returned when the sheet row of a mirrored record to
+// correct or void is no longer in its tab.
+var ErrRowNotFound = repo.ErrRowNotFound
+
+// ErrUnauthorized indicates the sender's role may not use the command.
+var ErrUnauthorized = errors.New("command not allowed for sender role")
+
+const dateFormat = "02/01/2006"
 
 // ReportingAdapter defines the reporting functions required by the dispatcher.
 type ReportingAdapter interface {
+	GenerateDailyReport(ctx context.Context, reportDate time.Time) (string, error)
+	GenerateWeeklyReport(ctx context.Context, referenceDate time.Time) (string, error)
+	GenerateMonthlyReport(ctx context.Context, referenceDate time.Time) (string, error)
 	CalculateEggsSummary(ctx context.Context, start, end time.Time) (string, error)
 	CalculateMortalityRate(ctx context.Context, start, end time.Time) (string, error)
 	CalculateFeedEfficiency(ctx context.Context, start, end time.Time) (string, error)
+	CalculateFeedRunway(ctx context.Context, asOf time.Time) (string, error)
 }
 
 // Dispatcher executes parsed commands and persists the structured payloads.
 type Dispatcher interface {
 	HandleCommand(ctx context.Context, cmd models.Command, sender string) (string, error)
+	HandleBatch(ctx context.Context, cmds []models.Command, sender string) (string, error)
 	SaveEggsRecord(ctx context.Context, record models.EggRecord) error
 	SaveFeedRecord(ctx context.Context, record models.FeedRecord) error
+	SaveFeedDeliveryRecord(ctx context.Context, record models.FeedDeliveryRecord) error
 	SaveMortalityRecord(ctx context.Context, record models.MortalityRecord) error
 	SaveSaleRecord(ctx context.Context, record models.SaleRecord) error
+	SavePaymentRecord(ctx context.Context, record models.PaymentRecord) error
 	SaveExpenseRecord(ctx context.Context, record models.ExpenseRecord) error
 	SaveStateStockRecord(ctx context.Context, record models.StateStockRecord) error
 	SaveEggReceptionRecord(ctx context.Context, record models.EggReceptionRecord) error
+	SaveVaccinationRecord(ctx context.Context, record models.VaccinationRecord) error
+	SaveEggPriceRecord(ctx context.Context, record models.EggPriceRecord) error
+	LatestEggPrice(ctx context.Context) (float64, bool, error)
+	Rules() ValidationRules
+	Now() time.Time
+	SaveUnit(ctx context.Context, sender, entity string, save func(ctx context.Context) error) error
+}
+
+// Flags tells whether a feature is on for a sender; see
+// internal/service/flags.
+type Flags interface {
+	Enabled(feature models.Feature, user string) bool
 }
 
 // Service implements the Dispatcher interface.
 type Service struct {
 	repo      repo.Repository
+	records   *repo.Entities
 	mongoRepo mongodb.Repository
 	reporting ReportingAdapter
-	logger    *zap.Logger
-	now       func() time.Time
+	flags     Flags
+	// rules is replaced as a whole by Reload.
+	rules  atomic.Pointer[ValidationRules]
+	undo   *undoStore
+	logger *zap.Logger
+	now    func() time.Time
 }
 
-// NewService constructs a command dispatcher.
-func NewService(repository repo.Repository, mongoRepo mongodb.Repository, reporting ReportingAdapter, logger *zap.Logger) *Service {
+// NewService constructs a command dispatcher writing to the tabs of layout.
+// Without flags, every feature keeps its default: records are mirrored.
+// Records are dated in location, the farm's timezone (UTC when nil).
+func NewService(repository repo.Repository, layout repo.Layout, mongoRepo mongodb.Repository, reporting ReportingAdapter, rules ValidationRules, flags Flags, location *time.Location, logger *zap.Logger) *Service {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &Service{
-		repo:      repository,
+	if location == nil {
+		location = time.UTC
+	}
+	tracked := trackedRepository{Repository: repository}
+	svc := &Service{
+		repo:      tracked,
+		records:   repo.NewEntities(tracked, layout),
 		mongoRepo: mongoRepo,
 		reporting: reporting,
+		flags:     flags,
+		undo:      newUndoStore(),
 		logger:    logger,
-		now:       time.Now,
+		now:       func() time.Time { return time.Now().In(location) },
 	}
+	svc.rules.Store(&rules)
+	return svc
 }
 
-// HandleCommand converts the command to its record representation and persists it.
-func (s *Service) HandleCommand(ctx context.Context, cmd models.Command, sender string) (string, error) {
-	normalizedNow := s.now().UTC()
-	startOfWeek := mondayStart(normalizedNow)
-
-	s.logger.Debug("dispatching command", zap.String("command", string(cmd.Type)), zap.String("sender", sender), zap.Any("args", cmd.Args))
-
-	switch cmd.Type {
-	case models.CommandEggs:
-		record, err := s.buildEggRecord(cmd, normalizedNow)
-		if err != nil {
-			return "", err
-		}
-		if err := s.SaveEggsRecord(ctx, record); err != nil {
-			return "", err
-		}
-		summary := s.safeSummary(ctx, func(ctx context.Context) (string, error) {
-			if s.reporting == nil {
-				return "", nil
-			}
-			return s.reporting.CalculateEggsSummary(ctx, startOfWeek, normalizedNow)
-		})
-		message := fmt.Sprintf("Egg record saved for %s with %d eggs.", record.Date.Format(dateFormat), record.Quantity)
-		if summary != "" {
-			message += "\n" + summary
-		}
-		return message, nil
-	case models.CommandFeed:
-		record, err := s.buildFeedRecord(cmd, normalizedNow)
-		if err != nil {
-			return "", err
-		}
-		if err := s.SaveFeedRecord(ctx, record); err != nil {
-			return "", err
-		}
-		summary := s.safeSummary(ctx, func(ctx context.Context) (string, error) {
-			if s.reporting == nil {
-				return "", nil
-			}
-			return s.reporting.CalculateFeedEfficiency(ctx, startOfWeek, normalizedNow)
-		})
-		message := fmt.Sprintf("Feed usage saved for %s: %.2f kg.", record.Date.Format(dateFormat), record.FeedKg)
-		if record.Population > 0 {
-			message += fmt.Sprintf(" Population %d birds.", record.Population)
-		}
-		if summary != "" {
-			message += "\n" + summary
-		}
-		return message, nil
-	case models.CommandMortality:
-		record, err := s.buildMortalityRecord(cmd, normalizedNow)
-		if err != nil {
-			return "", err
-		}
-		if err := s.SaveMortalityRecord(ctx, record); err != nil {
-			return "", err
-		}
-		summary := s.safeSummary(ctx, func(ctx context.Context) (string, error) {
-			if s.reporting == nil {
-				return "", nil
-			}
-			return s.reporting.CalculateMortalityRate(ctx, startOfWeek, normalizedNow)
-		})
-		message := fmt.Sprintf("Mortality logged for %s: B1:%d, B2:%d, B3:%d.", record.Date.Format(dateFormat), record.Band1, record.Band2, record.Band3)
-		if summary != "" {
-			message += "\n" + summary
-		}
-		return message, nil
-	case models.CommandSales:
-		record, err := s.buildSaleRecord(cmd, normalizedNow)
-		if err != nil {
-			return "", err
-		}
-		if err := s.SaveSaleRecord(ctx, record); err != nil {
-			return "", err
-		}
-		total := float64(record.Quantity) * record.PricePerUnit
-		message := fmt.Sprintf("Sale recorded for %s: %d units @ %.2f (expected %.2f, paid %.2f).", record.Client, record.Quantity, record.PricePerUnit, total, record.Paid)
-		return message, nil
-	case models.CommandExpenses:
-		record, err := s.buildExpenseRecord(cmd, normalizedNow)
-		if err != nil {
-			return "", err
-		}
-		if err := s.SaveExpenseRecord(ctx, record); err != nil {
-			return "", err
-		}
-		message := fmt.Sprintf("Expense logged: %s %.2f on %s.", record.Category, record.Amount, record.Date.Format(dateFormat))
-		return message, nil
-	default:
-		return "", ErrUnsupportedCommand
-	}
+// Reload applies new validation rules to the commands handled from now on.
+func (s *Service) Reload(rules ValidationRules) {
+	s.rules.Store(&rules)
 }
 
-// SaveEggsRecord persists an egg record to Google Sheets.
-func (s *Service) SaveEggsRecord(ctx context.Context, record models.EggRecord) error {
-	values := []interface{}{
-		record.Date.Format(dateFormat),
-		record.Band1,
-		record.Band2,
-		record.Band3,
-		record.Quantity,
-		record.Notes,
+// enabled reports whether feature is on for sender.
+func (s *Service) enabled(feature models.Feature, sender string) bool {
+	if s.flags == nil {
+		return models.Features[feature]
 	}
-	return s.repo.WriteRow(ctx, eggsWriteRange, values)
-}
-
-// SaveFeedRecord persists feed consumption data.
-func (s *Service) SaveFeedRecord(ctx context.Context, record models.FeedRecord) error {
-	values := []interface{}{record.Date.Format(dateFormat), record.FeedKg, record.Population}
-	return s.repo.WriteRow(ctx, feedWriteRange, values)
+	return s.flags.Enabled(feature, sender)
 }
 
-// SaveMortalityRecord persists mortality data.
-func (s *Service) SaveMortalityRecord(ctx context.Context, record models.MortalityRecord) error {
-	values := []interface{}{record.Date.Format(dateFormat), record.Band1, record.Band2, record.Band3}
-	return s.repo.WriteRow(ctx, mortalityWriteRange, values)
+// Rules returns the validation rules in force, for the records built
+// outside the dispatcher.
+func (s *Service) Rules() ValidationRules {
+	return *s.rules.Load()
 }
 
-// SaveSaleRecord persists sales transactions.
-func (s *Service) SaveSaleRecord(ctx context.Context, record models.SaleRecord) error {
-	values := []interface{}{record.Date.Format(dateFormat), record.Client, record.Quantity, record.PricePerUnit, record.Paid}
-	return s.repo.WriteRow(ctx, salesWriteRange, values)
+// Now returns the current time in the farm's timezone.
+func (s *Service) Now() time.Time {
+	return s.now()
 }
 
-// SaveExpenseRecord appends a new expense entry to the sheet.
-func (s *Service) SaveExpenseRecord(ctx context.Context, record models.ExpenseRecord) error {
-	values := []interface{}{
-		record.Date.Format(dateFormat),
-		record.Category,
-		record.Quantity,
-		record.UnitPrice,
-		record.Notes,
-	}
-	return s.repo.WriteRow(ctx, expenseWriteRange, values)
-}
-// SaveStateStockRecord appends a new stock entry to the sheet.
-func (s *Service) SaveStateStockRecord(ctx context.Context, record models.StateStockRecord) error {
-	values := []interface{}{
-		record.Date.Format(dateFormat),
-		record.ItemName,
-		record.Quantity,
-		record.UnitPrice,
-		record.Condition,
-	}
-	if err := s.repo.WriteRow(ctx, stateStockWriteRange, values); err != nil {
-		return fmt.Errorf("write to sheets: %w", err)
-	}
-
-	if s.mongoRepo != nil {
-		if err := s.mongoRepo.SaveStockItem(ctx, record); err != nil {
-			s.logger.Error("failed to save stock item to mongodb", zap.Error(err))
-			// Don't fail the operation if mongo fails, as sheet is primary for now?
-			// Or maybe we should log and continue.
-		}
-		return nil
-	}
-	return s.repo.WriteRow(ctx, stateStockWriteRange, values)
-}
-
-
-// SaveEggReceptionRecord persists egg reception data.
-func (s *Service) SaveEggReceptionRecord(ctx context.Context, record models.EggReceptionRecord) error {
-	values := []interface{}{record.Date.Format(dateFormat), record.Quantity, record.UnitPrice}
-	return s.repo.WriteRow(ctx, eggReceptionWriteRange, values)
-}
-
-func (s *Service) buildEggRecord(cmd models.Command, now time.Time) (models.EggRecord, error) {
-	if len(cmd.Args) < 3 {
-		return models.EggRecord{}, errors.New("requires 3 arguments: band1 band2 band3")
-	}
-
-	b1, err1 := strconv.Atoi(cmd.Args[0])
-	b2, err2 := strconv.Atoi(cmd.Args[1])
-	b3, err3 := strconv.Atoi(cmd.Args[2])
-
-	if err1 != nil || err2 != nil || err3 != nil {
-		return models.EggRecord{}, ErrInvalidArguments
-	}
-
-	notes := ""
-	if len(cmd.Args) > 3 {
-		notes = strings.Join(cmd.Args[3:], " ")
+// HandleCommand checks the sender's role against the command registry, converts
+// the command to its record representation and persists it. The rows written
+// are remembered per sender so /undo can void them, and every outcome is
+// recorded in the command audit log.
+func (s *Service) HandleCommand(ctx context.Context, cmd models.Command, sender string) (string, error) {
+	ctx, span := tracer.Start(ctx, "command "+string(cmd.Type))
+	ctx, tracker := withWriteTracker(ctx)
+	message, err := s.authorizeAndDispatch(ctx, cmd, sender)
+	tracing.End(span, err)
+	refs := tracker.snapshot()
+	s.auditCommand(ctx, cmd, sender, refs, err)
+	if err != nil {
+		return "", err
 	}
-
-	total := b1 + b2 + b3
-
-	return models.EggRecord{
-		Date:     now,
-		Band1:    b1,
-		Band2:    b2,
-		Band3:    b3,
-		Quantity: total,
-		Notes:    notes,
-	}, nil
+	s.undo.remember(sender, string(cmd.Type), refs)
+	return message, nil
 }
 
-func (s *Service) buildFeedRecord(cmd models.Command, now time.Time) (models.FeedRecord, error) {
-	if len(cmd.Args) == 0 {
-		return models.FeedRecord{}, ErrInvalidArguments
+// HandleBatch processes several commands sent in one message (one per line)
+// and returns a consolidated confirmation. Failing lines do not stop the
+// others; /undo afterwards voids every row written by the batch.
+func (s *Service) HandleBatch(ctx context.Context, cmds []models.Command, sender string) (string, error) {
+	if len(cmds) == 0 {
+		return "", ErrInvalidArguments
 	}
 
-	feedKg, err := strconv.ParseFloat(cmd.Args[0], 64)
-	if err != nil {
-		return models.FeedRecord{}, ErrInvalidArguments
+	// Rows are queued while the lines are handled and written with one append
+	// per sheet tab once every line has run.
+	ctx, span := tracer.Start(ctx, "command batch", trace.WithAttributes(attribute.Int("command.count", len(cmds))))
+	defer span.End()
+	bufferCtx, buffer := withRowBuffer(ctx)
+	messages := make([]string, len(cmds))
+	errs := make([]error, len(cmds))
+	lineRefs := make([][]recordRef, len(cmds))
+	for i, cmd := range cmds {
+		buffer.startLine(i)
+		lineCtx, tracker := withWriteTracker(bufferCtx)
+		messages[i], errs[i] = s.authorizeAndDispatch(lineCtx, cmd, sender)
+		lineRefs[i] = tracker.snapshot()
 	}
-
-	population := 0
-	if len(cmd.Args) > 1 {
-		pop, err := strconv.Atoi(cmd.Args[1])
+	written, failures := s.flushRows(ctx, buffer)
+	mirrored := s.flushMirrors(ctx, buffer, failures)
+
+	var batchRefs []recordRef
+	lines := make([]string, 0, len(cmds))
+	succeeded := 0
+	for i, cmd := range cmds {
+		label := strings.TrimSpace(cmd.Raw)
+		refs := append(append(lineRefs[i], written[i]...), mirrored[i]...)
+		batchRefs = append(batchRefs, refs...)
+		err := errs[i]
 		if err == nil {
-			population = pop
+			err = failures[i]
 		}
+		s.auditCommand(ctx, cmd, sender, refs, err)
+		if err != nil {
+			logger.FromContext(ctx, s.logger).Warn("batch line failed", zap.Error(err), zap.String("line", label))
+			reason := err.Error()
+			var validationErr *ValidationError
+			var confirmErr *ConfirmationRequiredError
+			switch {
+			case errors.As(err, &validationErr):
+				reason = validationErr.Message
+			case errors.As(err, &confirmErr):
+				reason = "needs confirmation, send it as a single message"
+			case errors.Is(err, ErrSchemaDrift):
+				reason = "the spreadsheet columns changed, ask an admin to restore the headers"
+			case errors.Is(err, ErrWriteUncertain):
+				reason = "Google Sheets did not confirm it, check the sheet before sending it again"
+			case errors.Is(err, ErrUnsupportedCommand):
+				if suggestion := SuggestionFor(cmd); suggestion != "" {
+					reason = suggestion
+				}
+			}
+			lines = append(lines, fmt.Sprintf("❌ %s: %s", label, reason))
+			continue
+		}
+		succeeded++
+		lines = append(lines, "✅ "+messages[i])
 	}
 
-	return models.FeedRecord{Date: now, FeedKg: feedKg, Population: population}, nil
+	s.undo.remember(sender, "batch", batchRefs)
+	header := fmt.Sprintf("Batch processed: %d/%d lines saved.", succeeded, len(cmds))
+	return header + "\n" + strings.Join(lines, "\n"), nil
 }
 
-func (s *Service) buildMortalityRecord(cmd models.Command, now time.Time) (models.MortalityRecord, error) {
-	if len(cmd.Args) < 3 {
-		return models.MortalityRecord{}, errors.New("requires 3 arguments: band1 band2 band3")
+func (s *Service) authorizeAndDispatch(ctx context.Context, cmd models.Command, sender string) (string, error) {
+	spec, ok := models.LookupCommand(string(cmd.Type))
+	if ok && !spec.AllowedFor(cmd.Role) {
+		logger.FromContext(ctx, s.logger).Warn("command rejected for role", zap.String("command", string(cmd.Type)), zap.String("sender", sender), zap.String("role", string(cmd.Role)))
+		return "", ErrUnauthorized
 	}
-
-	b1, err1 := strconv.Atoi(cmd.Args[0])
-	b2, err2 := strconv.Atoi(cmd.Args[1])
-	b3, err3 := strconv.Atoi(cmd.Args[2])
-
-	if err1 != nil || err2 != nil || err3 != nil {
-		return models.MortalityRecord{}, ErrInvalidArguments
-	}
-
-	return models.MortalityRecord{
-		Date:  now,
-		Band1: b1,
-		Band2: b2,
-		Band3: b3,
-	}, nil
+	return s.dispatch(ctx, cmd, sender)
 }
 
-func (s *Service) buildSaleRecord(cmd models.Command, now time.Time) (models.SaleRecord, error) {
-	if len(cmd.Args) < 2 {
-		return models.SaleRecord{}, ErrInvalidArguments
-	}
+func (s *Service) dispatch(ctx context.Context, cmd models.Command, sender string) (string, error) {
+	original := cmd
 
-	quantity, err := strconv.Atoi(cmd.Args[0])
+	// Records default to now but may be backfilled with date:YYYY-MM-DD or hier.
+	cmd, normalizedNow, err := extractDateOverride(cmd, s.rules.Load().RecordDate(s.now()))
 	if err != nil {
-		return models.SaleRecord{}, ErrInvalidArguments
-	}
-
-	pricePerUnit, err := strconv.ParseFloat(cmd.Args[1], 64)
-	if err != nil {
-		return models.SaleRecord{}, ErrInvalidArguments
-	}
-
-	paid := float64(quantity) * pricePerUnit
-	idx := 2
-	if len(cmd.Args) > 2 {
-		if v, err := strconv.ParseFloat(cmd.Args[2], 64); err == nil {
-			paid = v
-			idx = 3
-		}
+		return "", err
 	}
 
-	client := "Walk-in"
-	if len(cmd.Args) > idx {
-		client = strings.Join(cmd.Args[idx:], " ")
-	}
-
-	return models.SaleRecord{
-		Date:         now,
-		Client:       client,
-		Quantity:     quantity,
-		PricePerUnit: pricePerUnit,
-		Paid:         paid,
-	}, nil
-}
-
-func (s *Service) buildExpenseRecord(cmd models.Command, now time.Time) (models.ExpenseRecord, error) {
-	if len(cmd.Args) < 2 {
-		return models.ExpenseRecord{}, ErrInvalidArguments
-	}
+	logger.FromContext(ctx, s.logger).Debug("dispatching command", zap.String("command", string(cmd.Type)), zap.String("sender", sender), zap.Any("args", cmd.Args))
 
-	amount, err := strconv.ParseFloat(cmd.Args[0], 64)
-	if err != nil {
-		return models.ExpenseRecord{}, ErrInvalidArguments
+	handle, ok := handlerFor(cmd.Type)
+	if !ok {
+		return "", ErrUnsupportedCommand
 	}
-
-	label := strings.Join(cmd.Args[1:], " ")
-	return models.ExpenseRecord{
-		Date:      now,
-		Category:  label,
-		Quantity:  1,
-		UnitPrice: amount,
-		Amount:    amount,
-		Notes:     "Via Command",
-	}, nil
+	return handle(s, ctx, commandRequest{Cmd: cmd, Original: original, Sender: sender, Now: normalizedNow})
 }
 
 func (s *Service) safeSummary(ctx context.Context, fn func(context.Context) (string, error)) string {
@@ -379,7 +266,7 @@ func (s *Service) safeSummary(ctx context.Context, fn func(context.Context) (str
 
 	summary, err := fn(ctx)
 	if err != nil {
-		s.logger.Debug("analytics summary failed", zap.Error(err))
+		logger.FromContext(ctx, s.logger).Debug("analytics summary failed", zap.Error(err))
 		return ""
 	}
 
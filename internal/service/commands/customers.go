@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+var clientCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandClient,
+		Aliases:     []string{"clients", "customer"},
+		Usage:       "/client add Mamadou 622123456 50000 pays monthly",
+		Description: "Register a customer (name, phone, default tray price, notes); /client list or /client <name> to look up",
+		Roles:       []models.Role{models.RoleSeller},
+	},
+	Handle: (*Service).handleClient,
+}
+
+func (s *Service) handleClient(ctx context.Context, req commandRequest) (string, error) {
+	if s.mongoRepo == nil {
+		return "", ErrUnsupportedCommand
+	}
+
+	args := req.Cmd.Args
+	if len(args) == 0 {
+		return "", ErrInvalidArguments
+	}
+
+	switch args[0] {
+	case "add", "ajout", "ajouter", "nouveau":
+		customer, err := parseCustomer(args[1:])
+		if err != nil {
+			return "", err
+		}
+		customer.CreatedBy = req.Sender
+		customer.CreatedAt = s.now().UTC()
+		if err := s.mongoRepo.SaveCustomer(ctx, customer); err != nil {
+			return "", fmt.Errorf("save customer: %w", err)
+		}
+		return "Customer saved: " + s.formatCustomer(customer), nil
+	case "list", "liste":
+		customers, err := s.mongoRepo.ListCustomers(ctx)
+		if err != nil {
+			return "", fmt.Errorf("list customers: %w", err)
+		}
+		if len(customers) == 0 {
+			return "No customers registered yet. Send /client add <name> <phone> [price].", nil
+		}
+		lines := make([]string, 0, len(customers)+1)
+		lines = append(lines, fmt.Sprintf("👥 %d customers:", len(customers)))
+		for _, c := range customers {
+			lines = append(lines, "• "+s.formatCustomer(c))
+		}
+		return strings.Join(lines, "\n"), nil
+	default:
+		customer, ok := s.matchCustomer(ctx, strings.Join(args, " "))
+		if !ok {
+			return fmt.Sprintf("No customer matches %q. Send /client list to see them.", strings.Join(args, " ")), nil
+		}
+		return s.formatCustomer(customer), nil
+	}
+}
+
+// parseCustomer reads "<name words> [phone] [default price] [notes...]". The
+// name ends at the first number; a number of 8+ digits is taken as the phone.
+func parseCustomer(args []string) (models.Customer, error) {
+	nameEnd := len(args)
+	for i, arg := range args {
+		if _, err := strconv.ParseFloat(arg, 64); err == nil {
+			nameEnd = i
+			break
+		}
+	}
+	if nameEnd == 0 {
+		return models.Customer{}, ErrInvalidArguments
+	}
+
+	customer := models.Customer{Name: displayName(args[:nameEnd])}
+	customer.NameKey = models.CustomerKey(customer.Name)
+
+	rest := args[nameEnd:]
+	if len(rest) > 0 && len(strings.TrimPrefix(rest[0], "+")) >= 8 {
+		customer.Phone = rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		if price, err := strconv.ParseFloat(rest[0], 64); err == nil {
+			if price < 0 {
+				return models.Customer{}, ErrInvalidArguments
+			}
+			customer.DefaultPrice = price
+			rest = rest[1:]
+		}
+	}
+	customer.Notes = strings.Join(rest, " ")
+	return customer, nil
+}
+
+// matchCustomer finds the registered customer for a free-form name: an exact
+// normalized match first, then a unique prefix ("mamadou" for "Mamadou Diallo").
+func (s *Service) matchCustomer(ctx context.Context, name string) (models.Customer, bool) {
+	key := models.CustomerKey(name)
+	if s.mongoRepo == nil || key == "" {
+		return models.Customer{}, false
+	}
+
+	customers, err := s.mongoRepo.ListCustomers(ctx)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Warn("customer lookup failed", zap.Error(err))
+		return models.Customer{}, false
+	}
+
+	var candidates []models.Customer
+	for _, c := range customers {
+		if c.NameKey == key {
+			return c, true
+		}
+		if strings.HasPrefix(c.NameKey, key+" ") {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	return models.Customer{}, false
+}
+
+func (s *Service) formatCustomer(c models.Customer) string {
+	parts := []string{c.Name}
+	if c.Phone != "" {
+		parts = append(parts, "📞 "+c.Phone)
+	}
+	if c.DefaultPrice > 0 {
+		parts = append(parts, s.money(c.DefaultPrice)+"/tray")
+	}
+	if c.Notes != "" {
+		parts = append(parts, c.Notes)
+	}
+	return strings.Join(parts, " — ")
+}
+
+// displayName capitalizes each word; command args arrive lower-cased.
+func displayName(words []string) string {
+	out := make([]string, len(words))
+	for i, w := range words {
+		r := []rune(w)
+		out[i] = strings.ToUpper(string(r[:1])) + string(r[1:])
+	}
+	return strings.Join(out, " ")
+}
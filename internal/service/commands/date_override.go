@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// ErrFutureDate indicates a date override pointing after today.
+var ErrFutureDate = errors.New("date is in the future")
+
+// extractDateOverride removes a `date:YYYY-MM-DD` (or DD/MM/YYYY) token or a
+// relative keyword (`hier`, `avant-hier`, `yesterday`) from the command args
+// and returns the resulting record date. Without override, now is returned.
+func extractDateOverride(cmd models.Command, now time.Time) (models.Command, time.Time, error) {
+	date := now
+	found := false
+	args := make([]string, 0, len(cmd.Args))
+
+	for _, arg := range cmd.Args {
+		if found {
+			args = append(args, arg)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(arg, "date:"):
+			parsed, ok := parseSheetDate(strings.TrimPrefix(arg, "date:"))
+			if !ok {
+				return cmd, now, ErrInvalidArguments
+			}
+			date = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
+			found = true
+		case arg == "hier" || arg == "yesterday":
+			date = now.AddDate(0, 0, -1)
+			found = true
+		case arg == "avant-hier":
+			date = now.AddDate(0, 0, -2)
+			found = true
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	if date.After(now) && !sameDay(date, now) {
+		return cmd, now, ErrFutureDate
+	}
+
+	cmd.Args = args
+	return cmd, date, nil
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var debtsCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandDebts,
+		Aliases:     []string{"debts", "credits"},
+		Usage:       "/dettes",
+		Description: "Clients who still owe money, largest balance first",
+		Roles:       []models.Role{models.RoleSeller},
+	},
+	Handle: func(s *Service, ctx context.Context, req commandRequest) (string, error) {
+		return s.buildDebts(ctx)
+	},
+}
+
+// debtsListLimit bounds the clients listed in a /dettes reply; the total
+// still covers every client.
+const debtsListLimit = 20
+
+// buildDebts lists the outstanding client balances from the Mongo ledger.
+func (s *Service) buildDebts(ctx context.Context) (string, error) {
+	if s.mongoRepo == nil {
+		return "", fmt.Errorf("mongodb repository not initialized")
+	}
+	balances, err := s.mongoRepo.GetClientBalances(ctx)
+	if err != nil {
+		return "", fmt.Errorf("load client balances: %w", err)
+	}
+	if len(balances) == 0 {
+		return "No outstanding debts.", nil
+	}
+
+	var total float64
+	lines := make([]string, 0, min(len(balances), debtsListLimit)+2)
+	for i, balance := range balances {
+		total += balance.Balance
+		if i >= debtsListLimit {
+			continue
+		}
+		line := fmt.Sprintf("- %s: %s", balance.Client, s.money(balance.Balance))
+		if !balance.OldestUnpaid.IsZero() {
+			line += fmt.Sprintf(" (since %s)", balance.OldestUnpaid.Format(dateFormat))
+		}
+		lines = append(lines, line)
+	}
+	if len(balances) > debtsListLimit {
+		lines = append(lines, fmt.Sprintf("… and %d more", len(balances)-debtsListLimit))
+	}
+	header := fmt.Sprintf("Outstanding debts: %s across %d clients.", s.money(total), len(balances))
+	return header + "\n" + strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+var eggsCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandEggs,
+		Aliases:     []string{"oeufs", "œufs", "ponte"},
+		Usage:       "/eggs 120 130 110",
+		Description: "Egg collection for Band1 Band2 Band3",
+		Roles:       []models.Role{models.RoleFarmer},
+	},
+	Handle: (*Service).handleEggs,
+}
+
+func (s *Service) handleEggs(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildEggRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SaveEggsRecord(ctx, record); err != nil {
+		return "", err
+	}
+	summary := s.safeSummary(ctx, func(ctx context.Context) (string, error) {
+		if s.reporting == nil {
+			return "", nil
+		}
+		return s.reporting.CalculateEggsSummary(ctx, mondayStart(req.Now), req.Now)
+	})
+	message := fmt.Sprintf("Egg record saved for %s with %d eggs.", record.Date.Format(dateFormat), record.Quantity)
+	if record.Band1+record.Band2+record.Band3 > 0 {
+		message = fmt.Sprintf("Egg record saved for %s with %d eggs (B1:%d, B2:%d, B3:%d).", record.Date.Format(dateFormat), record.Quantity, record.Band1, record.Band2, record.Band3)
+	}
+	if summary != "" {
+		message += "\n" + summary
+	}
+	return message, nil
+}
+
+// SaveEggsRecord persists an egg record to Google Sheets.
+func (s *Service) SaveEggsRecord(ctx context.Context, record models.EggRecord) error {
+	if err := s.validateEggRecord(ctx, record); err != nil {
+		return err
+	}
+	if _, err := s.records.Eggs.Append(ctx, record); err != nil {
+		return err
+	}
+	s.mirrorRecord(ctx, models.RecordEggs, func(store mongodb.Repository) (string, error) {
+		return store.SaveEggRecord(ctx, record)
+	})
+	return nil
+}
+
+func (s *Service) buildEggRecord(cmd models.Command, now time.Time) (models.EggRecord, error) {
+	// Up to three leading quantities are read as Band1 Band2 Band3; a single
+	// one is treated as the day's total without a band breakdown. Each may
+	// carry a unit: "12 alvéoles" or "12alv" counts 360 eggs.
+	var counts []int
+	next := 0
+	for len(counts) < 3 {
+		v, consumed, ok := readQuantity(cmd.Args, next, eggUnits(s.eggsPerTray()))
+		if !ok {
+			break
+		}
+		counts = append(counts, int(math.Round(v)))
+		next += consumed
+	}
+	if len(counts) == 0 {
+		return models.EggRecord{}, ErrInvalidArguments
+	}
+
+	notes := strings.Join(cmd.Args[next:], " ")
+
+	record := models.EggRecord{Date: now, Notes: notes}
+	if len(counts) == 1 {
+		record.Quantity = counts[0]
+		return record, nil
+	}
+
+	bands := []*int{&record.Band1, &record.Band2, &record.Band3}
+	for i, v := range counts {
+		*bands[i] = v
+		record.Quantity += v
+	}
+	return record, nil
+}
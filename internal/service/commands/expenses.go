@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+var expensesCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandExpenses,
+		Aliases:     []string{"depenses", "dépenses", "depense", "dépense"},
+		Usage:       "/expenses 75000 vaccines",
+		Description: "Expense amount and label",
+		Roles:       []models.Role{models.RoleExpenseManager},
+	},
+	Handle: (*Service).handleExpenses,
+}
+
+func (s *Service) handleExpenses(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildExpenseRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.requireConfirmation(req.Original, record.Amount, fmt.Sprintf("expense %s of %s", record.Category, s.money(record.Amount))); err != nil {
+		return "", err
+	}
+	if err := s.SaveExpenseRecord(ctx, record); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Expense logged: %s %.2f on %s.", record.Category, record.Amount, record.Date.Format(dateFormat)), nil
+}
+
+// SaveExpenseRecord appends a new expense entry to the sheet. The category
+// is normalized to the expense taxonomy so reports can group on it.
+func (s *Service) SaveExpenseRecord(ctx context.Context, record models.ExpenseRecord) error {
+	record = s.normalizeExpense(record)
+	if err := s.validateExpenseRecord(record); err != nil {
+		return err
+	}
+	if _, err := s.records.Expenses.Append(ctx, record); err != nil {
+		return err
+	}
+	s.mirrorRecord(ctx, models.RecordExpenses, func(store mongodb.Repository) (string, error) {
+		return store.SaveExpenseRecord(ctx, record)
+	})
+	return nil
+}
+
+func (s *Service) buildExpenseRecord(cmd models.Command, now time.Time) (models.ExpenseRecord, error) {
+	if len(cmd.Args) < 2 {
+		return models.ExpenseRecord{}, ErrInvalidArguments
+	}
+
+	amount, err := strconv.ParseFloat(cmd.Args[0], 64)
+	if err != nil {
+		return models.ExpenseRecord{}, ErrInvalidArguments
+	}
+
+	label := strings.Join(cmd.Args[1:], " ")
+	return s.normalizeExpense(models.ExpenseRecord{
+		Date:      now,
+		Category:  label,
+		Quantity:  1,
+		UnitPrice: amount,
+		Amount:    amount,
+		Notes:     "Via Command",
+	}), nil
+}
+
+// normalizeExpense replaces a free-text category with its taxonomy category.
+// The original label is kept in the notes so no detail is lost. Records whose
+// category is already in the taxonomy are returned unchanged.
+func (s *Service) normalizeExpense(record models.ExpenseRecord) models.ExpenseRecord {
+	label := strings.TrimSpace(record.Category)
+	category, ok := models.NormalizeExpenseCategory(label)
+	if !ok {
+		s.logger.Info("expense category not in taxonomy", zap.String("label", label), zap.String("category", category))
+	}
+	if category == label {
+		return record
+	}
+
+	record.Category = category
+	switch {
+	case label == "":
+	case record.Notes == "":
+		record.Notes = label
+	default:
+		record.Notes = label + " - " + record.Notes
+	}
+	return record
+}
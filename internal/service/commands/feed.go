@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+var feedCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandFeed,
+		Aliases:     []string{"aliment", "aliments"},
+		Usage:       "/feed 6.5 1200",
+		Description: "Feed consumed in kg, optional population",
+		Roles:       []models.Role{models.RoleFarmer},
+	},
+	Handle: (*Service).handleFeed,
+}
+
+func (s *Service) handleFeed(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildFeedRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SaveFeedRecord(ctx, record); err != nil {
+		return "", err
+	}
+	summary := s.safeSummary(ctx, func(ctx context.Context) (string, error) {
+		if s.reporting == nil {
+			return "", nil
+		}
+		return s.reporting.CalculateFeedEfficiency(ctx, mondayStart(req.Now), req.Now)
+	})
+	message := fmt.Sprintf("Feed usage saved for %s: %.2f kg.", record.Date.Format(dateFormat), record.FeedKg)
+	if record.Population > 0 {
+		message += fmt.Sprintf(" Population %d birds.", record.Population)
+	}
+	if summary != "" {
+		message += "\n" + summary
+	}
+	if runway := s.feedRunway(ctx, req.Now); runway != "" {
+		message += "\n" + runway
+	}
+	return message, nil
+}
+
+// SaveFeedRecord persists feed consumption data.
+func (s *Service) SaveFeedRecord(ctx context.Context, record models.FeedRecord) error {
+	if err := s.validateFeedRecord(record); err != nil {
+		return err
+	}
+	if _, err := s.records.Feed.Append(ctx, record); err != nil {
+		return err
+	}
+	s.mirrorRecord(ctx, models.RecordFeed, func(store mongodb.Repository) (string, error) {
+		return store.SaveFeedRecord(ctx, record)
+	})
+	return nil
+}
+
+func (s *Service) buildFeedRecord(cmd models.Command, now time.Time) (models.FeedRecord, error) {
+	if len(cmd.Args) == 0 {
+		return models.FeedRecord{}, ErrInvalidArguments
+	}
+
+	// The quantity is in kg unless given in bags: "/feed 6 sacs", "/feed 300kg".
+	feedKg, consumed, ok := readQuantity(cmd.Args, 0, feedUnits(s.bagKg()))
+	if !ok {
+		return models.FeedRecord{}, ErrInvalidArguments
+	}
+
+	population := 0
+	if len(cmd.Args) > consumed {
+		pop, err := strconv.Atoi(cmd.Args[consumed])
+		if err == nil {
+			population = pop
+		}
+	}
+
+	return models.FeedRecord{Date: now, FeedKg: feedKg, Population: population}, nil
+}
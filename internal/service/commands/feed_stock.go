@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// defaultKgPerBag is the bag weight used when FEED_BAG_KG is not configured.
+const defaultKgPerBag = 50
+
+var feedStockCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandFeedStock,
+		Aliases:     []string{"feedstock", "livraison"},
+		Usage:       "/alimentstock 20 50 350000",
+		Description: "Feed delivery: bags, kg per bag (default FEED_BAG_KG), price per bag; no arguments shows the stock left",
+		Roles:       []models.Role{models.RoleFarmer, models.RoleExpenseManager},
+	},
+	Handle: (*Service).handleFeedStock,
+}
+
+func (s *Service) handleFeedStock(ctx context.Context, req commandRequest) (string, error) {
+	if len(req.Cmd.Args) == 0 {
+		runway := s.feedRunway(ctx, req.Now)
+		if runway == "" {
+			return "No feed delivery logged yet. Send /alimentstock 20 50 350000 when bags arrive.", nil
+		}
+		return runway, nil
+	}
+
+	record, err := s.buildFeedDeliveryRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SaveFeedDeliveryRecord(ctx, record); err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Feed delivery logged for %s: %s bags x %s kg = %s kg.", record.Date.Format(dateFormat),
+		strconv.FormatFloat(record.Bags, 'f', -1, 64), strconv.FormatFloat(record.KgPerBag, 'f', -1, 64), strconv.FormatFloat(record.TotalKg(), 'f', -1, 64))
+	if runway := s.feedRunway(ctx, req.Now); runway != "" {
+		message += "\n" + runway
+	}
+	return message, nil
+}
+
+// SaveFeedDeliveryRecord appends a feed delivery to the feed stock sheet.
+func (s *Service) SaveFeedDeliveryRecord(ctx context.Context, record models.FeedDeliveryRecord) error {
+	if err := s.validateFeedDeliveryRecord(record); err != nil {
+		return err
+	}
+	_, err := s.records.FeedStock.Append(ctx, record)
+	return err
+}
+
+func (s *Service) buildFeedDeliveryRecord(cmd models.Command, now time.Time) (models.FeedDeliveryRecord, error) {
+	values := make([]float64, 0, 3)
+	for _, arg := range cmd.Args {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return models.FeedDeliveryRecord{}, ErrInvalidArguments
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 || len(values) > 3 {
+		return models.FeedDeliveryRecord{}, ErrInvalidArguments
+	}
+
+	record := models.FeedDeliveryRecord{Date: now, Bags: values[0], KgPerBag: s.bagKg()}
+	if len(values) > 1 {
+		record.KgPerBag = values[1]
+	}
+	if len(values) > 2 {
+		record.PricePerBag = values[2]
+	}
+	return record, nil
+}
+
+// feedRunway returns the stock-left line from reporting, or "" when unavailable.
+func (s *Service) feedRunway(ctx context.Context, asOf time.Time) string {
+	return s.safeSummary(ctx, func(ctx context.Context) (string, error) {
+		if s.reporting == nil {
+			return "", nil
+		}
+		return s.reporting.CalculateFeedRunway(ctx, asOf)
+	})
+}
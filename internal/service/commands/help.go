@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var helpCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandHelp,
+		Aliases:     []string{"aide"},
+		Usage:       "/help",
+		Description: "List the commands available to you",
+		Public:      true,
+	},
+	Handle: (*Service).handleHelp,
+}
+
+func (s *Service) handleHelp(_ context.Context, req commandRequest) (string, error) {
+	return HelpMessage(req.Cmd.Role), nil
+}
+
+// SuggestionFor proposes the closest command the sender may use for an
+// unknown keyword, e.g. "Vouliez-vous dire /ventes ?". It returns "" when the
+// command is known or nothing is close enough.
+func SuggestionFor(cmd models.Command) string {
+	if cmd.Type != models.CommandUnknown {
+		return ""
+	}
+	fields := strings.Fields(cmd.Raw)
+	if len(fields) == 0 {
+		return ""
+	}
+	suggestion, ok := models.SuggestCommand(fields[0], cmd.Role)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("Vouliez-vous dire /%s ?", suggestion)
+}
+
+// HelpMessage lists the commands available to the role, built from the command registry.
+func HelpMessage(role models.Role) string {
+	var builder strings.Builder
+	builder.WriteString("📖 Available commands:\n")
+	for _, spec := range models.CommandsForRole(role) {
+		keyword := "/" + string(spec.Type)
+		if len(spec.Aliases) > 0 {
+			keyword += " (/" + strings.Join(spec.Aliases, ", /") + ")"
+		}
+		fmt.Fprintf(&builder, "• %s — %s\n  e.g. %s\n", keyword, spec.Description, spec.Usage)
+	}
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// mirrorRecord copies a record just appended to Sheets into its MongoDB
+// collection, for queries that should not scan the sheet. Sheets stays the
+// primary store, so a Mongo failure is logged only. The copy is tracked so
+// /undo removes it along with the row. Nothing is copied while the
+// mongo_dual_write feature is off for the whole farm. In a batch the copy
+// waits for the batch's rows to reach Sheets, see flushMirrors.
+func (s *Service) mirrorRecord(ctx context.Context, kind models.RecordKind, save func(mongodb.Repository) (string, error)) {
+	if s.mongoRepo == nil || !s.enabled(models.FeatureMongoDualWrite, "") {
+		return
+	}
+	if buffer, ok := rowBufferFrom(ctx); ok {
+		buffer.addMirror(kind, save)
+		return
+	}
+	s.saveMirror(ctx, kind, save)
+}
+
+// flushMirrors saves the copies queued by a batch once its rows are
+// flushed, skipping the lines whose rows failed to reach Sheets so Mongo
+// never holds a record the sheet does not. It returns the copies' references
+// per batch line.
+func (s *Service) flushMirrors(ctx context.Context, buffer *rowBuffer, failures map[int]error) map[int][]recordRef {
+	buffer.mu.Lock()
+	mirrors := buffer.mirrors
+	buffer.mirrors = nil
+	buffer.mu.Unlock()
+
+	refs := make(map[int][]recordRef)
+	for _, m := range mirrors {
+		if failures[m.line] != nil {
+			continue
+		}
+		lineCtx, tracker := withWriteTracker(ctx)
+		s.saveMirror(lineCtx, m.kind, m.save)
+		refs[m.line] = append(refs[m.line], tracker.snapshot()...)
+	}
+	return refs
+}
+
+// saveMirror saves the copy and tracks it for /undo.
+func (s *Service) saveMirror(ctx context.Context, kind models.RecordKind, save func(mongodb.Repository) (string, error)) {
+	id, err := save(s.mongoRepo)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("failed to save record to mongodb", zap.String("kind", string(kind)), zap.Error(err))
+		return
+	}
+	if id != "" {
+		trackWrite(ctx, recordRef{RecordKind: kind, RecordID: id})
+	}
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var monthCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandMonth,
+		Aliases:     []string{"month", "mensuel"},
+		Usage:       "/mois (or /mois dernier, /mois date:2024-05-01)",
+		Description: "Monthly totals and averages, compared with the previous month",
+	},
+	Handle: (*Service).handleMonth,
+}
+
+func (s *Service) handleMonth(ctx context.Context, req commandRequest) (string, error) {
+	if s.reporting == nil {
+		return "", ErrUnsupportedCommand
+	}
+
+	reference := req.Now
+	if len(req.Cmd.Args) > 0 {
+		switch req.Cmd.Args[0] {
+		case "dernier", "precedent", "précédent", "last":
+			reference = time.Date(req.Now.Year(), req.Now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+		default:
+			return "", ErrInvalidArguments
+		}
+	}
+
+	report, err := s.reporting.GenerateMonthlyReport(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("generate monthly report: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+var mortalityCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandMortality,
+		Aliases:     []string{"mortalite", "mortalité", "morts"},
+		Usage:       "/mortality 1 0 2",
+		Description: "Dead birds for Band1 Band2 Band3",
+		Roles:       []models.Role{models.RoleFarmer},
+	},
+	Handle: (*Service).handleMortality,
+}
+
+func (s *Service) handleMortality(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildMortalityRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SaveMortalityRecord(ctx, record); err != nil {
+		return "", err
+	}
+	summary := s.safeSummary(ctx, func(ctx context.Context) (string, error) {
+		if s.reporting == nil {
+			return "", nil
+		}
+		return s.reporting.CalculateMortalityRate(ctx, mondayStart(req.Now), req.Now)
+	})
+	message := fmt.Sprintf("Mortality logged for %s: B1:%d, B2:%d, B3:%d.", record.Date.Format(dateFormat), record.Band1, record.Band2, record.Band3)
+	if alert := s.rules.Load().MortalityAlert; alert > 0 && record.Band1+record.Band2+record.Band3 > alert {
+		message += fmt.Sprintf("\n⚠️ %d deaths, above the alert threshold of %d: check the flock and inform the vet.", record.Band1+record.Band2+record.Band3, alert)
+	}
+	if summary != "" {
+		message += "\n" + summary
+	}
+	return message, nil
+}
+
+// SaveMortalityRecord persists mortality data.
+func (s *Service) SaveMortalityRecord(ctx context.Context, record models.MortalityRecord) error {
+	if err := s.validateMortalityRecord(ctx, record); err != nil {
+		return err
+	}
+	if _, err := s.records.Mortality.Append(ctx, record); err != nil {
+		return err
+	}
+	s.mirrorRecord(ctx, models.RecordMortality, func(store mongodb.Repository) (string, error) {
+		return store.SaveMortalityRecord(ctx, record)
+	})
+	return nil
+}
+
+func (s *Service) buildMortalityRecord(cmd models.Command, now time.Time) (models.MortalityRecord, error) {
+	if len(cmd.Args) < 3 {
+		return models.MortalityRecord{}, errors.New("requires 3 arguments: band1 band2 band3")
+	}
+
+	b1, err1 := strconv.Atoi(cmd.Args[0])
+	b2, err2 := strconv.Atoi(cmd.Args[1])
+	b3, err3 := strconv.Atoi(cmd.Args[2])
+
+	if err1 != nil || err2 != nil || err3 != nil {
+		return models.MortalityRecord{}, ErrInvalidArguments
+	}
+
+	return models.MortalityRecord{
+		Date:  now,
+		Band1: b1,
+		Band2: b2,
+		Band3: b3,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+var paymentCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandPayment,
+		Aliases:     []string{"payment", "paye", "remboursement"},
+		Usage:       "/paiement Mamadou 150000",
+		Description: "Client repaying an earlier unpaid sale: client, amount, optional notes",
+		Roles:       []models.Role{models.RoleSeller},
+	},
+	Handle: (*Service).handlePayment,
+}
+
+func (s *Service) handlePayment(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildPaymentRecord(ctx, req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.requireConfirmation(req.Original, record.Amount, fmt.Sprintf("payment of %s from %s", s.money(record.Amount), record.Client)); err != nil {
+		return "", err
+	}
+	if err := s.SavePaymentRecord(ctx, record); err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Payment recorded for %s: %s on %s.", record.Client, s.money(record.Amount), record.Date.Format(dateFormat))
+	if balance, err := s.clientBalance(ctx, record.Client); err == nil {
+		message += fmt.Sprintf(" Remaining balance: %s.", s.money(balance))
+	}
+	return message, nil
+}
+
+// SavePaymentRecord appends a debt repayment to the Payments sheet. The
+// original sale row is left untouched; balances are derived from both tabs.
+func (s *Service) SavePaymentRecord(ctx context.Context, record models.PaymentRecord) error {
+	if customer, ok := s.matchCustomer(ctx, record.Client); ok {
+		record.Client = customer.Name
+	}
+	if err := s.validatePaymentRecord(ctx, record); err != nil {
+		return err
+	}
+	if _, err := s.records.Payments.Append(ctx, record); err != nil {
+		return err
+	}
+	s.mirrorRecord(ctx, models.RecordPayments, func(store mongodb.Repository) (string, error) {
+		return store.SavePaymentRecord(ctx, record)
+	})
+	return nil
+}
+
+func (s *Service) buildPaymentRecord(ctx context.Context, cmd models.Command, now time.Time) (models.PaymentRecord, error) {
+	// The client name spans every word before the amount.
+	amountIdx := -1
+	for i, arg := range cmd.Args {
+		if _, err := strconv.ParseFloat(arg, 64); err == nil {
+			amountIdx = i
+			break
+		}
+	}
+	if amountIdx < 1 {
+		return models.PaymentRecord{}, ErrInvalidArguments
+	}
+
+	amount, _ := strconv.ParseFloat(cmd.Args[amountIdx], 64)
+	client := strings.Join(cmd.Args[:amountIdx], " ")
+	if customer, ok := s.matchCustomer(ctx, client); ok {
+		client = customer.Name
+	}
+
+	return models.PaymentRecord{
+		Date:   now,
+		Client: client,
+		Amount: amount,
+		Notes:  strings.Join(cmd.Args[amountIdx+1:], " "),
+	}, nil
+}
+
+// clientBalance returns what the client still owes: unpaid sale amounts minus
+// recorded repayments.
+func (s *Service) clientBalance(ctx context.Context, client string) (float64, error) {
+	key := models.CustomerKey(client)
+
+	sales, err := s.records.Sales.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load sales: %w", err)
+	}
+	var balance float64
+	for _, sale := range sales {
+		if models.CustomerKey(sale.Client) != key {
+			continue
+		}
+		if unpaid := float64(sale.Quantity)*sale.PricePerUnit - sale.Paid; unpaid > 0 {
+			balance += unpaid
+		}
+	}
+
+	payments, err := s.records.Payments.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load payments: %w", err)
+	}
+	for _, payment := range payments {
+		if models.CustomerKey(payment.Client) == key {
+			balance -= payment.Amount
+		}
+	}
+
+	if balance < 0 {
+		balance = 0
+	}
+	return balance, nil
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+var populationCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandPopulation,
+		Aliases:     []string{"effectif", "effectifs"},
+		Usage:       "/population B1 1500 B2 1480 B3 1500",
+		Description: "Live birds per band (bands not given keep their last count); no arguments shows the current one",
+		Roles:       []models.Role{models.RoleFarmer},
+	},
+	Handle: (*Service).handlePopulation,
+}
+
+func (s *Service) handlePopulation(ctx context.Context, req commandRequest) (string, error) {
+	current, known := s.latestPopulationRecord(ctx)
+	if len(req.Cmd.Args) == 0 {
+		if !known {
+			return "No population recorded yet. Send /population B1 1500 B2 1480 B3 1500.", nil
+		}
+		return fmt.Sprintf("Population on %s: %s.", current.Date.Format(dateFormat), formatPopulation(current)), nil
+	}
+
+	record, err := buildPopulationRecord(req.Cmd, current, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SavePopulationRecord(ctx, record); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Population saved for %s: %s.", record.Date.Format(dateFormat), formatPopulation(record)), nil
+}
+
+// SavePopulationRecord appends a head count to the Population sheet.
+func (s *Service) SavePopulationRecord(ctx context.Context, record models.PopulationRecord) error {
+	if err := nonNegative("population", float64(record.Band1), float64(record.Band2), float64(record.Band3)); err != nil {
+		return err
+	}
+	if record.Total() == 0 {
+		return invalid("population", "Population must be greater than zero.")
+	}
+	_, err := s.records.Population.Append(ctx, record)
+	return err
+}
+
+// buildPopulationRecord reads "b1 1500 b2 1480 b3 1500" (any subset of bands)
+// or three positional counts. Bands left out keep their previous count.
+func buildPopulationRecord(cmd models.Command, previous models.PopulationRecord, now time.Time) (models.PopulationRecord, error) {
+	record := previous
+	record.Date = now
+	bands := []*int{&record.Band1, &record.Band2, &record.Band3}
+
+	args := cmd.Args
+	if !strings.HasPrefix(args[0], "b") {
+		if len(args) != 3 {
+			return models.PopulationRecord{}, ErrInvalidArguments
+		}
+		for i, arg := range args {
+			v, err := strconv.Atoi(arg)
+			if err != nil {
+				return models.PopulationRecord{}, ErrInvalidArguments
+			}
+			*bands[i] = v
+		}
+		return record, nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		// Accept both "b1 1500" and "b1:1500".
+		label, value, attached := strings.Cut(args[i], ":")
+		if !attached {
+			if i+1 >= len(args) {
+				return models.PopulationRecord{}, ErrInvalidArguments
+			}
+			i++
+			value = args[i]
+		}
+		band, err := parseBand(label)
+		if err != nil || band < 1 || band > len(bands) {
+			return models.PopulationRecord{}, ErrInvalidArguments
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return models.PopulationRecord{}, ErrInvalidArguments
+		}
+		*bands[band-1] = count
+	}
+	return record, nil
+}
+
+// latestPopulationRecord returns the last head count from the Population sheet.
+func (s *Service) latestPopulationRecord(ctx context.Context) (models.PopulationRecord, bool) {
+	record, ok, err := s.records.Population.Latest(ctx)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Debug("population sheet lookup failed", zap.Error(err))
+		return models.PopulationRecord{}, false
+	}
+	return record, ok
+}
+
+func formatPopulation(r models.PopulationRecord) string {
+	return fmt.Sprintf("B1:%d, B2:%d, B3:%d (total %d birds)", r.Band1, r.Band2, r.Band3, r.Total())
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+var priceCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandPrice,
+		Aliases:     []string{"price"},
+		Usage:       "/prix 52000",
+		Description: "Set the tray price effective today (no amount shows the current one)",
+		Roles:       []models.Role{models.RoleSeller},
+	},
+	Handle: func(s *Service, ctx context.Context, req commandRequest) (string, error) {
+		return s.handlePrice(ctx, req.Cmd, req.Sender, req.Now)
+	},
+}
+
+// SaveEggPriceRecord persists a tray price to Sheets and MongoDB.
+func (s *Service) SaveEggPriceRecord(ctx context.Context, record models.EggPriceRecord) error {
+	if err := s.checkTrayPrice(record.PricePerTray); err != nil {
+		return err
+	}
+	if _, err := s.records.Prices.Append(ctx, record); err != nil {
+		return fmt.Errorf("write to sheets: %w", err)
+	}
+
+	if s.mongoRepo != nil {
+		if err := s.mongoRepo.SaveEggPrice(ctx, record); err != nil {
+			logger.FromContext(ctx, s.logger).Error("failed to save egg price to mongodb", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// LatestEggPrice returns the current tray price, DEFAULT_TRAY_PRICE until one
+// is recorded. The boolean is false when there is neither.
+func (s *Service) LatestEggPrice(ctx context.Context) (float64, bool, error) {
+	if s.mongoRepo != nil {
+		price, err := s.mongoRepo.GetLatestEggPrice(ctx)
+		if err == nil && price != nil {
+			return price.PricePerTray, true, nil
+		}
+		if err != nil {
+			logger.FromContext(ctx, s.logger).Warn("latest egg price lookup in mongodb failed, falling back to sheets", zap.Error(err))
+		}
+	}
+
+	record, ok, err := s.records.Prices.Latest(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("load prices: %w", err)
+	}
+	if !ok {
+		fallback := s.rules.Load().DefaultTrayPrice
+		return fallback, fallback > 0, nil
+	}
+	return record.PricePerTray, true, nil
+}
+
+func (s *Service) handlePrice(ctx context.Context, cmd models.Command, sender string, now time.Time) (string, error) {
+	if len(cmd.Args) == 0 {
+		price, ok, err := s.LatestEggPrice(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "No tray price set yet. Send /prix 52000 to set one.", nil
+		}
+		return fmt.Sprintf("Current tray price: %s.", s.money(price)), nil
+	}
+
+	price, err := strconv.ParseFloat(cmd.Args[0], 64)
+	if err != nil || price <= 0 {
+		return "", ErrInvalidArguments
+	}
+
+	record := models.EggPriceRecord{
+		EffectiveDate: now,
+		PricePerTray:  price,
+		SetBy:         sender,
+		CreatedAt:     s.now(),
+	}
+	if err := s.SaveEggPriceRecord(ctx, record); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Tray price set to %s effective %s.", s.money(price), now.Format(dateFormat)), nil
+}
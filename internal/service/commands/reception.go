@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var receptionCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandReception,
+		Aliases:     []string{"réception", "recu", "reçu"},
+		Usage:       "/reception 40 52000",
+		Description: "Trays received from the farm, optional unit price (defaults to /prix)",
+		Roles:       []models.Role{models.RoleSeller},
+	},
+	Handle: (*Service).handleReception,
+}
+
+func (s *Service) handleReception(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildEggReceptionRecord(ctx, req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SaveEggReceptionRecord(ctx, record); err != nil {
+		return "", err
+	}
+	message := fmt.Sprintf("Reception logged for %s: %d trays.", record.Date.Format(dateFormat), record.Quantity)
+	if record.UnitPrice > 0 {
+		message = fmt.Sprintf("Reception logged for %s: %d trays @ %s.", record.Date.Format(dateFormat), record.Quantity, s.money(record.UnitPrice))
+	}
+	return message, nil
+}
+
+// SaveEggReceptionRecord persists egg reception data.
+func (s *Service) SaveEggReceptionRecord(ctx context.Context, record models.EggReceptionRecord) error {
+	if err := s.validateEggReceptionRecord(record); err != nil {
+		return err
+	}
+	_, err := s.records.Receptions.Append(ctx, record)
+	return err
+}
+
+func (s *Service) buildEggReceptionRecord(ctx context.Context, cmd models.Command, now time.Time) (models.EggReceptionRecord, error) {
+	if len(cmd.Args) == 0 {
+		return models.EggReceptionRecord{}, ErrInvalidArguments
+	}
+
+	quantity, err := strconv.Atoi(cmd.Args[0])
+	if err != nil {
+		return models.EggReceptionRecord{}, ErrInvalidArguments
+	}
+
+	var unitPrice float64
+	if len(cmd.Args) > 1 {
+		unitPrice, err = strconv.ParseFloat(cmd.Args[1], 64)
+		if err != nil {
+			return models.EggReceptionRecord{}, ErrInvalidArguments
+		}
+	} else if price, ok, err := s.LatestEggPrice(ctx); err == nil && ok {
+		unitPrice = price
+	}
+
+	return models.EggReceptionRecord{Date: now, Quantity: quantity, UnitPrice: unitPrice}, nil
+}
@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// SaveRecord saves a farm record entered outside WhatsApp, e.g. from the
+// records API, the way its command would: validated, appended to Sheets and
+// mirrored. It runs as a unit audited as "<kind>" on behalf of sender. It
+// returns the ID of the mirrored copy, empty when nothing was mirrored.
+func (s *Service) SaveRecord(ctx context.Context, sender string, kind models.RecordKind, record interface{}) (string, error) {
+	var id string
+	err := s.SaveUnit(ctx, sender, string(kind), func(ctx context.Context) error {
+		var err error
+		switch r := record.(type) {
+		case *models.EggRecord:
+			err = s.SaveEggsRecord(ctx, *r)
+		case *models.FeedRecord:
+			err = s.SaveFeedRecord(ctx, *r)
+		case *models.MortalityRecord:
+			err = s.SaveMortalityRecord(ctx, *r)
+		case *models.SaleRecord:
+			err = s.SaveSaleRecord(ctx, *r)
+		case *models.PaymentRecord:
+			err = s.SavePaymentRecord(ctx, *r)
+		case *models.ExpenseRecord:
+			err = s.SaveExpenseRecord(ctx, *r)
+		default:
+			return fmt.Errorf("%w: no %s record", ErrUnsupportedCommand, kind)
+		}
+		if err != nil {
+			return err
+		}
+		if tracker, ok := ctx.Value(writeTrackerKey{}).(*writeTracker); ok {
+			for _, ref := range tracker.snapshot() {
+				if ref.RecordKind == kind && ref.RecordID != "" {
+					id = ref.RecordID
+				}
+			}
+		}
+		return nil
+	})
+	return id, err
+}
+
+// ValidateRecord checks a correction of a farm record against the rules its
+// command enforces, normalizing it in place as saving would: customer names
+// and expense categories. The payment balance check is skipped, as the
+// balance still includes the payment being corrected.
+func (s *Service) ValidateRecord(ctx context.Context, record interface{}) error {
+	switch r := record.(type) {
+	case *models.EggRecord:
+		return s.validateEggRecord(ctx, *r)
+	case *models.FeedRecord:
+		return s.validateFeedRecord(*r)
+	case *models.MortalityRecord:
+		return s.validateMortalityRecord(ctx, *r)
+	case *models.SaleRecord:
+		if customer, ok := s.matchCustomer(ctx, r.Client); ok {
+			r.Client = customer.Name
+		}
+		return s.validateSaleRecord(*r)
+	case *models.PaymentRecord:
+		if customer, ok := s.matchCustomer(ctx, r.Client); ok {
+			r.Client = customer.Name
+		}
+		if r.Amount <= 0 {
+			return invalid("amount", "Payment amount must be greater than zero.")
+		}
+		return nil
+	case *models.ExpenseRecord:
+		*r = s.normalizeExpense(*r)
+		return s.validateExpenseRecord(*r)
+	}
+	return fmt.Errorf("%w: %T", ErrUnsupportedCommand, record)
+}
+
+// UpdateRecord replaces the current version id of a mirrored record with
+// record, checked like a new one. The sheet row holding the current version
+// is overwritten, then the copy is stored as its next version by sender; the
+// row is put back when that fails. It returns the new version's ID, or
+// ErrRowNotFound when the row is gone (archived, changed by hand or still
+// queued).
+func (s *Service) UpdateRecord(ctx context.Context, sender string, kind models.RecordKind, id string, record interface{}) (string, error) {
+	current, rows, err := s.currentRecord(ctx, kind, id)
+	if err != nil {
+		return "", err
+	}
+	if err := s.ValidateRecord(ctx, record); err != nil {
+		return "", err
+	}
+	s.localDate(record)
+
+	rowRange, err := rows.find(ctx, current)
+	if err != nil {
+		return "", err
+	}
+	if err := rows.update(ctx, rowRange, record); err != nil {
+		return "", fmt.Errorf("update %s row: %w", kind, err)
+	}
+	newID, err := s.mongoRepo.CorrectRecord(ctx, kind, id, record, sender)
+	if err != nil {
+		return "", s.restoreRow(ctx, rows, rowRange, current, err)
+	}
+	s.auditCommand(ctx, models.Command{Type: models.CommandType(string(kind) + "_update")}, sender,
+		[]recordRef{{SheetRange: rowRange}, {RecordKind: kind, RecordID: newID}}, nil)
+	return newID, nil
+}
+
+// VoidRecord clears the sheet row holding the current version id of a
+// mirrored record, then marks the version deleted by sender; the row is put
+// back when that fails.
+func (s *Service) VoidRecord(ctx context.Context, sender string, kind models.RecordKind, id string) error {
+	current, rows, err := s.currentRecord(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+	rowRange, err := rows.find(ctx, current)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.ClearRange(ctx, rowRange); err != nil {
+		return fmt.Errorf("clear %s row: %w", kind, err)
+	}
+	if err := s.mongoRepo.DeleteRecord(ctx, kind, id, sender); err != nil {
+		return s.restoreRow(ctx, rows, rowRange, current, err)
+	}
+	s.auditCommand(ctx, models.Command{Type: models.CommandType(string(kind) + "_void")}, sender,
+		[]recordRef{{SheetRange: rowRange}, {RecordKind: kind, RecordID: id}}, nil)
+	return nil
+}
+
+// currentRecord returns the current version id of a mirrored record, dated
+// in the farm's timezone like its sheet row, and the rows of its tab.
+func (s *Service) currentRecord(ctx context.Context, kind models.RecordKind, id string) (interface{}, recordRows, error) {
+	rows, ok := s.recordRows(kind)
+	if !ok || s.mongoRepo == nil {
+		return nil, nil, fmt.Errorf("%s records are not mirrored: %w", kind, mongodb.ErrRecordNotFound)
+	}
+	history, err := s.mongoRepo.GetRecordHistory(ctx, kind, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, version := range history {
+		if version.ID != id {
+			continue
+		}
+		if !version.Current {
+			return nil, nil, fmt.Errorf("%s record %s: %w", kind, id, mongodb.ErrNotCurrent)
+		}
+		record, _ := models.NewRecord(kind)
+		if err := version.Decode(record); err != nil {
+			return nil, nil, fmt.Errorf("decode %s record %s: %w", kind, id, err)
+		}
+		s.localDate(record)
+		return record, rows, nil
+	}
+	return nil, nil, fmt.Errorf("%s record %s: %w", kind, id, mongodb.ErrRecordNotFound)
+}
+
+// localDate moves a record's date to the farm's timezone, in which its row
+// was dated.
+func (s *Service) localDate(record interface{}) {
+	date := models.RecordDate(record)
+	*date = date.In(s.now().Location())
+}
+
+// restoreRow writes the record back to its row after the store refused the
+// change, and returns cause with the restore failure, if any.
+func (s *Service) restoreRow(ctx context.Context, rows recordRows, rowRange string, record interface{}, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+	if err := rows.update(ctx, rowRange, record); err != nil {
+		logger.FromContext(ctx, s.logger).Error("sheet row left changed", zap.String("range", rowRange), zap.Error(err))
+		return errors.Join(cause, fmt.Errorf("restore row %s: %w", rowRange, err))
+	}
+	return cause
+}
+
+// recordRows finds and rewrites the sheet rows of one mirrored record kind.
+type recordRows interface {
+	find(ctx context.Context, record interface{}) (string, error)
+	update(ctx context.Context, rowRange string, record interface{}) error
+}
+
+// entityRows adapts a typed tab to recordRows; records are pointers as
+// returned by models.NewRecord.
+type entityRows[T any] struct {
+	entity *repo.EntityRepository[T]
+}
+
+func (e entityRows[T]) find(ctx context.Context, record interface{}) (string, error) {
+	r, ok := record.(*T)
+	if !ok {
+		return "", fmt.Errorf("%w: %T", ErrUnsupportedCommand, record)
+	}
+	return e.entity.Find(ctx, *r)
+}
+
+func (e entityRows[T]) update(ctx context.Context, rowRange string, record interface{}) error {
+	r, ok := record.(*T)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnsupportedCommand, record)
+	}
+	return e.entity.Update(ctx, rowRange, *r)
+}
+
+// recordRows returns the tab of a mirrored record kind.
+func (s *Service) recordRows(kind models.RecordKind) (recordRows, bool) {
+	switch kind {
+	case models.RecordEggs:
+		return entityRows[models.EggRecord]{s.records.Eggs}, true
+	case models.RecordFeed:
+		return entityRows[models.FeedRecord]{s.records.Feed}, true
+	case models.RecordMortality:
+		return entityRows[models.MortalityRecord]{s.records.Mortality}, true
+	case models.RecordSales:
+		return entityRows[models.SaleRecord]{s.records.Sales}, true
+	case models.RecordPayments:
+		return entityRows[models.PaymentRecord]{s.records.Payments}, true
+	case models.RecordExpenses:
+		return entityRows[models.ExpenseRecord]{s.records.Expenses}, true
+	}
+	return nil, false
+}
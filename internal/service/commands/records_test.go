@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+// fakeSheets holds the rows of the Sales tab, row 1 being the header.
+type fakeSheets struct {
+	repo.Repository
+	rows    [][]interface{}
+	cleared []string
+}
+
+func (f *fakeSheets) ReadRange(ctx context.Context, sheetRange string) ([][]interface{}, error) {
+	return f.rows[:1], nil
+}
+
+func (f *fakeSheets) FindRows(ctx context.Context, sheetRange string, query repo.RowQuery) ([]repo.RowMatch, error) {
+	var matches []repo.RowMatch
+	for i, row := range f.rows[1:] {
+		if fmt.Sprint(row[0]) == query.Date.Format("02/01/2006") {
+			matches = append(matches, repo.RowMatch{Row: i + 2, Range: fmt.Sprintf("Sales!A%d:E%d", i+2, i+2), Values: row})
+		}
+	}
+	return matches, nil
+}
+
+func (f *fakeSheets) UpdateRow(ctx context.Context, a1Range string, values []interface{}) error {
+	var row int
+	fmt.Sscanf(a1Range, "Sales!A%d:", &row)
+	f.rows[row-1] = values
+	return nil
+}
+
+func (f *fakeSheets) ClearRange(ctx context.Context, a1Range string) error {
+	f.cleared = append(f.cleared, a1Range)
+	return nil
+}
+
+// fakeVersions holds one current sale version.
+type fakeVersions struct {
+	mongodb.Repository
+	version   models.RecordVersion
+	corrected interface{}
+	deletedBy string
+	failWith  error
+}
+
+func (f *fakeVersions) GetRecordHistory(ctx context.Context, kind models.RecordKind, id string) ([]models.RecordVersion, error) {
+	if id != f.version.ID {
+		return nil, mongodb.ErrRecordNotFound
+	}
+	return []models.RecordVersion{f.version}, nil
+}
+
+func (f *fakeVersions) CorrectRecord(ctx context.Context, kind models.RecordKind, id string, record interface{}, by string) (string, error) {
+	if f.failWith != nil {
+		return "", f.failWith
+	}
+	f.corrected = record
+	return "v2", nil
+}
+
+func (f *fakeVersions) DeleteRecord(ctx context.Context, kind models.RecordKind, id, by string) error {
+	if f.failWith != nil {
+		return f.failWith
+	}
+	f.deletedBy = by
+	return nil
+}
+
+func (f *fakeVersions) ListCustomers(ctx context.Context) ([]models.Customer, error) {
+	return nil, nil
+}
+
+func (f *fakeVersions) SaveCommandAudit(ctx context.Context, entry models.CommandAuditEntry) error {
+	return nil
+}
+
+// newRecordsFixture returns a service over a Sales tab holding two sales of
+// 15/10/2026, the second being the mirrored version v1. The copy was saved at
+// 20:00 UTC, already the 15th in the farm's UTC+9 timezone.
+func newRecordsFixture(t *testing.T) (*Service, *fakeSheets, *fakeVersions) {
+	t.Helper()
+	sheets := &fakeSheets{rows: [][]interface{}{
+		{"Date", "Client", "Quantity", "PricePerUnit", "Paid"},
+		{"15/10/2026", "Awa", "2", "45000", "90000"},
+		{"15/10/2026", "Binta", "10", "45000", "0"},
+	}}
+	store := &fakeVersions{version: models.RecordVersion{ID: "v1", Current: true, Record: map[string]interface{}{
+		"date": "2026-10-14T20:00:00Z", "client": "Binta", "quantity": 10, "price_per_unit": 45000, "paid": 0,
+	}}}
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	svc := NewService(sheets, repo.Layout{}, store, nil, ValidationRules{}, nil, zone, nil)
+	svc.now = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, zone) }
+	return svc, sheets, store
+}
+
+func TestUpdateRecordRewritesTheSheetRowAndVersionsTheCopy(t *testing.T) {
+	svc, sheets, store := newRecordsFixture(t)
+	fixed := &models.SaleRecord{Date: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC), Client: "Binta", Quantity: 10, PricePerUnit: 45000, Paid: 450000}
+
+	id, err := svc.UpdateRecord(context.Background(), "office", models.RecordSales, "v1", fixed)
+	if err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	if id != "v2" || store.corrected != fixed {
+		t.Errorf("got version %q storing %v, want v2 storing the fix", id, store.corrected)
+	}
+	if got := fmt.Sprint(sheets.rows[2]); got != "[15/10/2026 Binta 10 45000 450000]" {
+		t.Errorf("row 3 = %s, want the fix", got)
+	}
+	if got := fmt.Sprint(sheets.rows[1]); got != "[15/10/2026 Awa 2 45000 90000]" {
+		t.Errorf("row 2 = %s, want it untouched", got)
+	}
+}
+
+func TestUpdateRecordPutsTheRowBackWhenTheStoreRefuses(t *testing.T) {
+	svc, sheets, store := newRecordsFixture(t)
+	store.failWith = mongodb.ErrNotCurrent
+	fixed := &models.SaleRecord{Date: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC), Client: "Binta", Quantity: 12, PricePerUnit: 45000}
+
+	if _, err := svc.UpdateRecord(context.Background(), "office", models.RecordSales, "v1", fixed); !errors.Is(err, mongodb.ErrNotCurrent) {
+		t.Fatalf("UpdateRecord error = %v, want ErrNotCurrent", err)
+	}
+	if got := fmt.Sprint(sheets.rows[2]); got != "[15/10/2026 Binta 10 45000 0]" {
+		t.Errorf("row 3 = %s, want the current version back", got)
+	}
+}
+
+func TestUpdateRecordWithoutItsRowChangesNothing(t *testing.T) {
+	svc, sheets, store := newRecordsFixture(t)
+	sheets.rows = sheets.rows[:2] // archived
+	fixed := &models.SaleRecord{Date: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC), Client: "Binta", Quantity: 12, PricePerUnit: 45000}
+
+	if _, err := svc.UpdateRecord(context.Background(), "office", models.RecordSales, "v1", fixed); !errors.Is(err, ErrRowNotFound) {
+		t.Fatalf("UpdateRecord error = %v, want ErrRowNotFound", err)
+	}
+	if store.corrected != nil {
+		t.Errorf("the copy was corrected without its row")
+	}
+}
+
+func TestVoidRecordClearsTheSheetRowAndDeletesTheCopy(t *testing.T) {
+	svc, sheets, store := newRecordsFixture(t)
+
+	if err := svc.VoidRecord(context.Background(), "office", models.RecordSales, "v1"); err != nil {
+		t.Fatalf("VoidRecord: %v", err)
+	}
+	if fmt.Sprint(sheets.cleared) != "[Sales!A3:E3]" {
+		t.Errorf("cleared %v, want the row of v1", sheets.cleared)
+	}
+	if store.deletedBy != "office" {
+		t.Errorf("deleted by %q, want office", store.deletedBy)
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// commandRequest carries everything a handler needs for one command.
+type commandRequest struct {
+	// Cmd has date overrides (date:..., hier) already stripped from its Args.
+	Cmd models.Command
+	// Original is the command as received, kept for confirmation replays.
+	Original models.Command
+	Sender   string
+	// Now is the record date: the current time or the backfill override.
+	Now time.Time
+}
+
+// commandHandler parses the request arguments, persists the resulting record
+// and returns the confirmation relayed to the sender.
+type commandHandler func(s *Service, ctx context.Context, req commandRequest) (string, error)
+
+// commandDef couples a command's registry entry (keywords, usage, roles, help
+// text) with its handler. Each command declares one in its own file.
+type commandDef struct {
+	Spec   models.CommandSpec
+	Handle commandHandler
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[models.CommandType]commandHandler{}
+)
+
+// builtinCommands is evaluated before any init function, so the built-ins
+// keep this order in /help and commands registered from other files' init
+// functions are listed after them.
+var builtinCommands = registerCommands(
+	eggsCommand,
+	feedCommand,
+	feedStockCommand,
+	mortalityCommand,
+	populationCommand,
+	transferCommand,
+	salesCommand,
+	receptionCommand,
+	expensesCommand,
+	stockCommand,
+	vaccineCommand,
+	priceCommand,
+	clientCommand,
+	paymentCommand,
+	debtsCommand,
+	undoCommand,
+	statusCommand,
+	reportCommand,
+	weekCommand,
+	monthCommand,
+	helpCommand,
+)
+
+func registerCommands(defs ...commandDef) []commandDef {
+	for _, def := range defs {
+		mustRegister(def)
+	}
+	return defs
+}
+
+// mustRegister adds a command to the models registry and binds its handler.
+// It panics on conflicts since registration happens at package init.
+func mustRegister(def commandDef) {
+	if def.Handle == nil {
+		panic(fmt.Sprintf("command %q registered without handler", def.Spec.Type))
+	}
+	if err := models.RegisterCommand(def.Spec); err != nil {
+		panic(err)
+	}
+
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[def.Spec.Type] = def.Handle
+}
+
+func handlerFor(t models.CommandType) (commandHandler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[t]
+	return h, ok
+}
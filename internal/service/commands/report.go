@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var reportCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandReport,
+		Aliases:     []string{"rapport"},
+		Usage:       "/report",
+		Description: "Today's full report",
+	},
+	Handle: (*Service).handleReport,
+}
+
+func (s *Service) handleReport(ctx context.Context, req commandRequest) (string, error) {
+	if s.reporting == nil {
+		return "", ErrUnsupportedCommand
+	}
+	report, err := s.reporting.GenerateDailyReport(ctx, req.Now)
+	if err != nil {
+		return "", fmt.Errorf("generate daily report: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,249 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+// pendingRow is a row queued by a batch line, waiting for the batch flush.
+type pendingRow struct {
+	line       int
+	sheetRange string
+	values     []interface{}
+}
+
+// pendingMirror is the Mongo copy of a batch line's record, saved once the
+// batch's rows are flushed.
+type pendingMirror struct {
+	line int
+	kind models.RecordKind
+	save func(mongodb.Repository) (string, error)
+}
+
+// rowBuffer queues the rows written while a batch is handled so each sheet
+// tab receives a single append call instead of one per line, and the Mongo
+// copies of their records until those rows are written.
+type rowBuffer struct {
+	mu      sync.Mutex
+	line    int
+	pending []pendingRow
+	mirrors []pendingMirror
+}
+
+type rowBufferKey struct{}
+
+func withRowBuffer(ctx context.Context) (context.Context, *rowBuffer) {
+	buffer := &rowBuffer{}
+	return context.WithValue(ctx, rowBufferKey{}, buffer), buffer
+}
+
+func rowBufferFrom(ctx context.Context) (*rowBuffer, bool) {
+	buffer, ok := ctx.Value(rowBufferKey{}).(*rowBuffer)
+	return buffer, ok && buffer != nil
+}
+
+// startLine attributes the rows queued from now on to the given batch line.
+func (b *rowBuffer) startLine(line int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.line = line
+}
+
+func (b *rowBuffer) add(sheetRange string, values []interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, pendingRow{line: b.line, sheetRange: sheetRange, values: values})
+}
+
+func (b *rowBuffer) addMirror(kind models.RecordKind, save func(mongodb.Repository) (string, error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mirrors = append(b.mirrors, pendingMirror{line: b.line, kind: kind, save: save})
+}
+
+// rowsFor returns the queued rows written to the same tab as sheetRange.
+func (b *rowBuffer) rowsFor(sheetRange string) [][]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tab := sheetTab(sheetRange)
+	var rows [][]interface{}
+	for _, p := range b.pending {
+		if sheetTab(p.sheetRange) == tab {
+			rows = append(rows, p.values)
+		}
+	}
+	return rows
+}
+
+// trackedRepository decorates the Sheets adaptor used by the handlers: rows
+// appended are recorded for /undo, or queued when a batch is being handled,
+// and reads include the rows a batch has queued for the same tab so later
+// lines see what earlier lines wrote.
+type trackedRepository struct {
+	repo.Repository
+}
+
+func (r trackedRepository) AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error) {
+	if buffer, ok := rowBufferFrom(ctx); ok {
+		buffer.add(sheetRange, values)
+		return "", nil
+	}
+	updatedRange, err := r.Repository.AppendRow(ctx, sheetRange, values)
+	if err != nil {
+		return "", err
+	}
+	if updatedRange != "" {
+		trackWrite(ctx, recordRef{SheetRange: updatedRange, Rows: [][]interface{}{values}})
+	}
+	return updatedRange, nil
+}
+
+func (r trackedRepository) AppendRows(ctx context.Context, sheetRange string, rows [][]interface{}) (string, error) {
+	if buffer, ok := rowBufferFrom(ctx); ok {
+		for _, values := range rows {
+			buffer.add(sheetRange, values)
+		}
+		return "", nil
+	}
+	updatedRange, err := r.Repository.AppendRows(ctx, sheetRange, rows)
+	if err != nil {
+		return "", err
+	}
+	if updatedRange != "" {
+		trackWrite(ctx, recordRef{SheetRange: updatedRange, Rows: rows})
+	}
+	return updatedRange, nil
+}
+
+func (r trackedRepository) WriteRows(ctx context.Context, sheetRange string, rows [][]interface{}) error {
+	_, err := r.AppendRows(ctx, sheetRange, rows)
+	return err
+}
+
+func (r trackedRepository) WriteRow(ctx context.Context, sheetRange string, values []interface{}) error {
+	_, err := r.AppendRow(ctx, sheetRange, values)
+	return err
+}
+
+func (r trackedRepository) ReadRange(ctx context.Context, sheetRange string) ([][]interface{}, error) {
+	rows, err := r.Repository.ReadRange(ctx, sheetRange)
+	if err != nil {
+		return nil, err
+	}
+	if buffer, ok := rowBufferFrom(ctx); ok {
+		rows = append(rows, buffer.rowsFor(sheetRange)...)
+	}
+	return rows, nil
+}
+
+func (r trackedRepository) ReadSince(ctx context.Context, sheetRange string, since time.Time) ([][]interface{}, error) {
+	rows, err := r.Repository.ReadSince(ctx, sheetRange, since)
+	if err != nil {
+		return nil, err
+	}
+	if buffer, ok := rowBufferFrom(ctx); ok {
+		rows = append(rows, buffer.rowsFor(sheetRange)...)
+	}
+	return rows, nil
+}
+
+// flushRows writes the queued rows with one AppendRows call per range, in the
+// order the ranges were first used. It returns the written row ranges and the
+// write error, if any, per batch line.
+func (s *Service) flushRows(ctx context.Context, buffer *rowBuffer) (map[int][]recordRef, map[int]error) {
+	buffer.mu.Lock()
+	pending := buffer.pending
+	buffer.pending = nil
+	buffer.mu.Unlock()
+
+	var order []string
+	grouped := make(map[string][]pendingRow)
+	for _, p := range pending {
+		if _, ok := grouped[p.sheetRange]; !ok {
+			order = append(order, p.sheetRange)
+		}
+		grouped[p.sheetRange] = append(grouped[p.sheetRange], p)
+	}
+
+	refs := make(map[int][]recordRef)
+	failures := make(map[int]error)
+	for _, sheetRange := range order {
+		group := grouped[sheetRange]
+		rows := make([][]interface{}, len(group))
+		for i, p := range group {
+			rows[i] = p.values
+		}
+
+		updatedRange, err := s.repo.AppendRows(ctx, sheetRange, rows)
+		if err != nil {
+			for _, p := range group {
+				failures[p.line] = err
+			}
+			continue
+		}
+		if updatedRange == "" {
+			continue
+		}
+
+		rowRanges := splitRowRanges(updatedRange, len(group))
+		for i, p := range group {
+			if rowRanges == nil {
+				// Unparseable range: every line points at the whole append.
+				refs[p.line] = append(refs[p.line], recordRef{SheetRange: updatedRange, Rows: rows})
+				continue
+			}
+			refs[p.line] = append(refs[p.line], recordRef{SheetRange: rowRanges[i], Rows: [][]interface{}{p.values}})
+		}
+	}
+	return refs, failures
+}
+
+// splitRowRanges turns "Eggs!A12:F14" into one range per row
+// ("Eggs!A12:F12", ...). It returns nil when the range does not cover
+// exactly count rows.
+func splitRowRanges(updatedRange string, count int) []string {
+	sep := strings.LastIndex(updatedRange, "!")
+	if sep < 0 {
+		return nil
+	}
+	tab, cells := updatedRange[:sep], updatedRange[sep+1:]
+	first, last, ok := strings.Cut(cells, ":")
+	if !ok {
+		last = first
+	}
+	startCol, startRow, err1 := splitCell(first)
+	endCol, endRow, err2 := splitCell(last)
+	if err1 != nil || err2 != nil || endRow-startRow+1 != count {
+		return nil
+	}
+
+	ranges := make([]string, 0, count)
+	for row := startRow; row <= endRow; row++ {
+		ranges = append(ranges, fmt.Sprintf("%s!%s%d:%s%d", tab, startCol, row, endCol, row))
+	}
+	return ranges
+}
+
+func splitCell(cell string) (string, int, error) {
+	i := strings.IndexFunc(cell, func(r rune) bool { return r >= '0' && r <= '9' })
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid cell %q", cell)
+	}
+	row, err := strconv.Atoi(cell[i:])
+	return cell[:i], row, err
+}
+
+func sheetTab(sheetRange string) string {
+	if i := strings.LastIndex(sheetRange, "!"); i >= 0 {
+		return sheetRange[:i]
+	}
+	return sheetRange
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+)
+
+var salesCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandSales,
+		Aliases:     []string{"ventes", "vente"},
+		Usage:       "/sales 10 52000 520000 CoopMarket",
+		Description: "Trays sold, unit price (defaults to /prix), amount paid, client",
+		Roles:       []models.Role{models.RoleSeller},
+	},
+	Handle: (*Service).handleSales,
+}
+
+func (s *Service) handleSales(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildSaleRecord(ctx, req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	total := float64(record.Quantity) * record.PricePerUnit
+	if err := s.requireConfirmation(req.Original, total, fmt.Sprintf("sale of %d trays to %s for %s", record.Quantity, record.Client, s.money(total))); err != nil {
+		return "", err
+	}
+	if err := s.SaveSaleRecord(ctx, record); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Sale recorded for %s: %d units @ %.2f (expected %.2f, paid %.2f).", record.Client, record.Quantity, record.PricePerUnit, total, record.Paid), nil
+}
+
+// SaveSaleRecord persists sales transactions. Client names matching a
+// registered customer are stored under the customer's canonical name.
+func (s *Service) SaveSaleRecord(ctx context.Context, record models.SaleRecord) error {
+	if err := s.validateSaleRecord(record); err != nil {
+		return err
+	}
+	if customer, ok := s.matchCustomer(ctx, record.Client); ok {
+		record.Client = customer.Name
+	}
+	if _, err := s.records.Sales.Append(ctx, record); err != nil {
+		return err
+	}
+	s.mirrorRecord(ctx, models.RecordSales, func(store mongodb.Repository) (string, error) {
+		return store.SaveSaleRecord(ctx, record)
+	})
+	return nil
+}
+
+func (s *Service) buildSaleRecord(ctx context.Context, cmd models.Command, now time.Time) (models.SaleRecord, error) {
+	if len(cmd.Args) < 1 {
+		return models.SaleRecord{}, ErrInvalidArguments
+	}
+
+	quantity, err := strconv.Atoi(cmd.Args[0])
+	if err != nil {
+		return models.SaleRecord{}, ErrInvalidArguments
+	}
+
+	// Optional numbers after the quantity: unit price, then amount paid.
+	idx := 1
+	var numbers []float64
+	for idx < len(cmd.Args) && len(numbers) < 2 {
+		v, err := strconv.ParseFloat(cmd.Args[idx], 64)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, v)
+		idx++
+	}
+
+	client := "Walk-in"
+	if len(cmd.Args) > idx {
+		client = strings.Join(cmd.Args[idx:], " ")
+	}
+	customer, known := s.matchCustomer(ctx, client)
+	if known {
+		client = customer.Name
+	}
+
+	// The unit price defaults to the customer's registered price, then to the
+	// latest /prix entry.
+	var pricePerUnit float64
+	if len(numbers) > 0 {
+		pricePerUnit = numbers[0]
+	} else if known && customer.DefaultPrice > 0 {
+		pricePerUnit = customer.DefaultPrice
+	} else {
+		price, ok, err := s.LatestEggPrice(ctx)
+		if err != nil || !ok {
+			return models.SaleRecord{}, ErrInvalidArguments
+		}
+		pricePerUnit = price
+	}
+
+	paid := float64(quantity) * pricePerUnit
+	if len(numbers) > 1 {
+		paid = numbers[1]
+	}
+
+	return models.SaleRecord{
+		Date:         now,
+		Client:       client,
+		Quantity:     quantity,
+		PricePerUnit: pricePerUnit,
+		Paid:         paid,
+	}, nil
+}
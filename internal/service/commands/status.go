@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+var statusCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandStatus,
+		Aliases:     []string{"statut"},
+		Usage:       "/status",
+		Description: "What you have already logged today",
+	},
+	Handle: func(s *Service, ctx context.Context, req commandRequest) (string, error) {
+		return s.buildStatus(ctx, req.Cmd.Role, req.Now)
+	},
+}
+
+// dayRecords is the part of a typed repository /status needs.
+type dayRecords interface {
+	HasRecordOn(ctx context.Context, day time.Time) (bool, error)
+}
+
+// statusEntry describes one daily entry a role is expected to log.
+type statusEntry struct {
+	Label   string
+	Missing string
+	Records func(*repo.Entities) dayRecords
+}
+
+// roleStatusEntries lists the daily entries checked by /status per role.
+var roleStatusEntries = map[models.Role][]statusEntry{
+	models.RoleFarmer: {
+		{Label: "ponte", Missing: "ponte manquante", Records: func(e *repo.Entities) dayRecords { return e.Eggs }},
+		{Label: "mortalité", Missing: "mortalité manquante", Records: func(e *repo.Entities) dayRecords { return e.Mortality }},
+		{Label: "aliment", Missing: "aliment manquant", Records: func(e *repo.Entities) dayRecords { return e.Feed }},
+	},
+	models.RoleSeller: {
+		{Label: "ventes", Missing: "ventes manquantes", Records: func(e *repo.Entities) dayRecords { return e.Sales }},
+		{Label: "réception", Missing: "réception manquante", Records: func(e *repo.Entities) dayRecords { return e.Receptions }},
+	},
+	models.RoleExpenseManager: {
+		{Label: "dépenses", Missing: "dépenses manquantes", Records: func(e *repo.Entities) dayRecords { return e.Expenses }},
+	},
+}
+
+// buildStatus reports which of the role's daily entries already exist for the day.
+func (s *Service) buildStatus(ctx context.Context, role models.Role, day time.Time) (string, error) {
+	entries, ok := roleStatusEntries[role]
+	if !ok {
+		entries = roleStatusEntries[models.RoleFarmer]
+	}
+
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		logged, err := entry.Records(s.records).HasRecordOn(ctx, day)
+		if err != nil {
+			return "", fmt.Errorf("load %s status: %w", entry.Label, err)
+		}
+		if logged {
+			lines = append(lines, "✅ "+entry.Label)
+		} else {
+			lines = append(lines, "❌ "+entry.Missing)
+		}
+	}
+
+	return fmt.Sprintf("Statut du %s :\n%s", day.Format(dateFormat), strings.Join(lines, "\n")), nil
+}
+
+// parseSheetDate accepts the DD/MM/YYYY format written by the dispatcher as
+// well as ISO dates entered manually.
+func parseSheetDate(value interface{}) (time.Time, bool) {
+	str := strings.TrimSpace(fmt.Sprint(value))
+	if len(str) > 10 {
+		str = str[:10]
+	}
+	for _, layout := range []string{dateFormat, "2006-01-02"} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+var stockCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandStock,
+		Aliases:     []string{"inventaire"},
+		Usage:       "/stock wheelbarrow 2 350000 new",
+		Description: "Inventory item, quantity, unit price, optional condition; alone or with list [item] [etat:condition], shows the inventory",
+		Roles:       []models.Role{models.RoleFarmer, models.RoleExpenseManager},
+	},
+	Handle: (*Service).handleStock,
+}
+
+// stockListLimit bounds the items listed in a /stock reply.
+const stockListLimit = 20
+
+func (s *Service) handleStock(ctx context.Context, req commandRequest) (string, error) {
+	if len(req.Cmd.Args) == 0 || req.Cmd.Args[0] == "list" || req.Cmd.Args[0] == "liste" {
+		return s.listStock(ctx, req.Cmd.Args)
+	}
+	record, err := s.buildStateStockRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SaveStateStockRecord(ctx, record); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Stock item added: %s x%s @ %.2f (%s) on %s.", record.ItemName, strconv.FormatFloat(record.Quantity, 'f', -1, 64), record.UnitPrice, record.Condition, record.Date.Format(dateFormat)), nil
+}
+
+// listStock replies with the newest stock items, filtered by the item name
+// words and an optional etat:<condition> token following "list".
+func (s *Service) listStock(ctx context.Context, args []string) (string, error) {
+	if s.mongoRepo == nil {
+		return "", fmt.Errorf("mongodb repository not initialized")
+	}
+	query := models.StockQuery{Limit: stockListLimit}
+	var name []string
+	for i, arg := range args {
+		switch {
+		case i == 0:
+			// "list" keyword.
+		case strings.HasPrefix(arg, "etat:") || strings.HasPrefix(arg, "condition:"):
+			_, query.Condition, _ = strings.Cut(arg, ":")
+		default:
+			name = append(name, arg)
+		}
+	}
+	query.ItemName = strings.Join(name, " ")
+
+	items, err := s.mongoRepo.GetStockItems(ctx, query)
+	if err != nil {
+		return "", fmt.Errorf("load stock items: %w", err)
+	}
+	if len(items) == 0 {
+		return "No stock items found.", nil
+	}
+	lines := make([]string, 0, len(items)+1)
+	lines = append(lines, "Stock items (newest first):")
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("- %s x%s @ %.0f (%s), %s", item.ItemName, strconv.FormatFloat(item.Quantity, 'f', -1, 64), item.UnitPrice, item.Condition, item.Date.Format(dateFormat)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// SaveStateStockRecord appends a new stock entry to the sheet.
+func (s *Service) SaveStateStockRecord(ctx context.Context, record models.StateStockRecord) error {
+	if err := s.validateStateStockRecord(record); err != nil {
+		return err
+	}
+	if _, err := s.records.StateStock.Append(ctx, record); err != nil {
+		return fmt.Errorf("write to sheets: %w", err)
+	}
+
+	if s.mongoRepo != nil {
+		id, err := s.mongoRepo.SaveStockItem(ctx, record)
+		if err != nil {
+			// The sheet is the primary store; a Mongo failure is logged only.
+			logger.FromContext(ctx, s.logger).Error("failed to save stock item to mongodb", zap.Error(err))
+		} else {
+			trackWrite(ctx, recordRef{StockID: id})
+		}
+	}
+	return nil
+}
+
+func (s *Service) buildStateStockRecord(cmd models.Command, now time.Time) (models.StateStockRecord, error) {
+	// Item names may span several words: everything before the first number.
+	qtyIdx := -1
+	for i, arg := range cmd.Args {
+		if _, err := strconv.ParseFloat(arg, 64); err == nil {
+			qtyIdx = i
+			break
+		}
+	}
+	if qtyIdx < 1 || len(cmd.Args) < qtyIdx+2 {
+		return models.StateStockRecord{}, ErrInvalidArguments
+	}
+
+	quantity, _ := strconv.ParseFloat(cmd.Args[qtyIdx], 64)
+	unitPrice, err := strconv.ParseFloat(cmd.Args[qtyIdx+1], 64)
+	if err != nil {
+		return models.StateStockRecord{}, ErrInvalidArguments
+	}
+
+	condition := "Bon"
+	if len(cmd.Args) > qtyIdx+2 {
+		condition = strings.Join(cmd.Args[qtyIdx+2:], " ")
+	}
+
+	return models.StateStockRecord{
+		Date:      now,
+		ItemName:  strings.Join(cmd.Args[:qtyIdx], " "),
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		Condition: condition,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var transferCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandTransfer,
+		Aliases:     []string{"transfer", "transfere", "transféré"},
+		Usage:       "/transfert B1 B2 200 réorganisation",
+		Description: "Birds moved from one band to another, optional reason",
+		Roles:       []models.Role{models.RoleFarmer},
+	},
+	Handle: (*Service).handleTransfer,
+}
+
+func (s *Service) handleTransfer(ctx context.Context, req commandRequest) (string, error) {
+	record, err := buildTransferRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	population, err := s.SaveTransferRecord(ctx, record)
+	if err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Transfer saved for %s: %d birds from B%d to B%d.", record.Date.Format(dateFormat), record.Quantity, record.FromBand, record.ToBand)
+	if population.Total() > 0 {
+		message += fmt.Sprintf("\nPopulation now %s.", formatPopulation(population))
+	}
+	return message, nil
+}
+
+// SaveTransferRecord appends the transfer to the Transfers sheet and, when a
+// head count exists, records the resulting per-band population so later
+// counts and rates start from the reorganized bands. It returns that
+// population, or a zero record when no head count was available.
+func (s *Service) SaveTransferRecord(ctx context.Context, record models.TransferRecord) (models.PopulationRecord, error) {
+	if err := validTransferBand("from band", record.FromBand); err != nil {
+		return models.PopulationRecord{}, err
+	}
+	if err := validTransferBand("to band", record.ToBand); err != nil {
+		return models.PopulationRecord{}, err
+	}
+	if record.FromBand == record.ToBand {
+		return models.PopulationRecord{}, invalid("band", "Source and destination bands must differ (got B%d twice).", record.FromBand)
+	}
+	if record.Quantity <= 0 {
+		return models.PopulationRecord{}, invalid("quantity", "Transfer quantity must be greater than zero.")
+	}
+
+	current, known := s.latestPopulationRecord(ctx)
+	if known && record.Quantity > current.Band(record.FromBand) {
+		return models.PopulationRecord{}, invalid("quantity", "B%d only has %d birds, cannot move %d.", record.FromBand, current.Band(record.FromBand), record.Quantity)
+	}
+
+	if _, err := s.records.Transfers.Append(ctx, record); err != nil {
+		return models.PopulationRecord{}, err
+	}
+	if !known {
+		return models.PopulationRecord{}, nil
+	}
+
+	population := current
+	population.Date = record.Date
+	bands := []*int{&population.Band1, &population.Band2, &population.Band3}
+	*bands[record.FromBand-1] -= record.Quantity
+	*bands[record.ToBand-1] += record.Quantity
+	if err := s.SavePopulationRecord(ctx, population); err != nil {
+		return models.PopulationRecord{}, fmt.Errorf("update population after transfer: %w", err)
+	}
+	return population, nil
+}
+
+// buildTransferRecord reads "<from> <to> <quantity> [reason]", where bands are
+// written "B1", "band1" or "1".
+func buildTransferRecord(cmd models.Command, now time.Time) (models.TransferRecord, error) {
+	if len(cmd.Args) < 3 {
+		return models.TransferRecord{}, ErrInvalidArguments
+	}
+
+	from, err := parseBand(cmd.Args[0])
+	if err != nil {
+		return models.TransferRecord{}, ErrInvalidArguments
+	}
+	to, err := parseBand(cmd.Args[1])
+	if err != nil {
+		return models.TransferRecord{}, ErrInvalidArguments
+	}
+	quantity, err := strconv.Atoi(cmd.Args[2])
+	if err != nil {
+		return models.TransferRecord{}, ErrInvalidArguments
+	}
+
+	return models.TransferRecord{
+		Date:     now,
+		FromBand: from,
+		ToBand:   to,
+		Quantity: quantity,
+		Reason:   strings.Join(cmd.Args[3:], " "),
+	}, nil
+}
+
+func parseBand(arg string) (int, error) {
+	return strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(arg, "band"), "b"))
+}
+
+func validTransferBand(field string, band int) error {
+	if band < 1 || band > 3 {
+		return invalid(field, "Band must be B1, B2 or B3 (got %d).", band)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// ErrNothingToUndo indicates the sender has no tracked record to remove.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrUndoRowChanged indicates a row to remove no longer holds what the sender
+// wrote, because it was edited or archival moved it. Nothing is cleared.
+var ErrUndoRowChanged = errors.New("row changed since it was written")
+
+var undoCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandUndo,
+		Aliases:     []string{"annuler"},
+		Usage:       "/undo",
+		Description: "Remove the last record you sent",
+	},
+	Handle: func(s *Service, ctx context.Context, req commandRequest) (string, error) {
+		return s.undoLast(ctx, req.Sender)
+	},
+}
+
+// recordRef points at a persisted record so it can be voided later.
+type recordRef struct {
+	SheetRange string          // A1 range returned by the Sheets append, e.g. Eggs!A12:F12
+	Rows       [][]interface{} // values written at SheetRange, checked before clearing it
+	StockID    string          // Mongo stock_items document ID, when applicable
+	// RecordKind and RecordID locate the Mongo copy of a mirrored record.
+	RecordKind models.RecordKind
+	RecordID   string
+}
+
+// writeTracker collects the references produced while handling one command.
+type writeTracker struct {
+	mu   sync.Mutex
+	refs []recordRef
+}
+
+type writeTrackerKey struct{}
+
+func withWriteTracker(ctx context.Context) (context.Context, *writeTracker) {
+	tracker := &writeTracker{}
+	return context.WithValue(ctx, writeTrackerKey{}, tracker), tracker
+}
+
+func trackWrite(ctx context.Context, ref recordRef) {
+	tracker, ok := ctx.Value(writeTrackerKey{}).(*writeTracker)
+	if !ok || tracker == nil {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.refs = append(tracker.refs, ref)
+}
+
+func (t *writeTracker) snapshot() []recordRef {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	refs := make([]recordRef, len(t.refs))
+	copy(refs, t.refs)
+	return refs
+}
+
+// undoEntry is the last record created by a sender.
+type undoEntry struct {
+	Entity string
+	Refs   []recordRef
+	seq    uint64 // tells the entry from one remembered later
+}
+
+// undoStore keeps the last undoable entry per sender in memory.
+type undoStore struct {
+	mu      sync.Mutex
+	entries map[string]undoEntry
+	seq     uint64
+}
+
+func newUndoStore() *undoStore {
+	return &undoStore{entries: make(map[string]undoEntry)}
+}
+
+func (u *undoStore) remember(sender, entity string, refs []recordRef) {
+	if sender == "" || len(refs) == 0 {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.seq++
+	u.entries[sender] = undoEntry{Entity: entity, Refs: refs, seq: u.seq}
+}
+
+// peek returns the sender's entry, which stays remembered until forget.
+func (u *undoStore) peek(sender string) (undoEntry, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	entry, ok := u.entries[sender]
+	return entry, ok
+}
+
+// forget drops entry unless the sender has written something newer since.
+func (u *undoStore) forget(sender string, entry undoEntry) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if current, ok := u.entries[sender]; ok && current.seq == entry.seq {
+		delete(u.entries, sender)
+	}
+}
+
+// narrow keeps only the refs of entry left to void, unless the sender has
+// written something newer since.
+func (u *undoStore) narrow(sender string, entry undoEntry, refs []recordRef) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if current, ok := u.entries[sender]; ok && current.seq == entry.seq {
+		current.Refs = refs
+		u.entries[sender] = current
+	}
+}
+
+// undoLast voids the last record created by the sender in Sheets and Mongo.
+// The entry is forgotten once every row is cleared; a failed clear keeps the
+// rows left so /undo can be sent again.
+func (s *Service) undoLast(ctx context.Context, sender string) (string, error) {
+	entry, ok := s.undo.peek(sender)
+	if !ok {
+		return "", ErrNothingToUndo
+	}
+
+	for _, ref := range entry.Refs {
+		if err := s.checkUnchanged(ctx, ref); err != nil {
+			if errors.Is(err, ErrUndoRowChanged) {
+				s.undo.forget(sender, entry)
+			}
+			return "", fmt.Errorf("undo %s: %w", entry.Entity, err)
+		}
+	}
+
+	var cleared []string
+	for i, ref := range entry.Refs {
+		if ref.SheetRange != "" {
+			if err := s.repo.ClearRange(ctx, ref.SheetRange); err != nil {
+				s.undo.narrow(sender, entry, entry.Refs[i:])
+				return "", fmt.Errorf("undo %s: %w", entry.Entity, err)
+			}
+			cleared = append(cleared, ref.SheetRange)
+		}
+		if ref.StockID != "" && s.mongoRepo != nil {
+			if err := s.mongoRepo.DeleteStockItem(ctx, ref.StockID); err != nil {
+				logger.FromContext(ctx, s.logger).Error("failed to delete stock item from mongodb", zap.Error(err), zap.String("id", ref.StockID))
+			}
+		}
+		if ref.RecordID != "" && s.mongoRepo != nil {
+			if err := s.mongoRepo.DeleteRecord(ctx, ref.RecordKind, ref.RecordID, sender); err != nil {
+				logger.FromContext(ctx, s.logger).Error("failed to delete record from mongodb", zap.Error(err), zap.String("kind", string(ref.RecordKind)), zap.String("id", ref.RecordID))
+			}
+		}
+	}
+	s.undo.forget(sender, entry)
+
+	logger.FromContext(ctx, s.logger).Info("record undone", zap.String("sender", sender), zap.String("entity", entry.Entity), zap.Strings("ranges", cleared))
+	return fmt.Sprintf("Last %s record removed (%s).", entry.Entity, strings.Join(cleared, ", ")), nil
+}
+
+// checkUnchanged returns ErrUndoRowChanged when the rows at ref no longer
+// hold the values written there.
+func (s *Service) checkUnchanged(ctx context.Context, ref recordRef) error {
+	if ref.SheetRange == "" || len(ref.Rows) == 0 {
+		return nil
+	}
+	rows, err := s.repo.ReadRange(ctx, ref.SheetRange)
+	if err != nil {
+		return err
+	}
+	for i, want := range ref.Rows {
+		var got []interface{}
+		if i < len(rows) {
+			got = rows[i]
+		}
+		if !repo.SameRow(got, want) {
+			return ErrUndoRowChanged
+		}
+	}
+	return nil
+}
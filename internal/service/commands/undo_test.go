@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+// rangeSheets holds rows by A1 range and fails the next clear with failClear.
+type rangeSheets struct {
+	repo.Repository
+	rows      map[string][]interface{}
+	cleared   []string
+	failClear error
+}
+
+func (f *rangeSheets) AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error) {
+	f.rows["Eggs!A12:F12"] = values
+	return "Eggs!A12:F12", nil
+}
+
+func (f *rangeSheets) ReadRange(ctx context.Context, sheetRange string) ([][]interface{}, error) {
+	if row, ok := f.rows[sheetRange]; ok {
+		return [][]interface{}{row}, nil
+	}
+	return nil, nil
+}
+
+func (f *rangeSheets) ClearRange(ctx context.Context, a1Range string) error {
+	if err := f.failClear; err != nil {
+		f.failClear = nil
+		return err
+	}
+	delete(f.rows, a1Range)
+	f.cleared = append(f.cleared, a1Range)
+	return nil
+}
+
+// newUndoFixture returns a service whose sender "224600000001" has just
+// written one eggs row.
+func newUndoFixture(t *testing.T) (*Service, *rangeSheets) {
+	t.Helper()
+	sheets := &rangeSheets{rows: make(map[string][]interface{})}
+	svc := NewService(sheets, repo.Layout{}, nil, nil, ValidationRules{}, nil, nil, nil)
+	ctx, tracker := withWriteTracker(context.Background())
+	if _, err := svc.repo.AppendRow(ctx, "Eggs!A:F", []interface{}{"15/10/2026", 320, 0, 0, "", "224600000001"}); err != nil {
+		t.Fatalf("AppendRow: %v", err)
+	}
+	svc.undo.remember("224600000001", "eggs", tracker.snapshot())
+	return svc, sheets
+}
+
+func TestUndoClearsTheRowAndForgetsIt(t *testing.T) {
+	svc, sheets := newUndoFixture(t)
+
+	if _, err := svc.undoLast(context.Background(), "224600000001"); err != nil {
+		t.Fatalf("undoLast: %v", err)
+	}
+	if len(sheets.cleared) != 1 || sheets.cleared[0] != "Eggs!A12:F12" {
+		t.Errorf("cleared = %v, want the eggs row", sheets.cleared)
+	}
+	if _, err := svc.undoLast(context.Background(), "224600000001"); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("second undo: %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestUndoKeepsTheEntryWhenTheClearFails(t *testing.T) {
+	svc, sheets := newUndoFixture(t)
+	sheets.failClear = repo.ErrUnavailable
+
+	if _, err := svc.undoLast(context.Background(), "224600000001"); !errors.Is(err, repo.ErrUnavailable) {
+		t.Fatalf("undoLast: %v, want ErrUnavailable", err)
+	}
+	if _, err := svc.undoLast(context.Background(), "224600000001"); err != nil {
+		t.Fatalf("retried undo: %v", err)
+	}
+	if len(sheets.cleared) != 1 {
+		t.Errorf("cleared = %v, want the row cleared on the retry", sheets.cleared)
+	}
+}
+
+// TestUndoLeavesAMovedRowAlone covers archival moving the row away and
+// another row taking its place.
+func TestUndoLeavesAMovedRowAlone(t *testing.T) {
+	svc, sheets := newUndoFixture(t)
+	sheets.rows["Eggs!A12:F12"] = []interface{}{"16/10/2026", "280", "0", "0", "", "224600000002"}
+
+	if _, err := svc.undoLast(context.Background(), "224600000001"); !errors.Is(err, ErrUndoRowChanged) {
+		t.Fatalf("undoLast: %v, want ErrUndoRowChanged", err)
+	}
+	if len(sheets.cleared) != 0 {
+		t.Errorf("cleared = %v, want nothing", sheets.cleared)
+	}
+	if _, err := svc.undoLast(context.Background(), "224600000001"); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("second undo: %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestUndoMatchesTheRowAsSheetsFormatsIt(t *testing.T) {
+	svc, sheets := newUndoFixture(t)
+	sheets.rows["Eggs!A12:F12"] = []interface{}{"15/10/2026", "320", "0", "0", "", "224600000001"}
+
+	if _, err := svc.undoLast(context.Background(), "224600000001"); err != nil {
+		t.Fatalf("undoLast: %v", err)
+	}
+	if len(sheets.cleared) != 1 {
+		t.Errorf("cleared = %v, want the eggs row", sheets.cleared)
+	}
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// SaveUnit runs save, which stores several records that belong together such
+// as the eggs, mortality, sales and expenses of a completed conversation, as
+// one unit. Sheets has no transactions, so when save fails the rows and Mongo
+// copies it already wrote are voided again instead. The outcome is recorded
+// in the command audit log under entity; a failed unit whose writes could not
+// all be voided keeps the leftover references there, so the partial save can
+// be found and repaired. On success /undo voids the whole unit.
+func (s *Service) SaveUnit(ctx context.Context, sender, entity string, save func(ctx context.Context) error) error {
+	ctx, tracker := withWriteTracker(ctx)
+	err := save(ctx)
+	refs := tracker.snapshot()
+	if err == nil {
+		s.auditCommand(ctx, models.Command{Type: models.CommandType(entity)}, sender, refs, nil)
+		s.undo.remember(sender, entity, refs)
+		return nil
+	}
+
+	// Roll back even when the request was cancelled half way.
+	leftover, rollbackErr := s.voidRefs(context.WithoutCancel(ctx), refs, sender)
+	if rollbackErr != nil {
+		logger.FromContext(ctx, s.logger).Error("partial save left behind",
+			zap.String("entity", entity),
+			zap.String("sender", sender),
+			zap.Strings("refs", refStrings(leftover)),
+			zap.Error(rollbackErr))
+		err = fmt.Errorf("%w (rollback incomplete: %v)", err, rollbackErr)
+	} else if len(refs) > 0 {
+		logger.FromContext(ctx, s.logger).Warn("partial save rolled back", zap.String("entity", entity), zap.String("sender", sender), zap.Int("writes", len(refs)))
+	}
+	s.auditCommand(ctx, models.Command{Type: models.CommandType(entity)}, sender, leftover, err)
+	return err
+}
+
+// voidRefs clears the referenced rows and deletes their Mongo copies on
+// behalf of by, carrying on past failures. It returns the references it
+// could not void.
+func (s *Service) voidRefs(ctx context.Context, refs []recordRef, by string) ([]recordRef, error) {
+	var leftover []recordRef
+	var errs []error
+	for _, ref := range refs {
+		var failed recordRef
+		if ref.SheetRange != "" {
+			if err := s.repo.ClearRange(ctx, ref.SheetRange); err != nil {
+				failed.SheetRange = ref.SheetRange
+				errs = append(errs, err)
+			}
+		}
+		if ref.StockID != "" && s.mongoRepo != nil {
+			if err := s.mongoRepo.DeleteStockItem(ctx, ref.StockID); err != nil {
+				failed.StockID = ref.StockID
+				errs = append(errs, err)
+			}
+		}
+		if ref.RecordID != "" && s.mongoRepo != nil {
+			if err := s.mongoRepo.DeleteRecord(ctx, ref.RecordKind, ref.RecordID, by); err != nil {
+				failed.RecordKind, failed.RecordID = ref.RecordKind, ref.RecordID
+				errs = append(errs, err)
+			}
+		}
+		if failed.SheetRange != "" || failed.StockID != "" || failed.RecordID != "" {
+			leftover = append(leftover, failed)
+		}
+	}
+	return leftover, errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultEggsPerTray is the number of eggs in an alvéole (tray) when
+// EGGS_PER_TRAY is not configured.
+const defaultEggsPerTray = 30
+
+// eggUnits converts egg quantities to single eggs using the tray size.
+func eggUnits(perTray float64) map[string]float64 {
+	return map[string]float64{
+		"oeuf": 1, "oeufs": 1, "œuf": 1, "œufs": 1, "eggs": 1,
+		"alv": perTray, "alveole": perTray, "alveoles": perTray, "alvéole": perTray, "alvéoles": perTray,
+		"plateau": perTray, "plateaux": perTray, "tray": perTray, "trays": perTray,
+	}
+}
+
+// feedUnits converts feed quantities to kilograms using the bag weight.
+func feedUnits(bagKg float64) map[string]float64 {
+	return map[string]float64{
+		"kg": 1, "kgs": 1, "kilo": 1, "kilos": 1,
+		"sac": bagKg, "sacs": bagKg, "bag": bagKg, "bags": bagKg,
+	}
+}
+
+// bagKg returns the configured feed bag weight.
+func (s *Service) bagKg() float64 {
+	if bagKg := s.rules.Load().FeedBagKg; bagKg > 0 {
+		return bagKg
+	}
+	return defaultKgPerBag
+}
+
+// eggsPerTray returns the configured tray size.
+func (s *Service) eggsPerTray() float64 {
+	if perTray := s.rules.Load().EggsPerTray; perTray > 0 {
+		return float64(perTray)
+	}
+	return defaultEggsPerTray
+}
+
+// readQuantity parses the number at args[i] with an optional unit, attached
+// ("300kg") or as the following token ("6 sacs"), and converts it with units.
+// It returns the value and how many tokens were consumed. A number without
+// unit is returned as is.
+func readQuantity(args []string, i int, units map[string]float64) (float64, int, bool) {
+	if i >= len(args) {
+		return 0, 0, false
+	}
+
+	number, suffix := splitNumber(args[i])
+	value, err := strconv.ParseFloat(number, 64)
+	if number == "" || err != nil {
+		return 0, 0, false
+	}
+
+	if suffix != "" {
+		factor, ok := units[suffix]
+		if !ok {
+			return 0, 0, false
+		}
+		return value * factor, 1, true
+	}
+	if i+1 < len(args) {
+		if factor, ok := units[args[i+1]]; ok {
+			return value * factor, 2, true
+		}
+	}
+	return value, 1, true
+}
+
+// splitNumber separates a leading number (comma or dot decimals) from a unit suffix.
+func splitNumber(token string) (string, string) {
+	end := 0
+	for end < len(token) {
+		c := token[end]
+		if (c < '0' || c > '9') && c != '.' && c != ',' {
+			break
+		}
+		end++
+	}
+	return strings.ReplaceAll(token[:end], ",", "."), token[end:]
+}
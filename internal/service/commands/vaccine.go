@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var vaccineCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandVaccine,
+		Aliases:     []string{"vaccin"},
+		Usage:       "/vaccine newcastle 2 eye drop",
+		Description: "Vaccine or treatment, band (1-3 or all), optional notes",
+		Roles:       []models.Role{models.RoleFarmer},
+	},
+	Handle: (*Service).handleVaccine,
+}
+
+func (s *Service) handleVaccine(ctx context.Context, req commandRequest) (string, error) {
+	record, err := s.buildVaccinationRecord(req.Cmd, req.Now)
+	if err != nil {
+		return "", err
+	}
+	if err := s.SaveVaccinationRecord(ctx, record); err != nil {
+		return "", err
+	}
+	target := "all bands"
+	if record.Band > 0 {
+		target = fmt.Sprintf("band %d", record.Band)
+	}
+	return fmt.Sprintf("Vaccination logged for %s: %s on %s.", record.Date.Format(dateFormat), record.Vaccine, target), nil
+}
+
+// SaveVaccinationRecord persists a vaccination or treatment entry.
+func (s *Service) SaveVaccinationRecord(ctx context.Context, record models.VaccinationRecord) error {
+	_, err := s.records.Vaccinations.Append(ctx, record)
+	return err
+}
+
+func (s *Service) buildVaccinationRecord(cmd models.Command, now time.Time) (models.VaccinationRecord, error) {
+	if len(cmd.Args) < 2 {
+		return models.VaccinationRecord{}, ErrInvalidArguments
+	}
+
+	band := 0
+	switch cmd.Args[1] {
+	case "all", "tous", "toutes":
+	default:
+		b, err := strconv.Atoi(cmd.Args[1])
+		if err != nil || b < 1 || b > 3 {
+			return models.VaccinationRecord{}, ErrInvalidArguments
+		}
+		band = b
+	}
+
+	notes := ""
+	if len(cmd.Args) > 2 {
+		notes = strings.Join(cmd.Args[2:], " ")
+	}
+
+	return models.VaccinationRecord{
+		Date:    now,
+		Vaccine: cmd.Args[0],
+		Band:    band,
+		Notes:   notes,
+	}, nil
+}
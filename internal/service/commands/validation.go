@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// ValidationRules bounds the values accepted before a record is persisted.
+// Zero bounds disable the corresponding check.
+type ValidationRules struct {
+	// FeedBagKg converts feed reported in bags (sacs) to kg.
+	FeedBagKg float64
+	// EggsPerTray converts eggs reported in trays (alvéoles) to eggs.
+	EggsPerTray  int
+	MinTrayPrice float64
+	MaxTrayPrice float64
+	// DefaultTrayPrice is the sale price used until a /prix is set.
+	DefaultTrayPrice float64
+	// ConfirmAbove is the sale/expense amount above which the sender must
+	// confirm before the record is persisted.
+	ConfirmAbove float64
+	// Currency labels the amounts of the replies.
+	Currency string
+	// CutoffHour is the hour before which records are dated the previous
+	// day.
+	CutoffHour int
+	// MortalityAlert is the daily death count above which the reply warns;
+	// 0 disables the warning.
+	MortalityAlert int
+}
+
+// RecordDate returns the date records logged at now are filed under: now,
+// or the last second of the previous day before CutoffHour, so a night
+// entry counts for the day it reports on.
+func (r ValidationRules) RecordDate(now time.Time) time.Time {
+	if now.Hour() >= r.CutoffHour {
+		return now
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(-time.Second)
+}
+
+// money formats an amount in the configured currency, GNF by default.
+func (s *Service) money(amount float64) string {
+	currency := s.rules.Load().Currency
+	if currency == "" {
+		currency = "GNF"
+	}
+	return fmt.Sprintf("%.0f %s", amount, currency)
+}
+
+// ValidationError explains why a record was rejected. Message is safe to
+// relay to the worker as-is.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// ConfirmationRequiredError is returned for high-value entries that were not
+// confirmed yet. The messaging layer keeps Command pending and resends it with
+// Confirmed set once the sender answers yes.
+type ConfirmationRequiredError struct {
+	Command models.Command
+	Summary string
+}
+
+func (e *ConfirmationRequiredError) Error() string {
+	return "confirmation required: " + e.Summary
+}
+
+func (s *Service) requireConfirmation(cmd models.Command, amount float64, summary string) error {
+	threshold := s.rules.Load().ConfirmAbove
+	if cmd.Confirmed || threshold <= 0 || amount <= threshold {
+		return nil
+	}
+	return &ConfirmationRequiredError{Command: cmd, Summary: summary}
+}
+
+func invalid(field, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+func nonNegative(field string, values ...float64) error {
+	for _, v := range values {
+		if v < 0 {
+			return invalid(field, "%s must not be negative (got %s).", field, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkTrayPrice(price float64) error {
+	rules := s.rules.Load()
+	if rules.MinTrayPrice > 0 && price < rules.MinTrayPrice {
+		return invalid("price", "Price %.0f is below the minimum of %s.", price, s.money(rules.MinTrayPrice))
+	}
+	if rules.MaxTrayPrice > 0 && price > rules.MaxTrayPrice {
+		return invalid("price", "Price %.0f is above the maximum of %s.", price, s.money(rules.MaxTrayPrice))
+	}
+	return nil
+}
+
+// checkAgainstPopulation rejects counts exceeding the latest known population.
+func (s *Service) checkAgainstPopulation(ctx context.Context, field string, count int) error {
+	population := s.latestPopulation(ctx)
+	if population > 0 && count > population {
+		return invalid(field, "%d %s exceeds the flock population of %d birds.", count, field, population)
+	}
+	return nil
+}
+
+// latestPopulation returns the latest /population head count, falling back to
+// the most recent non-zero population from the feed log.
+func (s *Service) latestPopulation(ctx context.Context) int {
+	if record, ok := s.latestPopulationRecord(ctx); ok && record.Total() > 0 {
+		return record.Total()
+	}
+
+	feed, err := s.records.Feed.List(ctx)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Debug("population lookup failed", zap.Error(err))
+		return 0
+	}
+	for i := len(feed) - 1; i >= 0; i-- {
+		if feed[i].Population > 0 {
+			return feed[i].Population
+		}
+	}
+	return 0
+}
+
+func (s *Service) validateEggRecord(ctx context.Context, record models.EggRecord) error {
+	if err := nonNegative("eggs", float64(record.Band1), float64(record.Band2), float64(record.Band3), float64(record.Quantity)); err != nil {
+		return err
+	}
+	return s.checkAgainstPopulation(ctx, "eggs", record.Quantity)
+}
+
+func (s *Service) validateFeedRecord(record models.FeedRecord) error {
+	return nonNegative("feed", record.FeedKg, float64(record.Population))
+}
+
+func (s *Service) validateFeedDeliveryRecord(record models.FeedDeliveryRecord) error {
+	if record.Bags <= 0 || record.KgPerBag <= 0 {
+		return invalid("feed delivery", "Bags and kg per bag must be greater than zero.")
+	}
+	return nonNegative("price per bag", record.PricePerBag)
+}
+
+func (s *Service) validateMortalityRecord(ctx context.Context, record models.MortalityRecord) error {
+	if err := nonNegative("mortality", float64(record.Band1), float64(record.Band2), float64(record.Band3)); err != nil {
+		return err
+	}
+	return s.checkAgainstPopulation(ctx, "deaths", record.Band1+record.Band2+record.Band3)
+}
+
+func (s *Service) validateSaleRecord(record models.SaleRecord) error {
+	if record.Quantity <= 0 {
+		return invalid("quantity", "Quantity sold must be greater than zero.")
+	}
+	if err := nonNegative("paid", record.Paid); err != nil {
+		return err
+	}
+	return s.checkTrayPrice(record.PricePerUnit)
+}
+
+func (s *Service) validatePaymentRecord(ctx context.Context, record models.PaymentRecord) error {
+	if record.Amount <= 0 {
+		return invalid("amount", "Payment amount must be greater than zero.")
+	}
+	balance, err := s.clientBalance(ctx, record.Client)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Debug("client balance lookup failed", zap.Error(err))
+		return nil
+	}
+	if record.Amount > balance {
+		return invalid("amount", "%s only owes %s, a payment of %s is too much.", record.Client, s.money(balance), s.money(record.Amount))
+	}
+	return nil
+}
+
+func (s *Service) validateEggReceptionRecord(record models.EggReceptionRecord) error {
+	if record.Quantity <= 0 {
+		return invalid("quantity", "Trays received must be greater than zero.")
+	}
+	return nonNegative("unit price", record.UnitPrice)
+}
+
+func (s *Service) validateExpenseRecord(record models.ExpenseRecord) error {
+	if err := nonNegative("expense", record.Quantity, record.UnitPrice); err != nil {
+		return err
+	}
+	if record.Amount <= 0 && record.Quantity*record.UnitPrice <= 0 {
+		return invalid("amount", "Expense amount must be greater than zero.")
+	}
+	return nil
+}
+
+func (s *Service) validateStateStockRecord(record models.StateStockRecord) error {
+	if record.Quantity <= 0 {
+		return invalid("quantity", "Stock quantity must be greater than zero.")
+	}
+	return nonNegative("unit price", record.UnitPrice)
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+var weekCommand = commandDef{
+	Spec: models.CommandSpec{
+		Type:        models.CommandWeek,
+		Aliases:     []string{"week", "hebdo"},
+		Usage:       "/semaine (or /semaine derniere, /semaine date:2024-05-06)",
+		Description: "Weekly summary so far, or for a past week",
+	},
+	Handle: (*Service).handleWeek,
+}
+
+func (s *Service) handleWeek(ctx context.Context, req commandRequest) (string, error) {
+	if s.reporting == nil {
+		return "", ErrUnsupportedCommand
+	}
+
+	reference := req.Now
+	if len(req.Cmd.Args) > 0 {
+		switch req.Cmd.Args[0] {
+		case "derniere", "dernière", "passee", "passée", "last":
+			reference = mondayStart(req.Now).AddDate(0, 0, -1)
+		default:
+			return "", ErrInvalidArguments
+		}
+	}
+
+	// A past week is reported in full: use its Sunday as the reference date.
+	today := s.now()
+	if !sameDay(mondayStart(reference), mondayStart(today)) {
+		reference = mondayStart(reference).AddDate(0, 0, 6)
+	}
+
+	report, err := s.reporting.GenerateWeeklyReport(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("generate weekly report: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,151 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// ErrUnknownFeature is returned when setting a flag no feature answers to.
+var ErrUnknownFeature = errors.New("unknown feature")
+
+// Store keeps the flags set at run time, shared by every instance; see
+// internal/repository/mongodb.
+type Store interface {
+	SaveFeatureFlag(ctx context.Context, flag models.FeatureFlag) error
+	ListFeatureFlags(ctx context.Context) ([]models.FeatureFlag, error)
+}
+
+// Service answers whether a feature is on for a user, from the FEATURE_FLAGS
+// defaults overridden by the flags saved in the store. Reads only use the
+// flags in memory, refreshed from the store by Run.
+type Service struct {
+	store  Store
+	logger *zap.Logger
+
+	mu       sync.RWMutex
+	defaults map[models.Feature]models.FeatureFlag
+	stored   map[models.Feature]models.FeatureFlag
+}
+
+// NewService builds the flags over defaults, usually cfg.Flags.Flags. A nil
+// store keeps the defaults only.
+func NewService(defaults map[models.Feature]models.FeatureFlag, store Store, logger *zap.Logger) *Service {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Service{
+		store:    store,
+		logger:   logger,
+		defaults: defaults,
+		stored:   map[models.Feature]models.FeatureFlag{},
+	}
+}
+
+// Reload applies new FEATURE_FLAGS defaults. Stored flags still take
+// precedence.
+func (s *Service) Reload(defaults map[models.Feature]models.FeatureFlag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults = defaults
+}
+
+// Enabled reports whether feature is on for user. An empty user, e.g. a
+// scheduled job, gets the features that are on for everyone.
+func (s *Service) Enabled(feature models.Feature, user string) bool {
+	return s.flag(feature).EnabledFor(user)
+}
+
+// List returns the flag in effect for every known feature, by name.
+func (s *Service) List() []models.FeatureFlag {
+	flags := make([]models.FeatureFlag, 0, len(models.Features))
+	for feature := range models.Features {
+		flags = append(flags, s.flag(feature))
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// Set saves flag in the store, where it overrides FEATURE_FLAGS on every
+// instance, and applies it here at once.
+func (s *Service) Set(ctx context.Context, flag models.FeatureFlag) (models.FeatureFlag, error) {
+	if _, ok := models.Features[flag.Name]; !ok {
+		return models.FeatureFlag{}, fmt.Errorf("%w: %q", ErrUnknownFeature, flag.Name)
+	}
+	flag.UpdatedAt = time.Now().UTC()
+	if s.store != nil {
+		if err := s.store.SaveFeatureFlag(ctx, flag); err != nil {
+			return models.FeatureFlag{}, err
+		}
+	}
+
+	s.mu.Lock()
+	s.stored[flag.Name] = flag
+	s.mu.Unlock()
+	s.logger.Info("feature flag set", zap.String("feature", string(flag.Name)), zap.Bool("enabled", flag.Enabled), zap.Strings("users", flag.Users))
+	return flag, nil
+}
+
+// Refresh reads the stored flags again. Flags of features no longer known
+// are ignored.
+func (s *Service) Refresh(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	flags, err := s.store.ListFeatureFlags(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load feature flags: %w", err)
+	}
+	stored := make(map[models.Feature]models.FeatureFlag, len(flags))
+	for _, flag := range flags {
+		if _, ok := models.Features[flag.Name]; ok {
+			stored[flag.Name] = flag
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stored = stored
+	return nil
+}
+
+// Run refreshes the stored flags every interval until ctx is done. A failed
+// refresh keeps the flags already loaded.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	if s.store == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Warn("feature flags not refreshed", zap.Error(err))
+		}
+	}
+}
+
+// flag returns the stored flag of feature, else its default, else the
+// feature's built-in default.
+func (s *Service) flag(feature models.Feature) models.FeatureFlag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if flag, ok := s.stored[feature]; ok {
+		return flag
+	}
+	if flag, ok := s.defaults[feature]; ok {
+		return flag
+	}
+	return models.FeatureFlag{Name: feature, Enabled: models.Features[feature]}
+}
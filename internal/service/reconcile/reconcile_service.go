@@ -0,0 +1,319 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/mongodb"
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+const dateLayout = "02/01"
+
+// Discrepancy counts the records of one kind found in a single store.
+type Discrepancy struct {
+	Kind            models.RecordKind
+	MissingInMongo  int
+	MissingInSheets int
+	// CopiedToMongo and CopiedToSheets count the missing records repaired.
+	CopiedToMongo  int
+	CopiedToSheets int
+}
+
+// Report is the outcome of one comparison. Kinds whose stores agree are
+// left out of Discrepancies.
+type Report struct {
+	Start         time.Time
+	End           time.Time
+	Discrepancies []Discrepancy
+}
+
+// Consistent reports whether both stores held the same records.
+func (r Report) Consistent() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// Message renders the report for the admin's WhatsApp.
+func (r Report) Message() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔎 Sheets/Mongo check (%s-%s)", r.Start.Format(dateLayout), r.End.Format(dateLayout))
+	if r.Consistent() {
+		b.WriteString(": no discrepancy.")
+		return b.String()
+	}
+	for _, d := range r.Discrepancies {
+		fmt.Fprintf(&b, "\n- %s:", d.Kind)
+		if d.MissingInMongo > 0 {
+			fmt.Fprintf(&b, " %d missing in Mongo", d.MissingInMongo)
+			if d.CopiedToMongo > 0 {
+				fmt.Fprintf(&b, " (%d copied)", d.CopiedToMongo)
+			}
+		}
+		if d.MissingInSheets > 0 {
+			if d.MissingInMongo > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, " %d missing in Sheets", d.MissingInSheets)
+			if d.CopiedToSheets > 0 {
+				fmt.Fprintf(&b, " (%d copied)", d.CopiedToSheets)
+			}
+		}
+	}
+	return b.String()
+}
+
+// Service compares the recent rows of the mirrored Sheets tabs with their
+// Mongo copies. Records are matched on their day and values, so a row edited
+// by hand in Sheets shows up as missing on both sides.
+type Service struct {
+	records *sheets.Entities
+	store   mongodb.Repository
+	days    int
+	repair  repair
+	now     func() time.Time
+	// location is the farm's timezone, in which days are compared.
+	location *time.Location
+	logger   *zap.Logger
+}
+
+// repair selects the stores missing records are copied into.
+type repair struct {
+	mongo  bool
+	sheets bool
+}
+
+// NewService builds the reconciler over the tabs of layout. Missing records
+// are copied into the stores listed in cfg.Repair. Days are those of
+// location, the farm's timezone (UTC when nil).
+func NewService(repository sheets.Repository, layout sheets.Layout, store mongodb.Repository, cfg config.ReconcileConfig, location *time.Location, logger *zap.Logger) *Service {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	s := &Service{
+		records:  sheets.NewEntities(repository, layout),
+		store:    store,
+		days:     cfg.Days,
+		now:      time.Now,
+		location: location,
+		logger:   logger,
+	}
+	for _, target := range cfg.Repair {
+		switch target {
+		case config.ReconcileRepairMongo:
+			s.repair.mongo = true
+		case config.ReconcileRepairSheets:
+			s.repair.sheets = true
+		}
+	}
+	return s
+}
+
+// Run compares the records of the last days, today included. A failing kind
+// does not stop the others.
+func (s *Service) Run(ctx context.Context) (Report, error) {
+	end := s.today()
+	return s.compare(ctx, end.AddDate(0, 0, 1-s.days), end, s.repair)
+}
+
+// Backfill copies into Mongo the records dated from since's day through today
+// that it does not hold yet, whatever RECONCILE_REPAIR says, so history
+// entered before the mirroring started reaches the Mongo reports. Records
+// already copied are matched and skipped, so it can be run again safely. A
+// zero since reads the whole tabs.
+func (s *Service) Backfill(ctx context.Context, since time.Time) (Report, error) {
+	if !since.IsZero() {
+		since = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, s.location)
+	}
+	return s.compare(ctx, since, s.today(), repair{mongo: true})
+}
+
+func (s *Service) today() time.Time {
+	now := s.now().In(s.location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+}
+
+// compare checks every mirrored kind over the days from start through end.
+func (s *Service) compare(ctx context.Context, start, end time.Time, fix repair) (Report, error) {
+	report := Report{Start: start, End: end}
+
+	checks := []func(ctx context.Context, start, end time.Time, fix repair) (Discrepancy, error){
+		check[models.EggRecord]{
+			kind:  models.RecordEggs,
+			sheet: s.records.Eggs,
+			mongo: s.store.GetEggRecords,
+			date:  func(r *models.EggRecord) *time.Time { return &r.Date },
+			fields: func(r models.EggRecord) string {
+				return fmt.Sprintf("%d|%d|%d|%d|%s", r.Band1, r.Band2, r.Band3, r.Quantity, strings.TrimSpace(r.Notes))
+			},
+			save: mongodb.Repository.SaveEggRecord,
+		}.runner(s),
+		check[models.FeedRecord]{
+			kind:   models.RecordFeed,
+			sheet:  s.records.Feed,
+			mongo:  s.store.GetFeedRecords,
+			date:   func(r *models.FeedRecord) *time.Time { return &r.Date },
+			fields: func(r models.FeedRecord) string { return fmt.Sprintf("%g|%d", r.FeedKg, r.Population) },
+			save:   mongodb.Repository.SaveFeedRecord,
+		}.runner(s),
+		check[models.MortalityRecord]{
+			kind:   models.RecordMortality,
+			sheet:  s.records.Mortality,
+			mongo:  s.store.GetMortalityRecords,
+			date:   func(r *models.MortalityRecord) *time.Time { return &r.Date },
+			fields: func(r models.MortalityRecord) string { return fmt.Sprintf("%d|%d|%d", r.Band1, r.Band2, r.Band3) },
+			save:   mongodb.Repository.SaveMortalityRecord,
+		}.runner(s),
+		check[models.SaleRecord]{
+			kind:  models.RecordSales,
+			sheet: s.records.Sales,
+			mongo: s.store.GetSaleRecords,
+			date:  func(r *models.SaleRecord) *time.Time { return &r.Date },
+			fields: func(r models.SaleRecord) string {
+				return fmt.Sprintf("%s|%d|%g|%g", strings.TrimSpace(r.Client), r.Quantity, r.PricePerUnit, r.Paid)
+			},
+			save: mongodb.Repository.SaveSaleRecord,
+		}.runner(s),
+		check[models.PaymentRecord]{
+			kind:  models.RecordPayments,
+			sheet: s.records.Payments,
+			mongo: s.store.GetPaymentRecords,
+			date:  func(r *models.PaymentRecord) *time.Time { return &r.Date },
+			fields: func(r models.PaymentRecord) string {
+				return fmt.Sprintf("%s|%g|%s", strings.TrimSpace(r.Client), r.Amount, strings.TrimSpace(r.Notes))
+			},
+			save: mongodb.Repository.SavePaymentRecord,
+		}.runner(s),
+		// The Expenses tab has no amount column; it is recomputed on read.
+		check[models.ExpenseRecord]{
+			kind:  models.RecordExpenses,
+			sheet: s.records.Expenses,
+			mongo: s.store.GetExpenseRecords,
+			date:  func(r *models.ExpenseRecord) *time.Time { return &r.Date },
+			fields: func(r models.ExpenseRecord) string {
+				return fmt.Sprintf("%s|%g|%g|%s", strings.TrimSpace(r.Category), r.Quantity, r.UnitPrice, strings.TrimSpace(r.Notes))
+			},
+			save: mongodb.Repository.SaveExpenseRecord,
+		}.runner(s),
+	}
+
+	var errs []error
+	for _, run := range checks {
+		discrepancy, err := run(ctx, start, end, fix)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if discrepancy.MissingInMongo > 0 || discrepancy.MissingInSheets > 0 {
+			report.Discrepancies = append(report.Discrepancies, discrepancy)
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// check compares one record kind between its tab and its collection.
+type check[T any] struct {
+	kind  models.RecordKind
+	sheet *sheets.EntityRepository[T]
+	mongo func(ctx context.Context, start, end time.Time) ([]T, error)
+	// date points at the record's date, normalized to midnight in the farm's
+	// timezone before records are matched.
+	date func(*T) *time.Time
+	// fields renders the values compared, the date excepted.
+	fields func(T) string
+	save   func(store mongodb.Repository, ctx context.Context, record T) (string, error)
+}
+
+func (c check[T]) runner(s *Service) func(ctx context.Context, start, end time.Time, fix repair) (Discrepancy, error) {
+	return func(ctx context.Context, start, end time.Time, fix repair) (Discrepancy, error) {
+		return c.run(ctx, s, start, end, fix)
+	}
+}
+
+// run matches the records of the days from start through end one to one and
+// copies the unmatched ones into the stores selected by fix.
+func (c check[T]) run(ctx context.Context, s *Service, start, end time.Time, fix repair) (Discrepancy, error) {
+	result := Discrepancy{Kind: c.kind}
+
+	fromSheets, err := c.sheet.Between(ctx, start, end)
+	if err != nil {
+		return result, fmt.Errorf("read %s from sheets: %w", c.kind, err)
+	}
+	fromMongo, err := c.mongo(ctx, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return result, fmt.Errorf("read %s from mongo: %w", c.kind, err)
+	}
+
+	// Sheets rows carry their day at UTC midnight, Mongo copies the time
+	// they were saved at.
+	for i := range fromSheets {
+		date := c.date(&fromSheets[i])
+		*date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.location)
+	}
+	for i := range fromMongo {
+		date := c.date(&fromMongo[i])
+		local := date.In(s.location)
+		*date = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)
+	}
+
+	unmatched := make(map[string]int)
+	for _, record := range fromMongo {
+		unmatched[c.key(record)]++
+	}
+	var missingInMongo, missingInSheets []T
+	for _, record := range fromSheets {
+		if key := c.key(record); unmatched[key] > 0 {
+			unmatched[key]--
+			continue
+		}
+		missingInMongo = append(missingInMongo, record)
+	}
+	for _, record := range fromMongo {
+		if key := c.key(record); unmatched[key] > 0 {
+			unmatched[key]--
+			missingInSheets = append(missingInSheets, record)
+		}
+	}
+	result.MissingInMongo, result.MissingInSheets = len(missingInMongo), len(missingInSheets)
+
+	var errs []error
+	if fix.mongo {
+		for _, record := range missingInMongo {
+			if _, err := c.save(s.store, ctx, record); err != nil {
+				errs = append(errs, fmt.Errorf("copy %s to mongo: %w", c.kind, err))
+				continue
+			}
+			result.CopiedToMongo++
+		}
+	}
+	if fix.sheets {
+		for _, record := range missingInSheets {
+			if _, err := c.sheet.Append(ctx, record); err != nil {
+				errs = append(errs, fmt.Errorf("copy %s to sheets: %w", c.kind, err))
+				continue
+			}
+			result.CopiedToSheets++
+		}
+	}
+	if result.MissingInMongo > 0 || result.MissingInSheets > 0 {
+		s.logger.Warn("sheets and mongo disagree",
+			zap.String("kind", string(c.kind)),
+			zap.Int("missing_in_mongo", result.MissingInMongo),
+			zap.Int("missing_in_sheets", result.MissingInSheets),
+			zap.Int("copied_to_mongo", result.CopiedToMongo),
+			zap.Int("copied_to_sheets", result.CopiedToSheets))
+	}
+	return result, errors.Join(errs...)
+}
+
+func (c check[T]) key(record T) string {
+	return c.date(&record).Format("2006-01-02") + "|" + c.fields(record)
+}
@@ -0,0 +1,224 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+const dateFormat = "02/01/2006"
+
+// Notifier sends the reminders; see internal/service/whatsapp.
+type Notifier interface {
+	SendOutbound(ctx context.Context, req models.OutboundMessageRequest) error
+	SendTemplate(ctx context.Context, req models.TemplateMessageRequest) error
+}
+
+// dayRecords is the part of a typed repository the check needs.
+type dayRecords interface {
+	HasRecordOn(ctx context.Context, day time.Time) (bool, error)
+}
+
+// dailyEntry is an entry the farmers are expected to log every day.
+type dailyEntry struct {
+	Label   string
+	Records func(*sheets.Entities) dayRecords
+}
+
+var dailyEntries = []dailyEntry{
+	{Label: "ponte", Records: func(e *sheets.Entities) dayRecords { return e.Eggs }},
+	{Label: "mortalité", Records: func(e *sheets.Entities) dayRecords { return e.Mortality }},
+}
+
+// Service reminds the farmers of the day's egg and mortality entries they
+// have not logged yet, then tells the owner about those still missing. It
+// also warns them of the vaccinations due the next day.
+type Service struct {
+	records  *sheets.Entities
+	notifier Notifier
+	// cfg is replaced as a whole by Reload.
+	cfg    atomic.Pointer[config.ReminderConfig]
+	logger *zap.Logger
+}
+
+// NewService builds the reminder over the tabs of layout.
+func NewService(repository sheets.Repository, layout sheets.Layout, notifier Notifier, cfg config.ReminderConfig, logger *zap.Logger) *Service {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	svc := &Service{
+		records:  sheets.NewEntities(repository, layout),
+		notifier: notifier,
+		logger:   logger,
+	}
+	svc.cfg.Store(&cfg)
+	return svc
+}
+
+// Reload applies new templates and vaccination calendar.
+func (s *Service) Reload(cfg config.ReminderConfig) {
+	s.cfg.Store(&cfg)
+}
+
+// Missing returns the labels of the daily entries not logged on day.
+func (s *Service) Missing(ctx context.Context, day time.Time) ([]string, error) {
+	var missing []string
+	for _, entry := range dailyEntries {
+		logged, err := entry.Records(s.records).HasRecordOn(ctx, day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s entries: %w", entry.Label, err)
+		}
+		if !logged {
+			missing = append(missing, entry.Label)
+		}
+	}
+	return missing, nil
+}
+
+// Remind sends every worker the list of the day's missing entries, if any. A
+// failed send does not stop the others.
+func (s *Service) Remind(ctx context.Context, day time.Time, workers []string) error {
+	missing, err := s.Missing(ctx, day)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	text := fmt.Sprintf("⏰ Rappel : %s pas encore saisie(s) pour le %s.", strings.Join(missing, ", "), day.Format(dateFormat))
+	var errs []error
+	for _, worker := range workers {
+		if err := s.send(ctx, worker, day, missing, text); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remind %s: %w", worker, err))
+		}
+	}
+	s.logger.Info("missing entries reminded", zap.Strings("missing", missing), zap.Int("workers", len(workers)-len(errs)))
+	return errors.Join(errs...)
+}
+
+// Escalate tells the owners which of the day's entries are still missing, if
+// any. A failed send does not stop the others.
+func (s *Service) Escalate(ctx context.Context, day time.Time, owners []string) error {
+	missing, err := s.Missing(ctx, day)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	text := fmt.Sprintf("⚠️ %s toujours manquante(s) pour le %s malgré le rappel aux ouvriers.", strings.Join(missing, ", "), day.Format(dateFormat))
+	var errs []error
+	for _, owner := range owners {
+		if err := s.send(ctx, owner, day, missing, text); err != nil {
+			errs = append(errs, fmt.Errorf("failed to escalate to %s: %w", owner, err))
+		}
+	}
+	s.logger.Info("missing entries escalated", zap.Strings("missing", missing), zap.Int("owners", len(owners)-len(errs)))
+	return errors.Join(errs...)
+}
+
+// Vaccination is a step of the vaccination calendar due for a band.
+type Vaccination struct {
+	Band int
+	config.VaccinationStep
+}
+
+// VaccinationsDue returns the calendar steps falling on day for the bands of
+// the Flock tab, from each band's age at placement.
+func (s *Service) VaccinationsDue(ctx context.Context, day time.Time) ([]Vaccination, error) {
+	calendar := s.cfg.Load().VaccinationCalendar
+	if len(calendar) == 0 {
+		return nil, nil
+	}
+	bands, err := s.records.Flock.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load flock: %w", err)
+	}
+
+	var due []Vaccination
+	for _, band := range bands {
+		if band.PlacementDate.IsZero() {
+			continue
+		}
+		days := calendarDays(band.PlacementDate, day)
+		if days < 0 {
+			continue
+		}
+		age := band.AgeAtPlacement*7 + days
+		for _, step := range calendar {
+			if step.AgeDays == age {
+				due = append(due, Vaccination{Band: band.Band, VaccinationStep: step})
+			}
+		}
+	}
+	return due, nil
+}
+
+// RemindVaccinations warns every worker of the vaccinations due the day
+// after day, one message per band and vaccine. A failed send does not stop
+// the others.
+func (s *Service) RemindVaccinations(ctx context.Context, day time.Time, workers []string) error {
+	tomorrow := day.AddDate(0, 0, 1)
+	due, err := s.VaccinationsDue(ctx, tomorrow)
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	var errs []error
+	for _, vaccination := range due {
+		text := fmt.Sprintf("💉 %s demain pour Bande %d", vaccination.Vaccine, vaccination.Band)
+		if vaccination.Note != "" {
+			text += " (" + vaccination.Note + ")"
+		}
+		for _, worker := range workers {
+			if err := s.sendVaccination(ctx, worker, tomorrow, vaccination, text); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remind %s of %s: %w", worker, vaccination.Vaccine, err))
+			}
+		}
+	}
+	s.logger.Info("vaccinations reminded", zap.Int("vaccinations", len(due)), zap.Int("workers", len(workers)))
+	return errors.Join(errs...)
+}
+
+// calendarDays counts the calendar days from from to to, ignoring the time
+// of day and the locations they are expressed in.
+func calendarDays(from, to time.Time) int {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// sendVaccination uses the vaccination template, filled with the vaccine,
+// the band and the day, or text without one.
+func (s *Service) sendVaccination(ctx context.Context, to string, day time.Time, vaccination Vaccination, text string) error {
+	cfg := s.cfg.Load()
+	if cfg.VaccinationTemplate == "" {
+		return s.notifier.SendOutbound(ctx, models.OutboundMessageRequest{To: to, Message: text})
+	}
+	return s.notifier.SendTemplate(ctx, models.TemplateMessageRequest{
+		To:         to,
+		Name:       cfg.VaccinationTemplate,
+		Language:   cfg.TemplateLanguage,
+		Parameters: []string{vaccination.Vaccine, fmt.Sprintf("Bande %d", vaccination.Band), day.Format(dateFormat)},
+	})
+}
+
+// send uses the configured template, filled with the day and the missing
+// entries, or text without one.
+func (s *Service) send(ctx context.Context, to string, day time.Time, missing []string, text string) error {
+	cfg := s.cfg.Load()
+	if cfg.Template == "" {
+		return s.notifier.SendOutbound(ctx, models.OutboundMessageRequest{To: to, Message: text})
+	}
+	return s.notifier.SendTemplate(ctx, models.TemplateMessageRequest{
+		To:         to,
+		Name:       cfg.Template,
+		Language:   cfg.TemplateLanguage,
+		Parameters: []string{day.Format(dateFormat), strings.Join(missing, ", ")},
+	})
+}
@@ -0,0 +1,56 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// overdueDebtsListLimit bounds the clients listed in the debt reminder; the
+// total still covers every overdue client.
+const overdueDebtsListLimit = 30
+
+// OverdueDebts lists, largest balance first, the clients whose oldest unpaid
+// sale is at least minDays old at asOf, with their phone number to call. It
+// returns an empty string when no client is overdue.
+func (s *Service) OverdueDebts(ctx context.Context, asOf time.Time, minDays int) (string, error) {
+	if s.reportRepo == nil {
+		return "", fmt.Errorf("mongodb repository not initialized")
+	}
+	balances, err := s.reportRepo.GetClientBalances(ctx)
+	if err != nil {
+		return "", fmt.Errorf("load client balances: %w", err)
+	}
+
+	cutoff := truncateToDay(asOf).AddDate(0, 0, -minDays)
+	var total float64
+	var lines []string
+	overdue := 0
+	for _, balance := range balances {
+		if balance.OldestUnpaid.IsZero() || balance.OldestUnpaid.After(cutoff) {
+			continue
+		}
+		overdue++
+		total += balance.Balance
+		if overdue > overdueDebtsListLimit {
+			continue
+		}
+		days := int(truncateToDay(asOf).Sub(truncateToDay(balance.OldestUnpaid)).Hours() / 24)
+		line := fmt.Sprintf("- %s: %s, unpaid since %s (%d days)",
+			balance.Client, s.money(balance.Balance), balance.OldestUnpaid.Format("02/01/2006"), days)
+		if balance.Phone != "" {
+			line += " 📞 " + balance.Phone
+		}
+		lines = append(lines, line)
+	}
+	if overdue == 0 {
+		return "", nil
+	}
+	if overdue > overdueDebtsListLimit {
+		lines = append(lines, fmt.Sprintf("… and %d more", overdue-overdueDebtsListLimit))
+	}
+
+	header := fmt.Sprintf("💰 Debts to collect (unpaid for %d+ days): %s across %d clients.", minDays, s.money(total), overdue)
+	return header + "\n" + strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,126 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// feedRunwayWindowDays is the consumption window used to estimate how many
+// days the remaining feed will last.
+const feedRunwayWindowDays = 7
+
+// feedStockLevel is the feed on hand: deliveries minus the consumption logged
+// since the first delivery.
+type feedStockLevel struct {
+	DeliveredKg float64
+	ConsumedKg  float64
+	DailyAvgKg  float64 // average consumption over feedRunwayWindowDays
+	HasData     bool
+}
+
+// RemainingKg returns the feed left, never below zero: consumption beyond
+// the deliveries means a delivery was not logged, not a debt of feed.
+func (l feedStockLevel) RemainingKg() float64 {
+	return max(l.DeliveredKg-l.ConsumedKg, 0)
+}
+
+// RunwayDays returns how many days the stock lasts at the recent pace, or -1
+// when consumption is unknown.
+func (l feedStockLevel) RunwayDays() float64 {
+	if l.DailyAvgKg <= 0 {
+		return -1
+	}
+	return l.RemainingKg() / l.DailyAvgKg
+}
+
+// computeFeedStock sums deliveries (FeedStock tab) and consumption (Feed tab)
+// up to asOf. Consumption logged before the first delivery is left out: the
+// feed eaten then came from a stock that was never recorded.
+func (s *Service) computeFeedStock(ctx context.Context, asOf time.Time) (feedStockLevel, error) {
+	var level feedStockLevel
+	end := truncateToDay(asOf)
+
+	deliveries, err := s.records.FeedStock.Between(ctx, time.Time{}, end)
+	if err != nil {
+		return level, fmt.Errorf("load feed stock data: %w", err)
+	}
+	var firstDelivery time.Time
+	for _, d := range deliveries {
+		level.DeliveredKg += d.TotalKg()
+		if !level.HasData || d.Date.Before(firstDelivery) {
+			firstDelivery = truncateToDay(d.Date)
+		}
+		level.HasData = true
+	}
+	if !level.HasData {
+		return level, nil
+	}
+
+	windowStart := end.AddDate(0, 0, -(feedRunwayWindowDays - 1))
+	since := firstDelivery
+	if windowStart.Before(since) {
+		since = windowStart
+	}
+	consumption, err := s.records.Feed.Between(ctx, since, end)
+	if err != nil {
+		return level, fmt.Errorf("load feed data: %w", err)
+	}
+	var windowKg float64
+	for _, f := range consumption {
+		if !f.Date.Before(firstDelivery) {
+			level.ConsumedKg += f.FeedKg
+		}
+		if !f.Date.Before(windowStart) {
+			windowKg += f.FeedKg
+		}
+	}
+	level.DailyAvgKg = windowKg / feedRunwayWindowDays
+
+	return level, nil
+}
+
+// CalculateFeedRunway reports the feed stock left and how long it will last,
+// with a warning when the runway drops below the LowFeedDays setting.
+func (s *Service) CalculateFeedRunway(ctx context.Context, asOf time.Time) (string, error) {
+	level, err := s.computeFeedStock(ctx, asOf)
+	if err != nil {
+		return "", err
+	}
+	return formatFeedStockLine(level, s.settings.Load().LowFeedDays), nil
+}
+
+// FeedStockAlert returns a low-stock warning when the feed left at asOf lasts
+// less than minDays at the recent pace, and an empty string otherwise,
+// including when no delivery or consumption is logged yet.
+func (s *Service) FeedStockAlert(ctx context.Context, asOf time.Time, minDays int) (string, error) {
+	level, err := s.computeFeedStock(ctx, asOf)
+	if err != nil {
+		return "", err
+	}
+	runway := level.RunwayDays()
+	if !level.HasData || runway < 0 || runway >= float64(minDays) {
+		return "", nil
+	}
+	return fmt.Sprintf("⚠️ Low feed stock: %s kg left, ~%.1f days at %s kg/day (alert under %d days). Order feed now.",
+		formatFloat(level.RemainingKg(), 0), runway, formatFloat(level.DailyAvgKg, 1), minDays), nil
+}
+
+// formatFeedStockLine describes level, warning when it lasts less than
+// lowDays; 0 disables the warning.
+func formatFeedStockLine(level feedStockLevel, lowDays int) string {
+	if !level.HasData {
+		return ""
+	}
+
+	line := fmt.Sprintf("🏚️ Feed stock: %s kg", formatFloat(level.RemainingKg(), 0))
+	runway := level.RunwayDays()
+	if runway < 0 {
+		return line + " (consumption unknown)"
+	}
+	line += fmt.Sprintf(" (~%.1f days at %s kg/day)", runway, formatFloat(level.DailyAvgKg, 1))
+	if runway < float64(lowDays) {
+		line += fmt.Sprintf("\n⚠️ Low feed stock: less than %d days left, order feed now.", lowDays)
+	}
+	return line
+}
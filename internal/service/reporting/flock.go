@@ -0,0 +1,140 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// bandPerformance summarizes a band's results over a reporting window.
+type bandPerformance struct {
+	Flock        models.FlockBand
+	AgeWeeks     int
+	Eggs         int
+	Days         int
+	Deaths       int // Cumulative deaths since placement up to the window end
+	LiveBirds    int
+	LayingRate   float64
+	CumMortality float64
+}
+
+// computeBandPerformance derives laying rate and cumulative mortality for every
+// configured band (Flock tab) over the [start, end] window.
+func (s *Service) computeBandPerformance(ctx context.Context, start, end time.Time) ([]bandPerformance, error) {
+	flock, err := s.records.Flock.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load flock data: %w", err)
+	}
+	if len(flock) == 0 {
+		return nil, nil
+	}
+
+	eggs, err := s.records.Eggs.Between(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("load eggs data: %w", err)
+	}
+	mortality, err := s.records.Mortality.Between(ctx, time.Time{}, end)
+	if err != nil {
+		return nil, fmt.Errorf("load mortality data: %w", err)
+	}
+
+	days := int(truncateToDay(end).Sub(truncateToDay(start)).Hours()/24) + 1
+	counted, hasCount := s.populationAsOf(ctx, end)
+	transfers, err := s.loadTransfers(ctx, time.Time{}, end)
+	if err != nil {
+		// The Transfers tab is optional until the first /transfert.
+		logger.FromContext(ctx, s.logger).Debug("transfers data unavailable", zap.Error(err))
+	}
+
+	perf := make([]bandPerformance, 0, len(flock))
+	for _, band := range flock {
+		totalEggs := 0
+		for _, r := range eggs {
+			totalEggs += r.Band(band.Band)
+		}
+		deaths := 0
+		for _, r := range mortality {
+			if !r.Date.Before(truncateToDay(band.PlacementDate)) {
+				deaths += r.Band(band.Band)
+			}
+		}
+
+		// Prefer the /population head count once taken after placement. A
+		// /transfert updates that count itself, so only moves logged after it
+		// are applied on top.
+		live := band.InitialCount - deaths + netTransfers(transfers, band.Band, band.PlacementDate)
+		if hasCount && !counted.Date.Before(truncateToDay(band.PlacementDate)) && counted.Band(band.Band) > 0 {
+			live = counted.Band(band.Band) + netTransfers(transfers, band.Band, counted.Date.AddDate(0, 0, 1))
+		}
+		if live < 0 {
+			live = 0
+		}
+
+		p := bandPerformance{
+			Flock:        band,
+			AgeWeeks:     band.AgeInWeeks(end),
+			Eggs:         totalEggs,
+			Days:         days,
+			Deaths:       deaths,
+			LiveBirds:    live,
+			CumMortality: float64(deaths) / float64(band.InitialCount) * 100,
+		}
+		if live > 0 && days > 0 {
+			p.LayingRate = float64(totalEggs) / float64(live*days) * 100
+		}
+		perf = append(perf, p)
+	}
+
+	return perf, nil
+}
+
+// formatFlockSection renders the per-band comparison against breed standards.
+func formatFlockSection(perf []bandPerformance) string {
+	if len(perf) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🐣 Flock vs standard:\n")
+	for _, p := range perf {
+		breed := p.Flock.Breed
+		if breed == "" {
+			breed = "unknown breed"
+		}
+		fmt.Fprintf(&builder, "• Band %d (%s, %d wks): lay %.1f%%", p.Flock.Band, breed, p.AgeWeeks, p.LayingRate)
+		std, ok := standardFor(p.Flock.Breed, p.AgeWeeks)
+		if ok {
+			fmt.Fprintf(&builder, " vs std %.1f%%", std.LayingRate)
+		}
+		fmt.Fprintf(&builder, ", mortality %.2f%%", p.CumMortality)
+		if ok {
+			fmt.Fprintf(&builder, " vs std %.2f%%", std.CumMortality)
+		}
+		builder.WriteString("\n")
+	}
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// formatCumulativeMortalityLine renders deaths since placement as a share of the
+// initial population per band, flagging bands running above their breed standard.
+func formatCumulativeMortalityLine(perf []bandPerformance) string {
+	if len(perf) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(perf))
+	for _, p := range perf {
+		part := fmt.Sprintf("B%d %.2f%% (%s/%s)", p.Flock.Band, p.CumMortality, formatInt(p.Deaths), formatInt(p.Flock.InitialCount))
+		if std, ok := standardFor(p.Flock.Breed, p.AgeWeeks); ok && p.CumMortality > std.CumMortality {
+			part += " ⚠️"
+		}
+		parts = append(parts, part)
+	}
+	return "🪦 Cumulative mortality: " + strings.Join(parts, " · ")
+}
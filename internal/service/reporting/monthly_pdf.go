@@ -0,0 +1,51 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mamadbah2/farmer/pkg/pdf"
+)
+
+// GenerateMonthlyReportPDF renders the monthly report of the calendar month
+// containing referenceDate as a PDF: the same totals as
+// GenerateMonthlyReport, followed by one row per stored daily report.
+func (s *Service) GenerateMonthlyReportPDF(ctx context.Context, referenceDate time.Time) ([]byte, error) {
+	summary, err := s.GenerateMonthlyReport(ctx, referenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	monthStart := time.Date(referenceDate.Year(), referenceDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	days, err := s.reportRepo.GetDailyReports(ctx, monthStart, monthStart.AddDate(0, 1, 0).Add(-time.Nanosecond))
+	if err != nil {
+		return nil, fmt.Errorf("fetch daily reports from mongodb: %w", err)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+
+	doc := pdf.New()
+	doc.Heading(fmt.Sprintf("Farm monthly report - %s", monthStart.Format("01/2006")))
+	doc.Text(summary)
+
+	doc.Heading(fmt.Sprintf("Daily breakdown (%s)", s.Currency()))
+	if len(days) == 0 {
+		doc.Text("No daily report stored for this month.")
+		return doc.Bytes(), nil
+	}
+	row := "%-10s %8s %5s %8s %12s %12s %12s"
+	doc.Text(fmt.Sprintf(row, "Date", "Eggs", "Dead", "Feed", "Sales", "Expenses", "Profit"))
+	for _, day := range days {
+		doc.Text(fmt.Sprintf(row,
+			day.Date.Format("02/01/2006"),
+			formatInt(day.EggsCollected),
+			formatInt(day.Mortality),
+			formatFloat(day.FeedConsumed, 1),
+			formatFloat(day.SalesAmount, 0),
+			formatFloat(day.Expenses, 0),
+			formatFloat(day.Profit, 0),
+		))
+	}
+	return doc.Bytes(), nil
+}
@@ -0,0 +1,41 @@
+package reporting
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/pkg/logger"
+)
+
+// populationAsOf returns the latest /population head count recorded on or
+// before asOf.
+func (s *Service) populationAsOf(ctx context.Context, asOf time.Time) (models.PopulationRecord, bool) {
+	records, err := s.records.Population.Between(ctx, time.Time{}, asOf)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Debug("population data unavailable", zap.Error(err))
+		return models.PopulationRecord{}, false
+	}
+
+	var latest models.PopulationRecord
+	found := false
+	for _, record := range records {
+		if found && record.Date.Before(latest.Date) {
+			continue
+		}
+		latest = record
+		found = true
+	}
+	return latest, found && latest.Total() > 0
+}
+
+// totalPopulation returns the flock size used by rate calculations: the
+// /population head count, falling back to the population column of feed rows.
+func (s *Service) totalPopulation(ctx context.Context, start, end time.Time) int {
+	if record, ok := s.populationAsOf(ctx, end); ok {
+		return record.Total()
+	}
+	return s.estimatePopulation(ctx, start, end)
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,87 +14,100 @@ import (
 	"github.com/mamadbah2/farmer/internal/domain/models"
 	"github.com/mamadbah2/farmer/internal/repository/mongodb"
 	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+	"github.com/mamadbah2/farmer/pkg/logger"
 )
 
-const (
-	dateLayout         = "2006-01-02"
-	eggsDataRange      = "Eggs!A:C"
-	feedDataRange      = "Feed!A:C"
-	mortalityDataRange = "Mortality!A:D"
-	salesDataRange     = "Sales!A:E"
-	expensesDataRange  = "Expenses!A:C"
-)
+const dateLayout = "2006-01-02"
+
+// Provider describes the reporting operations consumed by the scheduler and
+// command dispatcher. Alternative implementations (cached, Mongo-only, mocks)
+// can be injected wherever a Provider is expected.
+type Provider interface {
+	GenerateDailyReport(ctx context.Context, reportDate time.Time) (string, error)
+	GenerateWeeklyReport(ctx context.Context, referenceDate time.Time) (string, error)
+	GenerateMonthlyReport(ctx context.Context, referenceDate time.Time) (string, error)
+	GenerateMonthlyReportPDF(ctx context.Context, referenceDate time.Time) ([]byte, error)
+	CalculateEggsSummary(ctx context.Context, start, end time.Time) (string, error)
+	CalculateMortalityRate(ctx context.Context, start, end time.Time) (string, error)
+	CalculateFeedEfficiency(ctx context.Context, start, end time.Time) (string, error)
+	CalculateFeedRunway(ctx context.Context, asOf time.Time) (string, error)
+	FeedStockAlert(ctx context.Context, asOf time.Time, minDays int) (string, error)
+	OverdueDebts(ctx context.Context, asOf time.Time, minDays int) (string, error)
+}
+
+var _ Provider = (*Service)(nil)
+
+// Settings are the business constants the reports apply.
+type Settings struct {
+	// Currency labels every amount, GNF when empty.
+	Currency string
+	// CutoffHour is the hour before which a daily report covers the
+	// previous day, for reports sent after midnight.
+	CutoffHour int
+	// MortalityAlert is the daily death count above which the daily report
+	// warns; 0 disables the warning.
+	MortalityAlert int
+	// LowFeedDays is the feed runway, in days, under which reports and the
+	// feed stock reply warn; 0 disables the warning.
+	LowFeedDays int
+}
 
 // Service exposes lightweight analytics for WhatsApp summaries.
 type Service struct {
-	repo       repo.Repository
+	records    *repo.Entities
 	reportRepo mongodb.Repository
-	logger     *zap.Logger
+	// settings is replaced as a whole by Reload.
+	settings atomic.Pointer[Settings]
+	logger   *zap.Logger
 }
 
-// NewService wires a new reporting service instance.
-func NewService(repository repo.Repository, reportRepo mongodb.Repository, logger *zap.Logger) *Service {
+// NewService wires a new reporting service instance reading the tabs of layout.
+func NewService(repository repo.Repository, layout repo.Layout, reportRepo mongodb.Repository, settings Settings, logger *zap.Logger) *Service {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &Service{repo: repository, reportRepo: reportRepo, logger: logger}
+	svc := &Service{records: repo.NewEntities(repository, layout), reportRepo: reportRepo, logger: logger}
+	svc.settings.Store(&settings)
+	return svc
+}
+
+// Reload applies new settings to the reports generated from now on.
+func (s *Service) Reload(settings Settings) {
+	s.settings.Store(&settings)
 }
 
 // GenerateDailyReport aggregates key metrics for the provided date and formats a WhatsApp-ready message.
 func (s *Service) GenerateDailyReport(ctx context.Context, reportDate time.Time) (string, error) {
+	settings := s.settings.Load()
+	if reportDate.Hour() < settings.CutoffHour {
+		reportDate = reportDate.AddDate(0, 0, -1)
+	}
 	referenceDate := truncateToDay(reportDate)
-	previousDate := referenceDate.AddDate(0, 0, -1)
 
-	eggRows, err := s.repo.ReadRange(ctx, eggsDataRange)
-	if err != nil {
-		return "", fmt.Errorf("load eggs data: %w", err)
-	}
-	feedRows, err := s.repo.ReadRange(ctx, feedDataRange)
-	if err != nil {
-		return "", fmt.Errorf("load feed data: %w", err)
-	}
-	mortalityRows, err := s.repo.ReadRange(ctx, mortalityDataRange)
+	figures, err := s.loadDailyFigures(ctx, referenceDate)
 	if err != nil {
-		return "", fmt.Errorf("load mortality data: %w", err)
+		return "", err
 	}
-	salesRows, err := s.repo.ReadRange(ctx, salesDataRange)
-	if err != nil {
-		return "", fmt.Errorf("load sales data: %w", err)
-	}
-	expenseRows, err := s.repo.ReadRange(ctx, expensesDataRange)
-	if err != nil {
-		return "", fmt.Errorf("load expenses data: %w", err)
-	}
-
-	eggsToday, eggsPrev := aggregateEggs(eggRows, referenceDate, previousDate)
-	feedToday, feedPrev := aggregateFeed(feedRows, referenceDate, previousDate)
-	mortalityToday, mortalityPrev := aggregateMortality(mortalityRows, referenceDate, previousDate)
-	salesToday, salesPrev := aggregateSales(salesRows, referenceDate, previousDate)
-	expensesToday, expensesPrev := aggregateExpenses(expenseRows, referenceDate, previousDate)
-	profitToday := salesToday.Paid - expensesToday.Total
-	profitPrev := salesPrev.Paid - expensesPrev.Total
+	eggsToday, eggsPrev := figures.eggs, figures.eggsPrev
+	feedToday, feedPrev := figures.feed, figures.feedPrev
+	mortalityToday, mortalityPrev := figures.mortality, figures.mortalityPrev
+	salesToday, salesPrev := figures.sales, figures.salesPrev
+	expensesToday, expensesPrev := figures.expenses, figures.expensesPrev
+	collectedToday, collectedPrev := figures.collected, figures.collectedPrev
+	profitToday, profitPrev := figures.profit(), figures.profitPrev()
 
 	// Save to MongoDB
 	if s.reportRepo != nil {
-		report := models.DailyReport{
-			Date:          referenceDate,
-			EggsCollected: eggsToday,
-			Mortality:     mortalityToday,
-			FeedConsumed:  feedToday.TotalKg,
-			SalesAmount:   salesToday.Paid,
-			UnpaidBalance: salesToday.Unpaid,
-			Expenses:      expensesToday.Total,
-			Profit:        profitToday,
-			CreatedAt:     time.Now(),
-		}
+		report := figures.report(referenceDate)
+		report.CreatedAt = time.Now()
 		if err := s.reportRepo.SaveDailyReport(ctx, report); err != nil {
-			s.logger.Error("failed to save daily report to mongodb", zap.Error(err))
+			logger.FromContext(ctx, s.logger).Error("failed to save daily report to mongodb", zap.Error(err))
 		}
 	}
 
 	weeklySummary, err := s.GenerateWeeklyReport(ctx, referenceDate)
 	if err != nil {
-		s.logger.Debug("weekly summary failed", zap.Error(err))
+		logger.FromContext(ctx, s.logger).Debug("weekly summary failed", zap.Error(err))
 		weeklySummary = "Weekly summary will be available once data sync completes."
 	}
 
@@ -102,22 +116,136 @@ func (s *Service) GenerateDailyReport(ctx context.Context, reportDate time.Time)
 	fmt.Fprintf(&builder, "🐔 DAILY REPORT – %s\n", referenceDate.Format("02/01/2006"))
 	fmt.Fprintf(&builder, "🥚 Eggs collected: %s (%s vs yesterday)\n", formatInt(eggsToday), formatDelta(eggsToday-eggsPrev))
 	fmt.Fprintf(&builder, "🪦 Mortality: %s birds (%s vs yesterday)\n", formatInt(mortalityToday), formatDelta(mortalityToday-mortalityPrev))
+	if settings.MortalityAlert > 0 && mortalityToday > settings.MortalityAlert {
+		fmt.Fprintf(&builder, "⚠️ Mortality above the alert threshold of %s birds\n", formatInt(settings.MortalityAlert))
+	}
+	if perf, err := s.computeBandPerformance(ctx, referenceDate, referenceDate); err != nil {
+		logger.FromContext(ctx, s.logger).Debug("cumulative mortality unavailable", zap.Error(err))
+	} else if line := formatCumulativeMortalityLine(perf); line != "" {
+		fmt.Fprintf(&builder, "%s\n", line)
+	}
+	vaccinations, err := s.loadVaccinations(ctx, referenceDate.AddDate(0, 0, -vaccinationLookbackDays), referenceDate)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Debug("vaccinations unavailable", zap.Error(err))
+	} else if line := formatVaccinationLine(vaccinations); line != "" {
+		fmt.Fprintf(&builder, "%s\n", line)
+	}
+	if transfers, err := s.loadTransfers(ctx, referenceDate, referenceDate); err != nil {
+		logger.FromContext(ctx, s.logger).Debug("transfers unavailable", zap.Error(err))
+	} else if line := formatTransferLine(transfers); line != "" {
+		fmt.Fprintf(&builder, "%s\n", line)
+	}
 	feedLine := formatFeedLine(feedToday, feedPrev)
 	fmt.Fprintf(&builder, "%s\n", feedLine)
-	fmt.Fprintf(&builder, "💸 Sales: %s GNF (%s vs yesterday)\n", formatFloat(salesToday.Paid, 0), formatCurrencyDelta(salesToday.Paid-salesPrev.Paid))
-	fmt.Fprintf(&builder, "📉 Unpaid balance: %s GNF\n", formatFloat(salesToday.Unpaid, 0))
-	fmt.Fprintf(&builder, "🧾 Expenses: %s GNF (%s vs yesterday)\n", formatFloat(expensesToday.Total, 0), formatCurrencyDelta(expensesToday.Total-expensesPrev.Total))
-	fmt.Fprintf(&builder, "📈 Profit: %s GNF (%s vs yesterday)\n", formatFloat(profitToday, 0), formatCurrencyDelta(profitToday-profitPrev))
+	if level, err := s.computeFeedStock(ctx, referenceDate); err != nil {
+		logger.FromContext(ctx, s.logger).Debug("feed stock unavailable", zap.Error(err))
+	} else if line := formatFeedStockLine(level, settings.LowFeedDays); line != "" {
+		fmt.Fprintf(&builder, "%s\n", line)
+	}
+	fmt.Fprintf(&builder, "💸 Sales: %s (%s vs yesterday)\n", s.money(salesToday.Paid), formatCurrencyDelta(salesToday.Paid-salesPrev.Paid))
+	fmt.Fprintf(&builder, "📉 Unpaid balance: %s\n", s.money(salesToday.Unpaid))
+	if collectedToday > 0 || collectedPrev > 0 {
+		fmt.Fprintf(&builder, "💵 Debt collected: %s (%s vs yesterday)\n", s.money(collectedToday), formatCurrencyDelta(collectedToday-collectedPrev))
+	}
+	fmt.Fprintf(&builder, "🧾 Expenses: %s (%s vs yesterday)\n", s.money(expensesToday.Total), formatCurrencyDelta(expensesToday.Total-expensesPrev.Total))
+	fmt.Fprintf(&builder, "📈 Profit: %s (%s vs yesterday)\n", s.money(profitToday), formatCurrencyDelta(profitToday-profitPrev))
 	writeDivider(&builder)
 	fmt.Fprintf(&builder, "%s\n", weeklySummary)
 	writeDivider(&builder)
 	fmt.Fprintf(&builder, "Next goals: Increase survival rates and reduce feed cost.\n")
-	writeDivider(&builder)
-	builder.WriteString("TODO: Attach PDF dashboard and schedule broadcast once BI module ships.\n")
 
 	return builder.String(), nil
 }
 
+// DailyMetrics returns the totals of day as they stand now, computed from the
+// records like the daily report's, without saving anything.
+func (s *Service) DailyMetrics(ctx context.Context, day time.Time) (models.DailyReport, error) {
+	day = truncateToDay(day)
+	figures, err := s.loadDailyFigures(ctx, day)
+	if err != nil {
+		return models.DailyReport{}, err
+	}
+	return figures.report(day), nil
+}
+
+// dailyFigures are the totals of a day and of the day before it.
+type dailyFigures struct {
+	eggs, eggsPrev           int
+	mortality, mortalityPrev int
+	feed, feedPrev           feedSnapshot
+	sales, salesPrev         salesSnapshot
+	expenses, expensesPrev   expenseSnapshot
+	collected, collectedPrev float64
+}
+
+// loadDailyFigures reads the records of referenceDate and of the day before.
+func (s *Service) loadDailyFigures(ctx context.Context, referenceDate time.Time) (dailyFigures, error) {
+	previousDate := referenceDate.AddDate(0, 0, -1)
+
+	eggs, err := s.records.Eggs.Between(ctx, previousDate, referenceDate)
+	if err != nil {
+		return dailyFigures{}, fmt.Errorf("load eggs data: %w", err)
+	}
+	feed, err := s.records.Feed.Between(ctx, previousDate, referenceDate)
+	if err != nil {
+		return dailyFigures{}, fmt.Errorf("load feed data: %w", err)
+	}
+	mortality, err := s.records.Mortality.Between(ctx, previousDate, referenceDate)
+	if err != nil {
+		return dailyFigures{}, fmt.Errorf("load mortality data: %w", err)
+	}
+	sales, err := s.records.Sales.Between(ctx, previousDate, referenceDate)
+	if err != nil {
+		return dailyFigures{}, fmt.Errorf("load sales data: %w", err)
+	}
+	expenses, err := s.records.Expenses.Between(ctx, previousDate, referenceDate)
+	if err != nil {
+		return dailyFigures{}, fmt.Errorf("load expenses data: %w", err)
+	}
+	payments, err := s.records.Payments.Between(ctx, previousDate, referenceDate)
+	if err != nil {
+		// The Payments tab is optional until the first /paiement.
+		logger.FromContext(ctx, s.logger).Debug("payments data unavailable", zap.Error(err))
+	}
+
+	var f dailyFigures
+	f.eggs, f.eggsPrev = aggregateEggs(eggs, referenceDate, previousDate)
+	f.feed, f.feedPrev = aggregateFeed(feed, referenceDate, previousDate)
+	if record, ok := s.populationAsOf(ctx, referenceDate); ok {
+		f.feed.Population = record.Total()
+	}
+	f.mortality, f.mortalityPrev = aggregateMortality(mortality, referenceDate, previousDate)
+	f.sales, f.salesPrev = aggregateSales(sales, referenceDate, previousDate)
+	f.expenses, f.expensesPrev = aggregateExpenses(expenses, referenceDate, previousDate)
+	f.collected, f.collectedPrev = aggregatePayments(payments, referenceDate, previousDate)
+	return f, nil
+}
+
+// profit is the day's cash result: debt repayments count on the day the
+// money comes in.
+func (f dailyFigures) profit() float64 {
+	return f.sales.Paid + f.collected - f.expenses.Total
+}
+
+func (f dailyFigures) profitPrev() float64 {
+	return f.salesPrev.Paid + f.collectedPrev - f.expensesPrev.Total
+}
+
+// report returns the figures of the day as a daily_reports document.
+func (f dailyFigures) report(date time.Time) models.DailyReport {
+	return models.DailyReport{
+		Date:          date,
+		EggsCollected: f.eggs,
+		Mortality:     f.mortality,
+		FeedConsumed:  f.feed.TotalKg,
+		SalesAmount:   f.sales.Paid,
+		UnpaidBalance: f.sales.Unpaid,
+		DebtCollected: f.collected,
+		Expenses:      f.expenses.Total,
+		Profit:        f.profit(),
+	}
+}
+
 // GenerateWeeklyReport produces a lightweight overview for the week of the provided date.
 func (s *Service) GenerateWeeklyReport(ctx context.Context, referenceDate time.Time) (string, error) {
 	weekEnd := truncateToDay(referenceDate)
@@ -133,54 +261,81 @@ func (s *Service) GenerateWeeklyReport(ctx context.Context, referenceDate time.T
 	}
 
 	var weeklyEggs, weeklyMortality int
-	var weeklyFeed, weeklySales, weeklyExpenses, weeklyProfit float64
+	var weeklyFeed, weeklySales, weeklyCollected, weeklyExpenses, weeklyProfit float64
 
 	for _, r := range reports {
 		weeklyEggs += r.EggsCollected
 		weeklyMortality += r.Mortality
 		weeklyFeed += r.FeedConsumed
 		weeklySales += r.SalesAmount
+		weeklyCollected += r.DebtCollected
 		weeklyExpenses += r.Expenses
 		weeklyProfit += r.Profit
 	}
 
-	return fmt.Sprintf("Weekly summary (%s-%s) – 🥚 %s eggs, 🌾 %.2f kg feed, 🪦 %s mortality, 💸 %s GNF sales, 🧾 %s GNF expenses, 📈 %s GNF profit.",
+	summary := fmt.Sprintf("Weekly summary (%s-%s) – 🥚 %s eggs, 🌾 %.2f kg feed, 🪦 %s mortality, 💸 %s sales, 💵 %s debt collected, 🧾 %s expenses, 📈 %s profit.",
 		weekStart.Format("02/01"), weekEnd.Format("02/01"), formatInt(weeklyEggs), weeklyFeed, formatInt(weeklyMortality),
-		formatFloat(weeklySales, 0), formatFloat(weeklyExpenses, 0), formatFloat(weeklyProfit, 0)), nil
-}
+		s.money(weeklySales), s.money(weeklyCollected), s.money(weeklyExpenses), s.money(weeklyProfit))
 
-// CalculateEggsSummary aggregates egg production for a period and returns a formatted string.
-func (s *Service) CalculateEggsSummary(ctx context.Context, start, end time.Time) (string, error) {
-	rows, err := s.repo.ReadRange(ctx, eggsDataRange)
+	perf, err := s.computeBandPerformance(ctx, weekStart, weekEnd)
 	if err != nil {
-		return "", fmt.Errorf("load eggs range: %w", err)
+		logger.FromContext(ctx, s.logger).Debug("flock performance unavailable", zap.Error(err))
+	} else if section := formatFlockSection(perf); section != "" {
+		summary += "\n" + section
 	}
 
-	var total int
-	var entries int
+	return summary, nil
+}
 
-	for _, row := range rows {
-		if len(row) < 2 {
-			continue
-		}
+// GenerateMonthlyReport summarises the calendar month containing the provided
+// date from MongoDB's monthly aggregation, compared with the previous month.
+func (s *Service) GenerateMonthlyReport(ctx context.Context, referenceDate time.Time) (string, error) {
+	if s.reportRepo == nil {
+		return "", fmt.Errorf("mongodb repository not initialized")
+	}
 
-		dateValue, err := parseDate(row[0])
-		if err != nil {
-			s.logger.Debug("skip eggs row with invalid date", zap.Any("value", row[0]), zap.Error(err))
-			continue
-		}
-		if dateValue.Before(start) || dateValue.After(end) {
-			continue
-		}
+	monthStart := time.Date(referenceDate.Year(), referenceDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	previousStart := monthStart.AddDate(0, -1, 0)
+	stats, err := s.reportRepo.GetMonthlyStats(ctx, previousStart, monthStart.AddDate(0, 1, 0))
+	if err != nil {
+		return "", fmt.Errorf("fetch monthly stats from mongodb: %w", err)
+	}
 
-		qty, err := parseInt(row[1])
-		if err != nil {
-			s.logger.Debug("skip eggs row with invalid qty", zap.Any("value", row[1]), zap.Error(err))
-			continue
+	var current, previous models.MonthlyStats
+	for _, month := range stats {
+		switch {
+		case month.Month.Equal(monthStart):
+			current = month
+		case month.Month.Equal(previousStart):
+			previous = month
 		}
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Monthly report (%s)\n", monthStart.Format("01/2006"))
+	fmt.Fprintf(&builder, "🥚 Eggs: %s (avg %s/day over %d days)\n",
+		formatInt(current.Eggs), formatFloat(current.AvgEggsPerDay, 0), current.EggDays)
+	fmt.Fprintf(&builder, "💸 Sales: %s trays, %s (avg %s/tray), %s paid\n",
+		formatInt(current.TraysSold), s.money(current.SalesAmount), s.money(current.AvgTrayPrice), s.money(current.SalesPaid))
+	fmt.Fprintf(&builder, "💵 Debt collected: %s\n", s.money(current.DebtCollected))
+	fmt.Fprintf(&builder, "🧾 Expenses: %s (%s vs last month)\n",
+		s.money(current.Expenses), formatCurrencyDelta(current.Expenses-previous.Expenses))
+	fmt.Fprintf(&builder, "📈 Profit: %s (%s vs last month)",
+		s.money(current.Profit), formatCurrencyDelta(current.Profit-previous.Profit))
+	return builder.String(), nil
+}
+
+// CalculateEggsSummary aggregates egg production for a period and returns a formatted string.
+func (s *Service) CalculateEggsSummary(ctx context.Context, start, end time.Time) (string, error) {
+	records, err := s.records.Eggs.Between(ctx, start, end)
+	if err != nil {
+		return "", fmt.Errorf("load eggs range: %w", err)
+	}
 
-		total += qty
-		entries++
+	var total int
+	entries := len(records)
+	for _, record := range records {
+		total += record.Quantity
 	}
 
 	if entries == 0 {
@@ -192,39 +347,22 @@ func (s *Service) CalculateEggsSummary(ctx context.Context, start, end time.Time
 
 // CalculateMortalityRate produces a simple mortality ratio using the latest population information.
 func (s *Service) CalculateMortalityRate(ctx context.Context, start, end time.Time) (string, error) {
-	rows, err := s.repo.ReadRange(ctx, mortalityDataRange)
+	records, err := s.records.Mortality.Between(ctx, start, end)
 	if err != nil {
 		return "", fmt.Errorf("load mortality range: %w", err)
 	}
 
 	var totalDeaths int
-	var events int
-
-	for _, row := range rows {
-		if len(row) < 2 {
-			continue
-		}
-
-		dateValue, err := parseDate(row[0])
-		if err != nil || dateValue.Before(start) || dateValue.After(end) {
-			continue
-		}
-
-		qty, err := parseInt(row[1])
-		if err != nil {
-			s.logger.Debug("skip mortality row with invalid qty", zap.Any("value", row[1]), zap.Error(err))
-			continue
-		}
-
-		totalDeaths += qty
-		events++
+	events := len(records)
+	for _, record := range records {
+		totalDeaths += record.Band1 + record.Band2 + record.Band3
 	}
 
 	if events == 0 {
 		return fmt.Sprintf("Mortality (%s-%s): no incidents logged.", start.Format(dateLayout), end.Format(dateLayout)), nil
 	}
 
-	population := s.estimatePopulation(ctx, start, end)
+	population := s.totalPopulation(ctx, start, end)
 
 	var ratioStatement string
 	if population > 0 {
@@ -232,7 +370,7 @@ func (s *Service) CalculateMortalityRate(ctx context.Context, start, end time.Ti
 		rate = math.Round(rate*100) / 100
 		ratioStatement = fmt.Sprintf("Mortality rate %.2f%% based on population %d.", rate, population)
 	} else {
-		ratioStatement = "Population unknown. Log /feed with population to compute rate."
+		ratioStatement = "Population unknown. Log /population B1 1500 B2 1480 B3 1500 to compute rate."
 	}
 
 	return fmt.Sprintf("Mortality (%s-%s): %d deaths across %d reports. %s", start.Format(dateLayout), end.Format(dateLayout), totalDeaths, events, ratioStatement), nil
@@ -240,48 +378,27 @@ func (s *Service) CalculateMortalityRate(ctx context.Context, start, end time.Ti
 
 // CalculateFeedEfficiency estimates feed usage per bird for a period.
 func (s *Service) CalculateFeedEfficiency(ctx context.Context, start, end time.Time) (string, error) {
-	rows, err := s.repo.ReadRange(ctx, feedDataRange)
+	records, err := s.records.Feed.Between(ctx, start, end)
 	if err != nil {
 		return "", fmt.Errorf("load feed range: %w", err)
 	}
 
 	var totalFeed float64
 	var population int
-	var entries int
-
-	for _, row := range rows {
-		if len(row) < 2 {
-			continue
-		}
-
-		dateValue, err := parseDate(row[0])
-		if err != nil || dateValue.Before(start) || dateValue.After(end) {
-			continue
-		}
-
-		feedValue, err := parseFloat(row[1])
-		if err != nil {
-			s.logger.Debug("skip feed row with invalid feedkg", zap.Any("value", row[1]), zap.Error(err))
-			continue
-		}
-
-		totalFeed += feedValue
-		thisPopulation := 0
-		if len(row) > 2 {
-			if pop, err := parseInt(row[2]); err == nil {
-				thisPopulation = pop
-			}
+	entries := len(records)
+	for _, record := range records {
+		totalFeed += record.FeedKg
+		if record.Population > 0 {
+			population = record.Population
 		}
-
-		if thisPopulation > 0 {
-			population = thisPopulation
-		}
-		entries++
 	}
 
 	if entries == 0 {
 		return fmt.Sprintf("Feed (%s-%s): awaiting data.", start.Format(dateLayout), end.Format(dateLayout)), nil
 	}
+	if record, ok := s.populationAsOf(ctx, end); ok {
+		population = record.Total()
+	}
 
 	var efficiencyStatement string
 	if population > 0 {
@@ -297,65 +414,21 @@ func (s *Service) CalculateFeedEfficiency(ctx context.Context, start, end time.T
 // TODO: integrate with scheduled reports & dashboards when cron engine is introduced.
 
 func (s *Service) estimatePopulation(ctx context.Context, start, end time.Time) int {
-	rows, err := s.repo.ReadRange(ctx, feedDataRange)
+	records, err := s.records.Feed.Between(ctx, start, end)
 	if err != nil {
-		s.logger.Debug("fallback population lookup failed", zap.Error(err))
+		logger.FromContext(ctx, s.logger).Debug("fallback population lookup failed", zap.Error(err))
 		return 0
 	}
 
-	for i := len(rows) - 1; i >= 0; i-- {
-		row := rows[i]
-		if len(row) < 3 {
-			continue
-		}
-
-		dateValue, err := parseDate(row[0])
-		if err != nil {
-			continue
-		}
-
-		if dateValue.Before(start) || dateValue.After(end) {
-			continue
-		}
-
-		pop, err := parseInt(row[2])
-		if err != nil || pop <= 0 {
-			continue
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Population > 0 {
+			return records[i].Population
 		}
-
-		return pop
 	}
 
 	return 0
 }
 
-func parseDate(value interface{}) (time.Time, error) {
-	str := fmt.Sprint(value)
-	if str == "" {
-		return time.Time{}, fmt.Errorf("empty date")
-	}
-	if len(str) > 10 {
-		str = str[:10]
-	}
-	return time.Parse(dateLayout, str)
-}
-
-func parseInt(value interface{}) (int, error) {
-	str := fmt.Sprint(value)
-	if str == "" {
-		return 0, fmt.Errorf("empty numeric value")
-	}
-	return strconv.Atoi(str)
-}
-
-func parseFloat(value interface{}) (float64, error) {
-	str := fmt.Sprint(value)
-	if str == "" {
-		return 0, fmt.Errorf("empty numeric value")
-	}
-	return strconv.ParseFloat(str, 64)
-}
-
 type feedSnapshot struct {
 	TotalKg    float64
 	Population int
@@ -371,187 +444,103 @@ type expenseSnapshot struct {
 	Total float64
 }
 
-func aggregateEggs(rows [][]interface{}, target, previous time.Time) (int, int) {
+func aggregateEggs(records []models.EggRecord, target, previous time.Time) (int, int) {
 	var today, prev int
-	targetKey := target.Format(dateLayout)
-	prevKey := previous.Format(dateLayout)
-
-	for _, row := range rows {
-		if len(row) < 2 {
-			continue
-		}
-		dateValue, err := parseDate(row[0])
-		if err != nil {
-			continue
-		}
-		qty, err := parseInt(row[1])
-		if err != nil {
-			continue
-		}
-		switch dateValue.Format(dateLayout) {
-		case targetKey:
-			today += qty
-		case prevKey:
-			prev += qty
+	for _, r := range records {
+		switch {
+		case sameDay(r.Date, target):
+			today += r.Quantity
+		case sameDay(r.Date, previous):
+			prev += r.Quantity
 		}
 	}
-
 	return today, prev
 }
 
-func aggregateMortality(rows [][]interface{}, target, previous time.Time) (int, int) {
+func aggregateMortality(records []models.MortalityRecord, target, previous time.Time) (int, int) {
 	var today, prev int
-	targetKey := target.Format(dateLayout)
-	prevKey := previous.Format(dateLayout)
-
-	for _, row := range rows {
-		if len(row) < 4 {
-			continue
-		}
-		dateValue, err := parseDate(row[0])
-		if err != nil {
-			continue
-		}
-
-		b1, _ := parseInt(row[1])
-		b2, _ := parseInt(row[2])
-		b3, _ := parseInt(row[3])
-		qty := b1 + b2 + b3
-
-		switch dateValue.Format(dateLayout) {
-		case targetKey:
+	for _, r := range records {
+		qty := r.Band1 + r.Band2 + r.Band3
+		switch {
+		case sameDay(r.Date, target):
 			today += qty
-		case prevKey:
+		case sameDay(r.Date, previous):
 			prev += qty
 		}
 	}
-
 	return today, prev
 }
 
-func aggregateFeed(rows [][]interface{}, target, previous time.Time) (feedSnapshot, feedSnapshot) {
-	var today feedSnapshot
-	var prev feedSnapshot
-	targetKey := target.Format(dateLayout)
-	prevKey := previous.Format(dateLayout)
-
-	for _, row := range rows {
-		if len(row) < 2 {
-			continue
-		}
-		dateValue, err := parseDate(row[0])
-		if err != nil {
-			continue
-		}
-		feedKg, err := parseFloat(row[1])
-		if err != nil {
-			continue
-		}
-		population := 0
-		if len(row) > 2 {
-			if pop, err := parseInt(row[2]); err == nil && pop > 0 {
-				population = pop
-			}
-		}
-
+func aggregateFeed(records []models.FeedRecord, target, previous time.Time) (feedSnapshot, feedSnapshot) {
+	var today, prev feedSnapshot
+	for _, r := range records {
 		var snapshot *feedSnapshot
-		switch dateValue.Format(dateLayout) {
-		case targetKey:
+		switch {
+		case sameDay(r.Date, target):
 			snapshot = &today
-		case prevKey:
+		case sameDay(r.Date, previous):
 			snapshot = &prev
 		default:
 			continue
 		}
 
-		snapshot.TotalKg += feedKg
-		if population > 0 {
-			snapshot.Population = population
+		snapshot.TotalKg += r.FeedKg
+		if r.Population > 0 {
+			snapshot.Population = r.Population
 		}
 	}
-
 	return today, prev
 }
 
-func aggregateSales(rows [][]interface{}, target, previous time.Time) (salesSnapshot, salesSnapshot) {
-	var today salesSnapshot
-	var prev salesSnapshot
-	targetKey := target.Format(dateLayout)
-	prevKey := previous.Format(dateLayout)
-
-	for _, row := range rows {
-		if len(row) < 4 {
-			continue
-		}
-		dateValue, err := parseDate(row[0])
-		if err != nil {
-			continue
-		}
-		qty, err := parseInt(row[2])
-		if err != nil {
-			continue
-		}
-		price, err := parseFloat(row[3])
-		if err != nil {
-			continue
-		}
-		paid := price * float64(qty)
-		if len(row) > 4 {
-			if v, err := parseFloat(row[4]); err == nil {
-				paid = v
-			}
-		}
-		expected := float64(qty) * price
-		unpaid := expected - paid
-		if unpaid < 0 {
-			unpaid = 0
-		}
-
+func aggregateSales(records []models.SaleRecord, target, previous time.Time) (salesSnapshot, salesSnapshot) {
+	var today, prev salesSnapshot
+	for _, r := range records {
 		var snapshot *salesSnapshot
-		switch dateValue.Format(dateLayout) {
-		case targetKey:
+		switch {
+		case sameDay(r.Date, target):
 			snapshot = &today
-		case prevKey:
+		case sameDay(r.Date, previous):
 			snapshot = &prev
 		default:
 			continue
 		}
 
-		snapshot.Paid += paid
+		expected := float64(r.Quantity) * r.PricePerUnit
+		unpaid := expected - r.Paid
+		if unpaid < 0 {
+			unpaid = 0
+		}
+		snapshot.Paid += r.Paid
 		snapshot.Expected += expected
 		snapshot.Unpaid += unpaid
 	}
-
 	return today, prev
 }
 
-func aggregateExpenses(rows [][]interface{}, target, previous time.Time) (expenseSnapshot, expenseSnapshot) {
-	var today expenseSnapshot
-	var prev expenseSnapshot
-	targetKey := target.Format(dateLayout)
-	prevKey := previous.Format(dateLayout)
-
-	for _, row := range rows {
-		if len(row) < 3 {
-			continue
-		}
-		dateValue, err := parseDate(row[0])
-		if err != nil {
-			continue
-		}
-		amount, err := parseFloat(row[2])
-		if err != nil {
-			continue
+// aggregatePayments sums debt repayments.
+func aggregatePayments(records []models.PaymentRecord, target, previous time.Time) (float64, float64) {
+	var today, prev float64
+	for _, r := range records {
+		switch {
+		case sameDay(r.Date, target):
+			today += r.Amount
+		case sameDay(r.Date, previous):
+			prev += r.Amount
 		}
+	}
+	return today, prev
+}
 
-		switch dateValue.Format(dateLayout) {
-		case targetKey:
-			today.Total += amount
-		case prevKey:
-			prev.Total += amount
+func aggregateExpenses(records []models.ExpenseRecord, target, previous time.Time) (expenseSnapshot, expenseSnapshot) {
+	var today, prev expenseSnapshot
+	for _, r := range records {
+		switch {
+		case sameDay(r.Date, target):
+			today.Total += r.Amount
+		case sameDay(r.Date, previous):
+			prev.Total += r.Amount
 		}
 	}
-
 	return today, prev
 }
 
@@ -574,6 +563,19 @@ func formatDelta(delta int) string {
 	return "no change"
 }
 
+// Currency returns the label of amounts, GNF by default.
+func (s *Service) Currency() string {
+	if currency := s.settings.Load().Currency; currency != "" {
+		return currency
+	}
+	return "GNF"
+}
+
+// money formats a whole amount followed by the currency.
+func (s *Service) money(amount float64) string {
+	return formatFloat(amount, 0) + " " + s.Currency()
+}
+
 func formatCurrencyDelta(delta float64) string {
 	if delta > 0 {
 		return "+" + formatFloat(delta, 0)
@@ -639,6 +641,11 @@ func writeDivider(builder *strings.Builder) {
 	builder.WriteString("----------------------------------------------------\n")
 }
 
+// sameDay compares calendar days; sheet dates are parsed as UTC midnight.
+func sameDay(a, b time.Time) bool {
+	return a.Format(dateLayout) == b.Format(dateLayout)
+}
+
 func truncateToDay(t time.Time) time.Time {
 	y, m, d := t.Date()
 	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
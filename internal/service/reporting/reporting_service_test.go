@@ -0,0 +1,35 @@
+package reporting
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	repo "github.com/mamadbah2/farmer/internal/repository/sheets"
+)
+
+// emptySheets answers every tab with no rows.
+type emptySheets struct {
+	repo.Repository
+}
+
+func (emptySheets) ReadRange(ctx context.Context, sheetRange string) ([][]interface{}, error) {
+	return nil, nil
+}
+
+func (emptySheets) ReadSince(ctx context.Context, sheetRange string, since time.Time) ([][]interface{}, error) {
+	return nil, nil
+}
+
+func TestDailyReportEndsWithTheGoals(t *testing.T) {
+	svc := NewService(emptySheets{}, repo.Layout{}, nil, Settings{}, nil)
+
+	report, err := svc.GenerateDailyReport(context.Background(), time.Date(2026, 10, 15, 20, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GenerateDailyReport: %v", err)
+	}
+	if !strings.HasSuffix(report, "Next goals: Increase survival rates and reduce feed cost.\n") || strings.Contains(report, "TODO") {
+		t.Errorf("report ends with %q, want the goals and no placeholder", report[strings.LastIndex(strings.TrimSuffix(report, "\n"), "\n")+1:])
+	}
+}
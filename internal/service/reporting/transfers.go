@@ -0,0 +1,53 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// loadTransfers returns the bird transfers logged between start and end (inclusive).
+func (s *Service) loadTransfers(ctx context.Context, start, end time.Time) ([]models.TransferRecord, error) {
+	records, err := s.records.Transfers.Between(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("load transfers data: %w", err)
+	}
+	return records, nil
+}
+
+// netTransfers returns the birds a band gained (positive) or lost (negative)
+// through transfers dated on or after since.
+func netTransfers(records []models.TransferRecord, band int, since time.Time) int {
+	net := 0
+	for _, r := range records {
+		if r.Date.Before(truncateToDay(since)) {
+			continue
+		}
+		switch band {
+		case r.FromBand:
+			net -= r.Quantity
+		case r.ToBand:
+			net += r.Quantity
+		}
+	}
+	return net
+}
+
+func formatTransferLine(records []models.TransferRecord) string {
+	if len(records) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(records))
+	for _, r := range records {
+		part := fmt.Sprintf("B%d→B%d %s", r.FromBand, r.ToBand, formatInt(r.Quantity))
+		if r.Reason != "" {
+			part += fmt.Sprintf(" (%s)", r.Reason)
+		}
+		parts = append(parts, part)
+	}
+	return "🔀 Transfers: " + strings.Join(parts, ", ")
+}
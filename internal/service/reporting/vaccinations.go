@@ -0,0 +1,39 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// vaccinationLookbackDays controls how far back treatments are listed next
+// to mortality so post-vaccination losses can be spotted.
+const vaccinationLookbackDays = 7
+
+// loadVaccinations returns the treatments logged between start and end (inclusive).
+func (s *Service) loadVaccinations(ctx context.Context, start, end time.Time) ([]models.VaccinationRecord, error) {
+	records, err := s.records.Vaccinations.Between(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("load vaccinations data: %w", err)
+	}
+	return records, nil
+}
+
+func formatVaccinationLine(records []models.VaccinationRecord) string {
+	if len(records) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(records))
+	for _, r := range records {
+		target := "all"
+		if r.Band > 0 {
+			target = fmt.Sprintf("B%d", r.Band)
+		}
+		parts = append(parts, fmt.Sprintf("%s %s (%s)", r.Vaccine, target, r.Date.Format("02/01")))
+	}
+	return "💉 Recent treatments: " + strings.Join(parts, ", ")
+}
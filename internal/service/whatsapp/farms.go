@@ -0,0 +1,84 @@
+package whatsapp
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// FarmRouter serves several farms from one webhook. Each inbound message
+// goes to the farm whose WhatsApp number received it or, among farms sharing
+// that number, to the one whose staff includes the sender; unknown senders
+// go to the first of them. Outbound messages leave from the default farm.
+type FarmRouter struct {
+	farms []*MetaWhatsAppService
+}
+
+// NewFarmRouter routes between defaultFarm, which also answers the webhook
+// verification and /send-message, and others.
+func NewFarmRouter(defaultFarm *MetaWhatsAppService, others ...*MetaWhatsAppService) *FarmRouter {
+	return &FarmRouter{farms: append([]*MetaWhatsAppService{defaultFarm}, others...)}
+}
+
+// VerifyWebhookToken checks the default farm's verify token; the farms
+// share the webhook.
+func (r *FarmRouter) VerifyWebhookToken(mode, verifyToken, challenge string) (string, error) {
+	return r.farms[0].VerifyWebhookToken(mode, verifyToken, challenge)
+}
+
+// HandleWebhook hands every message of payload to its farm, in order, and
+// returns the first error.
+func (r *FarmRouter) HandleWebhook(ctx context.Context, payload models.WebhookPayload) error {
+	if len(r.farms) == 1 {
+		return r.farms[0].HandleWebhook(ctx, payload)
+	}
+
+	var firstErr error
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			for _, msg := range change.Value.Messages {
+				single := change
+				single.Value.Messages = []models.InboundMessage{msg}
+				farm := r.route(change.Value.Metadata.PhoneNumberID, msg.From)
+				err := farm.HandleWebhook(ctx, models.WebhookPayload{
+					Object: payload.Object,
+					Entry:  []models.WebhookEntry{{ID: entry.ID, Changes: []models.WebhookChange{single}}},
+				})
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	}
+	return firstErr
+}
+
+// route picks the farm of a message from sender received on phoneNumberID.
+func (r *FarmRouter) route(phoneNumberID, sender string) *MetaWhatsAppService {
+	var candidates []*MetaWhatsAppService
+	for _, farm := range r.farms {
+		if phoneNumberID == "" || farm.cfg.Load().PhoneNumberID == phoneNumberID {
+			candidates = append(candidates, farm)
+		}
+	}
+	if len(candidates) == 0 {
+		return r.farms[0]
+	}
+	for _, farm := range candidates {
+		if slices.Contains(farm.cfg.Load().Staff(), sender) {
+			return farm
+		}
+	}
+	return candidates[0]
+}
+
+// SendOutbound sends req from the default farm's number.
+func (r *FarmRouter) SendOutbound(ctx context.Context, req models.OutboundMessageRequest) error {
+	return r.farms[0].SendOutbound(ctx, req)
+}
+
+// SendDocument sends req from the default farm's number.
+func (r *FarmRouter) SendDocument(ctx context.Context, req models.DocumentMessageRequest) error {
+	return r.farms[0].SendDocument(ctx, req)
+}
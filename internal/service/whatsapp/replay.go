@@ -0,0 +1,53 @@
+package whatsapp
+
+import (
+	"context"
+
+	client "github.com/mamadbah2/farmer/pkg/clients/whatsapp"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
+)
+
+// ReplayResult is the outcome of a replayed webhook.
+type ReplayResult struct {
+	DryRun bool `json:"dry_run"`
+	// Error is the first message's failure, if any.
+	Error string `json:"error,omitempty"`
+	// Replies are the messages that would have been sent, in dry-run mode.
+	Replies []client.SentMessage `json:"replies,omitempty"`
+}
+
+// Replayer processes webhook payloads again, to reproduce parsing or AI
+// issues from the message audit.
+type Replayer struct {
+	live   MessagingService
+	dryRun MessagingService
+}
+
+// NewReplayer replays through live, the service answering the webhook, or
+// through dryRun, a service built over dry-run repositories and a
+// client.RecordingClient so nothing is written or sent.
+func NewReplayer(live, dryRun MessagingService) *Replayer {
+	return &Replayer{live: live, dryRun: dryRun}
+}
+
+// Replay runs payload through the dry-run service, returning the replies it
+// would have sent, or, with dryRun false, through the live one like a new
+// delivery from Meta: records are saved, replies sent and the messages
+// audited again.
+func (r *Replayer) Replay(ctx context.Context, payload models.WebhookPayload, dryRun bool) ReplayResult {
+	result := ReplayResult{DryRun: dryRun}
+	var err error
+	if dryRun {
+		var transcript *client.Transcript
+		ctx, transcript = client.WithTranscript(ctx)
+		err = r.dryRun.HandleWebhook(ctx, payload)
+		result.Replies = transcript.Messages()
+	} else {
+		err = r.live.HandleWebhook(ctx, payload)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"github.com/mamadbah2/farmer/internal/config"
@@ -14,41 +19,95 @@ import (
 	commandsvc "github.com/mamadbah2/farmer/internal/service/commands"
 	"github.com/mamadbah2/farmer/pkg/clients/anthropic"
 	client "github.com/mamadbah2/farmer/pkg/clients/whatsapp"
+	"github.com/mamadbah2/farmer/pkg/logger"
+	"github.com/mamadbah2/farmer/pkg/tracing"
 )
 
+var tracer = otel.Tracer("github.com/mamadbah2/farmer/internal/service/whatsapp")
+
 // MessagingService describes the operations the HTTP layer can perform.
 type MessagingService interface {
 	VerifyWebhookToken(mode, verifyToken, challenge string) (string, error)
 	HandleWebhook(ctx context.Context, payload models.WebhookPayload) error
 	SendOutbound(ctx context.Context, req models.OutboundMessageRequest) error
+	SendDocument(ctx context.Context, req models.DocumentMessageRequest) error
+}
+
+// MessageRecorder stores inbound messages for the admin audit trail.
+type MessageRecorder interface {
+	SaveMessageAudit(ctx context.Context, entry models.MessageAuditEntry) error
+}
+
+// Flags tells whether a feature is on for a sender; see
+// internal/service/flags.
+type Flags interface {
+	Enabled(feature models.Feature, user string) bool
 }
 
 // MetaWhatsAppService is the production implementation backed by WhatsApp Cloud API.
 type MetaWhatsAppService struct {
-	cfg        config.WhatsAppConfig
+	// cfg is replaced as a whole by Reload.
+	cfg        atomic.Pointer[config.WhatsAppConfig]
 	client     client.Client
 	aiClient   anthropic.Client
 	dispatcher commandsvc.Dispatcher
+	messages   MessageRecorder
+	flags      Flags
 	sessions   *SessionManager
 	logger     *zap.Logger
 }
 
-// NewMetaWhatsAppService wires a new service instance.
-func NewMetaWhatsAppService(cfg config.WhatsAppConfig, client client.Client, aiClient anthropic.Client, dispatcher commandsvc.Dispatcher, logger *zap.Logger) *MetaWhatsAppService {
+// NewMetaWhatsAppService wires a new service instance. messages may be nil,
+// in which case inbound messages are not recorded. flags may be nil, in
+// which case every sender talks to the AI client when there is one.
+func NewMetaWhatsAppService(cfg config.WhatsAppConfig, client client.Client, aiClient anthropic.Client, dispatcher commandsvc.Dispatcher, messages MessageRecorder, flags Flags, logger *zap.Logger) *MetaWhatsAppService {
 	svc := &MetaWhatsAppService{
-		cfg:        cfg,
 		client:     client,
 		aiClient:   aiClient,
 		dispatcher: dispatcher,
+		messages:   messages,
+		flags:      flags,
 		sessions:   NewSessionManager(),
 		logger:     logger,
 	}
 	if svc.logger == nil {
 		svc.logger = zap.NewNop()
 	}
+	svc.cfg.Store(&cfg)
 	return svc
 }
 
+// Reload applies new staff numbers and roles; conversations in progress
+// carry on. The client keeps the credentials it was built with.
+func (s *MetaWhatsAppService) Reload(cfg config.WhatsAppConfig) {
+	s.cfg.Store(&cfg)
+}
+
+// Sessions returns the conversations in progress and the commands awaiting
+// confirmation on this instance, with the staff member's name.
+func (s *MetaWhatsAppService) Sessions() []Session {
+	cfg := s.cfg.Load()
+	sessions := s.sessions.List()
+	for i := range sessions {
+		if user, ok := cfg.User(sessions[i].WaID); ok {
+			sessions[i].User = user.Name
+		}
+	}
+	return sessions
+}
+
+// ResetSession drops waID's conversation and pending command, so their next
+// message starts afresh, and reports whether there was any.
+func (s *MetaWhatsAppService) ResetSession(waID string) bool {
+	return s.sessions.Reset(waID)
+}
+
+// Button IDs accepted as answers to a confirmation request.
+const (
+	confirmYesID = "confirm_yes"
+	confirmNoID  = "confirm_no"
+)
+
 var commandReplies = map[models.CommandType]models.AutomationReply{
 	models.CommandEggs: {
 		Title:   "Egg Collection",
@@ -58,21 +117,77 @@ var commandReplies = map[models.CommandType]models.AutomationReply{
 		Title:   "Feed Usage",
 		Message: "Share feed consumption with remaining inventory, e.g. /feed 6 bags remaining 20 bags.",
 	},
+	models.CommandFeedStock: {
+		Title:   "Feed Stock",
+		Message: "Log a feed delivery with bags, kg per bag and price per bag, e.g. /alimentstock 20 50 350000. Send /alimentstock alone to see the stock left.",
+	},
 	models.CommandMortality: {
 		Title:   "Mortality Update",
 		Message: "Report mortality per band, e.g. /mortality 1 0 2 (Band1 Band2 Band3).",
 	},
+	models.CommandPopulation: {
+		Title:   "Population",
+		Message: "Report live birds per band, e.g. /population B1 1500 B2 1480 B3 1500.",
+	},
 	models.CommandSales: {
 		Title:   "Sales Report",
 		Message: "Capture livestock or egg sales, e.g. /sales 10 crates 250000.",
 	},
+	models.CommandReception: {
+		Title:   "Egg Reception",
+		Message: "Log trays received from the farm with an optional unit price, e.g. /reception 40 52000.",
+	},
 	models.CommandExpenses: {
 		Title:   "Expense Logging",
 		Message: "Record expenses with supplier name, e.g. /expenses medication 55000 vet-shop.",
 	},
+	models.CommandStock: {
+		Title:   "Inventory",
+		Message: "Add an inventory item with quantity, unit price and condition, e.g. /stock wheelbarrow 2 350000 new.",
+	},
+	models.CommandVaccine: {
+		Title:   "Vaccination",
+		Message: "Log a vaccine or treatment with the band (1-3 or all), e.g. /vaccine newcastle 2 eye drop.",
+	},
+	models.CommandPrice: {
+		Title:   "Egg Price",
+		Message: "Set the tray price effective today, e.g. /prix 52000. Send /prix alone to see the current price.",
+	},
+	models.CommandClient: {
+		Title:   "Customers",
+		Message: "Register a customer with /client add Mamadou 622123456 50000, list them with /client list.",
+	},
+	models.CommandPayment: {
+		Title:   "Debt Payment",
+		Message: "Record a client paying back, e.g. /paiement Mamadou 150000.",
+	},
+	models.CommandDebts: {
+		Title:   "Debts",
+		Message: "Send /dettes to list the clients who still owe money.",
+	},
+	models.CommandUndo: {
+		Title:   "Undo",
+		Message: "Send /undo (or /annuler) to remove the last record you sent.",
+	},
+	models.CommandStatus: {
+		Title:   "Status",
+		Message: "Send /status (or /statut) to see what you have logged today.",
+	},
+	models.CommandReport: {
+		Title:   "Daily Report",
+		Message: "Send /report (or /rapport) to receive today's full report.",
+	},
+	models.CommandWeek: {
+		Title:   "Weekly Summary",
+		Message: "Send /semaine for this week's numbers so far, or /semaine derniere for last week.",
+	},
+	models.CommandMonth: {
+		Title:   "Monthly Report",
+		Message: "Send /mois for this month's numbers so far, or /mois dernier for last month.",
+	},
 	models.CommandUnknown: {
 		Title:   "Command Help",
-		Message: "Unknown command. Supported: /eggs, /feed, /mortality, /sales, /expenses.",
+		Message: "Unknown command. Send /help (or /aide) to list the commands available to you.",
 	},
 }
 
@@ -86,7 +201,7 @@ func (s *MetaWhatsAppService) VerifyWebhookToken(mode, verifyToken, challenge st
 		return "", fmt.Errorf("unsupported hub.mode %s", mode)
 	}
 
-	if verifyToken != s.cfg.VerifyToken {
+	if verifyToken != s.cfg.Load().VerifyToken {
 		return "", errors.New("invalid verify token")
 	}
 
@@ -108,8 +223,15 @@ func (s *MetaWhatsAppService) HandleWebhook(ctx context.Context, payload models.
 			}
 
 			for _, msg := range change.Value.Messages {
-				if err := s.handleInboundMessage(ctx, msg); err != nil {
-					s.logger.Error("failed to handle inbound message", zap.Error(err), zap.String("message_id", msg.ID))
+				msgCtx, span := tracer.Start(ctx, "whatsapp message", trace.WithAttributes(
+					attribute.String("messaging.message.id", msg.ID),
+					attribute.String("whatsapp.message.type", msg.Type),
+				))
+				err := s.handleInboundMessage(msgCtx, msg)
+				s.recordMessage(msgCtx, msg, err)
+				tracing.End(span, err)
+				if err != nil {
+					logger.FromContext(ctx, s.logger).Error("failed to handle inbound message", zap.Error(err), zap.String("message_id", msg.ID))
 					if firstErr == nil {
 						firstErr = err
 					}
@@ -121,20 +243,65 @@ func (s *MetaWhatsAppService) HandleWebhook(ctx context.Context, payload models.
 	return firstErr
 }
 
+// recordMessage stores the message and the outcome of its handling in the
+// message audit. Failures are logged only, like the command audit.
+func (s *MetaWhatsAppService) recordMessage(ctx context.Context, msg models.InboundMessage, handleErr error) {
+	if s.messages == nil {
+		return
+	}
+
+	entry := models.MessageAuditEntry{
+		MessageID:  msg.ID,
+		WaID:       msg.From,
+		Type:       msg.Type,
+		Text:       extractMessageText(msg),
+		Result:     models.MessageProcessed,
+		ReceivedAt: time.Now().UTC(),
+		Raw:        &msg,
+	}
+	if seconds, err := strconv.ParseInt(msg.Timestamp, 10, 64); err == nil {
+		entry.SentAt = time.Unix(seconds, 0).UTC()
+	}
+	if handleErr != nil {
+		entry.Result = models.MessageFailed
+		entry.Error = handleErr.Error()
+	}
+
+	if err := s.messages.SaveMessageAudit(context.WithoutCancel(ctx), entry); err != nil {
+		logger.FromContext(ctx, s.logger).Error("failed to save message audit", zap.Error(err), zap.String("message_id", msg.ID))
+	}
+}
+
 func (s *MetaWhatsAppService) handleInboundMessage(ctx context.Context, msg models.InboundMessage) error {
 	text := extractMessageText(msg)
 	if text == "" {
 		return errors.New("empty message body")
 	}
 
-	// 1. Check if it's a direct command (starts with /)
+	// 0. Answer to a pending high-value confirmation
+	if pending, ok := s.sessions.TakePending(msg.From); ok {
+		switch answer := strings.ToLower(strings.TrimSpace(text)); answer {
+		case "oui", "yes", "o", "y", confirmYesID:
+			pending.Confirmed = true
+			return s.executeCommand(ctx, pending, msg.From)
+		case "non", "no", "n", confirmNoID:
+			return s.sendReply(ctx, msg.From, "Entry cancelled, nothing was saved.")
+		}
+		// Any other message drops the pending entry and is processed normally.
+		logger.FromContext(ctx, s.logger).Info("pending confirmation discarded", zap.String("user_id", msg.From))
+	}
+
+	// 1. Check if it's a direct command (starts with /), possibly one per line
 	if strings.HasPrefix(text, "/") {
+		if cmds := models.ParseCommands(text); len(cmds) > 1 {
+			return s.executeBatch(ctx, cmds, msg.From)
+		}
 		cmd := models.ParseCommand(text)
 		return s.executeCommand(ctx, cmd, msg.From)
 	}
 
-	// 2. If AI is enabled, use the conversational flow
-	if s.aiClient != nil {
+	// 2. If AI is enabled for the sender, use the conversational flow
+	if s.aiClient != nil && (s.flags == nil || s.flags.Enabled(models.FeatureAIConversations, msg.From)) {
 		return s.handleConversation(ctx, msg.From, text)
 	}
 
@@ -147,23 +314,28 @@ func (s *MetaWhatsAppService) handleConversation(ctx context.Context, userID, in
 	// Get current session state
 	currentState := s.sessions.GetSession(userID)
 
-	// Determine user role
-	role := "farmer"
-	// Farmer: *, Expense: 224622350064, Seller: 224612868926
-	switch userID {
-	case "224612868926":
-		role = "seller"
-	case "224622350064":
-		role = "expense_manager"
+	role := s.roleFor(userID)
+	user, _ := s.cfg.Load().User(userID)
+
+	logger.FromContext(ctx, s.logger).Info("processing message", zap.String("user_id", userID), zap.String("user", user.Name), zap.String("role", string(role)))
+
+	if role == models.RoleGuest {
+		logger.FromContext(ctx, s.logger).Warn("conversation rejected for unregistered sender", zap.String("user_id", userID))
+		return s.sendReply(ctx, userID, "Désolé, ce numéro n'est pas autorisé à enregistrer des données.")
 	}
 
-	s.logger.Info("processing message", zap.String("user_id", userID), zap.String("role", role))
+	// Sellers no longer need to repeat the unit price: seed it from the price list.
+	if role == models.RoleSeller && currentState.SalePrice == nil && s.dispatcher != nil {
+		if price, ok, err := s.dispatcher.LatestEggPrice(ctx); err == nil && ok {
+			currentState.SalePrice = &price
+		}
+	}
 
 	// Process with AI
-	newState, reply, err := s.aiClient.ProcessConversation(ctx, currentState, input, role)
+	newState, reply, err := s.aiClient.ProcessConversation(ctx, currentState, input, string(role))
 	if err != nil {
-		s.logger.Error("ai conversation failed", zap.Error(err))
-		return s.sendReply(ctx, userID, "Désolé, une erreur technique est survenue. Veuillez réessayer.")
+		logger.FromContext(ctx, s.logger).Error("ai conversation failed", zap.Error(err))
+		return s.sendReply(ctx, userID, withReference(ctx, "Désolé, une erreur technique est survenue. Veuillez réessayer."))
 	}
 
 	// MERGE LOGIC: Update current state with new info while preserving existing data
@@ -173,9 +345,13 @@ func (s *MetaWhatsAppService) handleConversation(ctx context.Context, userID, in
 	// Check if conversation is complete
 	if currentState.Step == "COMPLETED" {
 		// Save all data
-		if err := s.saveDailyReport(ctx, currentState); err != nil {
-			s.logger.Error("failed to save daily report", zap.Error(err))
-			return s.sendReply(ctx, userID, "Merci, mais j'ai eu un problème pour sauvegarder les données. Veuillez contacter l'admin.")
+		if err := s.saveDailyReport(ctx, userID, currentState); err != nil {
+			logger.FromContext(ctx, s.logger).Error("failed to save daily report", zap.Error(err))
+			var validationErr *commandsvc.ValidationError
+			if errors.As(err, &validationErr) {
+				return s.sendReply(ctx, userID, "Données non enregistrées : "+validationErr.Message+" Merci de corriger la valeur.")
+			}
+			return s.sendReply(ctx, userID, withReference(ctx, "Merci, mais j'ai eu un problème pour sauvegarder les données. Veuillez contacter l'admin."))
 		}
 
 		// Clear session and confirm
@@ -190,25 +366,29 @@ func (s *MetaWhatsAppService) handleConversation(ctx context.Context, userID, in
 	return s.sendReply(ctx, userID, reply)
 }
 
-func (s *MetaWhatsAppService) saveDailyReport(ctx context.Context, state anthropic.ConversationState) error {
+// saveDailyReport stores the records collected by a conversation as one
+// unit: if one of them fails, those already written are voided again.
+func (s *MetaWhatsAppService) saveDailyReport(ctx context.Context, userID string, state anthropic.ConversationState) error {
 	if s.dispatcher == nil {
 		return errors.New("dispatcher not configured")
 	}
 
-	if err := s.saveFarmerData(ctx, state); err != nil {
-		return err
-	}
-	if err := s.saveSellerData(ctx, state); err != nil {
-		return err
-	}
-	if err := s.saveExpenseData(ctx, state); err != nil {
-		return err
-	}
-
-	return nil
+	// The records are dated like commands, a night entry before
+	// REPORT_CUTOFF_HOUR counting for the previous day.
+	rules := s.dispatcher.Rules()
+	date := rules.RecordDate(s.dispatcher.Now())
+	return s.dispatcher.SaveUnit(ctx, userID, "conversation", func(ctx context.Context) error {
+		if err := s.saveFarmerData(ctx, state, date, rules.FeedBagKg); err != nil {
+			return err
+		}
+		if err := s.saveSellerData(ctx, state, date); err != nil {
+			return err
+		}
+		return s.saveExpenseData(ctx, state, date)
+	})
 }
 
-func (s *MetaWhatsAppService) saveFarmerData(ctx context.Context, state anthropic.ConversationState) error {
+func (s *MetaWhatsAppService) saveFarmerData(ctx context.Context, state anthropic.ConversationState, date time.Time, bagKg float64) error {
 	// Save Eggs
 	if state.EggsBand1 != nil || state.EggsBand2 != nil || state.EggsBand3 != nil {
 		b1, b2, b3 := 0, 0, 0
@@ -223,7 +403,7 @@ func (s *MetaWhatsAppService) saveFarmerData(ctx context.Context, state anthropi
 		}
 
 		err := s.dispatcher.SaveEggsRecord(ctx, models.EggRecord{
-			Date:     time.Now(),
+			Date:     date,
 			Band1:    b1,
 			Band2:    b2,
 			Band3:    b3,
@@ -249,7 +429,7 @@ func (s *MetaWhatsAppService) saveFarmerData(ctx context.Context, state anthropi
 		}
 
 		err := s.dispatcher.SaveMortalityRecord(ctx, models.MortalityRecord{
-			Date:  time.Now(),
+			Date:  date,
 			Band1: m1,
 			Band2: m2,
 			Band3: m3,
@@ -261,12 +441,13 @@ func (s *MetaWhatsAppService) saveFarmerData(ctx context.Context, state anthropi
 
 	// Save Feed (Reception)
 	if state.FeedReceived != nil && *state.FeedReceived {
+		// The assistant asks for the number of bags.
 		feedKg := 0.0
 		if state.FeedQty != nil {
-			feedKg = *state.FeedQty
+			feedKg = *state.FeedQty * bagKg
 		}
 		err := s.dispatcher.SaveFeedRecord(ctx, models.FeedRecord{
-			Date:       time.Now(),
+			Date:       date,
 			FeedKg:     feedKg,
 			Population: 0,
 		})
@@ -277,7 +458,7 @@ func (s *MetaWhatsAppService) saveFarmerData(ctx context.Context, state anthropi
 	return nil
 }
 
-func (s *MetaWhatsAppService) saveSellerData(ctx context.Context, state anthropic.ConversationState) error {
+func (s *MetaWhatsAppService) saveSellerData(ctx context.Context, state anthropic.ConversationState, date time.Time) error {
 	// Save Sales
 	if state.SaleQty != nil && *state.SaleQty > 0 {
 		price, paid := 0.0, 0.0
@@ -293,7 +474,7 @@ func (s *MetaWhatsAppService) saveSellerData(ctx context.Context, state anthropi
 		}
 
 		err := s.dispatcher.SaveSaleRecord(ctx, models.SaleRecord{
-			Date:         time.Now(),
+			Date:         date,
 			Client:       clientName,
 			Quantity:     *state.SaleQty,
 			PricePerUnit: price,
@@ -311,7 +492,7 @@ func (s *MetaWhatsAppService) saveSellerData(ctx context.Context, state anthropi
 			price = *state.ReceptionPrice
 		}
 		err := s.dispatcher.SaveEggReceptionRecord(ctx, models.EggReceptionRecord{
-			Date:      time.Now(),
+			Date:      date,
 			Quantity:  *state.ReceptionQty,
 			UnitPrice: price,
 		})
@@ -322,7 +503,7 @@ func (s *MetaWhatsAppService) saveSellerData(ctx context.Context, state anthropi
 	return nil
 }
 
-func (s *MetaWhatsAppService) saveExpenseData(ctx context.Context, state anthropic.ConversationState) error {
+func (s *MetaWhatsAppService) saveExpenseData(ctx context.Context, state anthropic.ConversationState, date time.Time) error {
 	if state.ExpenseCategory != nil || state.ExpenseQty != nil {
 		category := "Divers"
 		if state.ExpenseCategory != nil {
@@ -346,7 +527,7 @@ func (s *MetaWhatsAppService) saveExpenseData(ctx context.Context, state anthrop
 		amount := qty * unitPrice
 
 		err := s.dispatcher.SaveExpenseRecord(ctx, models.ExpenseRecord{
-			Date:      time.Now(),
+			Date:      date,
 			Category:  category,
 			Quantity:  qty,
 			UnitPrice: unitPrice,
@@ -360,14 +541,14 @@ func (s *MetaWhatsAppService) saveExpenseData(ctx context.Context, state anthrop
 		// If it's a physical asset, also save to StateStock
 		if state.ExpenseType != nil && strings.ToLower(*state.ExpenseType) == "physical" {
 			err := s.dispatcher.SaveStateStockRecord(ctx, models.StateStockRecord{
-				Date:      time.Now(),
+				Date:      date,
 				ItemName:  category, // Using category as item name for now
 				Quantity:  qty,
 				UnitPrice: unitPrice,
 				Condition: "Bon", // Default condition
 			})
 			if err != nil {
-				s.logger.Error("failed to save state stock record", zap.Error(err))
+				logger.FromContext(ctx, s.logger).Error("failed to save state stock record", zap.Error(err))
 				// We don't fail the whole request if stock save fails, just log it
 			}
 		}
@@ -375,9 +556,37 @@ func (s *MetaWhatsAppService) saveExpenseData(ctx context.Context, state anthrop
 	return nil
 }
 
+// roleFor determines the sender's farm role: the one of USERS_FILE, else
+// Expense: WHATSAPP_EXPENSE_MANAGER_ID, Seller: WHATSAPP_SELLER_ID, Farmer:
+// WHATSAPP_FARMER_IDS. Other senders are guests, also when no farmer is
+// listed.
+func (s *MetaWhatsAppService) roleFor(userID string) models.Role {
+	cfg := s.cfg.Load()
+	if user, ok := cfg.User(userID); ok {
+		return models.Role(user.Role)
+	}
+	switch userID {
+	case cfg.SellerID:
+		return models.RoleSeller
+	case cfg.ExpenseManagerID:
+		return models.RoleExpenseManager
+	}
+	for _, id := range cfg.FarmerIDs {
+		if id == userID {
+			return models.RoleFarmer
+		}
+	}
+	return models.RoleGuest
+}
+
 func (s *MetaWhatsAppService) executeCommand(ctx context.Context, cmd models.Command, sender string) error {
+	cmd.Role = s.roleFor(sender)
+
 	if s.dispatcher == nil {
-		s.logger.Warn("command dispatcher not configured")
+		logger.FromContext(ctx, s.logger).Warn("command dispatcher not configured")
+		if cmd.Type == models.CommandHelp {
+			return s.sendReply(ctx, sender, commandsvc.HelpMessage(cmd.Role))
+		}
 		reply := commandReplies[cmd.Type]
 		outbound := fmt.Sprintf("%s\n%s", reply.Title, reply.Message)
 		return s.sendReply(ctx, sender, outbound)
@@ -385,20 +594,42 @@ func (s *MetaWhatsAppService) executeCommand(ctx context.Context, cmd models.Com
 
 	response, err := s.dispatcher.HandleCommand(ctx, cmd, sender)
 	if err != nil {
-		s.logger.Warn("dispatcher failed to handle command", zap.Error(err), zap.String("command", string(cmd.Type)))
+		logger.FromContext(ctx, s.logger).Warn("dispatcher failed to handle command", zap.Error(err), zap.String("command", string(cmd.Type)))
 		reply := commandReplies[cmd.Type]
 		if reply.Message == "" {
 			reply = commandReplies[models.CommandUnknown]
 		}
 
 		var outbound string
+		var validationErr *commandsvc.ValidationError
+		var confirmErr *commandsvc.ConfirmationRequiredError
 		switch {
+		case errors.As(err, &confirmErr):
+			s.sessions.SetPending(sender, confirmErr.Command)
+			return s.sendConfirmationRequest(ctx, sender, confirmErr.Summary)
+		case errors.As(err, &validationErr):
+			outbound = fmt.Sprintf("Your %s update was not saved. %s", string(cmd.Type), validationErr.Message)
 		case errors.Is(err, commandsvc.ErrInvalidArguments):
 			outbound = fmt.Sprintf("Could not parse your %s update.\n%s", string(cmd.Type), reply.Message)
+		case errors.Is(err, commandsvc.ErrUnauthorized):
+			outbound = fmt.Sprintf("You are not allowed to use /%s. Send /help to see your commands.", string(cmd.Type))
+		case errors.Is(err, commandsvc.ErrFutureDate):
+			outbound = "The date cannot be in the future. Use date:YYYY-MM-DD or hier for past days."
+		case errors.Is(err, commandsvc.ErrSchemaDrift):
+			outbound = fmt.Sprintf("Your %s update was not saved: the spreadsheet columns were changed. Ask an admin to restore the headers.", string(cmd.Type))
+		case errors.Is(err, commandsvc.ErrWriteUncertain):
+			outbound = fmt.Sprintf("Google Sheets did not confirm your %s update: it may have been saved. Check the sheet before sending it again.", string(cmd.Type))
+		case errors.Is(err, commandsvc.ErrNothingToUndo):
+			outbound = "Nothing to undo: no recent record found for you."
+		case errors.Is(err, commandsvc.ErrUndoRowChanged):
+			outbound = "Nothing undone: your last record was edited or moved in the sheet since. Remove it by hand if needed."
 		case errors.Is(err, commandsvc.ErrUnsupportedCommand):
 			outbound = fmt.Sprintf("%s\n%s", reply.Title, reply.Message)
+			if suggestion := commandsvc.SuggestionFor(cmd); suggestion != "" {
+				outbound = suggestion
+			}
 		default:
-			outbound = "We hit a technical issue storing your update. Please retry shortly."
+			outbound = withReference(ctx, "We hit a technical issue storing your update. Please retry shortly.")
 		}
 
 		return s.sendReply(ctx, sender, outbound)
@@ -416,8 +647,50 @@ func (s *MetaWhatsAppService) executeCommand(ctx context.Context, cmd models.Com
 	return s.sendReply(ctx, sender, response)
 }
 
-// SendOutbound lets internal operators push quick notifications via HTTP.
+func (s *MetaWhatsAppService) executeBatch(ctx context.Context, cmds []models.Command, sender string) error {
+	if s.dispatcher == nil {
+		logger.FromContext(ctx, s.logger).Warn("command dispatcher not configured")
+		return s.sendReply(ctx, sender, withReference(ctx, "We hit a technical issue storing your update. Please retry shortly."))
+	}
+
+	role := s.roleFor(sender)
+	for i := range cmds {
+		cmds[i].Role = role
+	}
+
+	response, err := s.dispatcher.HandleBatch(ctx, cmds, sender)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Warn("dispatcher failed to handle batch", zap.Error(err), zap.Int("lines", len(cmds)))
+		return s.sendReply(ctx, sender, withReference(ctx, "We hit a technical issue storing your update. Please retry shortly."))
+	}
+	return s.sendReply(ctx, sender, response)
+}
+
+// defaultTemplateLanguage is the language of outbound templates that do not
+// name one.
+const defaultTemplateLanguage = "fr"
+
+// SendOutbound lets internal operators push quick notifications via HTTP:
+// text, an approved template, or an image or document given by media ID,
+// link or content, the latter uploaded first. req is expected to pass
+// Validate.
 func (s *MetaWhatsAppService) SendOutbound(ctx context.Context, req models.OutboundMessageRequest) error {
+	switch req.Type {
+	case models.OutboundTypeTemplate:
+		language := req.Template.Language
+		if language == "" {
+			language = defaultTemplateLanguage
+		}
+		return s.SendTemplate(ctx, models.TemplateMessageRequest{
+			To:         req.To,
+			Name:       req.Template.Name,
+			Language:   language,
+			Parameters: req.Template.Parameters,
+		})
+	case models.OutboundTypeImage, models.OutboundTypeDocument:
+		return s.sendMedia(ctx, req)
+	}
+
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
@@ -429,6 +702,106 @@ func (s *MetaWhatsAppService) SendOutbound(ctx context.Context, req models.Outbo
 	return err
 }
 
+// sendMedia sends the image or document of req, uploading its data first.
+func (s *MetaWhatsAppService) sendMedia(ctx context.Context, req models.OutboundMessageRequest) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	media := *req.Media
+	if len(media.Data) > 0 {
+		filename := media.Filename
+		if filename == "" {
+			filename = string(req.Type)
+		}
+		mediaID, err := s.client.UploadMedia(ctxWithTimeout, client.UploadMediaRequest{
+			Filename: filename,
+			MimeType: media.MimeType,
+			Data:     media.Data,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", filename, err)
+		}
+		media.ID = mediaID
+	}
+
+	var err error
+	if req.Type == models.OutboundTypeImage {
+		_, err = s.client.SendImageMessage(ctxWithTimeout, client.SendImageMessageRequest{
+			To:      req.To,
+			MediaID: media.ID,
+			Link:    media.Link,
+			Caption: req.Message,
+		})
+	} else {
+		_, err = s.client.SendDocumentMessage(ctxWithTimeout, client.SendDocumentMessageRequest{
+			To:       req.To,
+			MediaID:  media.ID,
+			Link:     media.Link,
+			Filename: media.Filename,
+			Caption:  req.Message,
+		})
+	}
+	return err
+}
+
+// SendTemplate sends an approved template, which reaches workers who have not
+// written in the last 24 hours.
+func (s *MetaWhatsAppService) SendTemplate(ctx context.Context, req models.TemplateMessageRequest) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := s.client.SendTemplateMessage(ctxWithTimeout, client.SendTemplateMessageRequest{
+		To:         req.To,
+		Name:       req.Name,
+		Language:   req.Language,
+		Parameters: req.Parameters,
+	})
+	return err
+}
+
+// SendDocument uploads the file, then sends it as a document.
+func (s *MetaWhatsAppService) SendDocument(ctx context.Context, req models.DocumentMessageRequest) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	mediaID, err := s.client.UploadMedia(ctxWithTimeout, client.UploadMediaRequest{
+		Filename: req.Filename,
+		MimeType: req.MimeType,
+		Data:     req.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", req.Filename, err)
+	}
+	_, err = s.client.SendDocumentMessage(ctxWithTimeout, client.SendDocumentMessageRequest{
+		To:       req.To,
+		MediaID:  mediaID,
+		Filename: req.Filename,
+		Caption:  req.Caption,
+	})
+	return err
+}
+
+func (s *MetaWhatsAppService) sendConfirmationRequest(ctx context.Context, to, summary string) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	body := fmt.Sprintf("Please confirm the %s. Reply oui to save or non to cancel.", summary)
+	_, err := s.client.SendButtonMessage(ctxWithTimeout, client.SendButtonMessageRequest{
+		To:   to,
+		Body: body,
+		Buttons: []client.Button{
+			{ID: confirmYesID, Title: "Oui"},
+			{ID: confirmNoID, Title: "Non"},
+		},
+	})
+	if err != nil {
+		// Fall back to plain text; the oui/non answer works either way.
+		logger.FromContext(ctx, s.logger).Warn("button message failed, falling back to text", zap.Error(err))
+		return s.sendReply(ctx, to, body)
+	}
+	return nil
+}
+
 func (s *MetaWhatsAppService) sendReply(ctx context.Context, to, body string) error {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -441,6 +814,15 @@ func (s *MetaWhatsAppService) sendReply(ctx context.Context, to, body string) er
 	return err
 }
 
+// withReference appends the request ID to a reply reporting a technical
+// failure, so the farmer can quote it and the failure be found in the logs.
+func withReference(ctx context.Context, text string) string {
+	if id := logger.RequestID(ctx); id != "" {
+		return text + "\nRéf. " + id
+	}
+	return text
+}
+
 func extractMessageText(msg models.InboundMessage) string {
 	if msg.Text != nil {
 		return msg.Text.Body
@@ -0,0 +1,84 @@
+package whatsapp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mamadbah2/farmer/internal/config"
+	"github.com/mamadbah2/farmer/internal/domain/models"
+	"github.com/mamadbah2/farmer/internal/repository/sheets"
+	commandsvc "github.com/mamadbah2/farmer/internal/service/commands"
+	"github.com/mamadbah2/farmer/pkg/clients/anthropic"
+	client "github.com/mamadbah2/farmer/pkg/clients/whatsapp"
+)
+
+// fakeClient records the text replies.
+type fakeClient struct {
+	client.Client
+	replies []string
+}
+
+func (f *fakeClient) SendTextMessage(ctx context.Context, req client.SendTextMessageRequest) (*client.SendTextMessageResponse, error) {
+	f.replies = append(f.replies, req.Body)
+	return &client.SendTextMessageResponse{}, nil
+}
+
+// fakeAI fails the test when a conversation reaches it.
+type fakeAI struct {
+	anthropic.Client
+	t *testing.T
+}
+
+func (f fakeAI) ProcessConversation(ctx context.Context, state anthropic.ConversationState, input string, role string) (anthropic.ConversationState, string, error) {
+	f.t.Errorf("conversation of a %s reached the AI client", role)
+	return state, "", nil
+}
+
+// noSheets fails the test when a row is appended; other calls panic.
+type noSheets struct {
+	sheets.Repository
+	t *testing.T
+}
+
+func (n noSheets) AppendRow(ctx context.Context, sheetRange string, values []interface{}) (string, error) {
+	n.t.Errorf("row appended to %s", sheetRange)
+	return "", nil
+}
+
+func message(from, text string) models.WebhookPayload {
+	return models.WebhookPayload{Entry: []models.WebhookEntry{{Changes: []models.WebhookChange{{Value: models.WebhookValue{
+		Messages: []models.InboundMessage{{From: from, ID: "wamid.1", Type: "text", Text: &models.TextContent{Body: text}}},
+	}}}}}}
+}
+
+// noFarmers has staff but no farmer list.
+var noFarmers = config.WhatsAppConfig{SellerID: "224622222222", ExpenseManagerID: "224611111111"}
+
+func TestUnknownSenderWithoutFarmerListCannotLogCommands(t *testing.T) {
+	replies := &fakeClient{}
+	dispatcher := commandsvc.NewService(noSheets{t: t}, sheets.Layout{}, nil, nil, commandsvc.ValidationRules{}, nil, nil, nil)
+	svc := NewMetaWhatsAppService(noFarmers, replies, nil, dispatcher, nil, nil, nil)
+
+	if got := svc.roleFor("224633333333"); got != models.RoleGuest {
+		t.Errorf("role = %s, want guest", got)
+	}
+	if err := svc.HandleWebhook(context.Background(), message("224633333333", "/eggs 120")); err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	if len(replies.replies) != 1 || !strings.Contains(replies.replies[0], "not allowed") {
+		t.Errorf("replies = %q, want a refusal", replies.replies)
+	}
+}
+
+func TestUnknownSenderWithoutFarmerListCannotTalkToTheAI(t *testing.T) {
+	replies := &fakeClient{}
+	svc := NewMetaWhatsAppService(noFarmers, replies, fakeAI{t: t}, nil, nil, nil, nil)
+
+	if err := svc.HandleWebhook(context.Background(), message("224633333333", "120 oeufs aujourd'hui")); err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	if len(replies.replies) != 1 || !strings.Contains(replies.replies[0], "pas autorisé") {
+		t.Errorf("replies = %q, want a refusal", replies.replies)
+	}
+}
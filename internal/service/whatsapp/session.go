@@ -1,21 +1,52 @@
 package whatsapp
 
 import (
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/mamadbah2/farmer/internal/domain/models"
 	"github.com/mamadbah2/farmer/pkg/clients/anthropic"
 )
 
+// pendingTTL bounds how long a command waits for the sender's confirmation.
+const pendingTTL = 15 * time.Minute
+
+type pendingCommand struct {
+	Command   models.Command
+	CreatedAt time.Time
+}
+
+type conversation struct {
+	State     anthropic.ConversationState
+	UpdatedAt time.Time
+}
+
+// Session is what the service remembers of a user between messages: the AI
+// conversation in progress and the command awaiting their confirmation.
+type Session struct {
+	WaID string `json:"wa_id"`
+	// User is the staff member's name, empty for unknown numbers.
+	User         string                       `json:"user,omitempty"`
+	Conversation *anthropic.ConversationState `json:"conversation,omitempty"`
+	UpdatedAt    time.Time                    `json:"updated_at"`
+	// Pending is the text of the command awaiting confirmation.
+	Pending      string     `json:"pending,omitempty"`
+	PendingSince *time.Time `json:"pending_since,omitempty"`
+}
+
 // SessionManager handles user conversation states.
 type SessionManager struct {
-	sessions map[string]anthropic.ConversationState
+	sessions map[string]conversation
+	pending  map[string]pendingCommand
 	mu       sync.RWMutex
 }
 
 // NewSessionManager creates a new session manager.
 func NewSessionManager() *SessionManager {
 	return &SessionManager{
-		sessions: make(map[string]anthropic.ConversationState),
+		sessions: make(map[string]conversation),
+		pending:  make(map[string]pendingCommand),
 	}
 }
 
@@ -23,8 +54,8 @@ func NewSessionManager() *SessionManager {
 func (sm *SessionManager) GetSession(userID string) anthropic.ConversationState {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
-	if state, exists := sm.sessions[userID]; exists {
-		return state
+	if session, exists := sm.sessions[userID]; exists {
+		return session.State
 	}
 	return anthropic.ConversationState{Step: "COLLECTING"}
 }
@@ -33,7 +64,7 @@ func (sm *SessionManager) GetSession(userID string) anthropic.ConversationState
 func (sm *SessionManager) UpdateSession(userID string, state anthropic.ConversationState) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
-	sm.sessions[userID] = state
+	sm.sessions[userID] = conversation{State: state, UpdatedAt: time.Now()}
 }
 
 // ClearSession removes a user's session.
@@ -42,3 +73,77 @@ func (sm *SessionManager) ClearSession(userID string) {
 	defer sm.mu.Unlock()
 	delete(sm.sessions, userID)
 }
+
+// SetPending stores a command awaiting the user's confirmation.
+func (sm *SessionManager) SetPending(userID string, cmd models.Command) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.pending[userID] = pendingCommand{Command: cmd, CreatedAt: time.Now()}
+}
+
+// TakePending removes and returns the user's pending command, if still valid.
+func (sm *SessionManager) TakePending(userID string) (models.Command, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	entry, exists := sm.pending[userID]
+	if !exists {
+		return models.Command{}, false
+	}
+	delete(sm.pending, userID)
+	if time.Since(entry.CreatedAt) > pendingTTL {
+		return models.Command{}, false
+	}
+	return entry.Command, true
+}
+
+// List returns the users with a conversation in progress or a command still
+// awaiting confirmation, most recently active first.
+func (sm *SessionManager) List() []Session {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	byUser := map[string]*Session{}
+	session := func(userID string) *Session {
+		if byUser[userID] == nil {
+			byUser[userID] = &Session{WaID: userID}
+		}
+		return byUser[userID]
+	}
+	for userID, conv := range sm.sessions {
+		state := conv.State
+		s := session(userID)
+		s.Conversation = &state
+		s.UpdatedAt = conv.UpdatedAt
+	}
+	for userID, entry := range sm.pending {
+		if time.Since(entry.CreatedAt) > pendingTTL {
+			continue
+		}
+		since := entry.CreatedAt
+		s := session(userID)
+		s.Pending = entry.Command.Raw
+		s.PendingSince = &since
+		if entry.CreatedAt.After(s.UpdatedAt) {
+			s.UpdatedAt = entry.CreatedAt
+		}
+	}
+
+	sessions := make([]Session, 0, len(byUser))
+	for _, s := range byUser {
+		sessions = append(sessions, *s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt) })
+	return sessions
+}
+
+// Reset forgets the user's conversation and pending command, and reports
+// whether there was any.
+func (sm *SessionManager) Reset(userID string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	_, conversing := sm.sessions[userID]
+	_, pending := sm.pending[userID]
+	delete(sm.sessions, userID)
+	delete(sm.pending, userID)
+	return conversing || pending
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/mamadbah2/farmer/internal/domain/models"
 )
 
 const (
@@ -141,6 +145,10 @@ type anthropicClient struct {
 // NewClient creates a configured Anthropic client.
 func NewClient(apiKey string) Client {
 	client := resty.New().
+		// Each completion is a client span of the conversation's trace.
+		SetTransport(otelhttp.NewTransport(http.DefaultTransport, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "anthropic " + r.Method
+		}))).
 		SetHeader("x-api-key", apiKey).
 		SetHeader("anthropic-version", apiVersion).
 		SetHeader("content-type", "application/json").
@@ -226,7 +234,7 @@ func (c *anthropicClient) ProcessConversation(ctx context.Context, state Convers
 
 		REQUIRED INFORMATION (Ask in this order if missing):
 		1. Expense Details:
-		   - Category (Rubrique/Dépense)
+		   - Category (Rubrique/Dépense): one of %s
 		   - Quantity
 		   - Unit Price
 		   - Notes (Motif/Observation)
@@ -245,15 +253,15 @@ func (c *anthropicClient) ProcessConversation(ctx context.Context, state Convers
 		  {
 			"updated_state": {
 				"step": "COLLECTING" or "COMPLETED",
-				"expense_category": (string or null),
+				"expense_category": (one of the categories above, or null),
 				"expense_qty": (float or null),
 				"expense_unit_price": (float or null),
-				"expense_notes": (string or null),
+				"expense_notes": (string or null, include the user's own wording of the expense),
 				"expense_type": "physical" or "other"
 			},
 			"reply": "Text to send to the expense manager (French)"
 		  }
-		`, string(stateJSON))
+		`, string(stateJSON), strings.Join(models.ExpenseCategories(), ", "))
 	} else {
 		// Default to Farmer (Chaby)
 		systemPrompt = fmt.Sprintf(`You are a helpful farm assistant for a poultry farm. Your job is to collect daily data from the farmer to fill an Excel sheet.
@@ -0,0 +1,29 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+)
+
+type fakeClient struct{}
+
+// NewFakeClient returns a Client answering without calling the API, for
+// local development: conversations go through the normal flow, but the
+// reply only acknowledges the message and no data is ever collected.
+func NewFakeClient() Client {
+	return fakeClient{}
+}
+
+func (fakeClient) TranslateToCommand(ctx context.Context, input string) (string, error) {
+	return input, nil
+}
+
+func (fakeClient) ProcessConversation(ctx context.Context, state ConversationState, input string, role string) (ConversationState, string, error) {
+	reply := fmt.Sprintf("🤖 IA simulée (%s) : message « %s » reçu. Utilisez /help pour les commandes.", role, input)
+	state.Step = "COLLECTING"
+	state.History = append(state.History,
+		Message{Role: "user", Content: input},
+		Message{Role: "assistant", Content: reply},
+	)
+	return state, reply, nil
+}
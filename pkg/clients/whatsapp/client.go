@@ -1,6 +1,7 @@
 package whatsapp
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"github.com/mamadbah2/farmer/internal/config"
 )
@@ -15,6 +17,11 @@ import (
 // Client exposes WhatsApp Cloud API operations used by the application.
 type Client interface {
 	SendTextMessage(ctx context.Context, req SendTextMessageRequest) (*SendTextMessageResponse, error)
+	SendButtonMessage(ctx context.Context, req SendButtonMessageRequest) (*SendTextMessageResponse, error)
+	SendTemplateMessage(ctx context.Context, req SendTemplateMessageRequest) (*SendTextMessageResponse, error)
+	UploadMedia(ctx context.Context, req UploadMediaRequest) (string, error)
+	SendDocumentMessage(ctx context.Context, req SendDocumentMessageRequest) (*SendTextMessageResponse, error)
+	SendImageMessage(ctx context.Context, req SendImageMessageRequest) (*SendTextMessageResponse, error)
 }
 
 // APIClient is a resty-backed implementation of Client.
@@ -29,6 +36,10 @@ func NewClient(cfg config.WhatsAppConfig) *APIClient {
 
 	restyClient := resty.New()
 	restyClient.
+		// Each Graph API call is a client span of the request's trace.
+		SetTransport(otelhttp.NewTransport(http.DefaultTransport, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "whatsapp " + r.Method
+		}))).
 		SetBaseURL(fmt.Sprintf("%s/%s", base, cfg.APIVersion)).
 		SetHeader("Authorization", fmt.Sprintf("Bearer %s", cfg.AccessToken)).
 		SetHeader("Content-Type", "application/json").
@@ -47,6 +58,57 @@ type SendTextMessageRequest struct {
 	PreviewURL bool
 }
 
+// Button is a quick-reply button of an interactive message.
+type Button struct {
+	ID    string
+	Title string
+}
+
+// SendButtonMessageRequest represents an interactive message with up to three reply buttons.
+type SendButtonMessageRequest struct {
+	To      string
+	Body    string
+	Buttons []Button
+}
+
+// SendTemplateMessageRequest represents a message built from an approved
+// template, the only kind Meta delivers outside the 24h session window.
+type SendTemplateMessageRequest struct {
+	To       string
+	Name     string
+	Language string
+	// Parameters fill the template body placeholders {{1}}, {{2}}... in order.
+	Parameters []string
+}
+
+// UploadMediaRequest is a file to store on Meta's servers before sending it.
+type UploadMediaRequest struct {
+	Filename string
+	MimeType string
+	Data     []byte
+}
+
+// SendDocumentMessageRequest sends an uploaded file, or the file at Link, as
+// a document.
+type SendDocumentMessageRequest struct {
+	To      string
+	MediaID string
+	// Link is a public URL Meta downloads the file from, used without
+	// MediaID.
+	Link string
+	// Filename is the name shown to the recipient.
+	Filename string
+	Caption  string
+}
+
+// SendImageMessageRequest sends an uploaded image, or the image at Link.
+type SendImageMessageRequest struct {
+	To      string
+	MediaID string
+	Link    string
+	Caption string
+}
+
 // SendTextMessageResponse mirrors the successful response from Meta.
 type SendTextMessageResponse struct {
 	Messages []struct {
@@ -77,6 +139,132 @@ func (c *APIClient) SendTextMessage(ctx context.Context, req SendTextMessageRequ
 		},
 	}
 
+	return c.postMessage(ctx, payload)
+}
+
+// SendButtonMessage sends an interactive message with reply buttons.
+func (c *APIClient) SendButtonMessage(ctx context.Context, req SendButtonMessageRequest) (*SendTextMessageResponse, error) {
+	if len(req.Buttons) == 0 || len(req.Buttons) > 3 {
+		return nil, fmt.Errorf("button message requires 1 to 3 buttons, got %d", len(req.Buttons))
+	}
+
+	buttons := make([]map[string]any, 0, len(req.Buttons))
+	for _, b := range req.Buttons {
+		buttons = append(buttons, map[string]any{
+			"type":  "reply",
+			"reply": map[string]any{"id": b.ID, "title": b.Title},
+		})
+	}
+
+	payload := map[string]any{
+		"messaging_product": "whatsapp",
+		"to":                req.To,
+		"type":              "interactive",
+		"interactive": map[string]any{
+			"type":   "button",
+			"body":   map[string]any{"text": req.Body},
+			"action": map[string]any{"buttons": buttons},
+		},
+	}
+
+	return c.postMessage(ctx, payload)
+}
+
+// SendTemplateMessage sends an approved message template.
+func (c *APIClient) SendTemplateMessage(ctx context.Context, req SendTemplateMessageRequest) (*SendTextMessageResponse, error) {
+	template := map[string]any{
+		"name":     req.Name,
+		"language": map[string]any{"code": req.Language},
+	}
+	if len(req.Parameters) > 0 {
+		parameters := make([]map[string]any, 0, len(req.Parameters))
+		for _, p := range req.Parameters {
+			parameters = append(parameters, map[string]any{"type": "text", "text": p})
+		}
+		template["components"] = []map[string]any{{"type": "body", "parameters": parameters}}
+	}
+
+	payload := map[string]any{
+		"messaging_product": "whatsapp",
+		"to":                req.To,
+		"type":              "template",
+		"template":          template,
+	}
+
+	return c.postMessage(ctx, payload)
+}
+
+// UploadMedia uploads a file and returns the media ID to send it with. Meta
+// keeps uploaded media for 30 days.
+func (c *APIClient) UploadMedia(ctx context.Context, req UploadMediaRequest) (string, error) {
+	result := new(struct {
+		ID string `json:"id"`
+	})
+	apiErr := new(apiError)
+
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetMultipartFormData(map[string]string{"messaging_product": "whatsapp", "type": req.MimeType}).
+		SetMultipartField("file", req.Filename, req.MimeType, bytes.NewReader(req.Data)).
+		SetResult(result).
+		SetError(apiErr).
+		Post(fmt.Sprintf("%s/media", c.phoneNumberID))
+	if err != nil {
+		return "", fmt.Errorf("upload whatsapp media: %w", err)
+	}
+	if err := responseError(resp, apiErr); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// SendDocumentMessage sends a file uploaded with UploadMedia, or found at
+// req.Link, as a document.
+func (c *APIClient) SendDocumentMessage(ctx context.Context, req SendDocumentMessageRequest) (*SendTextMessageResponse, error) {
+	document := mediaObject(req.MediaID, req.Link, req.Caption)
+	if req.Filename != "" {
+		document["filename"] = req.Filename
+	}
+
+	payload := map[string]any{
+		"messaging_product": "whatsapp",
+		"to":                req.To,
+		"type":              "document",
+		"document":          document,
+	}
+
+	return c.postMessage(ctx, payload)
+}
+
+// SendImageMessage sends an image uploaded with UploadMedia, or found at
+// req.Link.
+func (c *APIClient) SendImageMessage(ctx context.Context, req SendImageMessageRequest) (*SendTextMessageResponse, error) {
+	payload := map[string]any{
+		"messaging_product": "whatsapp",
+		"to":                req.To,
+		"type":              "image",
+		"image":             mediaObject(req.MediaID, req.Link, req.Caption),
+	}
+
+	return c.postMessage(ctx, payload)
+}
+
+// mediaObject is the image or document object of a message: the media ID,
+// or the link without one, and the caption.
+func mediaObject(mediaID, link, caption string) map[string]any {
+	media := map[string]any{}
+	if mediaID != "" {
+		media["id"] = mediaID
+	} else {
+		media["link"] = link
+	}
+	if caption != "" {
+		media["caption"] = caption
+	}
+	return media
+}
+
+func (c *APIClient) postMessage(ctx context.Context, payload map[string]any) (*SendTextMessageResponse, error) {
 	result := new(SendTextMessageResponse)
 	apiErr := new(apiError)
 
@@ -90,17 +278,41 @@ func (c *APIClient) SendTextMessage(ctx context.Context, req SendTextMessageRequ
 		return nil, fmt.Errorf("send whatsapp message: %w", err)
 	}
 
-	if resp.StatusCode() >= http.StatusBadRequest {
-		message := ""
-		code := resp.StatusCode()
-		if apiErr != nil {
-			message = apiErr.Error.Message
-			if apiErr.Error.Code != 0 {
-				code = apiErr.Error.Code
-			}
-		}
-		return nil, fmt.Errorf("whatsapp api error: code=%d, message=%s", code, message)
+	if err := responseError(resp, apiErr); err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
+
+// Ping checks that the access token is valid and grants access to the phone
+// number, by reading the number's ID.
+func (c *APIClient) Ping(ctx context.Context) error {
+	apiErr := new(apiError)
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetQueryParam("fields", "id").
+		SetError(apiErr).
+		Get(c.phoneNumberID)
+	if err != nil {
+		return fmt.Errorf("check whatsapp token: %w", err)
+	}
+	return responseError(resp, apiErr)
+}
+
+// responseError turns an error status into an error carrying the Meta API
+// code and message.
+func responseError(resp *resty.Response, apiErr *apiError) error {
+	if resp.StatusCode() < http.StatusBadRequest {
+		return nil
+	}
+	message := ""
+	code := resp.StatusCode()
+	if apiErr != nil {
+		message = apiErr.Error.Message
+		if apiErr.Error.Code != 0 {
+			code = apiErr.Error.Code
+		}
+	}
+	return fmt.Errorf("whatsapp api error: code=%d, message=%s", code, message)
+}
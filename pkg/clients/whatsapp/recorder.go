@@ -0,0 +1,110 @@
+package whatsapp
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// SentMessage is a message a RecordingClient was asked to send.
+type SentMessage struct {
+	To   string `json:"to"`
+	Type string `json:"type"` // text, buttons, template, image or document
+	// Text is the body, followed by the button titles, the template name
+	// and parameters, the image link and caption, or the document name and
+	// caption.
+	Text string `json:"text"`
+}
+
+// Transcript collects the messages sent with a context.
+type Transcript struct {
+	mu       sync.Mutex
+	messages []SentMessage
+}
+
+type transcriptKey struct{}
+
+// WithTranscript returns a context whose messages sent through a
+// RecordingClient are collected in the returned transcript.
+func WithTranscript(ctx context.Context) (context.Context, *Transcript) {
+	transcript := &Transcript{}
+	return context.WithValue(ctx, transcriptKey{}, transcript), transcript
+}
+
+// Messages returns the messages collected so far, in order.
+func (t *Transcript) Messages() []SentMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SentMessage{}, t.messages...)
+}
+
+// RecordingClient implements Client without calling the Graph API: each
+// message is added to the Transcript of its context, if any, and answered
+// without message IDs. Used to replay webhooks in dry-run mode.
+type RecordingClient struct{}
+
+// NewRecordingClient returns a client that sends nothing.
+func NewRecordingClient() *RecordingClient {
+	return &RecordingClient{}
+}
+
+func (c *RecordingClient) record(ctx context.Context, message SentMessage) (*SendTextMessageResponse, error) {
+	if transcript, ok := ctx.Value(transcriptKey{}).(*Transcript); ok {
+		transcript.mu.Lock()
+		transcript.messages = append(transcript.messages, message)
+		transcript.mu.Unlock()
+	}
+	return &SendTextMessageResponse{}, nil
+}
+
+// SendTextMessage records the text.
+func (c *RecordingClient) SendTextMessage(ctx context.Context, req SendTextMessageRequest) (*SendTextMessageResponse, error) {
+	return c.record(ctx, SentMessage{To: req.To, Type: "text", Text: req.Body})
+}
+
+// SendButtonMessage records the body and the button titles.
+func (c *RecordingClient) SendButtonMessage(ctx context.Context, req SendButtonMessageRequest) (*SendTextMessageResponse, error) {
+	text := req.Body
+	for _, button := range req.Buttons {
+		text += "\n[" + button.Title + "]"
+	}
+	return c.record(ctx, SentMessage{To: req.To, Type: "buttons", Text: text})
+}
+
+// SendTemplateMessage records the template name and parameters.
+func (c *RecordingClient) SendTemplateMessage(ctx context.Context, req SendTemplateMessageRequest) (*SendTextMessageResponse, error) {
+	text := req.Name
+	if len(req.Parameters) > 0 {
+		text += ": " + strings.Join(req.Parameters, ", ")
+	}
+	return c.record(ctx, SentMessage{To: req.To, Type: "template", Text: text})
+}
+
+// UploadMedia uploads nothing and returns the file name as the media ID.
+func (c *RecordingClient) UploadMedia(_ context.Context, req UploadMediaRequest) (string, error) {
+	return req.Filename, nil
+}
+
+// SendDocumentMessage records the document name, or link, and caption.
+func (c *RecordingClient) SendDocumentMessage(ctx context.Context, req SendDocumentMessageRequest) (*SendTextMessageResponse, error) {
+	text := req.Filename
+	if text == "" {
+		text = req.Link
+	}
+	if req.Caption != "" {
+		text += ": " + req.Caption
+	}
+	return c.record(ctx, SentMessage{To: req.To, Type: "document", Text: text})
+}
+
+// SendImageMessage records the image link, or media ID, and caption.
+func (c *RecordingClient) SendImageMessage(ctx context.Context, req SendImageMessageRequest) (*SendTextMessageResponse, error) {
+	text := req.Link
+	if text == "" {
+		text = req.MediaID
+	}
+	if req.Caption != "" {
+		text += ": " + req.Caption
+	}
+	return c.record(ctx, SentMessage{To: req.To, Type: "image", Text: text})
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being
+// served.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, empty outside a request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns base with the request_id field of the request ctx
+// belongs to, so every line logged while serving it can be found together;
+// base itself outside a request.
+func FromContext(ctx context.Context, base *zap.Logger) *zap.Logger {
+	if base == nil {
+		return zap.NewNop()
+	}
+	if id := RequestID(ctx); id != "" {
+		return base.With(zap.String("request_id", id))
+	}
+	return base
+}
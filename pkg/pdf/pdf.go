@@ -0,0 +1,149 @@
+// Package pdf writes plain text documents as PDF without any dependency: A4
+// pages, headings in Helvetica-Bold and body lines in Courier, so columns
+// padded with spaces stay aligned.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	pageWidth   = 595 // A4, in points
+	pageHeight  = 842
+	margin      = 50
+	headingSize = 13
+	bodySize    = 9
+	// bodyColumns is how many Courier characters (0.6 em wide) fit between
+	// the margins; longer lines wrap.
+	bodyColumns = (pageWidth - 2*margin) * 10 / (6 * bodySize)
+)
+
+type line struct {
+	text    string
+	heading bool
+}
+
+// Document accumulates lines and renders them with Bytes.
+type Document struct {
+	lines []line
+}
+
+// New returns an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// Heading adds a bold title line, preceded by a blank line unless it opens
+// the document.
+func (d *Document) Heading(text string) {
+	if len(d.lines) > 0 {
+		d.lines = append(d.lines, line{})
+	}
+	d.lines = append(d.lines, line{text: text, heading: true})
+}
+
+// Text adds body lines, one per line of text, wrapping those too long for
+// the page.
+func (d *Document) Text(text string) {
+	for _, raw := range strings.Split(text, "\n") {
+		for utf8.RuneCountInString(raw) > bodyColumns {
+			runes := []rune(raw)
+			d.lines = append(d.lines, line{text: string(runes[:bodyColumns])})
+			raw = string(runes[bodyColumns:])
+		}
+		d.lines = append(d.lines, line{text: raw})
+	}
+}
+
+// Bytes renders the document, starting a new page when one is full.
+func (d *Document) Bytes() []byte {
+	var pages []string
+	var content strings.Builder
+	y := pageHeight - margin
+	for _, l := range d.lines {
+		font, size := "F2", bodySize
+		if l.heading {
+			font, size = "F1", headingSize
+		}
+		step := size * 3 / 2
+		if y-step < margin && content.Len() > 0 {
+			pages = append(pages, content.String())
+			content.Reset()
+			y = pageHeight - margin
+		}
+		y -= step
+		if text := encode(l.text); text != "" {
+			fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, size, margin, y, text)
+		}
+	}
+	pages = append(pages, content.String())
+
+	// Objects: 1 catalog, 2 page tree, 3-4 fonts, then a page and its
+	// content stream for every page.
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // page tree, filled once the page objects are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	}
+	kids := make([]string, 0, len(pages))
+	for _, page := range pages {
+		pageNum := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNum))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, pageNum+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(page), page),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// winAnsi maps the characters of the WinAnsi code page outside Latin-1 that
+// reports use; other runes above 0xFF, such as emoji, are dropped.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, 'œ': 0x9C, 'Œ': 0x8C, '−': '-',
+}
+
+// encode converts text to a WinAnsi PDF string body, escaping the delimiters.
+// Leading spaces left by dropped emoji are trimmed.
+func encode(text string) string {
+	var out []byte
+	for _, r := range text {
+		var b byte
+		switch mapped, ok := winAnsi[r]; {
+		case ok:
+			b = mapped
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			b = byte(r)
+		default:
+			continue
+		}
+		if b == '(' || b == ')' || b == '\\' {
+			out = append(out, '\\')
+		}
+		out = append(out, b)
+	}
+	if len(out) > 0 && out[0] == ' ' && !strings.HasPrefix(text, " ") {
+		return strings.TrimLeft(string(out), " ")
+	}
+	return string(out)
+}
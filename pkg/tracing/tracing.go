@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options configure the exported traces.
+type Options struct {
+	// Endpoint is the base URL of an OTLP/HTTP collector, e.g.
+	// http://otel-collector:4318; spans are posted to Endpoint/v1/traces.
+	// Tracing is off when empty.
+	Endpoint string
+	// Headers are sent with every export, e.g. the collector's API key.
+	Headers map[string]string
+	// ServiceName and Environment identify the process in the traces.
+	ServiceName string
+	Environment string
+	// SampleRatio is the share of new traces kept, from 0 to 1. Requests
+	// carrying a sampled parent are always kept.
+	SampleRatio float64
+	// OnError is told of the spans that could not be exported.
+	OnError func(error)
+}
+
+// exportTimeout bounds each request to the collector.
+const exportTimeout = 10 * time.Second
+
+// Setup installs the global tracer provider exporting to opts.Endpoint and
+// the W3C trace context propagator. It returns the function flushing the
+// pending spans at shutdown. Without an endpoint spans are not recorded and
+// only the propagation is installed.
+func Setup(opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// The exporter's own client uses the default transport, so the exports
+	// are not traced themselves.
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(opts.Endpoint, "/")+"/v1/traces"),
+		otlptracehttp.WithHeaders(opts.Headers),
+		otlptracehttp.WithTimeout(exportTimeout),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	if opts.OnError != nil {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(opts.OnError))
+	}
+	attributes := []attribute.KeyValue{semconv.ServiceName(opts.ServiceName)}
+	if opts.Environment != "" {
+		attributes = append(attributes, semconv.DeploymentEnvironmentName(opts.Environment))
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attributes...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// End ends span, marking it failed when err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
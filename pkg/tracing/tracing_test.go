@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+// collector records the requests an OTLP/HTTP collector receives.
+type collector struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	c.requests = append(c.requests, r)
+	c.bodies = append(c.bodies, body)
+	c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/x-protobuf")
+}
+
+// TestSetupWithoutEndpointRecordsNothing runs first, before a test installs
+// a global provider.
+func TestSetupWithoutEndpointRecordsNothing(t *testing.T) {
+	shutdown, err := Setup(Options{})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdown(context.Background())
+
+	_, span := otel.GetTracerProvider().Tracer("tracing_test").Start(context.Background(), "idle")
+	defer span.End()
+	if span.IsRecording() {
+		t.Errorf("span recorded without an endpoint")
+	}
+}
+
+func TestSetupExportsSpansToTheCollector(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	shutdown, err := Setup(Options{
+		Endpoint:    server.URL + "/",
+		Headers:     map[string]string{"X-Api-Key": "secret"},
+		ServiceName: "farmer-test",
+		SampleRatio: 1,
+		OnError:     func(err error) { t.Errorf("export failed: %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	_, span := otel.Tracer("tracing_test").Start(context.Background(), "sheets append")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.requests) != 1 {
+		t.Fatalf("collector got %d requests, want the flushed batch", len(c.requests))
+	}
+	req, body := c.requests[0], c.bodies[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/v1/traces" {
+		t.Errorf("request = %s %s, want POST /v1/traces", req.Method, req.URL.Path)
+	}
+	if got := req.Header.Get("X-Api-Key"); got != "secret" {
+		t.Errorf("X-Api-Key = %q", got)
+	}
+	if !bytes.Contains(body, []byte("sheets append")) || !bytes.Contains(body, []byte("farmer-test")) {
+		t.Errorf("body does not carry the span and service names")
+	}
+}